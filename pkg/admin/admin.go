@@ -0,0 +1,467 @@
+package admin
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/rest-api/pkg/billing"
+	"github.com/monetr/rest-api/pkg/cache"
+	"github.com/monetr/rest-api/pkg/feature"
+	"github.com/monetr/rest-api/pkg/jobs"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/monetr/rest-api/pkg/repository"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// recentJobsLimit is the number of jobs that will be included in the details of an account.
+const recentJobsLimit = 25
+
+var (
+	// ErrLinkCannotBeSynced is returned when a resync is requested for a link that is not a Plaid link.
+	ErrLinkCannotBeSynced = errors.New("link is not a plaid link and cannot be synced")
+	// ErrInvalidFeature is returned when an override is requested for a feature that does not exist.
+	ErrInvalidFeature = errors.New("feature is not valid")
+)
+
+// Actor is the operator that is performing an admin action, it is recorded on every audit log.
+type Actor struct {
+	// Name is the email of the admin's login when using the API, or the operating system user when using the admin
+	// commands.
+	Name    string
+	LoginId *uint64
+}
+
+type SubscriptionState struct {
+	IsActive              bool       `json:"isActive"`
+	ActiveUntil           *time.Time `json:"activeUntil"`
+	HasStripeCustomer     bool       `json:"hasStripeCustomer"`
+	HasStripeSubscription bool       `json:"hasStripeSubscription"`
+}
+
+// AccountDetails is everything that an operator would typically need to help a user who has reported a problem.
+type AccountDetails struct {
+	Account models.Account `json:"account"`
+	// Features are the features the account is entitled to, including any overrides.
+	Features     []feature.Feature `json:"features"`
+	Users        []AccountUser     `json:"users"`
+	Links        []models.Link     `json:"links"`
+	Jobs         []models.Job      `json:"jobs"`
+	Subscription SubscriptionState `json:"subscription"`
+}
+
+type AccountUser struct {
+	UserId    uint64 `json:"userId"`
+	LoginId   uint64 `json:"loginId"`
+	Email     string `json:"email"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	IsEnabled bool   `json:"isEnabled"`
+}
+
+type Admin interface {
+	// LookupAccountsByEmail returns the details of every account that the login with the provided email belongs to.
+	LookupAccountsByEmail(ctx context.Context, actor Actor, email string) ([]AccountDetails, error)
+	GetAccountDetails(ctx context.Context, actor Actor, accountId uint64) (*AccountDetails, error)
+	// ResyncLink will enqueue a job to retrieve the latest transactions and balances for the provided Plaid link. If a
+	// sync is already queued for the link then no new job is enqueued and an empty job Id is returned.
+	ResyncLink(ctx context.Context, actor Actor, accountId, linkId uint64) (jobId string, err error)
+	// ExtendSubscription will set the subscription of the provided account to be active until the provided time.
+	// This does not change anything in Stripe, so the next webhook from Stripe for the account will overwrite it.
+	ExtendSubscription(ctx context.Context, actor Actor, accountId uint64, activeUntil time.Time) (*models.Account, error)
+	// SetLoginEnabled will enable or disable the provided login. Disabled logins cannot authenticate or use the API.
+	SetLoginEnabled(ctx context.Context, actor Actor, loginId uint64, enabled bool) error
+	// SetFeatureOverride will grant or revoke a feature for the provided account regardless of the account's plan. If
+	// enabled is nil then the override is removed and the account's plan decides whether the feature is available.
+	SetFeatureOverride(ctx context.Context, actor Actor, accountId uint64, item feature.Feature, enabled *bool) (*models.Account, error)
+	// GetBetaCodes returns every beta code, including the codes that users have created to invite other people.
+	GetBetaCodes(ctx context.Context, actor Actor) ([]models.Beta, error)
+	// CreateBetaCodes will generate the provided number of beta codes that expire at the provided time. The plain text
+	// codes are only returned here and cannot be retrieved again.
+	CreateBetaCodes(ctx context.Context, actor Actor, count int, expiresAt time.Time) ([]string, error)
+	// RevokeBetaCode will prevent an unused beta code from being used to register.
+	RevokeBetaCode(ctx context.Context, actor Actor, betaId uint64) error
+	// SetBetaInviteQuota will change the number of beta codes the provided user is allowed to create to invite other
+	// people.
+	SetBetaInviteQuota(ctx context.Context, actor Actor, userId uint64, quota int) error
+}
+
+var (
+	_ Admin = &adminBase{}
+)
+
+type adminBase struct {
+	log   *logrus.Entry
+	db    *pg.DB
+	cache cache.Cache
+	jobs  jobs.JobEnqueuer
+}
+
+// NewAdmin returns an Admin that will record an audit log for every action taken. Each audit log is created in the
+// same transaction as the action, so an action cannot happen without being audited.
+func NewAdmin(log *logrus.Entry, db *pg.DB, cacheClient cache.Cache, jobEnqueuer jobs.JobEnqueuer) Admin {
+	return &adminBase{
+		log:   log,
+		db:    db,
+		cache: cacheClient,
+		jobs:  jobEnqueuer,
+	}
+}
+
+func (a *adminBase) audit(ctx context.Context, txn pg.DBI, actor Actor, action models.AuditAction, accountId, loginId *uint64, details map[string]interface{}) error {
+	auditLog := models.AuditLog{
+		Actor:           actor.Name,
+		ActorLoginId:    actor.LoginId,
+		Action:          action,
+		TargetAccountId: accountId,
+		TargetLoginId:   loginId,
+		Details:         details,
+		CreatedAt:       time.Now().UTC(),
+	}
+	if _, err := txn.ModelContext(ctx, &auditLog).Insert(&auditLog); err != nil {
+		return errors.Wrap(err, "failed to create audit log")
+	}
+
+	a.log.WithContext(ctx).WithFields(logrus.Fields{
+		"actor":      actor.Name,
+		"action":     action,
+		"auditLogId": auditLog.AuditLogId,
+	}).Info("admin action performed")
+
+	return nil
+}
+
+func (a *adminBase) getAccountDetails(ctx context.Context, txn pg.DBI, accountId uint64) (*AccountDetails, error) {
+	var details AccountDetails
+	if err := txn.ModelContext(ctx, &details.Account).
+		Where(`"account"."account_id" = ?`, accountId).
+		Limit(1).
+		Select(&details.Account); err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve account")
+	}
+
+	users := make([]models.User, 0)
+	if err := txn.ModelContext(ctx, &users).
+		Relation("Login").
+		Where(`"user"."account_id" = ?`, accountId).
+		Order(`user.user_id ASC`).
+		Select(&users); err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve users")
+	}
+
+	details.Users = make([]AccountUser, len(users))
+	for i, user := range users {
+		details.Users[i] = AccountUser{
+			UserId:    user.UserId,
+			LoginId:   user.LoginId,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+		}
+		if user.Login != nil {
+			details.Users[i].Email = user.Login.Email
+			details.Users[i].IsEnabled = user.Login.IsEnabled
+		}
+	}
+
+	details.Links = make([]models.Link, 0)
+	if err := txn.ModelContext(ctx, &details.Links).
+		Where(`"link"."account_id" = ?`, accountId).
+		Order(`link.link_id ASC`).
+		Select(&details.Links); err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve links")
+	}
+
+	details.Jobs = make([]models.Job, 0)
+	if err := txn.ModelContext(ctx, &details.Jobs).
+		Where(`"job"."account_id" = ?`, accountId).
+		Order(`enqueued_at DESC`).
+		Limit(recentJobsLimit).
+		Select(&details.Jobs); err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve jobs")
+	}
+
+	details.Features = details.Account.GetFeatures()
+
+	details.Subscription = SubscriptionState{
+		IsActive:              details.Account.IsSubscriptionActive(),
+		ActiveUntil:           details.Account.SubscriptionActiveUntil,
+		HasStripeCustomer:     details.Account.StripeCustomerId != nil,
+		HasStripeSubscription: details.Account.StripeSubscriptionId != nil,
+	}
+
+	return &details, nil
+}
+
+func (a *adminBase) LookupAccountsByEmail(ctx context.Context, actor Actor, email string) ([]AccountDetails, error) {
+	span := sentry.StartSpan(ctx, "Admin - LookupAccountsByEmail")
+	defer span.Finish()
+
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	var result []AccountDetails
+	err := a.db.RunInTransaction(span.Context(), func(txn *pg.Tx) error {
+		var login models.Login
+		if err := txn.ModelContext(span.Context(), &login).
+			Relation("Users").
+			Where(`"login"."email" = ?`, email).
+			Limit(1).
+			Select(&login); err != nil {
+			return errors.Wrap(err, "failed to retrieve login")
+		}
+
+		if err := a.audit(span.Context(), txn, actor, models.AuditActionLookupAccount, nil, &login.LoginId, map[string]interface{}{
+			"email": email,
+		}); err != nil {
+			return err
+		}
+
+		result = make([]AccountDetails, 0, len(login.Users))
+		for _, user := range login.Users {
+			details, err := a.getAccountDetails(span.Context(), txn, user.AccountId)
+			if err != nil {
+				return err
+			}
+
+			result = append(result, *details)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+func (a *adminBase) GetAccountDetails(ctx context.Context, actor Actor, accountId uint64) (*AccountDetails, error) {
+	span := sentry.StartSpan(ctx, "Admin - GetAccountDetails")
+	defer span.Finish()
+
+	var result *AccountDetails
+	err := a.db.RunInTransaction(span.Context(), func(txn *pg.Tx) (err error) {
+		if err = a.audit(span.Context(), txn, actor, models.AuditActionViewAccount, &accountId, nil, nil); err != nil {
+			return err
+		}
+
+		result, err = a.getAccountDetails(span.Context(), txn, accountId)
+		return err
+	})
+
+	return result, err
+}
+
+func (a *adminBase) ResyncLink(ctx context.Context, actor Actor, accountId, linkId uint64) (jobId string, err error) {
+	span := sentry.StartSpan(ctx, "Admin - ResyncLink")
+	defer span.Finish()
+
+	err = a.db.RunInTransaction(span.Context(), func(txn *pg.Tx) error {
+		var link models.Link
+		if err := txn.ModelContext(span.Context(), &link).
+			Where(`"link"."account_id" = ?`, accountId).
+			Where(`"link"."link_id" = ?`, linkId).
+			Limit(1).
+			Select(&link); err != nil {
+			return errors.Wrap(err, "failed to retrieve link")
+		}
+
+		if link.LinkType != models.PlaidLinkType {
+			return errors.WithStack(ErrLinkCannotBeSynced)
+		}
+
+		if err := a.audit(span.Context(), txn, actor, models.AuditActionResyncLink, &accountId, nil, map[string]interface{}{
+			"linkId":     linkId,
+			"linkStatus": link.LinkStatus.String(),
+			"errorCode":  link.ErrorCode,
+		}); err != nil {
+			return err
+		}
+
+		// The job is enqueued last, if it cannot be enqueued then the audit log is rolled back.
+		jobId, err = a.jobs.TriggerPullLatestTransactions(accountId, linkId, 0)
+		return err
+	})
+
+	return jobId, err
+}
+
+func (a *adminBase) ExtendSubscription(ctx context.Context, actor Actor, accountId uint64, activeUntil time.Time) (*models.Account, error) {
+	span := sentry.StartSpan(ctx, "Admin - ExtendSubscription")
+	defer span.Finish()
+
+	var account models.Account
+	err := a.db.RunInTransaction(span.Context(), func(txn *pg.Tx) error {
+		if err := txn.ModelContext(span.Context(), &account).
+			Where(`"account"."account_id" = ?`, accountId).
+			For("UPDATE").
+			Limit(1).
+			Select(&account); err != nil {
+			return errors.Wrap(err, "failed to retrieve account")
+		}
+
+		if err := a.audit(span.Context(), txn, actor, models.AuditActionExtendSubscription, &accountId, nil, map[string]interface{}{
+			"previousActiveUntil": account.SubscriptionActiveUntil,
+			"activeUntil":         activeUntil,
+		}); err != nil {
+			return err
+		}
+
+		account.SubscriptionActiveUntil = &activeUntil
+
+		// The account repository will also update the cached account, this way the paywall sees the change right away.
+		return billing.NewAccountRepository(a.log, a.cache, txn).UpdateAccount(span.Context(), &account)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+func (a *adminBase) SetLoginEnabled(ctx context.Context, actor Actor, loginId uint64, enabled bool) error {
+	span := sentry.StartSpan(ctx, "Admin - SetLoginEnabled")
+	defer span.Finish()
+
+	return a.db.RunInTransaction(span.Context(), func(txn *pg.Tx) error {
+		result, err := txn.ModelContext(span.Context(), &models.Login{}).
+			Set(`"is_enabled" = ?`, enabled).
+			Where(`"login"."login_id" = ?`, loginId).
+			Update()
+		if err != nil {
+			return errors.Wrap(err, "failed to update login")
+		}
+
+		if affected := result.RowsAffected(); affected != 1 {
+			return errors.Errorf("invalid number of login(s) updated; expected: 1 updated: %d", affected)
+		}
+
+		return a.audit(span.Context(), txn, actor, models.AuditActionSetLoginEnabled, nil, &loginId, map[string]interface{}{
+			"enabled": enabled,
+		})
+	})
+}
+
+func (a *adminBase) SetFeatureOverride(ctx context.Context, actor Actor, accountId uint64, item feature.Feature, enabled *bool) (*models.Account, error) {
+	span := sentry.StartSpan(ctx, "Admin - SetFeatureOverride")
+	defer span.Finish()
+
+	if !item.IsValid() {
+		return nil, errors.WithStack(ErrInvalidFeature)
+	}
+
+	var account models.Account
+	err := a.db.RunInTransaction(span.Context(), func(txn *pg.Tx) error {
+		if err := txn.ModelContext(span.Context(), &account).
+			Where(`"account"."account_id" = ?`, accountId).
+			For("UPDATE").
+			Limit(1).
+			Select(&account); err != nil {
+			return errors.Wrap(err, "failed to retrieve account")
+		}
+
+		if err := a.audit(span.Context(), txn, actor, models.AuditActionSetFeatureOverride, &accountId, nil, map[string]interface{}{
+			"feature":    item,
+			"enabled":    enabled,
+			"hadFeature": account.HasFeature(item),
+		}); err != nil {
+			return err
+		}
+
+		if enabled == nil {
+			delete(account.FeatureOverrides, item)
+		} else {
+			if account.FeatureOverrides == nil {
+				account.FeatureOverrides = map[feature.Feature]bool{}
+			}
+			account.FeatureOverrides[item] = *enabled
+		}
+
+		if len(account.FeatureOverrides) == 0 {
+			account.FeatureOverrides = nil
+		}
+
+		return billing.NewAccountRepository(a.log, a.cache, txn).UpdateAccount(span.Context(), &account)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+func (a *adminBase) GetBetaCodes(ctx context.Context, actor Actor) ([]models.Beta, error) {
+	span := sentry.StartSpan(ctx, "Admin - GetBetaCodes")
+	defer span.Finish()
+
+	return repository.NewBetaRepository(a.db).GetBetaCodes(span.Context())
+}
+
+func (a *adminBase) CreateBetaCodes(ctx context.Context, actor Actor, count int, expiresAt time.Time) ([]string, error) {
+	span := sentry.StartSpan(ctx, "Admin - CreateBetaCodes")
+	defer span.Finish()
+
+	codes := make([]string, 0, count)
+	err := a.db.RunInTransaction(span.Context(), func(txn *pg.Tx) error {
+		betas := repository.NewBetaRepository(txn)
+		betaIds := make([]uint64, 0, count)
+		for i := 0; i < count; i++ {
+			code, beta, err := betas.CreateBetaCode(span.Context(), expiresAt, nil)
+			if err != nil {
+				return err
+			}
+
+			codes = append(codes, code)
+			betaIds = append(betaIds, beta.BetaID)
+		}
+
+		return a.audit(span.Context(), txn, actor, models.AuditActionCreateBetaCodes, nil, nil, map[string]interface{}{
+			"betaIds":   betaIds,
+			"expiresAt": expiresAt,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (a *adminBase) RevokeBetaCode(ctx context.Context, actor Actor, betaId uint64) error {
+	span := sentry.StartSpan(ctx, "Admin - RevokeBetaCode")
+	defer span.Finish()
+
+	return a.db.RunInTransaction(span.Context(), func(txn *pg.Tx) error {
+		if err := repository.NewBetaRepository(txn).RevokeBetaCode(span.Context(), betaId); err != nil {
+			return err
+		}
+
+		return a.audit(span.Context(), txn, actor, models.AuditActionRevokeBetaCode, nil, nil, map[string]interface{}{
+			"betaId": betaId,
+		})
+	})
+}
+
+func (a *adminBase) SetBetaInviteQuota(ctx context.Context, actor Actor, userId uint64, quota int) error {
+	span := sentry.StartSpan(ctx, "Admin - SetBetaInviteQuota")
+	defer span.Finish()
+
+	return a.db.RunInTransaction(span.Context(), func(txn *pg.Tx) error {
+		var user models.User
+		if err := txn.ModelContext(span.Context(), &user).
+			Where(`"user"."user_id" = ?`, userId).
+			Limit(1).
+			Select(&user); err != nil {
+			return errors.Wrap(err, "failed to retrieve user")
+		}
+
+		if err := repository.NewBetaRepository(txn).SetBetaInviteQuota(span.Context(), userId, quota); err != nil {
+			return err
+		}
+
+		return a.audit(span.Context(), txn, actor, models.AuditActionSetBetaInviteQuota, &user.AccountId, &user.LoginId, map[string]interface{}{
+			"userId":        userId,
+			"quota":         quota,
+			"previousQuota": user.BetaInviteQuota,
+		})
+	})
+}
@@ -0,0 +1,237 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/monetr/rest-api/pkg/cache"
+	"github.com/monetr/rest-api/pkg/feature"
+	"github.com/monetr/rest-api/pkg/internal/myownsanity"
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/monetr/rest-api/pkg/jobs"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/monetr/rest-api/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testJobEnqueuer struct {
+	linkIds []uint64
+}
+
+func (t *testJobEnqueuer) TriggerPullHistoricalTransactions(accountId, linkId uint64) (string, error) {
+	return "test-job", nil
+}
+
+func (t *testJobEnqueuer) TriggerPullInitialTransactions(accountId, userId, linkId uint64) (string, error) {
+	return "test-job", nil
+}
+
+func (t *testJobEnqueuer) TriggerRemoveTransactions(accountId, linkId uint64, removedTransactions []string) (string, error) {
+	return "test-job", nil
+}
+
+func (t *testJobEnqueuer) TriggerPullLatestTransactions(accountId, linkId uint64, numberOfTransactions int64) (string, error) {
+	// Like gocraft, a job for a link that is already queued is not enqueued again.
+	for _, queuedLinkId := range t.linkIds {
+		if queuedLinkId == linkId {
+			return "", nil
+		}
+	}
+
+	t.linkIds = append(t.linkIds, linkId)
+	return "test-job", nil
+}
+
+func TestAdminBase_ExtendSubscription(t *testing.T) {
+	db := testutils.GetPgDatabase(t)
+	log := testutils.GetLog(t)
+	user, _ := testutils.SeedAccount(t, db, testutils.Nothing)
+
+	service := NewAdmin(log, db, cache.NewCache(log, testutils.GetRedisPool(t)), &testJobEnqueuer{})
+	actor := Actor{
+		Name: "test",
+	}
+
+	activeUntil := time.Now().Add(30 * 24 * time.Hour).UTC().Truncate(time.Second)
+	account, err := service.ExtendSubscription(context.Background(), actor, user.AccountId, activeUntil)
+	require.NoError(t, err, "must extend subscription")
+	require.NotNil(t, account.SubscriptionActiveUntil, "active until must be set")
+	assert.True(t, account.IsSubscriptionActive(), "subscription should now be active")
+
+	var auditLogs []models.AuditLog
+	require.NoError(t, db.Model(&auditLogs).
+		Where(`"audit_log"."target_account_id" = ?`, user.AccountId).
+		Select(&auditLogs), "must retrieve audit logs")
+	require.Len(t, auditLogs, 1, "action must be audited")
+	assert.Equal(t, models.AuditActionExtendSubscription, auditLogs[0].Action)
+	assert.Equal(t, actor.Name, auditLogs[0].Actor)
+}
+
+func TestAdminBase_SetLoginEnabled(t *testing.T) {
+	db := testutils.GetPgDatabase(t)
+	log := testutils.GetLog(t)
+	user, _ := testutils.SeedAccount(t, db, testutils.Nothing)
+
+	service := NewAdmin(log, db, cache.NewCache(log, testutils.GetRedisPool(t)), &testJobEnqueuer{})
+	actor := Actor{
+		Name: "test",
+	}
+
+	err := service.SetLoginEnabled(context.Background(), actor, user.LoginId, false)
+	require.NoError(t, err, "must disable login")
+
+	var login models.Login
+	require.NoError(t, db.Model(&login).
+		Where(`"login"."login_id" = ?`, user.LoginId).
+		Limit(1).
+		Select(&login), "must retrieve login")
+	assert.False(t, login.IsEnabled, "login should now be disabled")
+
+	count, err := db.Model(&models.AuditLog{}).
+		Where(`"audit_log"."target_login_id" = ?`, user.LoginId).
+		Where(`"audit_log"."action" = ?`, models.AuditActionSetLoginEnabled).
+		Count()
+	require.NoError(t, err, "must count audit logs")
+	assert.Equal(t, 1, count, "action must be audited")
+}
+
+func TestAdminBase_ResyncLink(t *testing.T) {
+	t.Run("manual link", func(t *testing.T) {
+		db := testutils.GetPgDatabase(t)
+		log := testutils.GetLog(t)
+		user, _ := testutils.SeedAccount(t, db, testutils.WithManualAccount)
+
+		var link models.Link
+		require.NoError(t, db.Model(&link).
+			Where(`"link"."account_id" = ?`, user.AccountId).
+			Limit(1).
+			Select(&link), "must retrieve link")
+
+		enqueuer := &testJobEnqueuer{}
+		service := NewAdmin(log, db, cache.NewCache(log, testutils.GetRedisPool(t)), enqueuer)
+
+		_, err := service.ResyncLink(context.Background(), Actor{Name: "test"}, user.AccountId, link.LinkId)
+		assert.ErrorIs(t, err, ErrLinkCannotBeSynced, "manual links cannot be synced")
+		assert.Empty(t, enqueuer.linkIds, "no job should be enqueued")
+	})
+
+	t.Run("already queued", func(t *testing.T) {
+		db := testutils.GetPgDatabase(t)
+		log := testutils.GetLog(t)
+		user, _ := testutils.SeedAccount(t, db, testutils.WithPlaidAccount)
+
+		var link models.Link
+		require.NoError(t, db.Model(&link).
+			Where(`"link"."account_id" = ?`, user.AccountId).
+			Limit(1).
+			Select(&link), "must retrieve link")
+
+		// Use a real enqueuer, gocraft will not enqueue a unique job that is already queued.
+		redisPool := testutils.GetRedisPool(t)
+		service := NewAdmin(log, db, cache.NewCache(log, redisPool), jobs.NewJobEnqueuer(log, redisPool, "monetr"))
+
+		jobId, err := service.ResyncLink(context.Background(), Actor{Name: "test"}, user.AccountId, link.LinkId)
+		require.NoError(t, err, "must resync link")
+		assert.NotEmpty(t, jobId, "should return the Id of the enqueued job")
+
+		jobId, err = service.ResyncLink(context.Background(), Actor{Name: "test"}, user.AccountId, link.LinkId)
+		require.NoError(t, err, "resyncing a link that is already queued should not fail")
+		assert.Empty(t, jobId, "no new job should be enqueued")
+
+		count, err := db.Model(&models.AuditLog{}).
+			Where(`"audit_log"."target_account_id" = ?`, user.AccountId).
+			Where(`"audit_log"."action" = ?`, models.AuditActionResyncLink).
+			Count()
+		require.NoError(t, err, "must count audit logs")
+		assert.Equal(t, 2, count, "both resyncs should be audited")
+	})
+}
+
+func TestAdminBase_SetFeatureOverride(t *testing.T) {
+	db := testutils.GetPgDatabase(t)
+	log := testutils.GetLog(t)
+	user, _ := testutils.SeedAccount(t, db, testutils.Nothing)
+
+	service := NewAdmin(log, db, cache.NewCache(log, testutils.GetRedisPool(t)), &testJobEnqueuer{})
+	actor := Actor{
+		Name: "test",
+	}
+
+	account, err := service.SetFeatureOverride(context.Background(), actor, user.AccountId, feature.FeatureLinkedBudgeting, myownsanity.BoolP(true))
+	require.NoError(t, err, "must override feature")
+	assert.True(t, account.HasFeature(feature.FeatureLinkedBudgeting), "account should now have linked budgeting")
+
+	account, err = service.SetFeatureOverride(context.Background(), actor, user.AccountId, feature.FeatureLinkedBudgeting, nil)
+	require.NoError(t, err, "must remove feature override")
+	assert.Nil(t, account.FeatureOverrides, "there should be no more overrides")
+
+	_, err = service.SetFeatureOverride(context.Background(), actor, user.AccountId, feature.Feature("NotAFeature"), nil)
+	assert.ErrorIs(t, err, ErrInvalidFeature, "invalid features cannot be overridden")
+
+	count, err := db.Model(&models.AuditLog{}).
+		Where(`"audit_log"."target_account_id" = ?`, user.AccountId).
+		Where(`"audit_log"."action" = ?`, models.AuditActionSetFeatureOverride).
+		Count()
+	require.NoError(t, err, "must count audit logs")
+	assert.Equal(t, 2, count, "both overrides must be audited")
+}
+
+func TestAdminBase_RevokeBetaCode(t *testing.T) {
+	db := testutils.GetPgDatabase(t)
+	log := testutils.GetLog(t)
+
+	service := NewAdmin(log, db, cache.NewCache(log, testutils.GetRedisPool(t)), &testJobEnqueuer{})
+	actor := Actor{
+		Name: "test",
+	}
+
+	codes, err := service.CreateBetaCodes(context.Background(), actor, 2, time.Now().Add(24*time.Hour))
+	require.NoError(t, err, "must create beta codes")
+	assert.Len(t, codes, 2, "should have created two codes")
+
+	betas, err := service.GetBetaCodes(context.Background(), actor)
+	require.NoError(t, err, "must retrieve beta codes")
+	require.GreaterOrEqual(t, len(betas), 2, "must include the created codes")
+	betaId := betas[len(betas)-1].BetaID
+
+	err = service.RevokeBetaCode(context.Background(), actor, betaId)
+	require.NoError(t, err, "must revoke beta code")
+
+	err = service.RevokeBetaCode(context.Background(), actor, betaId)
+	assert.ErrorIs(t, err, repository.ErrBetaCodeCannotBeRevoked, "a beta code cannot be revoked twice")
+
+	count, err := db.Model(&models.AuditLog{}).
+		Where(`"audit_log"."action" = ?`, models.AuditActionRevokeBetaCode).
+		Where(`"audit_log"."details"->>'betaId' = ?`, fmt.Sprint(betaId)).
+		Count()
+	require.NoError(t, err, "must count audit logs")
+	assert.Equal(t, 1, count, "only the successful revoke must be audited")
+}
+
+func TestAdminBase_SetBetaInviteQuota(t *testing.T) {
+	db := testutils.GetPgDatabase(t)
+	log := testutils.GetLog(t)
+	user, _ := testutils.SeedAccount(t, db, testutils.Nothing)
+
+	service := NewAdmin(log, db, cache.NewCache(log, testutils.GetRedisPool(t)), &testJobEnqueuer{})
+	actor := Actor{
+		Name: "test",
+	}
+
+	err := service.SetBetaInviteQuota(context.Background(), actor, user.UserId, 3)
+	require.NoError(t, err, "must set beta invite quota")
+
+	_, quota, err := repository.NewBetaRepository(db).GetBetaInvites(context.Background(), user.UserId)
+	require.NoError(t, err, "must retrieve beta invites")
+	assert.Equal(t, 3, quota, "quota should have been updated")
+
+	count, err := db.Model(&models.AuditLog{}).
+		Where(`"audit_log"."target_login_id" = ?`, user.LoginId).
+		Where(`"audit_log"."action" = ?`, models.AuditActionSetBetaInviteQuota).
+		Count()
+	require.NoError(t, err, "must count audit logs")
+	assert.Equal(t, 1, count, "action must be audited")
+}
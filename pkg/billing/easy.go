@@ -10,6 +10,7 @@ import (
 	"github.com/getsentry/sentry-go"
 	"github.com/go-pg/pg/v10"
 	"github.com/monetr/rest-api/pkg/cache"
+	"github.com/monetr/rest-api/pkg/config"
 	"github.com/monetr/rest-api/pkg/models"
 	"github.com/monetr/rest-api/pkg/pubsub"
 	"github.com/pkg/errors"
@@ -25,6 +26,9 @@ type AccountRepository interface {
 	GetAccount(ctx context.Context, accountId uint64) (*models.Account, error)
 	GetAccountByCustomerId(ctx context.Context, stripeCustomerId string) (*models.Account, error)
 	UpdateAccount(ctx context.Context, account *models.Account) error
+	// GetAccountLogins returns the login of every user that belongs to the provided account. This is used to notify
+	// the people on an account about changes to its subscription.
+	GetAccountLogins(ctx context.Context, accountId uint64) ([]models.Login, error)
 }
 
 // BasicPayWall is used by the API middleware and other operations to restrict access to some features or functionality
@@ -45,11 +49,12 @@ type BasicBilling interface {
 	// webhook for a subscription being created (which would have an incomplete status) can be delivered after a update
 	// webhook for the same subscription (which would indicate an active status) causing the subscription to incorrectly
 	// show as inactive.
-	UpdateSubscription(ctx context.Context, customerId, subscriptionId string, activeUntil *time.Time, timestamp time.Time) error
+	// The features of the account are derived from the configured plan with the provided price Id.
+	UpdateSubscription(ctx context.Context, customerId, subscriptionId, priceId string, activeUntil *time.Time, timestamp time.Time) error
 	// UpdateCustomerSubscription does the same thing that UpdateSubscription does, but does not require that the
 	// stripe customerId match any customerId stored. Instead, it will take the provided account and update the customer
 	// ID and store it on the account with the new subscription data.
-	UpdateCustomerSubscription(ctx context.Context, account *models.Account, customerId, subscriptionId string, activeUntil *time.Time, timestamp time.Time) error
+	UpdateCustomerSubscription(ctx context.Context, account *models.Account, customerId, subscriptionId, priceId string, activeUntil *time.Time, timestamp time.Time) error
 }
 
 var (
@@ -149,19 +154,43 @@ func (p *postgresAccountRepository) UpdateAccount(ctx context.Context, account *
 	return nil
 }
 
+func (p *postgresAccountRepository) GetAccountLogins(ctx context.Context, accountId uint64) ([]models.Login, error) {
+	span := sentry.StartSpan(ctx, "Billing - GetAccountLogins")
+	defer span.Finish()
+
+	logins := make([]models.Login, 0)
+	if err := p.db.ModelContext(span.Context(), &logins).
+		Join(`INNER JOIN "users" AS "user"`).
+		JoinOn(`"user"."login_id" = "login"."login_id"`).
+		Where(`"user"."account_id" = ?`, accountId).
+		Select(&logins); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve logins for account")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return logins, nil
+}
+
 var (
 	_ BasicPayWall = &baseBasicPaywall{}
 )
 
 type baseBasicPaywall struct {
-	log      *logrus.Entry
-	accounts AccountRepository
+	log         *logrus.Entry
+	accounts    AccountRepository
+	gracePeriod time.Duration
 }
 
-func NewBasicPaywall(log *logrus.Entry, repo AccountRepository) BasicPayWall {
+// NewBasicPaywall returns a paywall that considers an account's subscription active until its active until date has
+// passed. If a payment for the subscription has failed then the account is still considered active until the grace
+// period after that failure is over.
+func NewBasicPaywall(log *logrus.Entry, repo AccountRepository, gracePeriod time.Duration) BasicPayWall {
 	return &baseBasicPaywall{
-		log:      log,
-		accounts: repo,
+		log:         log,
+		accounts:    repo,
+		gracePeriod: gracePeriod,
 	}
 }
 
@@ -213,7 +242,18 @@ func (b *baseBasicPaywall) GetSubscriptionIsActive(ctx context.Context, accountI
 
 	span.Status = sentry.SpanStatusOK
 
-	return account.IsSubscriptionActive(), nil
+	if account.IsSubscriptionActive() {
+		return true, nil
+	}
+
+	if account.IsInGracePeriod(b.gracePeriod) {
+		crumbs.Warn(span.Context(), "Subscription is not active, but the account is within the grace period for a failed payment", "subscription", map[string]interface{}{
+			"paymentFailedAt": account.PaymentFailedAt,
+		})
+		return true, nil
+	}
+
+	return false, nil
 }
 
 var (
@@ -224,17 +264,19 @@ type baseBasicBilling struct {
 	log    *logrus.Entry
 	repo   AccountRepository
 	notify pubsub.Publisher
+	stripe config.Stripe
 }
 
-func NewBasicBilling(log *logrus.Entry, repo AccountRepository, notifications pubsub.Publisher) BasicBilling {
+func NewBasicBilling(log *logrus.Entry, repo AccountRepository, notifications pubsub.Publisher, stripeConfig config.Stripe) BasicBilling {
 	return &baseBasicBilling{
 		log:    log,
 		repo:   repo,
 		notify: notifications,
+		stripe: stripeConfig,
 	}
 }
 
-func (b *baseBasicBilling) UpdateSubscription(ctx context.Context, customerId, subscriptionId string, activeUntil *time.Time, timestamp time.Time) error {
+func (b *baseBasicBilling) UpdateSubscription(ctx context.Context, customerId, subscriptionId, priceId string, activeUntil *time.Time, timestamp time.Time) error {
 	span := sentry.StartSpan(ctx, "Billing - UpdateSubscription")
 	defer span.Finish()
 
@@ -251,16 +293,17 @@ func (b *baseBasicBilling) UpdateSubscription(ctx context.Context, customerId, s
 		return errors.Wrap(err, "failed to retrieve account by stripe customer Id")
 	}
 
-	return b.UpdateCustomerSubscription(span.Context(), account, customerId, subscriptionId, activeUntil, timestamp)
+	return b.UpdateCustomerSubscription(span.Context(), account, customerId, subscriptionId, priceId, activeUntil, timestamp)
 }
 
-func (b *baseBasicBilling) UpdateCustomerSubscription(ctx context.Context, account *models.Account, customerId, subscriptionId string, activeUntil *time.Time, timestamp time.Time) error {
+func (b *baseBasicBilling) UpdateCustomerSubscription(ctx context.Context, account *models.Account, customerId, subscriptionId, priceId string, activeUntil *time.Time, timestamp time.Time) error {
 	span := sentry.StartSpan(ctx, "Billing - UpdateCustomerSubscription")
 	defer span.Finish()
 
 	log := b.log.WithContext(span.Context()).WithFields(logrus.Fields{
 		"customerId":     customerId,
 		"subscriptionId": subscriptionId,
+		"priceId":        priceId,
 		"accountId":      account.AccountId,
 	})
 
@@ -317,6 +360,18 @@ func (b *baseBasicBilling) UpdateCustomerSubscription(ctx context.Context, accou
 	account.SubscriptionActiveUntil = activeUntil
 	account.StripeWebhookLatestTimestamp = &timestamp
 
+	if priceId != "" {
+		account.StripePriceId = &priceId
+	}
+
+	// If the price does not belong to any plan that we know of then we leave the account's features alone. This way
+	// an account does not lose access to something just because a plan was removed from the configuration.
+	if plan, ok := b.stripe.GetPlanByPriceId(priceId); ok {
+		account.Features = plan.Features
+	} else {
+		log.Warn("subscription price does not match any configured plan, account features will not be changed")
+	}
+
 	if err := b.repo.UpdateAccount(span.Context(), account); err != nil {
 		log.WithError(err).Errorf("failed to update account subscription status")
 		return errors.Wrap(err, "failed to update account subscription status")
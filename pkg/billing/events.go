@@ -0,0 +1,260 @@
+package billing
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/rest-api/pkg/crumbs"
+	"github.com/monetr/rest-api/pkg/internal/myownsanity"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stripe/stripe-go/v72"
+	"time"
+)
+
+// StripeEventLeaseDuration is how long an event that is being processed is claimed for. If the event is still pending
+// after this then whatever was processing it is assumed to have died, and the next delivery of the event will claim it.
+const StripeEventLeaseDuration = 5 * time.Minute
+
+// StripeEventRepository keeps a record of every event that we receive from Stripe. It is used to skip events that
+// Stripe delivers more than once, and to look up events that need to be replayed.
+type StripeEventRepository interface {
+	// StartEvent records that the provided event has been received. It returns true if the event should be processed,
+	// and false if the event has already been processed successfully, or if it is still being processed and its lease
+	// has not expired.
+	StartEvent(ctx context.Context, event stripe.Event) (bool, error)
+	// FinishEvent records the outcome of processing the event. If handleErr is nil then the event is considered to be
+	// processed and any future deliveries of it will be skipped.
+	FinishEvent(ctx context.Context, stripeEventId string, handleErr error) error
+	GetEvent(ctx context.Context, stripeEventId string) (*models.StripeEvent, error)
+}
+
+var (
+	_ StripeEventRepository = &postgresStripeEventRepository{}
+)
+
+type postgresStripeEventRepository struct {
+	log *logrus.Entry
+	db  pg.DBI
+}
+
+func NewStripeEventRepository(log *logrus.Entry, db pg.DBI) StripeEventRepository {
+	return &postgresStripeEventRepository{
+		log: log,
+		db:  db,
+	}
+}
+
+func (p *postgresStripeEventRepository) StartEvent(ctx context.Context, event stripe.Event) (bool, error) {
+	span := sentry.StartSpan(ctx, "Billing - StartEvent")
+	defer span.Finish()
+
+	log := p.log.WithContext(span.Context()).WithFields(logrus.Fields{
+		"eventId":   event.ID,
+		"eventType": event.Type,
+	})
+
+	record, err := NewStripeEventRecord(event)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := p.db.ModelContext(span.Context(), record).
+		OnConflict(`("stripe_event_id") DO NOTHING`).
+		Insert(record)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return false, errors.Wrap(err, "failed to record stripe event")
+	}
+
+	if result.RowsAffected() > 0 {
+		return true, nil
+	}
+
+	var existing models.StripeEvent
+	if err = p.db.ModelContext(span.Context(), &existing).
+		Where(`"stripe_event"."stripe_event_id" = ?`, event.ID).
+		Limit(1).
+		Select(&existing); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return false, errors.Wrap(err, "failed to retrieve existing stripe event")
+	}
+
+	if existing.PayloadHash != record.PayloadHash {
+		log.WithFields(logrus.Fields{
+			"storedHash":   existing.PayloadHash,
+			"receivedHash": record.PayloadHash,
+		}).Warn("received a stripe event with the same Id but a different payload")
+	}
+
+	// Only events that failed, or that are pending but whose lease has expired are claimed again. An event that is
+	// pending with a valid lease is still being processed by another delivery. This is done as a single update so that
+	// two deliveries of the same event cannot both claim it.
+	now := time.Now().UTC()
+	result, err = p.db.ModelContext(span.Context(), &existing).
+		Set(`"outcome" = ?`, models.StripeEventOutcomePending).
+		Set(`"attempts" = "attempts" + 1`).
+		Set(`"error" = NULL`).
+		Set(`"claimed_at" = ?`, now).
+		Where(`"stripe_event"."stripe_event_id" = ?`, event.ID).
+		Where(
+			`"stripe_event"."outcome" = ? OR ("stripe_event"."outcome" = ? AND "stripe_event"."claimed_at" < ?)`,
+			models.StripeEventOutcomeFailed,
+			models.StripeEventOutcomePending,
+			now.Add(-StripeEventLeaseDuration),
+		).
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return false, errors.Wrap(err, "failed to update existing stripe event")
+	}
+
+	if result.RowsAffected() == 0 {
+		log.WithField("outcome", existing.Outcome).Debug("stripe event has already been processed or is being processed, it will be skipped")
+		return false, nil
+	}
+
+	log.WithField("previousOutcome", existing.Outcome).Info("stripe event was not processed successfully before, it will be retried")
+
+	return true, nil
+}
+
+func (p *postgresStripeEventRepository) FinishEvent(ctx context.Context, stripeEventId string, handleErr error) error {
+	span := sentry.StartSpan(ctx, "Billing - FinishEvent")
+	defer span.Finish()
+
+	outcome := models.StripeEventOutcomeProcessed
+	var message *string
+	if handleErr != nil {
+		outcome = models.StripeEventOutcomeFailed
+		message = myownsanity.StringP(handleErr.Error())
+	}
+
+	_, err := p.db.ModelContext(span.Context(), &models.StripeEvent{}).
+		Set(`"outcome" = ?`, outcome).
+		Set(`"error" = ?`, message).
+		Set(`"processed_at" = ?`, time.Now().UTC()).
+		Where(`"stripe_event"."stripe_event_id" = ?`, stripeEventId).
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to update stripe event outcome")
+	}
+
+	return nil
+}
+
+func (p *postgresStripeEventRepository) GetEvent(ctx context.Context, stripeEventId string) (*models.StripeEvent, error) {
+	span := sentry.StartSpan(ctx, "Billing - GetEvent")
+	defer span.Finish()
+
+	var event models.StripeEvent
+	if err := p.db.ModelContext(span.Context(), &event).
+		Where(`"stripe_event"."stripe_event_id" = ?`, stripeEventId).
+		Limit(1).
+		Select(&event); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve stripe event")
+	}
+
+	return &event, nil
+}
+
+// NewStripeEventRecord builds the record that is stored for the provided event. The record has not been processed.
+func NewStripeEventRecord(event stripe.Event) (*models.StripeEvent, error) {
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode stripe event")
+	}
+
+	var payload map[string]interface{}
+	if err = json.Unmarshal(encoded, &payload); err != nil {
+		return nil, errors.Wrap(err, "failed to decode stripe event payload")
+	}
+
+	hash := sha256.Sum256(encoded)
+	now := time.Now().UTC()
+
+	return &models.StripeEvent{
+		StripeEventId: event.ID,
+		Type:          event.Type,
+		Payload:       payload,
+		PayloadHash:   hex.EncodeToString(hash[:]),
+		Outcome:       models.StripeEventOutcomePending,
+		Attempts:      1,
+		ReceivedAt:    now,
+		ClaimedAt:     now,
+	}, nil
+}
+
+// StripeEventFromRecord rebuilds the event that was received from Stripe from the stored payload.
+func StripeEventFromRecord(record *models.StripeEvent) (stripe.Event, error) {
+	var event stripe.Event
+	encoded, err := json.Marshal(record.Payload)
+	if err != nil {
+		return event, errors.Wrap(err, "failed to encode stored stripe event payload")
+	}
+
+	if err = json.Unmarshal(encoded, &event); err != nil {
+		return event, errors.Wrap(err, "failed to decode stored stripe event payload")
+	}
+
+	return event, nil
+}
+
+var (
+	_ StripeWebhookHandler = &idempotentStripeWebhookHandler{}
+)
+
+type idempotentStripeWebhookHandler struct {
+	log     *logrus.Entry
+	events  StripeEventRepository
+	handler StripeWebhookHandler
+}
+
+// NewIdempotentStripeWebhookHandler wraps the provided handler so that every event is recorded before it is handled,
+// and events that have already been handled successfully are skipped.
+func NewIdempotentStripeWebhookHandler(log *logrus.Entry, events StripeEventRepository, handler StripeWebhookHandler) StripeWebhookHandler {
+	return &idempotentStripeWebhookHandler{
+		log:     log,
+		events:  events,
+		handler: handler,
+	}
+}
+
+func (i *idempotentStripeWebhookHandler) HandleWebhook(ctx context.Context, event stripe.Event) error {
+	span := sentry.StartSpan(ctx, "Stripe - Idempotent Webhook")
+	defer span.Finish()
+
+	log := i.log.WithContext(span.Context()).WithFields(logrus.Fields{
+		"eventType": event.Type,
+		"eventId":   event.ID,
+	})
+
+	shouldProcess, err := i.events.StartEvent(span.Context(), event)
+	if err != nil {
+		// If we cannot record the event then we still want to handle it, the webhook handler is tolerant of events
+		// being delivered more than once.
+		log.WithError(err).Warn("failed to record stripe event, it will be handled anyway")
+		return i.handler.HandleWebhook(span.Context(), event)
+	}
+
+	if !shouldProcess {
+		crumbs.Debug(span.Context(), "Skipping duplicate Stripe event.", map[string]interface{}{
+			"eventId": event.ID,
+			"type":    event.Type,
+		})
+		return nil
+	}
+
+	handleErr := i.handler.HandleWebhook(span.Context(), event)
+	if err = i.events.FinishEvent(span.Context(), event.ID, handleErr); err != nil {
+		log.WithError(err).Warn("failed to record outcome of stripe event")
+	}
+
+	return handleErr
+}
@@ -0,0 +1,283 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/monetr/rest-api/pkg/internal/myownsanity"
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v72"
+)
+
+var (
+	_ StripeEventRepository = &memoryStripeEventRepository{}
+	_ StripeWebhookHandler  = &countingStripeWebhookHandler{}
+)
+
+type memoryStripeEventRepository struct {
+	events map[string]*models.StripeEvent
+}
+
+func (m *memoryStripeEventRepository) StartEvent(ctx context.Context, event stripe.Event) (bool, error) {
+	if existing, ok := m.events[event.ID]; ok {
+		switch existing.Outcome {
+		case models.StripeEventOutcomeFailed:
+		case models.StripeEventOutcomePending:
+			if time.Since(existing.ClaimedAt) < StripeEventLeaseDuration {
+				return false, nil
+			}
+		default:
+			return false, nil
+		}
+
+		existing.Outcome = models.StripeEventOutcomePending
+		existing.Attempts++
+		existing.Error = nil
+		existing.ClaimedAt = time.Now().UTC()
+		return true, nil
+	}
+
+	record, err := NewStripeEventRecord(event)
+	if err != nil {
+		return false, err
+	}
+
+	m.events[event.ID] = record
+	return true, nil
+}
+
+func (m *memoryStripeEventRepository) FinishEvent(ctx context.Context, stripeEventId string, handleErr error) error {
+	record := m.events[stripeEventId]
+	record.Outcome = models.StripeEventOutcomeProcessed
+	record.Error = nil
+	if handleErr != nil {
+		record.Outcome = models.StripeEventOutcomeFailed
+		record.Error = myownsanity.StringP(handleErr.Error())
+	}
+	record.ProcessedAt = myownsanity.TimeP(time.Now())
+
+	return nil
+}
+
+func (m *memoryStripeEventRepository) GetEvent(ctx context.Context, stripeEventId string) (*models.StripeEvent, error) {
+	record, ok := m.events[stripeEventId]
+	if !ok {
+		return nil, errors.New("stripe event not found")
+	}
+
+	return record, nil
+}
+
+type countingStripeWebhookHandler struct {
+	calls int
+	err   error
+}
+
+func (c *countingStripeWebhookHandler) HandleWebhook(ctx context.Context, event stripe.Event) error {
+	c.calls++
+	return c.err
+}
+
+func TestIdempotentStripeWebhookHandler_HandleWebhook(t *testing.T) {
+	t.Run("skip duplicates", func(t *testing.T) {
+		events := &memoryStripeEventRepository{
+			events: map[string]*models.StripeEvent{},
+		}
+		inner := &countingStripeWebhookHandler{}
+		handler := NewIdempotentStripeWebhookHandler(testutils.GetLog(t), events, inner)
+
+		event := newInvoiceEvent(t, "invoice.paid", "cus_123", time.Now())
+		require.NoError(t, handler.HandleWebhook(context.Background(), event))
+		require.NoError(t, handler.HandleWebhook(context.Background(), event))
+		assert.Equal(t, 1, inner.calls, "duplicate event should not be handled again")
+
+		record, err := events.GetEvent(context.Background(), event.ID)
+		require.NoError(t, err, "event must be recorded")
+		assert.Equal(t, models.StripeEventOutcomeProcessed, record.Outcome, "event should be processed")
+		assert.Equal(t, "invoice.paid", record.Type, "event type should be recorded")
+		assert.NotEmpty(t, record.PayloadHash, "payload hash should be recorded")
+		assert.NotNil(t, record.ProcessedAt, "processed at should be recorded")
+	})
+
+	t.Run("retry failures", func(t *testing.T) {
+		events := &memoryStripeEventRepository{
+			events: map[string]*models.StripeEvent{},
+		}
+		inner := &countingStripeWebhookHandler{
+			err: errors.New("something went wrong"),
+		}
+		handler := NewIdempotentStripeWebhookHandler(testutils.GetLog(t), events, inner)
+
+		event := newInvoiceEvent(t, "invoice.paid", "cus_123", time.Now())
+		assert.EqualError(t, handler.HandleWebhook(context.Background(), event), "something went wrong")
+
+		record, err := events.GetEvent(context.Background(), event.ID)
+		require.NoError(t, err, "event must be recorded")
+		assert.Equal(t, models.StripeEventOutcomeFailed, record.Outcome, "event should have failed")
+		assert.Equal(t, "something went wrong", *record.Error, "error should be recorded")
+
+		inner.err = nil
+		require.NoError(t, handler.HandleWebhook(context.Background(), event))
+		assert.Equal(t, 2, inner.calls, "failed event should be handled again")
+		assert.Equal(t, models.StripeEventOutcomeProcessed, record.Outcome, "event should be processed")
+		assert.Equal(t, 2, record.Attempts, "both attempts should be recorded")
+		assert.Nil(t, record.Error, "error should be cleared")
+	})
+}
+
+func TestStripeEventFromRecord(t *testing.T) {
+	event := newInvoiceEvent(t, "invoice.payment_failed", "cus_123", time.Now())
+	record, err := NewStripeEventRecord(event)
+	require.NoError(t, err, "must build record")
+
+	again, err := NewStripeEventRecord(event)
+	require.NoError(t, err, "must build record")
+	assert.Equal(t, record.PayloadHash, again.PayloadHash, "hash should be stable for the same event")
+
+	replayed, err := StripeEventFromRecord(record)
+	require.NoError(t, err, "must rebuild event from record")
+	assert.Equal(t, event.ID, replayed.ID, "event Id should match")
+	assert.Equal(t, event.Type, replayed.Type, "event type should match")
+	assert.Equal(t, event.Created, replayed.Created, "event created should match")
+
+	var invoice stripe.Invoice
+	require.NotNil(t, replayed.Data, "event data should be rebuilt")
+	require.NoError(t, json.Unmarshal(replayed.Data.Raw, &invoice), "must decode invoice")
+	assert.Equal(t, "cus_123", invoice.Customer.ID, "invoice customer should match")
+}
+
+func TestPostgresStripeEventRepository_StartEvent(t *testing.T) {
+	t.Run("new event", func(t *testing.T) {
+		db := testutils.GetPgDatabaseTxn(t)
+		events := NewStripeEventRepository(testutils.GetLog(t), db)
+
+		event := newInvoiceEvent(t, "invoice.paid", "cus_123", time.Now())
+		event.ID = "evt_" + gofakeit.UUID()
+
+		shouldProcess, err := events.StartEvent(context.Background(), event)
+		require.NoError(t, err, "must start event")
+		assert.True(t, shouldProcess, "a new event should be processed")
+
+		record, err := events.GetEvent(context.Background(), event.ID)
+		require.NoError(t, err, "event must be recorded")
+		assert.Equal(t, models.StripeEventOutcomePending, record.Outcome, "event should be pending")
+		assert.Equal(t, 1, record.Attempts, "first attempt should be recorded")
+		assert.False(t, record.ClaimedAt.IsZero(), "claimed at should be recorded")
+	})
+
+	t.Run("conflict while pending", func(t *testing.T) {
+		db := testutils.GetPgDatabaseTxn(t)
+		events := NewStripeEventRepository(testutils.GetLog(t), db)
+
+		event := newInvoiceEvent(t, "invoice.paid", "cus_123", time.Now())
+		event.ID = "evt_" + gofakeit.UUID()
+
+		shouldProcess, err := events.StartEvent(context.Background(), event)
+		require.NoError(t, err, "must start event")
+		require.True(t, shouldProcess, "a new event should be processed")
+
+		// The first delivery is still processing the event, so a second delivery must not claim it.
+		shouldProcess, err = events.StartEvent(context.Background(), event)
+		require.NoError(t, err, "must start duplicate event")
+		assert.False(t, shouldProcess, "a pending event with a valid lease should not be processed again")
+
+		record, err := events.GetEvent(context.Background(), event.ID)
+		require.NoError(t, err, "event must be recorded")
+		assert.Equal(t, 1, record.Attempts, "duplicate should not count as an attempt")
+	})
+
+	t.Run("conflict after processed", func(t *testing.T) {
+		db := testutils.GetPgDatabaseTxn(t)
+		events := NewStripeEventRepository(testutils.GetLog(t), db)
+
+		event := newInvoiceEvent(t, "invoice.paid", "cus_123", time.Now())
+		event.ID = "evt_" + gofakeit.UUID()
+
+		shouldProcess, err := events.StartEvent(context.Background(), event)
+		require.NoError(t, err, "must start event")
+		require.True(t, shouldProcess, "a new event should be processed")
+		require.NoError(t, events.FinishEvent(context.Background(), event.ID, nil), "must finish event")
+
+		shouldProcess, err = events.StartEvent(context.Background(), event)
+		require.NoError(t, err, "must start duplicate event")
+		assert.False(t, shouldProcess, "a processed event should not be processed again")
+
+		record, err := events.GetEvent(context.Background(), event.ID)
+		require.NoError(t, err, "event must be recorded")
+		assert.Equal(t, models.StripeEventOutcomeProcessed, record.Outcome, "event should still be processed")
+		assert.NotNil(t, record.ProcessedAt, "processed at should be recorded")
+	})
+
+	t.Run("re-claim failed", func(t *testing.T) {
+		db := testutils.GetPgDatabaseTxn(t)
+		events := NewStripeEventRepository(testutils.GetLog(t), db)
+
+		event := newInvoiceEvent(t, "invoice.paid", "cus_123", time.Now())
+		event.ID = "evt_" + gofakeit.UUID()
+
+		shouldProcess, err := events.StartEvent(context.Background(), event)
+		require.NoError(t, err, "must start event")
+		require.True(t, shouldProcess, "a new event should be processed")
+		require.NoError(t, events.FinishEvent(
+			context.Background(),
+			event.ID,
+			errors.New("something went wrong"),
+		), "must finish event")
+
+		record, err := events.GetEvent(context.Background(), event.ID)
+		require.NoError(t, err, "event must be recorded")
+		assert.Equal(t, models.StripeEventOutcomeFailed, record.Outcome, "event should have failed")
+		require.NotNil(t, record.Error, "error should be recorded")
+		assert.Equal(t, "something went wrong", *record.Error, "error should match")
+
+		shouldProcess, err = events.StartEvent(context.Background(), event)
+		require.NoError(t, err, "must start event again")
+		assert.True(t, shouldProcess, "a failed event should be processed again")
+
+		record, err = events.GetEvent(context.Background(), event.ID)
+		require.NoError(t, err, "event must be recorded")
+		assert.Equal(t, models.StripeEventOutcomePending, record.Outcome, "event should be pending again")
+		assert.Equal(t, 2, record.Attempts, "both attempts should be recorded")
+		assert.Nil(t, record.Error, "error should be cleared")
+	})
+
+	t.Run("re-claim expired lease", func(t *testing.T) {
+		db := testutils.GetPgDatabaseTxn(t)
+		events := NewStripeEventRepository(testutils.GetLog(t), db)
+
+		event := newInvoiceEvent(t, "invoice.paid", "cus_123", time.Now())
+		event.ID = "evt_" + gofakeit.UUID()
+
+		shouldProcess, err := events.StartEvent(context.Background(), event)
+		require.NoError(t, err, "must start event")
+		require.True(t, shouldProcess, "a new event should be processed")
+
+		// Pretend that whatever claimed the event died before it could finish it.
+		_, err = db.Model(&models.StripeEvent{}).
+			Set(`"claimed_at" = ?`, time.Now().Add(-2*StripeEventLeaseDuration)).
+			Where(`"stripe_event"."stripe_event_id" = ?`, event.ID).
+			Update()
+		require.NoError(t, err, "must expire the lease")
+
+		shouldProcess, err = events.StartEvent(context.Background(), event)
+		require.NoError(t, err, "must start event again")
+		assert.True(t, shouldProcess, "a pending event with an expired lease should be processed again")
+
+		record, err := events.GetEvent(context.Background(), event.ID)
+		require.NoError(t, err, "event must be recorded")
+		assert.Equal(t, 2, record.Attempts, "both attempts should be recorded")
+		assert.WithinDuration(t, time.Now(), record.ClaimedAt, time.Minute, "the lease should be renewed")
+
+		// Now that the lease has been renewed, another delivery should not claim it.
+		shouldProcess, err = events.StartEvent(context.Background(), event)
+		require.NoError(t, err, "must start duplicate event")
+		assert.False(t, shouldProcess, "the renewed lease should be respected")
+	})
+}
@@ -3,10 +3,14 @@ package billing
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/getsentry/sentry-go"
+	"github.com/monetr/rest-api/pkg/communication"
+	"github.com/monetr/rest-api/pkg/config"
 	"github.com/monetr/rest-api/pkg/crumbs"
 	"github.com/monetr/rest-api/pkg/internal/myownsanity"
 	"github.com/monetr/rest-api/pkg/internal/stripe_helper"
+	"github.com/monetr/rest-api/pkg/models"
 	"github.com/monetr/rest-api/pkg/pubsub"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -28,19 +32,27 @@ type baseStripeWebhookHandler struct {
 	repo                 AccountRepository
 	billing              BasicBilling
 	billingNotifications pubsub.PublishSubscribe
+	communication        communication.UserCommunication
+	configuration        config.Configuration
 }
 
+// NewStripeWebhookHandler returns a handler for the webhooks that Stripe sends about subscriptions and payments. If
+// userCommunication is nil then no emails will be sent to users about failed payments or their trial ending.
 func NewStripeWebhookHandler(
 	log *logrus.Entry,
 	accountRepo AccountRepository,
 	billing BasicBilling,
 	publisher pubsub.PublishSubscribe,
+	userCommunication communication.UserCommunication,
+	configuration config.Configuration,
 ) StripeWebhookHandler {
 	return &baseStripeWebhookHandler{
 		log:                  log,
 		repo:                 accountRepo,
 		billing:              billing,
 		billingNotifications: publisher,
+		communication:        userCommunication,
+		configuration:        configuration,
 	}
 }
 
@@ -83,6 +95,7 @@ func (b *baseStripeWebhookHandler) HandleWebhook(ctx context.Context, event stri
 			span.Context(),
 			subscription.Customer.ID,
 			subscription.ID,
+			stripe_helper.SubscriptionPriceId(subscription),
 			validUntil,
 			timestamp,
 		); err != nil {
@@ -131,9 +144,182 @@ func (b *baseStripeWebhookHandler) HandleWebhook(ctx context.Context, event stri
 		}
 
 		return nil
+	case "invoice.payment_failed":
+		log.Info("handling invoice payment failed webhook")
+		var invoice stripe.Invoice
+		if err := json.Unmarshal(event.Data.Raw, &invoice); err != nil {
+			log.WithError(err).Errorf("failed to extract invoice from json")
+			return errors.Wrap(err, "failed to extract invoice from json")
+		}
+
+		return b.handlePaymentFailed(span.Context(), log, invoice, timestamp)
+	case "invoice.paid":
+		log.Info("handling invoice paid webhook")
+		var invoice stripe.Invoice
+		if err := json.Unmarshal(event.Data.Raw, &invoice); err != nil {
+			log.WithError(err).Errorf("failed to extract invoice from json")
+			return errors.Wrap(err, "failed to extract invoice from json")
+		}
+
+		return b.handleInvoicePaid(span.Context(), log, invoice, timestamp)
+	case "customer.subscription.trial_will_end":
+		log.Info("handling subscription trial will end webhook")
+		var subscription stripe.Subscription
+		if err := json.Unmarshal(event.Data.Raw, &subscription); err != nil {
+			log.WithError(err).Errorf("failed to extract subscription from json")
+			return errors.Wrap(err, "failed to extract subscription from json")
+		}
+
+		return b.handleTrialWillEnd(span.Context(), log, subscription)
 	default:
 		log.Warn("cannot handle stripe webhook event type")
 	}
 
 	return nil
 }
+
+// getAccountForCustomer will return the account for the provided Stripe customer. If there is no account for the
+// customer then nil is returned without an error, there is nothing we can do with events for customers we don't know.
+func (b *baseStripeWebhookHandler) getAccountForCustomer(ctx context.Context, log *logrus.Entry, customer *stripe.Customer) *models.Account {
+	if customer == nil || customer.ID == "" {
+		log.Warn("stripe event does not have a customer")
+		return nil
+	}
+
+	account, err := b.repo.GetAccountByCustomerId(ctx, customer.ID)
+	if err != nil {
+		log.WithError(err).Warn("failed to retrieve account by customer Id")
+		crumbs.Warn(ctx, "Failed to retrieve an account for this provided customer Id", "stripe", map[string]interface{}{
+			"customerId": customer.ID,
+		})
+		return nil
+	}
+
+	if hub := sentry.GetHubFromContext(ctx); hub != nil {
+		hub.Scope().SetUser(sentry.User{
+			ID: strconv.FormatUint(account.AccountId, 10),
+		})
+	}
+
+	return account
+}
+
+// getAccountLocation returns the timezone of the account so that dates in notifications are shown in the user's time.
+func (b *baseStripeWebhookHandler) getAccountLocation(log *logrus.Entry, account *models.Account) *time.Location {
+	location, err := account.GetTimezone()
+	if err != nil {
+		log.WithError(err).Warn("failed to parse account timezone, notification will use UTC")
+		return time.UTC
+	}
+
+	return location
+}
+
+func (b *baseStripeWebhookHandler) billingURL() string {
+	return fmt.Sprintf("https://%s/account/subscribe", b.configuration.UIDomainName)
+}
+
+// notifyAccount will call the provided callback for each login that belongs to the account. Failures to send a
+// notification are logged but are not returned, Stripe would retry the webhook and we would notify the rest of the
+// logins again.
+func (b *baseStripeWebhookHandler) notifyAccount(ctx context.Context, log *logrus.Entry, accountId uint64, send func(login models.Login) error) {
+	if b.communication == nil {
+		log.Debug("email is not enabled, account will not be notified")
+		return
+	}
+
+	logins, err := b.repo.GetAccountLogins(ctx, accountId)
+	if err != nil {
+		log.WithError(err).Warn("failed to retrieve logins to notify")
+		return
+	}
+
+	for _, login := range logins {
+		if err := send(login); err != nil {
+			log.WithError(err).WithField("loginId", login.LoginId).Warn("failed to notify login")
+		}
+	}
+}
+
+// handlePaymentFailed will record when the first payment failure happened for the account, this starts the grace
+// period for the account. Every failure, including Stripe's retries, will send an email to the account's users.
+func (b *baseStripeWebhookHandler) handlePaymentFailed(ctx context.Context, log *logrus.Entry, invoice stripe.Invoice, timestamp time.Time) error {
+	account := b.getAccountForCustomer(ctx, log, invoice.Customer)
+	if account == nil {
+		return nil
+	}
+
+	log = log.WithField("accountId", account.AccountId)
+
+	if account.PaymentFailedAt == nil {
+		account.PaymentFailedAt = &timestamp
+		if err := b.repo.UpdateAccount(ctx, account); err != nil {
+			log.WithError(err).Errorf("failed to record payment failure")
+			return errors.Wrap(err, "failed to record payment failure")
+		}
+
+		log.Info("payment failed, account is now in its grace period")
+	}
+
+	gracePeriodEndsAt := account.GetGracePeriodEndsAt(b.configuration.Stripe.GracePeriod)
+	if gracePeriodEndsAt != nil {
+		*gracePeriodEndsAt = gracePeriodEndsAt.In(b.getAccountLocation(log, account))
+	}
+	b.notifyAccount(ctx, log, account.AccountId, func(login models.Login) error {
+		return b.communication.SendPaymentFailedEmail(ctx, communication.PaymentFailedParams{
+			Login:             login,
+			BillingURL:        b.billingURL(),
+			GracePeriodEndsAt: gracePeriodEndsAt,
+		})
+	})
+
+	return nil
+}
+
+// handleInvoicePaid will end the grace period of the account if there was a failed payment before this invoice was
+// paid.
+func (b *baseStripeWebhookHandler) handleInvoicePaid(ctx context.Context, log *logrus.Entry, invoice stripe.Invoice, timestamp time.Time) error {
+	account := b.getAccountForCustomer(ctx, log, invoice.Customer)
+	if account == nil {
+		return nil
+	}
+
+	// If the payment failure happened after this invoice was paid, then we are receiving the webhooks out of order and
+	// the failure should not be cleared.
+	if account.PaymentFailedAt == nil || account.PaymentFailedAt.After(timestamp) {
+		return nil
+	}
+
+	account.PaymentFailedAt = nil
+	if err := b.repo.UpdateAccount(ctx, account); err != nil {
+		log.WithError(err).Errorf("failed to clear payment failure")
+		return errors.Wrap(err, "failed to clear payment failure")
+	}
+
+	log.WithField("accountId", account.AccountId).Info("invoice paid, account is no longer in its grace period")
+
+	return nil
+}
+
+func (b *baseStripeWebhookHandler) handleTrialWillEnd(ctx context.Context, log *logrus.Entry, subscription stripe.Subscription) error {
+	account := b.getAccountForCustomer(ctx, log, subscription.Customer)
+	if account == nil {
+		return nil
+	}
+
+	if subscription.TrialEnd == 0 {
+		log.Warn("subscription does not have a trial end")
+		return nil
+	}
+
+	trialEndsAt := time.Unix(subscription.TrialEnd, 0).In(b.getAccountLocation(log, account))
+	b.notifyAccount(ctx, log.WithField("accountId", account.AccountId), account.AccountId, func(login models.Login) error {
+		return b.communication.SendTrialEndingEmail(ctx, communication.TrialEndingParams{
+			Login:       login,
+			BillingURL:  b.billingURL(),
+			TrialEndsAt: trialEndsAt,
+		})
+	})
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/monetr/rest-api/pkg/communication"
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/internal/mock_mail"
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v72"
+)
+
+var (
+	_ AccountRepository = &memoryAccountRepository{}
+)
+
+type memoryAccountRepository struct {
+	account models.Account
+	logins  []models.Login
+}
+
+func (m *memoryAccountRepository) GetAccount(ctx context.Context, accountId uint64) (*models.Account, error) {
+	account := m.account
+	return &account, nil
+}
+
+func (m *memoryAccountRepository) GetAccountByCustomerId(ctx context.Context, stripeCustomerId string) (*models.Account, error) {
+	if m.account.StripeCustomerId == nil || *m.account.StripeCustomerId != stripeCustomerId {
+		return nil, errors.New("account not found")
+	}
+
+	account := m.account
+	return &account, nil
+}
+
+func (m *memoryAccountRepository) UpdateAccount(ctx context.Context, account *models.Account) error {
+	m.account = *account
+	return nil
+}
+
+func (m *memoryAccountRepository) GetAccountLogins(ctx context.Context, accountId uint64) ([]models.Login, error) {
+	return m.logins, nil
+}
+
+func newInvoiceEvent(t *testing.T, eventType, customerId string, created time.Time) stripe.Event {
+	data, err := json.Marshal(map[string]interface{}{
+		"id":       "in_123",
+		"object":   "invoice",
+		"customer": customerId,
+	})
+	require.NoError(t, err, "must encode invoice")
+
+	return stripe.Event{
+		ID:      "evt_123",
+		Type:    eventType,
+		Created: created.Unix(),
+		Data: &stripe.EventData{
+			Raw: data,
+		},
+	}
+}
+
+func TestStripeWebhookHandler_PaymentFailed(t *testing.T) {
+	log := testutils.GetLog(t)
+	customerId := "cus_123"
+	repo := &memoryAccountRepository{
+		account: models.Account{
+			AccountId:        1234,
+			StripeCustomerId: &customerId,
+		},
+		logins: []models.Login{
+			{
+				LoginId:   1234,
+				Email:     gofakeit.Email(),
+				FirstName: gofakeit.FirstName(),
+			},
+		},
+	}
+	mailMock := mock_mail.NewMockMail()
+	configuration := config.Configuration{
+		UIDomainName: "app.monetr.mini",
+		Stripe: config.Stripe{
+			GracePeriod: 7 * 24 * time.Hour,
+		},
+	}
+
+	handler := NewStripeWebhookHandler(
+		log,
+		repo,
+		nil,
+		nil,
+		communication.NewUserCommunication(log, config.Email{Domain: "monetr.mini"}, mailMock),
+		configuration,
+	)
+
+	failedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, handler.HandleWebhook(context.Background(), newInvoiceEvent(t, "invoice.payment_failed", customerId, failedAt)))
+	require.NotNil(t, repo.account.PaymentFailedAt, "payment failure must be recorded")
+	assert.True(t, failedAt.Equal(*repo.account.PaymentFailedAt), "payment failure should be recorded at the time of the event")
+	assert.True(t, repo.account.IsInGracePeriod(configuration.Stripe.GracePeriod), "account should be in its grace period")
+	require.Len(t, mailMock.Sent, 1, "login should be notified of the failed payment")
+	assert.Equal(t, repo.logins[0].Email, mailMock.Sent[0].To, "email should be sent to the login")
+
+	// Stripe will retry the payment, the grace period should still start at the first failure.
+	require.NoError(t, handler.HandleWebhook(context.Background(), newInvoiceEvent(t, "invoice.payment_failed", customerId, failedAt.Add(time.Minute))))
+	assert.True(t, failedAt.Equal(*repo.account.PaymentFailedAt), "grace period should start at the first failure")
+	assert.Len(t, mailMock.Sent, 2, "login should be notified of each failed payment")
+
+	// An invoice paid before the failure is out of order and should not end the grace period.
+	require.NoError(t, handler.HandleWebhook(context.Background(), newInvoiceEvent(t, "invoice.paid", customerId, failedAt.Add(-time.Minute))))
+	assert.NotNil(t, repo.account.PaymentFailedAt, "stale paid invoice should not clear the failure")
+
+	require.NoError(t, handler.HandleWebhook(context.Background(), newInvoiceEvent(t, "invoice.paid", customerId, failedAt.Add(2*time.Minute))))
+	assert.Nil(t, repo.account.PaymentFailedAt, "paid invoice should clear the failure")
+}
+
+func TestStripeWebhookHandler_TrialWillEnd(t *testing.T) {
+	log := testutils.GetLog(t)
+	customerId := "cus_123"
+	repo := &memoryAccountRepository{
+		account: models.Account{
+			AccountId:        1234,
+			StripeCustomerId: &customerId,
+		},
+		logins: []models.Login{
+			{
+				LoginId: 1234,
+				Email:   gofakeit.Email(),
+			},
+		},
+	}
+	mailMock := mock_mail.NewMockMail()
+
+	handler := NewStripeWebhookHandler(
+		log,
+		repo,
+		nil,
+		nil,
+		communication.NewUserCommunication(log, config.Email{Domain: "monetr.mini"}, mailMock),
+		config.Configuration{
+			UIDomainName: "app.monetr.mini",
+		},
+	)
+
+	data, err := json.Marshal(map[string]interface{}{
+		"id":        "sub_123",
+		"object":    "subscription",
+		"customer":  customerId,
+		"trial_end": time.Date(2021, 9, 12, 0, 0, 0, 0, time.UTC).Unix(),
+	})
+	require.NoError(t, err, "must encode subscription")
+
+	require.NoError(t, handler.HandleWebhook(context.Background(), stripe.Event{
+		ID:      "evt_123",
+		Type:    "customer.subscription.trial_will_end",
+		Created: time.Now().Unix(),
+		Data: &stripe.EventData{
+			Raw: data,
+		},
+	}))
+	require.Len(t, mailMock.Sent, 1, "login should be notified that the trial is ending")
+	assert.Contains(t, mailMock.Sent[0].Content, "September 12, 2021", "email should include when the trial ends")
+}
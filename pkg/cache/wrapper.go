@@ -93,10 +93,11 @@ func (r *redisCache) SetTTL(ctx context.Context, key string, value []byte, lifet
 
 	span.Description = key
 
+	// PX is used instead of EXAT as it is supported by older versions of redis, as well as miniredis.
 	return errors.Wrap(
 		r.send(
 			span.Context(),
-			"SET", key, value, "EXAT", time.Now().Add(lifetime).Unix(),
+			"SET", key, value, "PX", lifetime.Milliseconds(),
 		),
 		"failed to store item in cache",
 	)
@@ -0,0 +1,188 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/pkg/errors"
+)
+
+const (
+	ReCAPTCHAVerifyURL = "https://www.google.com/recaptcha/api/siteverify"
+	HCaptchaVerifyURL  = "https://hcaptcha.com/siteverify"
+	TurnstileVerifyURL = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+)
+
+var (
+	ErrInvalidCaptcha = errors.New("captcha is not valid")
+)
+
+// Verifier validates the token that a captcha widget provided to the client.
+type Verifier interface {
+	// Provider returns the name of the captcha provider, the UI uses this to determine which widget to load.
+	Provider() config.CaptchaProvider
+	// Verify will check the provided token with the captcha provider. If the token is not valid then an error wrapping
+	// ErrInvalidCaptcha is returned.
+	Verify(ctx context.Context, token string) error
+}
+
+// NewVerifier returns the verifier for the captcha provider in the provided configuration.
+func NewVerifier(configuration config.ReCAPTCHA) (Verifier, error) {
+	switch configuration.GetProvider() {
+	case config.CaptchaProviderReCAPTCHA:
+		switch configuration.Version {
+		case 0, 2:
+			return NewReCAPTCHAV2Verifier(configuration.PrivateKey), nil
+		case 3:
+			return NewReCAPTCHAV3Verifier(configuration.PrivateKey, configuration.MinimumScore), nil
+		default:
+			return nil, errors.Errorf("reCAPTCHA version %d is not supported", configuration.Version)
+		}
+	case config.CaptchaProviderHCaptcha:
+		return NewHCaptchaVerifier(configuration.PrivateKey, configuration.PublicKey), nil
+	case config.CaptchaProviderTurnstile:
+		return NewTurnstileVerifier(configuration.PrivateKey), nil
+	default:
+		return nil, errors.Errorf("captcha provider %s is not supported", configuration.Provider)
+	}
+}
+
+var (
+	_ Verifier = &siteVerifier{}
+)
+
+// siteVerifier implements the "siteverify" API that is shared by reCAPTCHA, hCaptcha and Turnstile. The providers
+// differ only in their URL and a few optional fields.
+type siteVerifier struct {
+	provider     config.CaptchaProvider
+	verifyUrl    string
+	secret       string
+	siteKey      string
+	minimumScore float64
+	client       *http.Client
+}
+
+// NewReCAPTCHAV2Verifier returns a verifier for Google reCAPTCHA v2 checkbox or invisible challenges.
+func NewReCAPTCHAV2Verifier(secret string) Verifier {
+	return newSiteVerifier(config.CaptchaProviderReCAPTCHA, ReCAPTCHAVerifyURL, secret)
+}
+
+// NewReCAPTCHAV3Verifier returns a verifier for Google reCAPTCHA v3. Tokens with a score below the minimum score are
+// rejected, scores range from 0.0 (very likely a bot) to 1.0 (very likely a human).
+func NewReCAPTCHAV3Verifier(secret string, minimumScore float64) Verifier {
+	verifier := newSiteVerifier(config.CaptchaProviderReCAPTCHA, ReCAPTCHAVerifyURL, secret)
+	verifier.minimumScore = minimumScore
+	return verifier
+}
+
+// NewHCaptchaVerifier returns a verifier for hCaptcha. If a site key is provided then hCaptcha will also make sure that
+// the token was issued for that site key.
+func NewHCaptchaVerifier(secret, siteKey string) Verifier {
+	verifier := newSiteVerifier(config.CaptchaProviderHCaptcha, HCaptchaVerifyURL, secret)
+	verifier.siteKey = siteKey
+	return verifier
+}
+
+// NewTurnstileVerifier returns a verifier for Cloudflare Turnstile.
+func NewTurnstileVerifier(secret string) Verifier {
+	return newSiteVerifier(config.CaptchaProviderTurnstile, TurnstileVerifyURL, secret)
+}
+
+func newSiteVerifier(provider config.CaptchaProvider, verifyUrl, secret string) *siteVerifier {
+	return &siteVerifier{
+		provider:  provider,
+		verifyUrl: verifyUrl,
+		secret:    secret,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+type siteVerifyResponse struct {
+	Success    bool     `json:"success"`
+	Score      *float64 `json:"score"`
+	Action     string   `json:"action"`
+	Hostname   string   `json:"hostname"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+func (s *siteVerifier) Provider() config.CaptchaProvider {
+	return s.provider
+}
+
+func (s *siteVerifier) Verify(ctx context.Context, token string) error {
+	span := sentry.StartSpan(ctx, "Captcha - Verify")
+	defer span.Finish()
+	span.Data = map[string]interface{}{
+		"provider": s.provider,
+	}
+
+	if strings.TrimSpace(token) == "" {
+		return errors.WithStack(ErrInvalidCaptcha)
+	}
+
+	form := url.Values{}
+	form.Set("secret", s.secret)
+	form.Set("response", token)
+	if s.siteKey != "" {
+		form.Set("sitekey", s.siteKey)
+	}
+
+	request, err := http.NewRequestWithContext(
+		span.Context(),
+		http.MethodPost,
+		s.verifyUrl,
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create captcha request")
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		span.Status = sentry.SpanStatusUnavailable
+		return errors.Wrap(err, "failed to verify captcha")
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		span.Status = sentry.SpanStatusUnavailable
+		return errors.Errorf("failed to verify captcha, status: %d", response.StatusCode)
+	}
+
+	var result siteVerifyResponse
+	if err = json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return errors.Wrap(err, "failed to decode captcha response")
+	}
+
+	if !result.Success {
+		span.Status = sentry.SpanStatusPermissionDenied
+		if len(result.ErrorCodes) == 0 {
+			return errors.WithStack(ErrInvalidCaptcha)
+		}
+
+		return errors.Wrap(ErrInvalidCaptcha, strings.Join(result.ErrorCodes, ", "))
+	}
+
+	if s.minimumScore > 0 {
+		if result.Score == nil {
+			span.Status = sentry.SpanStatusPermissionDenied
+			return errors.Wrap(ErrInvalidCaptcha, "captcha response did not include a score")
+		}
+
+		if *result.Score < s.minimumScore {
+			span.Status = sentry.SpanStatusPermissionDenied
+			return errors.Wrapf(ErrInvalidCaptcha, "captcha score %.2f is below the minimum %.2f", *result.Score, s.minimumScore)
+		}
+	}
+
+	return nil
+}
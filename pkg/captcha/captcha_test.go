@@ -0,0 +1,218 @@
+package captcha
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/jarcoal/httpmock"
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/internal/mock_http_helper"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSiteVerify registers a siteverify responder for the provided URL. The response function is given the secret
+// and token that were sent so that it can decide whether or not the captcha is valid.
+func mockSiteVerify(t *testing.T, verifyUrl string, response func(form map[string]string) map[string]interface{}) {
+	mock_http_helper.NewHttpMockJsonResponder(t,
+		"POST", verifyUrl,
+		func(t *testing.T, request *http.Request) (interface{}, int) {
+			require.NoError(t, request.ParseForm(), "must parse siteverify request")
+			form := map[string]string{}
+			for key := range request.PostForm {
+				form[key] = request.PostForm.Get(key)
+			}
+
+			return response(form), http.StatusOK
+		},
+		func(t *testing.T, request *http.Request, response interface{}, status int) map[string][]string {
+			return nil
+		},
+	)
+}
+
+func TestNewVerifier(t *testing.T) {
+	t.Run("defaults to recaptcha", func(t *testing.T) {
+		verifier, err := NewVerifier(config.ReCAPTCHA{
+			PrivateKey: gofakeit.UUID(),
+		})
+		assert.NoError(t, err, "must create verifier")
+		assert.Equal(t, config.CaptchaProviderReCAPTCHA, verifier.Provider())
+	})
+
+	t.Run("turnstile", func(t *testing.T) {
+		verifier, err := NewVerifier(config.ReCAPTCHA{
+			Provider:   "Turnstile",
+			PrivateKey: gofakeit.UUID(),
+		})
+		assert.NoError(t, err, "must create verifier")
+		assert.Equal(t, config.CaptchaProviderTurnstile, verifier.Provider())
+	})
+
+	t.Run("unsupported recaptcha version", func(t *testing.T) {
+		verifier, err := NewVerifier(config.ReCAPTCHA{
+			Version:    1,
+			PrivateKey: gofakeit.UUID(),
+		})
+		assert.EqualError(t, err, "reCAPTCHA version 1 is not supported")
+		assert.Nil(t, verifier)
+	})
+
+	t.Run("unsupported provider", func(t *testing.T) {
+		verifier, err := NewVerifier(config.ReCAPTCHA{
+			Provider:   "something",
+			PrivateKey: gofakeit.UUID(),
+		})
+		assert.EqualError(t, err, "captcha provider something is not supported")
+		assert.Nil(t, verifier)
+	})
+}
+
+func TestReCAPTCHAV2Verifier_Verify(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	secret := gofakeit.UUID()
+	validToken := gofakeit.UUID()
+	mockSiteVerify(t, ReCAPTCHAVerifyURL, func(form map[string]string) map[string]interface{} {
+		assert.Equal(t, secret, form["secret"], "secret must be sent")
+		if form["response"] != validToken {
+			return map[string]interface{}{
+				"success":     false,
+				"error-codes": []string{"invalid-input-response"},
+			}
+		}
+
+		return map[string]interface{}{
+			"success":  true,
+			"hostname": "app.monetr.mini",
+		}
+	})
+
+	verifier := NewReCAPTCHAV2Verifier(secret)
+
+	assert.NoError(t, verifier.Verify(context.Background(), validToken), "valid token should pass")
+
+	err := verifier.Verify(context.Background(), gofakeit.UUID())
+	assert.Equal(t, ErrInvalidCaptcha, errors.Cause(err), "invalid token should fail")
+	assert.EqualError(t, err, "invalid-input-response: captcha is not valid")
+
+	err = verifier.Verify(context.Background(), "")
+	assert.Equal(t, ErrInvalidCaptcha, errors.Cause(err), "blank token should fail")
+
+	assert.Equal(t, 2, httpmock.GetTotalCallCount(), "blank token should not be sent to the provider")
+}
+
+func TestReCAPTCHAV3Verifier_Verify(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	scores := map[string]interface{}{
+		"human": 0.9,
+		"bot":   0.1,
+		"none":  nil,
+	}
+	mockSiteVerify(t, ReCAPTCHAVerifyURL, func(form map[string]string) map[string]interface{} {
+		result := map[string]interface{}{
+			"success": true,
+			"action":  "login",
+		}
+		if score := scores[form["response"]]; score != nil {
+			result["score"] = score
+		}
+
+		return result
+	})
+
+	verifier := NewReCAPTCHAV3Verifier(gofakeit.UUID(), 0.5)
+
+	assert.NoError(t, verifier.Verify(context.Background(), "human"), "score above minimum should pass")
+
+	err := verifier.Verify(context.Background(), "bot")
+	assert.Equal(t, ErrInvalidCaptcha, errors.Cause(err), "score below minimum should fail")
+	assert.EqualError(t, err, "captcha score 0.10 is below the minimum 0.50: captcha is not valid")
+
+	err = verifier.Verify(context.Background(), "none")
+	assert.Equal(t, ErrInvalidCaptcha, errors.Cause(err), "missing score should fail")
+}
+
+func TestHCaptchaVerifier_Verify(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	secret, siteKey := gofakeit.UUID(), gofakeit.UUID()
+	validToken := gofakeit.UUID()
+	mockSiteVerify(t, HCaptchaVerifyURL, func(form map[string]string) map[string]interface{} {
+		assert.Equal(t, secret, form["secret"], "secret must be sent")
+		assert.Equal(t, siteKey, form["sitekey"], "site key must be sent")
+
+		return map[string]interface{}{
+			"success": form["response"] == validToken,
+		}
+	})
+
+	verifier := NewHCaptchaVerifier(secret, siteKey)
+	assert.Equal(t, config.CaptchaProviderHCaptcha, verifier.Provider())
+
+	assert.NoError(t, verifier.Verify(context.Background(), validToken), "valid token should pass")
+
+	err := verifier.Verify(context.Background(), gofakeit.UUID())
+	assert.Equal(t, ErrInvalidCaptcha, errors.Cause(err), "invalid token should fail")
+	assert.EqualError(t, err, "captcha is not valid")
+}
+
+func TestTurnstileVerifier_Verify(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		secret := gofakeit.UUID()
+		validToken := gofakeit.UUID()
+		mockSiteVerify(t, TurnstileVerifyURL, func(form map[string]string) map[string]interface{} {
+			assert.Equal(t, secret, form["secret"], "secret must be sent")
+			assert.NotContains(t, form, "sitekey", "site key should not be sent to turnstile")
+			if form["response"] != validToken {
+				return map[string]interface{}{
+					"success":     false,
+					"error-codes": []string{"timeout-or-duplicate"},
+				}
+			}
+
+			return map[string]interface{}{
+				"success": true,
+			}
+		})
+
+		verifier := NewTurnstileVerifier(secret)
+		assert.Equal(t, config.CaptchaProviderTurnstile, verifier.Provider())
+
+		assert.NoError(t, verifier.Verify(context.Background(), validToken), "valid token should pass")
+
+		err := verifier.Verify(context.Background(), gofakeit.UUID())
+		assert.Equal(t, ErrInvalidCaptcha, errors.Cause(err), "invalid token should fail")
+		assert.EqualError(t, err, "timeout-or-duplicate: captcha is not valid")
+	})
+
+	t.Run("provider is down", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		mock_http_helper.NewHttpMockJsonResponder(t,
+			"POST", TurnstileVerifyURL,
+			func(t *testing.T, request *http.Request) (interface{}, int) {
+				return map[string]interface{}{}, http.StatusServiceUnavailable
+			},
+			func(t *testing.T, request *http.Request, response interface{}, status int) map[string][]string {
+				return nil
+			},
+		)
+
+		verifier := NewTurnstileVerifier(gofakeit.UUID())
+		err := verifier.Verify(context.Background(), gofakeit.UUID())
+		assert.EqualError(t, err, "failed to verify captcha, status: 503")
+		assert.NotEqual(t, ErrInvalidCaptcha, errors.Cause(err), "should not be reported as an invalid captcha")
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/monetr/rest-api/pkg/config"
 	"github.com/monetr/rest-api/pkg/mail"
@@ -20,8 +21,44 @@ type VerifyEmailParams struct {
 	VerifyURL string
 }
 
+type UnlockAccountParams struct {
+	Login     models.Login
+	UnlockURL string
+}
+
+type PaymentFailedParams struct {
+	Login      models.Login
+	BillingURL string
+	// GracePeriodEndsAt is when the account will stop being usable if the payment is not resolved.
+	GracePeriodEndsAt *time.Time
+}
+
+type TrialEndingParams struct {
+	Login       models.Login
+	BillingURL  string
+	TrialEndsAt time.Time
+}
+
+// PendingTransactionsRemovedParams describes pending transactions that were removed because the institution dropped
+// them without posting them.
+type PendingTransactionsRemovedParams struct {
+	Login        models.Login
+	Transactions []RemovedTransaction
+}
+
+type RemovedTransaction struct {
+	Name string
+	// Amount is the formatted amount of the transaction, including its currency.
+	Amount string
+	Date   time.Time
+}
+
 type UserCommunication interface {
 	SendVerificationEmail(ctx context.Context, params VerifyEmailParams) error
+	SendUnlockAccountEmail(ctx context.Context, params UnlockAccountParams) error
+	SendPaymentFailedEmail(ctx context.Context, params PaymentFailedParams) error
+	SendTrialEndingEmail(ctx context.Context, params TrialEndingParams) error
+	SendPendingTransactionsRemovedEmail(ctx context.Context, params PendingTransactionsRemovedParams) error
 }
 
 type userCommunicationBase struct {
@@ -90,3 +127,147 @@ func (u *userCommunicationBase) getVerificationEmailContent(ctx context.Context,
 
 	return buffer.String(), nil
 }
+
+func (u *userCommunicationBase) SendUnlockAccountEmail(ctx context.Context, params UnlockAccountParams) error {
+	span := sentry.StartSpan(ctx, "SendUnlockAccountEmail")
+	defer span.Finish()
+
+	log := u.log.WithContext(ctx).WithFields(logrus.Fields{
+		"loginId": params.Login.LoginId,
+	})
+
+	unlockTemplate, err := email_templates.GetEmailTemplate(email_templates.UnlockAccountTemplate)
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve unlock account email template")
+		return errors.Wrap(err, "failed to retrieve unlock account email template")
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	if err = unlockTemplate.Execute(buffer, params); err != nil {
+		log.WithError(err).Error("failed to execute unlock account email template")
+		return errors.Wrap(err, "failed to execute unlock account email template")
+	}
+
+	log.Debug("sending unlock account email")
+
+	if err = u.mail.Send(span.Context(), mail.SendEmailRequest{
+		From:    fmt.Sprintf("no-reply@%s", u.options.Domain),
+		To:      params.Login.Email,
+		Subject: "Unlock Your Account",
+		Content: buffer.String(),
+		IsHTML:  true,
+	}); err != nil {
+		log.WithError(err).Error("failed to send unlock account email")
+		return errors.Wrap(err, "failed to send unlock account email")
+	}
+
+	return nil
+}
+
+func (u *userCommunicationBase) SendPaymentFailedEmail(ctx context.Context, params PaymentFailedParams) error {
+	span := sentry.StartSpan(ctx, "SendPaymentFailedEmail")
+	defer span.Finish()
+
+	log := u.log.WithContext(ctx).WithFields(logrus.Fields{
+		"loginId": params.Login.LoginId,
+	})
+
+	paymentFailedTemplate, err := email_templates.GetEmailTemplate(email_templates.PaymentFailedTemplate)
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve payment failed email template")
+		return errors.Wrap(err, "failed to retrieve payment failed email template")
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	if err = paymentFailedTemplate.Execute(buffer, params); err != nil {
+		log.WithError(err).Error("failed to execute payment failed email template")
+		return errors.Wrap(err, "failed to execute payment failed email template")
+	}
+
+	log.Debug("sending payment failed email")
+
+	if err = u.mail.Send(span.Context(), mail.SendEmailRequest{
+		From:    fmt.Sprintf("no-reply@%s", u.options.Domain),
+		To:      params.Login.Email,
+		Subject: "Your monetr Payment Failed",
+		Content: buffer.String(),
+		IsHTML:  true,
+	}); err != nil {
+		log.WithError(err).Error("failed to send payment failed email")
+		return errors.Wrap(err, "failed to send payment failed email")
+	}
+
+	return nil
+}
+
+func (u *userCommunicationBase) SendTrialEndingEmail(ctx context.Context, params TrialEndingParams) error {
+	span := sentry.StartSpan(ctx, "SendTrialEndingEmail")
+	defer span.Finish()
+
+	log := u.log.WithContext(ctx).WithFields(logrus.Fields{
+		"loginId": params.Login.LoginId,
+	})
+
+	trialEndingTemplate, err := email_templates.GetEmailTemplate(email_templates.TrialEndingTemplate)
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve trial ending email template")
+		return errors.Wrap(err, "failed to retrieve trial ending email template")
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	if err = trialEndingTemplate.Execute(buffer, params); err != nil {
+		log.WithError(err).Error("failed to execute trial ending email template")
+		return errors.Wrap(err, "failed to execute trial ending email template")
+	}
+
+	log.Debug("sending trial ending email")
+
+	if err = u.mail.Send(span.Context(), mail.SendEmailRequest{
+		From:    fmt.Sprintf("no-reply@%s", u.options.Domain),
+		To:      params.Login.Email,
+		Subject: "Your monetr Trial Is Ending",
+		Content: buffer.String(),
+		IsHTML:  true,
+	}); err != nil {
+		log.WithError(err).Error("failed to send trial ending email")
+		return errors.Wrap(err, "failed to send trial ending email")
+	}
+
+	return nil
+}
+
+func (u *userCommunicationBase) SendPendingTransactionsRemovedEmail(ctx context.Context, params PendingTransactionsRemovedParams) error {
+	span := sentry.StartSpan(ctx, "SendPendingTransactionsRemovedEmail")
+	defer span.Finish()
+
+	log := u.log.WithContext(ctx).WithFields(logrus.Fields{
+		"loginId": params.Login.LoginId,
+	})
+
+	pendingRemovedTemplate, err := email_templates.GetEmailTemplate(email_templates.PendingRemovedTemplate)
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve pending transactions removed email template")
+		return errors.Wrap(err, "failed to retrieve pending transactions removed email template")
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	if err = pendingRemovedTemplate.Execute(buffer, params); err != nil {
+		log.WithError(err).Error("failed to execute pending transactions removed email template")
+		return errors.Wrap(err, "failed to execute pending transactions removed email template")
+	}
+
+	log.Debug("sending pending transactions removed email")
+
+	if err = u.mail.Send(span.Context(), mail.SendEmailRequest{
+		From:    fmt.Sprintf("no-reply@%s", u.options.Domain),
+		To:      params.Login.Email,
+		Subject: "Pending Transactions Were Removed",
+		Content: buffer.String(),
+		IsHTML:  true,
+	}); err != nil {
+		log.WithError(err).Error("failed to send pending transactions removed email")
+		return errors.Wrap(err, "failed to send pending transactions removed email")
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
 	"github.com/monetr/rest-api/pkg/config"
@@ -66,3 +67,129 @@ func TestUserCommunicationBase_SendVerificationEmail(t *testing.T) {
 		assert.Empty(t, smtpMock.Sent, "should not have sent any emails")
 	})
 }
+
+func TestUserCommunicationBase_SendUnlockAccountEmail(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		smtpMock := mock_mail.NewMockMail()
+		options := config.Email{
+			Domain: "monetr.mini",
+		}
+		log := testutils.GetLog(t)
+
+		comms := NewUserCommunication(log, options, smtpMock)
+		assert.NotNil(t, comms, "communication interface must not be nil")
+
+		unlockUrl := fmt.Sprintf("https://app.monetr.mini/unlock/%s", gofakeit.Generate("????????????"))
+		params := UnlockAccountParams{
+			Login: models.Login{
+				LoginId:   1234,
+				Email:     gofakeit.Email(),
+				FirstName: gofakeit.FirstName(),
+				LastName:  gofakeit.LastName(),
+			},
+			UnlockURL: unlockUrl,
+		}
+
+		err := comms.SendUnlockAccountEmail(context.Background(), params)
+		assert.NoError(t, err, "must send email successfully")
+		assert.Len(t, smtpMock.Sent, 1, "should have sent 1 email")
+		assert.Equal(t, params.Login.Email, smtpMock.Sent[0].To, "should send the email to the login")
+		assert.Contains(t, smtpMock.Sent[0].Content, unlockUrl, "email should contain the unlock url")
+	})
+}
+
+func TestUserCommunicationBase_SendPaymentFailedEmail(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		smtpMock := mock_mail.NewMockMail()
+		options := config.Email{
+			Domain: "monetr.mini",
+		}
+		log := testutils.GetLog(t)
+
+		comms := NewUserCommunication(log, options, smtpMock)
+		assert.NotNil(t, comms, "communication interface must not be nil")
+
+		gracePeriodEndsAt := time.Date(2021, 9, 16, 0, 0, 0, 0, time.UTC)
+		params := PaymentFailedParams{
+			Login: models.Login{
+				LoginId:   1234,
+				Email:     gofakeit.Email(),
+				FirstName: gofakeit.FirstName(),
+				LastName:  gofakeit.LastName(),
+			},
+			BillingURL:        "https://app.monetr.mini/account/subscribe",
+			GracePeriodEndsAt: &gracePeriodEndsAt,
+		}
+
+		err := comms.SendPaymentFailedEmail(context.Background(), params)
+		assert.NoError(t, err, "must send email successfully")
+		assert.Len(t, smtpMock.Sent, 1, "should have sent 1 email")
+		assert.Equal(t, params.Login.Email, smtpMock.Sent[0].To, "should send the email to the login")
+		assert.Contains(t, smtpMock.Sent[0].Content, params.BillingURL, "email should contain the billing url")
+		assert.Contains(t, smtpMock.Sent[0].Content, "September 16, 2021", "email should contain the end of the grace period")
+	})
+}
+
+func TestUserCommunicationBase_SendTrialEndingEmail(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		smtpMock := mock_mail.NewMockMail()
+		options := config.Email{
+			Domain: "monetr.mini",
+		}
+		log := testutils.GetLog(t)
+
+		comms := NewUserCommunication(log, options, smtpMock)
+		assert.NotNil(t, comms, "communication interface must not be nil")
+
+		params := TrialEndingParams{
+			Login: models.Login{
+				LoginId:   1234,
+				Email:     gofakeit.Email(),
+				FirstName: gofakeit.FirstName(),
+				LastName:  gofakeit.LastName(),
+			},
+			BillingURL:  "https://app.monetr.mini/account/subscribe",
+			TrialEndsAt: time.Date(2021, 9, 12, 0, 0, 0, 0, time.UTC),
+		}
+
+		err := comms.SendTrialEndingEmail(context.Background(), params)
+		assert.NoError(t, err, "must send email successfully")
+		assert.Len(t, smtpMock.Sent, 1, "should have sent 1 email")
+		assert.Contains(t, smtpMock.Sent[0].Content, "September 12, 2021", "email should contain the end of the trial")
+	})
+}
+
+func TestUserCommunicationBase_SendPendingTransactionsRemovedEmail(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		smtpMock := mock_mail.NewMockMail()
+		options := config.Email{
+			Domain: "monetr.mini",
+		}
+		log := testutils.GetLog(t)
+
+		comms := NewUserCommunication(log, options, smtpMock)
+		assert.NotNil(t, comms, "communication interface must not be nil")
+
+		params := PendingTransactionsRemovedParams{
+			Login: models.Login{
+				LoginId:   1234,
+				Email:     gofakeit.Email(),
+				FirstName: gofakeit.FirstName(),
+				LastName:  gofakeit.LastName(),
+			},
+			Transactions: []RemovedTransaction{
+				{
+					Name:   "Pizza Place",
+					Amount: "20.00 USD",
+					Date:   time.Date(2021, 9, 10, 0, 0, 0, 0, time.UTC),
+				},
+			},
+		}
+
+		err := comms.SendPendingTransactionsRemovedEmail(context.Background(), params)
+		assert.NoError(t, err, "must send email successfully")
+		assert.Len(t, smtpMock.Sent, 1, "should have sent 1 email")
+		assert.Equal(t, params.Login.Email, smtpMock.Sent[0].To, "should send the email to the login")
+		assert.Contains(t, smtpMock.Sent[0].Content, "September 10, 2021 - Pizza Place: 20.00 USD", "email should contain the removed transaction")
+	})
+}
@@ -11,3 +11,58 @@ type Plan struct {
 	Features      []feature.Feature
 	Default       bool
 }
+
+// GetDefaultPlan returns the plan that new subscriptions should use when a specific plan is not requested. This is the
+// initial plan if one is configured, otherwise it is the first plan marked as the default.
+func (s Stripe) GetDefaultPlan() (Plan, bool) {
+	if s.InitialPlan != nil {
+		return *s.InitialPlan, true
+	}
+
+	for _, plan := range s.Plans {
+		if plan.Default {
+			return plan, true
+		}
+	}
+
+	return Plan{}, false
+}
+
+// GetVisiblePlans returns the plans that customers are able to subscribe to. The initial plan is always included, plans
+// that are not visible are kept so that existing subscriptions to them still work, but they cannot be chosen.
+func (s Stripe) GetVisiblePlans() []Plan {
+	plans := make([]Plan, 0, len(s.Plans)+1)
+	if s.InitialPlan != nil {
+		plans = append(plans, *s.InitialPlan)
+	}
+
+	for _, plan := range s.Plans {
+		if !plan.Visible {
+			continue
+		}
+
+		if s.InitialPlan != nil && s.InitialPlan.StripePriceId == plan.StripePriceId {
+			continue
+		}
+
+		plans = append(plans, plan)
+	}
+
+	return plans
+}
+
+// GetPlanByPriceId will return the configured plan with the provided Stripe price Id. The initial plan is included in
+// the search. If there is no plan with the price Id then false is returned.
+func (s Stripe) GetPlanByPriceId(priceId string) (Plan, bool) {
+	if s.InitialPlan != nil && s.InitialPlan.StripePriceId == priceId {
+		return *s.InitialPlan, true
+	}
+
+	for _, plan := range s.Plans {
+		if plan.StripePriceId == priceId {
+			return plan, true
+		}
+	}
+
+	return Plan{}, false
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/plaid/plaid-go/plaid"
@@ -31,20 +32,48 @@ type Configuration struct {
 	UIDomainName  string
 	APIDomainName string
 	AllowSignUp   bool
+	Admin         Admin
 	Beta          Beta
 	CORS          CORS
 	JWT           JWT
+	Jobs          Jobs
 	Logging       Logging
+	OIDC          OIDC
 	Plaid         Plaid
 	PostgreSQL    PostgreSQL
+	RateLimit     RateLimit
 	ReCAPTCHA     ReCAPTCHA
 	Redis         Redis
 	EMail         Email
 	Sentry        Sentry
+	SimpleFIN     SimpleFIN
+	SMS           SMS
 	Stripe        Stripe
 	Vault         Vault
 }
 
+// Admin configures the operator API that is used for support tasks. Admins authenticate like any other user, but their
+// login's email must be one of the configured emails. Every action taken through the admin API is audited.
+type Admin struct {
+	Enabled bool
+	Emails  []string
+}
+
+// IsAdmin will return true if the admin API is enabled and the provided email is one of the configured admin emails.
+func (a Admin) IsAdmin(email string) bool {
+	if !a.Enabled {
+		return false
+	}
+
+	for _, adminEmail := range a.Emails {
+		if strings.EqualFold(strings.TrimSpace(adminEmail), email) {
+			return true
+		}
+	}
+
+	return false
+}
+
 type Beta struct {
 	EnableBetaCodes bool
 }
@@ -92,16 +121,74 @@ func (s Email) ShouldVerifyEmails() bool {
 	return s.Enabled && s.VerifyEmails
 }
 
+// OIDC configures single sign-on through an OpenID Connect identity provider, like Authentik or Keycloak.
+type OIDC struct {
+	Enabled bool
+	// Name is the display name of the identity provider that will be shown on the login page.
+	Name string
+	// Issuer is the URL of the identity provider. The provider's discovery document is retrieved from
+	// `{Issuer}/.well-known/openid-configuration`, and the issuer in that document must match this exactly, including
+	// any trailing slash.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the URL that the identity provider will send the user back to after they have authenticated. This
+	// should be the OIDC callback page of the UI, which will pass the code and state back to the API.
+	RedirectURL string
+	// Scopes are requested in addition to the `openid`, `email` and `profile` scopes which are always requested.
+	Scopes []string
+}
+
+// RateLimit defines how authentication attempts are throttled. Attempts are tracked in redis, or in the embedded redis
+// if redis is not enabled.
+type RateLimit struct {
+	Enabled bool
+	// PerIPLimit is the number of login or register requests that a single IP address can make within PerIPWindow.
+	PerIPLimit  int
+	PerIPWindow time.Duration
+	// PerEmailLimit is the number of login attempts that can be made for a single email address within PerEmailWindow.
+	PerEmailLimit  int
+	PerEmailWindow time.Duration
+	// LockoutThreshold is the number of consecutive failed logins for a single email before that email is locked out.
+	// Each lockout starts at LockoutDuration and doubles with every subsequent lockout up to MaxLockoutDuration.
+	LockoutThreshold   int
+	LockoutDuration    time.Duration
+	MaxLockoutDuration time.Duration
+}
+
+type CaptchaProvider string
+
+const (
+	CaptchaProviderReCAPTCHA CaptchaProvider = "recaptcha"
+	CaptchaProviderHCaptcha  CaptchaProvider = "hcaptcha"
+	CaptchaProviderTurnstile CaptchaProvider = "turnstile"
+)
+
+// ReCAPTCHA configures the captcha that is used to verify logins and registrations. Despite the name this is not
+// limited to Google's reCAPTCHA, hCaptcha and Cloudflare Turnstile can be used as well.
 type ReCAPTCHA struct {
-	Enabled    bool
+	Enabled bool
+	// Provider is the captcha service to use, either recaptcha, hcaptcha or turnstile. Defaults to recaptcha.
+	Provider   CaptchaProvider
 	PublicKey  string
 	PrivateKey string
-	Version    int // Currently only version 2 is supported by the UI.
+	// Version is the reCAPTCHA version to use, either 2 or 3. It is ignored for other providers.
+	Version int
+	// MinimumScore is the lowest reCAPTCHA v3 score that will be accepted, between 0.0 and 1.0.
+	MinimumScore float64
 
 	VerifyLogin    bool
 	VerifyRegister bool
 }
 
+func (r ReCAPTCHA) GetProvider() CaptchaProvider {
+	if r.Provider == "" {
+		return CaptchaProviderReCAPTCHA
+	}
+
+	return CaptchaProvider(strings.ToLower(string(r.Provider)))
+}
+
 func (r ReCAPTCHA) ShouldVerifyLogin() bool {
 	return r.Enabled && r.VerifyLogin
 }
@@ -137,6 +224,43 @@ type Plaid struct {
 	// protocol or a path. The protocol is auto inserted as `https` as it is the only protocol supported. The path is
 	// currently hard coded until a need for different paths arises?
 	OAuthDomain string
+	// CountryCodes are the countries that institutions can be linked from, as ISO 3166-1 alpha-2 codes like "US" or
+	// "CA". If no country codes are specified then only institutions in the US are available.
+	CountryCodes []string
+
+	// Fake will replace Plaid with a fake provider that does not make any network requests. Fake institutions can be
+	// linked through the `/plaid/fake` endpoints, and each fake link will have new transactions every day. This is
+	// meant for local development and demos, no credentials are required when this is enabled.
+	Fake bool
+}
+
+// GetCountryCodes returns the configured country codes for Plaid, or just the US if none are configured.
+func (p Plaid) GetCountryCodes() []plaid.CountryCode {
+	if len(p.CountryCodes) == 0 {
+		return []plaid.CountryCode{
+			plaid.COUNTRYCODE_US,
+		}
+	}
+
+	countryCodes := make([]plaid.CountryCode, len(p.CountryCodes))
+	for i, countryCode := range p.CountryCodes {
+		countryCodes[i] = plaid.CountryCode(strings.ToUpper(strings.TrimSpace(countryCode)))
+	}
+
+	return countryCodes
+}
+
+// SimpleFIN configures links that are synced through a SimpleFIN Bridge (https://www.simplefin.org). Users provide a
+// setup token from their bridge, which monetr claims for an access URL. SimpleFIN does not support webhooks, so these
+// links are only synced on a schedule.
+type SimpleFIN struct {
+	Enabled bool
+	// Timeout is the maximum amount of time a single request to a SimpleFIN Bridge can take.
+	Timeout time.Duration
+	// AllowedHosts limits which SimpleFIN Bridges can be linked. If specified, setup tokens and access URLs must be for
+	// one of these hosts, like `beta-bridge.simplefin.org`. If it is empty then any bridge on a public address can be
+	// linked.
+	AllowedHosts []string
 }
 
 type CORS struct {
@@ -154,6 +278,72 @@ type Redis struct {
 	Namespace string
 }
 
+// Jobs defines how background jobs are scheduled and run by the worker pool.
+type Jobs struct {
+	// Namespace is the prefix used for all of the job queue keys in redis. If multiple deployments of monetr share a
+	// single redis then they should each use a different namespace.
+	Namespace string
+	// Concurrency is the total number of jobs that a single instance of the API will run at the same time.
+	Concurrency uint
+	// PlaidJitter is the maximum amount of time that a scheduled Plaid pull will be delayed for a single account. Each
+	// account is given a consistent offset within this window, this way every link is not synced at midnight UTC.
+	// Defaults to 30 minutes, set to 0 to disable the delay entirely.
+	PlaidJitter time.Duration
+	// Overrides can be used to change the schedule or concurrency of individual jobs. The key is the name of the job,
+	// like `EnqueuePullLatestTransactions`.
+	Overrides map[string]JobOverride
+}
+
+type JobOverride struct {
+	// Disabled will prevent the job from being enqueued on a schedule. The job can still be triggered by things like
+	// webhooks or API requests.
+	Disabled bool
+	// Schedule is a cron string (with seconds) that will be used instead of the job's default schedule.
+	Schedule string
+	// Concurrency is the maximum number of this job that can be run at the same time across every instance that shares
+	// the same redis and job namespace. The limit is tracked in redis, so adding instances does not raise it. If this
+	// is left as 0 then the job is only limited by the concurrency of each instance's worker pool.
+	Concurrency uint
+}
+
+// getOverride returns the override for the provided job name. Viper will lowercase all map keys when the config is
+// loaded so we need to look up the job name in a case-insensitive way.
+func (j Jobs) getOverride(jobName string) (JobOverride, bool) {
+	for name, override := range j.Overrides {
+		if strings.EqualFold(name, jobName) {
+			return override, true
+		}
+	}
+
+	return JobOverride{}, false
+}
+
+// GetSchedule returns the cron schedule that should be used for the provided job, or the provided default schedule
+// if one has not been configured. If the job has been disabled then enabled will be false.
+func (j Jobs) GetSchedule(jobName, defaultSchedule string) (schedule string, enabled bool) {
+	override, ok := j.getOverride(jobName)
+	if !ok {
+		return defaultSchedule, true
+	}
+
+	if override.Disabled {
+		return "", false
+	}
+
+	if override.Schedule != "" {
+		return override.Schedule, true
+	}
+
+	return defaultSchedule, true
+}
+
+// GetConcurrency returns the maximum number of the provided job that can be run at the same time across every instance.
+// 0 means no limit.
+func (j Jobs) GetConcurrency(jobName string) uint {
+	override, _ := j.getOverride(jobName)
+	return override.Concurrency
+}
+
 type Logging struct {
 	Level       string
 	StackDriver StackDriverLogging
@@ -172,6 +362,35 @@ type Sentry struct {
 	TraceSampleRate float64
 }
 
+type SMSProvider string
+
+const (
+	SMSProviderTwilio SMSProvider = "twilio"
+	// SMSProviderLog does not send any messages, instead they are written to the log. This is meant for local
+	// development only.
+	SMSProviderLog SMSProvider = "log"
+)
+
+// SMS configures sending text messages to users, this is currently only used to verify phone numbers.
+type SMS struct {
+	Enabled  bool
+	Provider SMSProvider
+	Twilio   Twilio
+	// CodeLifetime is how long a phone verification code can be used after it has been sent.
+	CodeLifetime time.Duration
+	// MaxAttempts is the number of incorrect codes that can be provided before a new code must be requested.
+	MaxAttempts int
+	// ResendCooldown is the minimum amount of time between sending verification codes to a single login.
+	ResendCooldown time.Duration
+}
+
+type Twilio struct {
+	AccountSID string
+	AuthToken  string
+	// From is the phone number that messages will be sent from in E.164 format.
+	From string
+}
+
 type Stripe struct {
 	Enabled         bool
 	APIKey          string
@@ -182,6 +401,10 @@ type Stripe struct {
 	InitialPlan     *Plan
 	Plans           []Plan
 	BillingEnabled  bool
+	// GracePeriod is how long an account can continue to be used after a payment for its subscription has failed.
+	// During the grace period the user is warned that their payment failed, once it is over the paywall applies as it
+	// would for any other inactive subscription.
+	GracePeriod time.Duration
 }
 
 // IsBillingEnabled will return true if both Stripe and Billing are enabled. It will return false any other time.
@@ -245,7 +468,29 @@ func setupDefaults(v *viper.Viper) {
 	v.SetDefault("PostgreSQL.Address", "localhost")
 	v.SetDefault("PostgreSQL.Username", "postgres")
 	v.SetDefault("PostgreSQL.Database", "postgres")
+	v.SetDefault("RateLimit.Enabled", true)
+	v.SetDefault("RateLimit.PerIPLimit", 30)
+	v.SetDefault("RateLimit.PerIPWindow", 10*time.Minute)
+	v.SetDefault("RateLimit.PerEmailLimit", 10)
+	v.SetDefault("RateLimit.PerEmailWindow", 10*time.Minute)
+	v.SetDefault("RateLimit.LockoutThreshold", 5)
+	v.SetDefault("RateLimit.LockoutDuration", 5*time.Minute)
+	v.SetDefault("RateLimit.MaxLockoutDuration", 24*time.Hour)
 	v.SetDefault("ReCAPTCHA.Enabled", false)
+	v.SetDefault("ReCAPTCHA.Provider", CaptchaProviderReCAPTCHA)
+	v.SetDefault("ReCAPTCHA.Version", 2)
+	v.SetDefault("ReCAPTCHA.MinimumScore", 0.5)
+	v.SetDefault("SimpleFIN.Enabled", false)
+	v.SetDefault("SimpleFIN.Timeout", 30*time.Second)
+	v.SetDefault("SMS.Enabled", false)
+	v.SetDefault("SMS.Provider", SMSProviderTwilio)
+	v.SetDefault("SMS.CodeLifetime", 10*time.Minute)
+	v.SetDefault("SMS.MaxAttempts", 5)
+	v.SetDefault("SMS.ResendCooldown", time.Minute)
+	v.SetDefault("Stripe.GracePeriod", 7*24*time.Hour)
+	v.SetDefault("Jobs.Namespace", "harder")
+	v.SetDefault("Jobs.Concurrency", 4)
+	v.SetDefault("Jobs.PlaidJitter", 30*time.Minute)
 	v.SetDefault("Logging.Level", "info")
 	v.SetDefault("Vault.Auth", "kubernetes")
 	v.SetDefault("Vault.Timeout", 30*time.Second)
@@ -259,12 +504,23 @@ func setupEnv(v *viper.Viper) {
 	v.BindEnv("APIDomainName", "MONETR_API_DOMAIN_NAME")
 	v.BindEnv("AllowSignUp", "MONETR_ALLOW_SIGN_UP")
 	v.BindEnv("EnableWebhooks", "MONETR_ENABLE_WEBHOOKS")
+	v.BindEnv("Admin.Enabled", "MONETR_ADMIN_ENABLED")
+	v.BindEnv("Admin.Emails", "MONETR_ADMIN_EMAILS")
 	v.BindEnv("Beta.EnableBetaCodes", "MONETR_ENABLE_BETA_CODES")
 	v.BindEnv("Cors.AllowedOrigins", "MONETR_CORS_ALLOWED_ORIGINS")
 	v.BindEnv("Cors.Debug", "MONETR_CORS_DEBUG")
 	v.BindEnv("JWT.LoginJwtSecret", "MONETR_JWT_LOGIN_SECRET")
 	v.BindEnv("JWT.RegistrationJwtSecret", "MONETR_JWT_REGISTRATION_SECRET")
+	v.BindEnv("Jobs.Namespace", "MONETR_JOBS_NAMESPACE")
+	v.BindEnv("Jobs.Concurrency", "MONETR_JOBS_CONCURRENCY")
+	v.BindEnv("Jobs.PlaidJitter", "MONETR_JOBS_PLAID_JITTER")
 	v.BindEnv("Logging.Level", "MONETR_LOG_LEVEL")
+	v.BindEnv("OIDC.Enabled", "MONETR_OIDC_ENABLED")
+	v.BindEnv("OIDC.Name", "MONETR_OIDC_NAME")
+	v.BindEnv("OIDC.Issuer", "MONETR_OIDC_ISSUER")
+	v.BindEnv("OIDC.ClientID", "MONETR_OIDC_CLIENT_ID")
+	v.BindEnv("OIDC.ClientSecret", "MONETR_OIDC_CLIENT_SECRET")
+	v.BindEnv("OIDC.RedirectURL", "MONETR_OIDC_REDIRECT_URL")
 	v.BindEnv("Plaid.ClientID", "MONETR_PLAID_CLIENT_ID")
 	v.BindEnv("Plaid.ClientSecret", "MONETR_PLAID_CLIENT_SECRET")
 	v.BindEnv("Plaid.Environment", "MONETR_PLAID_ENVIRONMENT")
@@ -273,6 +529,8 @@ func setupEnv(v *viper.Viper) {
 	v.BindEnv("Plaid.WebhooksEnabled", "MONETR_PLAID_WEBHOOKS_ENABLED")
 	v.BindEnv("Plaid.WebhooksDomain", "MONETR_PLAID_WEBHOOKS_DOMAIN")
 	v.BindEnv("Plaid.OAuthDomain", "MONETR_PLAID_OAUTH_DOMAIN")
+	v.BindEnv("Plaid.CountryCodes", "MONETR_PLAID_COUNTRY_CODES")
+	v.BindEnv("Plaid.Fake", "MONETR_PLAID_FAKE")
 	v.BindEnv("PostgreSQL.Address", "MONETR_PG_ADDRESS")
 	v.BindEnv("PostgreSQL.Port", "MONETR_PG_PORT")
 	v.BindEnv("PostgreSQL.Username", "MONETR_PG_USERNAME")
@@ -282,9 +540,20 @@ func setupEnv(v *viper.Viper) {
 	v.BindEnv("PostgreSQL.CACertificatePath", "MONETR_PG_CA_PATH")
 	v.BindEnv("PostgreSQL.CertificatePath", "MONETR_PG_CERT_PATH")
 	v.BindEnv("PostgreSQL.KeyPath", "MONETR_PG_KEY_PATH")
+	v.BindEnv("RateLimit.Enabled", "MONETR_RATE_LIMIT_ENABLED")
+	v.BindEnv("RateLimit.PerIPLimit", "MONETR_RATE_LIMIT_PER_IP_LIMIT")
+	v.BindEnv("RateLimit.PerIPWindow", "MONETR_RATE_LIMIT_PER_IP_WINDOW")
+	v.BindEnv("RateLimit.PerEmailLimit", "MONETR_RATE_LIMIT_PER_EMAIL_LIMIT")
+	v.BindEnv("RateLimit.PerEmailWindow", "MONETR_RATE_LIMIT_PER_EMAIL_WINDOW")
+	v.BindEnv("RateLimit.LockoutThreshold", "MONETR_RATE_LIMIT_LOCKOUT_THRESHOLD")
+	v.BindEnv("RateLimit.LockoutDuration", "MONETR_RATE_LIMIT_LOCKOUT_DURATION")
+	v.BindEnv("RateLimit.MaxLockoutDuration", "MONETR_RATE_LIMIT_MAX_LOCKOUT_DURATION")
 	v.BindEnv("ReCAPTCHA.Enabled", "MONETR_CAPTCHA_ENABLED")
+	v.BindEnv("ReCAPTCHA.Provider", "MONETR_CAPTCHA_PROVIDER")
 	v.BindEnv("ReCAPTCHA.PublicKey", "MONETR_CAPTCHA_PUBLIC_KEY")
 	v.BindEnv("ReCAPTCHA.PrivateKey", "MONETR_CAPTCHA_PRIVATE_KEY")
+	v.BindEnv("ReCAPTCHA.Version", "MONETR_CAPTCHA_VERSION")
+	v.BindEnv("ReCAPTCHA.MinimumScore", "MONETR_CAPTCHA_MINIMUM_SCORE")
 	v.BindEnv("ReCAPTCHA.VerifyLogin", "MONETR_CAPTCHA_VERIFY_LOGIN")
 	v.BindEnv("ReCAPTCHA.VerifyRegister", "MONETR_CAPTCHA_VERIFY_REGISTER")
 	v.BindEnv("Redis.Enabled", "MONETR_REDIS_ENABLED")
@@ -295,6 +564,14 @@ func setupEnv(v *viper.Viper) {
 	v.BindEnv("Sentry.DSN", "MONETR_SENTRY_DSN")
 	v.BindEnv("Sentry.SampleRate", "MONETR_SENTRY_SAMPLE_RATE")
 	v.BindEnv("Sentry.TraceSampleRate", "MONETR_SENTRY_TRACE_SAMPLE_RATE")
+	v.BindEnv("SimpleFIN.Enabled", "MONETR_SIMPLEFIN_ENABLED")
+	v.BindEnv("SimpleFIN.Timeout", "MONETR_SIMPLEFIN_TIMEOUT")
+	v.BindEnv("SimpleFIN.AllowedHosts", "MONETR_SIMPLEFIN_ALLOWED_HOSTS")
+	v.BindEnv("SMS.Enabled", "MONETR_SMS_ENABLED")
+	v.BindEnv("SMS.Provider", "MONETR_SMS_PROVIDER")
+	v.BindEnv("SMS.Twilio.AccountSID", "MONETR_TWILIO_ACCOUNT_SID")
+	v.BindEnv("SMS.Twilio.AuthToken", "MONETR_TWILIO_AUTH_TOKEN")
+	v.BindEnv("SMS.Twilio.From", "MONETR_TWILIO_FROM")
 	v.BindEnv("Stripe.Enabled", "MONETR_STRIPE_ENABLED")
 	v.BindEnv("Stripe.APIKey", "MONETR_STRIPE_API_KEY")
 	v.BindEnv("Stripe.PublicKey", "MONETR_STRIPE_PUBLIC_KEY")
@@ -302,6 +579,7 @@ func setupEnv(v *viper.Viper) {
 	v.BindEnv("Stripe.WebhooksDomain", "MONETR_STRIPE_WEBHOOKS_DOMAIN")
 	v.BindEnv("Stripe.WebhookSecret", "MONETR_STRIPE_WEBHOOK_SECRET")
 	v.BindEnv("Stripe.BillingEnabled", "MONETR_STRIPE_BILLING_ENABLED")
+	v.BindEnv("Stripe.GracePeriod", "MONETR_STRIPE_GRACE_PERIOD")
 	v.BindEnv("Vault.Enabled", "MONETR_VAULT_ENABLED")
 	v.BindEnv("Vault.Address", "MONETR_VAULT_ADDRESS")
 	v.BindEnv("Vault.Auth", "MONETR_VAULT_AUTH")
@@ -0,0 +1,437 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/core/router"
+	"github.com/monetr/rest-api/pkg/admin"
+	"github.com/monetr/rest-api/pkg/feature"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/monetr/rest-api/pkg/repository"
+	"github.com/monetr/rest-api/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const adminActorContextKey = "_adminActor_"
+
+// adminBetaCodeLimit is the maximum number of beta codes that can be created by a single request.
+const adminBetaCodeLimit = 100
+
+func (c *Controller) handleAdmin(p router.Party) {
+	p.Use(c.requireAdminMiddleware)
+
+	p.Get("/accounts", c.adminLookupAccounts)
+	p.Get("/accounts/{accountId:uint64}", c.adminGetAccount)
+	p.Post("/accounts/{accountId:uint64}/links/{linkId:uint64}/sync", c.adminResyncLink)
+	p.Put("/accounts/{accountId:uint64}/subscription", c.adminExtendSubscription)
+	p.Put("/accounts/{accountId:uint64}/features/{feature:string}", c.adminSetFeatureOverride)
+	p.Put("/logins/{loginId:uint64}/enabled", c.adminSetLoginEnabled)
+	p.Put("/users/{userId:uint64}/invites/quota", c.adminSetBetaInviteQuota)
+	p.Get("/beta", c.adminListBetaCodes)
+	p.Post("/beta", c.adminCreateBetaCodes)
+	p.Delete("/beta/{betaId:uint64}", c.adminRevokeBetaCode)
+}
+
+// requireAdminMiddleware will only allow the request to continue if the current login's email is one of the
+// configured admin emails. If the login is not an admin then a 404 is returned so that the admin API is not
+// discoverable.
+func (c *Controller) requireAdminMiddleware(ctx iris.Context) {
+	loginId := c.mustGetLoginId(ctx)
+
+	var login models.Login
+	if err := c.mustGetDatabase(ctx).ModelContext(c.getContext(ctx), &login).
+		Where(`"login"."login_id" = ?`, loginId).
+		Limit(1).
+		Select(&login); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to verify admin")
+		return
+	}
+
+	if !c.configuration.Admin.IsAdmin(login.Email) {
+		c.getLog(ctx).WithField("loginId", loginId).Warn("non-admin login attempted to use the admin API")
+		c.returnError(ctx, http.StatusNotFound, "not found")
+		return
+	}
+
+	ctx.Values().Set(adminActorContextKey, admin.Actor{
+		Name:    login.Email,
+		LoginId: &loginId,
+	})
+
+	ctx.Next()
+}
+
+func (c *Controller) mustGetAdminActor(ctx iris.Context) admin.Actor {
+	actor, ok := ctx.Values().Get(adminActorContextKey).(admin.Actor)
+	if !ok {
+		panic("no admin actor on context")
+	}
+
+	return actor
+}
+
+// Admin Lookup Accounts
+// @Summary Admin Lookup Accounts
+// @id admin-lookup-accounts
+// @tags Admin
+// @description Retrieve the details of every account that the login with the provided email belongs to. This includes
+// @description the account's users, links, recent jobs and subscription state.
+// @Security ApiKeyAuth
+// @Produce json
+// @Param email query string true "The email of the login to look up."
+// @Router /admin/accounts [get]
+// @Success 200 {array} admin.AccountDetails
+// @Failure 400 {object} ApiError An email was not provided.
+// @Failure 404 {object} ApiError There is no login with the provided email.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) adminLookupAccounts(ctx iris.Context) {
+	email := strings.TrimSpace(ctx.URLParam("email"))
+	if email == "" {
+		c.badRequest(ctx, "email is required")
+		return
+	}
+
+	details, err := c.admin.LookupAccountsByEmail(c.getContext(ctx), c.mustGetAdminActor(ctx), email)
+	if err != nil {
+		c.wrapPgError(ctx, err, "failed to lookup accounts")
+		return
+	}
+
+	ctx.JSON(details)
+}
+
+// Admin Get Account
+// @Summary Admin Get Account
+// @id admin-get-account
+// @tags Admin
+// @description Retrieve the users, links, recent jobs and subscription state of the provided account.
+// @Security ApiKeyAuth
+// @Produce json
+// @Param accountId path uint64 true "The account to retrieve."
+// @Router /admin/accounts/{accountId} [get]
+// @Success 200 {object} admin.AccountDetails
+// @Failure 404 {object} ApiError The account does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) adminGetAccount(ctx iris.Context) {
+	accountId := ctx.Params().GetUint64Default("accountId", 0)
+
+	details, err := c.admin.GetAccountDetails(c.getContext(ctx), c.mustGetAdminActor(ctx), accountId)
+	if err != nil {
+		c.wrapPgError(ctx, err, "failed to retrieve account")
+		return
+	}
+
+	ctx.JSON(details)
+}
+
+// Admin Resync Link
+// @Summary Admin Resync Link
+// @id admin-resync-link
+// @tags Admin
+// @description Force a sync of the latest transactions and balances for a Plaid link. Unlike the user facing sync
+// @description endpoint this does not have a cooldown.
+// @Security ApiKeyAuth
+// @Produce json
+// @Param accountId path uint64 true "The account the link belongs to."
+// @Param linkId path uint64 true "The Plaid link to sync."
+// @Router /admin/accounts/{accountId}/links/{linkId}/sync [post]
+// @Success 200 {object} swag.PlaidSyncResponse
+// @Failure 400 {object} ApiError The link is not a Plaid link.
+// @Failure 404 {object} ApiError The link does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) adminResyncLink(ctx iris.Context) {
+	accountId := ctx.Params().GetUint64Default("accountId", 0)
+	linkId := ctx.Params().GetUint64Default("linkId", 0)
+
+	jobId, err := c.admin.ResyncLink(c.getContext(ctx), c.mustGetAdminActor(ctx), accountId, linkId)
+	if err != nil {
+		if errors.Is(err, admin.ErrLinkCannotBeSynced) {
+			c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "failed to sync link")
+			return
+		}
+
+		c.wrapPgError(ctx, err, "failed to sync link")
+		return
+	}
+
+	if jobId == "" {
+		ctx.JSON(map[string]interface{}{
+			"jobId":          nil,
+			"alreadySyncing": true,
+		})
+		return
+	}
+
+	ctx.JSON(map[string]interface{}{
+		"jobId":          jobId,
+		"alreadySyncing": false,
+	})
+}
+
+// Admin Extend Subscription
+// @Summary Admin Extend Subscription
+// @id admin-extend-subscription
+// @tags Admin
+// @description Set the date that the account's subscription is active until. This does not change the subscription in
+// @description Stripe, the next subscription webhook from Stripe for this account will overwrite this date.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param accountId path uint64 true "The account to extend the subscription of."
+// @Param Request body swag.AdminExtendSubscriptionRequest true "Extend subscription request."
+// @Router /admin/accounts/{accountId}/subscription [put]
+// @Success 200 {object} models.Account
+// @Failure 400 {object} ApiError The date is not in the future.
+// @Failure 404 {object} ApiError The account does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) adminExtendSubscription(ctx iris.Context) {
+	accountId := ctx.Params().GetUint64Default("accountId", 0)
+
+	var request struct {
+		ActiveUntil time.Time `json:"activeUntil"`
+	}
+	if err := ctx.ReadJSON(&request); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed json")
+		return
+	}
+
+	if !request.ActiveUntil.After(time.Now()) {
+		c.badRequest(ctx, "active until must be in the future")
+		return
+	}
+
+	account, err := c.admin.ExtendSubscription(c.getContext(ctx), c.mustGetAdminActor(ctx), accountId, request.ActiveUntil)
+	if err != nil {
+		c.wrapPgError(ctx, err, "failed to extend subscription")
+		return
+	}
+
+	ctx.JSON(account)
+}
+
+// Admin Set Feature Override
+// @Summary Admin Set Feature Override
+// @id admin-set-feature-override
+// @tags Admin
+// @description Grant or revoke a feature for an account regardless of what the account's plan includes. Providing a
+// @description null value removes the override so that the account's plan decides whether the feature is available.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param accountId path uint64 true "The account to override the feature for."
+// @Param feature path string true "The feature to override, for example LinkedBudgeting."
+// @Param Request body swag.AdminSetFeatureOverrideRequest true "Set feature override request."
+// @Router /admin/accounts/{accountId}/features/{feature} [put]
+// @Success 200 {object} models.Account
+// @Failure 400 {object} ApiError The feature is not valid.
+// @Failure 404 {object} ApiError The account does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) adminSetFeatureOverride(ctx iris.Context) {
+	accountId := ctx.Params().GetUint64Default("accountId", 0)
+	item := feature.Feature(ctx.Params().GetStringTrim("feature"))
+
+	var request struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := ctx.ReadJSON(&request); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed json")
+		return
+	}
+
+	account, err := c.admin.SetFeatureOverride(c.getContext(ctx), c.mustGetAdminActor(ctx), accountId, item, request.Enabled)
+	if err != nil {
+		if errors.Is(err, admin.ErrInvalidFeature) {
+			c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "failed to override feature")
+			return
+		}
+
+		c.wrapPgError(ctx, err, "failed to override feature")
+		return
+	}
+
+	ctx.JSON(account)
+}
+
+// Admin Set Login Enabled
+// @Summary Admin Set Login Enabled
+// @id admin-set-login-enabled
+// @tags Admin
+// @description Enable or disable a login. Disabled logins cannot authenticate, and any tokens they already have will
+// @description be rejected.
+// @Security ApiKeyAuth
+// @Accept json
+// @Param loginId path uint64 true "The login to enable or disable."
+// @Param Request body swag.AdminSetLoginEnabledRequest true "Set login enabled request."
+// @Router /admin/logins/{loginId}/enabled [put]
+// @Success 200
+// @Failure 400 {object} ApiError Admins cannot disable their own login.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) adminSetLoginEnabled(ctx iris.Context) {
+	loginId := ctx.Params().GetUint64Default("loginId", 0)
+
+	var request struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := ctx.ReadJSON(&request); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed json")
+		return
+	}
+
+	if request.Enabled == nil {
+		c.badRequest(ctx, "enabled is required")
+		return
+	}
+
+	if !*request.Enabled && loginId == c.mustGetLoginId(ctx) {
+		c.badRequest(ctx, "cannot disable your own login")
+		return
+	}
+
+	if err := c.admin.SetLoginEnabled(c.getContext(ctx), c.mustGetAdminActor(ctx), loginId, *request.Enabled); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to update login")
+		return
+	}
+
+	ctx.StatusCode(http.StatusOK)
+}
+
+// Admin Set Beta Invite Quota
+// @Summary Admin Set Beta Invite Quota
+// @id admin-set-beta-invite-quota
+// @tags Admin
+// @description Set the number of beta codes a user is allowed to create to invite other people. Invites the user has
+// @description already created still count towards the new quota.
+// @Security ApiKeyAuth
+// @Accept json
+// @Param userId path uint64 true "The user to set the invite quota of."
+// @Param Request body swag.AdminSetBetaInviteQuotaRequest true "Set beta invite quota request."
+// @Router /admin/users/{userId}/invites/quota [put]
+// @Success 200
+// @Failure 400 {object} ApiError The quota is missing or is less than 0.
+// @Failure 404 {object} ApiError The user does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) adminSetBetaInviteQuota(ctx iris.Context) {
+	userId := ctx.Params().GetUint64Default("userId", 0)
+
+	var request struct {
+		Quota *int `json:"quota"`
+	}
+	if err := ctx.ReadJSON(&request); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed json")
+		return
+	}
+
+	if request.Quota == nil || *request.Quota < 0 {
+		c.badRequest(ctx, "quota must be at least 0")
+		return
+	}
+
+	if err := c.admin.SetBetaInviteQuota(c.getContext(ctx), c.mustGetAdminActor(ctx), userId, *request.Quota); err != nil {
+		c.wrapPgError(ctx, err, "failed to set beta invite quota")
+		return
+	}
+
+	ctx.StatusCode(http.StatusOK)
+}
+
+// Admin List Beta Codes
+// @Summary Admin List Beta Codes
+// @id admin-list-beta-codes
+// @tags Admin
+// @description List every beta code along with its status, including the codes that users have created to invite
+// @description other people.
+// @Security ApiKeyAuth
+// @Produce json
+// @Router /admin/beta [get]
+// @Success 200 {array} swag.BetaInvite
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) adminListBetaCodes(ctx iris.Context) {
+	betas, err := c.admin.GetBetaCodes(c.getContext(ctx), c.mustGetAdminActor(ctx))
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to retrieve beta codes")
+		return
+	}
+
+	ctx.JSON(newBetaInvites(betas))
+}
+
+// Admin Create Beta Codes
+// @Summary Admin Create Beta Codes
+// @id admin-create-beta-codes
+// @tags Admin
+// @description Generate beta codes that can be used to register. The codes are only returned once and cannot be
+// @description retrieved again.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param Request body swag.AdminCreateBetaCodesRequest true "Create beta codes request."
+// @Router /admin/beta [post]
+// @Success 200 {object} swag.AdminCreateBetaCodesResponse
+// @Failure 400 {object} ApiError The count is out of range or the expiration is not in the future.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) adminCreateBetaCodes(ctx iris.Context) {
+	var request struct {
+		Count     int        `json:"count"`
+		ExpiresAt *time.Time `json:"expiresAt"`
+	}
+	if err := ctx.ReadJSON(&request); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed json")
+		return
+	}
+
+	if request.Count < 1 || request.Count > adminBetaCodeLimit {
+		c.badRequest(ctx, "count must be between 1 and %d", adminBetaCodeLimit)
+		return
+	}
+
+	expiresAt := util.MidnightInLocal(time.Now().Add(betaInviteLifetime), time.UTC)
+	if request.ExpiresAt != nil {
+		expiresAt = *request.ExpiresAt
+	}
+
+	if !expiresAt.After(time.Now()) {
+		c.badRequest(ctx, "expires at must be in the future")
+		return
+	}
+
+	codes, err := c.admin.CreateBetaCodes(c.getContext(ctx), c.mustGetAdminActor(ctx), request.Count, expiresAt)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create beta codes")
+		return
+	}
+
+	ctx.JSON(map[string]interface{}{
+		"codes":     codes,
+		"expiresAt": expiresAt,
+	})
+}
+
+// Admin Revoke Beta Code
+// @Summary Admin Revoke Beta Code
+// @id admin-revoke-beta-code
+// @tags Admin
+// @description Revoke an unused beta code so that it can no longer be used to register.
+// @Security ApiKeyAuth
+// @Param betaId path uint64 true "The beta code to revoke."
+// @Router /admin/beta/{betaId} [delete]
+// @Success 200
+// @Failure 400 {object} ApiError The beta code does not exist, has already been used, or has already been revoked.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) adminRevokeBetaCode(ctx iris.Context) {
+	betaId := ctx.Params().GetUint64Default("betaId", 0)
+
+	if err := c.admin.RevokeBetaCode(c.getContext(ctx), c.mustGetAdminActor(ctx), betaId); err != nil {
+		if errors.Is(err, repository.ErrBetaCodeCannotBeRevoked) {
+			c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "failed to revoke beta code")
+			return
+		}
+
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to revoke beta code")
+		return
+	}
+
+	ctx.StatusCode(http.StatusOK)
+}
@@ -35,6 +35,7 @@ type MonetrClaims struct {
 // @Success 200 {object} swag.LoginResponse
 // @Failure 400 {object} ApiError Required data is missing.
 // @Failure 403 {object} ApiError Invalid credentials.
+// @Failure 429 {object} ApiError Too many attempts, the Retry-After header indicates when to try again.
 // @Failure 500 {object} ApiError Something went wrong on our end.
 func (c *Controller) loginEndpoint(ctx iris.Context) {
 	var loginRequest struct {
@@ -64,17 +65,65 @@ func (c *Controller) loginEndpoint(ctx iris.Context) {
 		return
 	}
 
-	hashedPassword := hash.HashPassword(loginRequest.Email, loginRequest.Password)
+	if !c.checkLoginRateLimit(ctx, loginRequest.Email) {
+		return
+	}
+
 	var login models.Login
 	if err := c.db.RunInTransaction(c.getContext(ctx), func(txn *pg.Tx) error {
+		var loginWithHash models.LoginWithHash
+		if err := txn.ModelContext(c.getContext(ctx), &loginWithHash).
+			Column("login_id", "password_hash").
+			Where(`"login_with_hash"."email" = ?`, loginRequest.Email).
+			Limit(1).
+			Select(&loginWithHash); err != nil {
+			if err == pg.ErrNoRows {
+				// Still hash the provided password when the login does not exist, this way the response time does not
+				// reveal whether or not a login exists for the provided email.
+				_, _, _ = hash.VerifyPassword(loginRequest.Email, loginRequest.Password, hash.DummyPasswordHash)
+			}
+
+			return err
+		}
+
+		valid, needsRehash, err := hash.VerifyPassword(
+			loginRequest.Email,
+			loginRequest.Password,
+			loginWithHash.PasswordHash,
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to verify password")
+		}
+
+		if !valid {
+			return pg.ErrNoRows
+		}
+
+		// If the login's password was stored using a legacy hash, or outdated parameters, then upgrade it now that we
+		// have the plain text password.
+		if needsRehash {
+			newHash, err := hash.NewPasswordHash(loginRequest.Password)
+			if err != nil {
+				return err
+			}
+
+			if _, err = txn.ModelContext(c.getContext(ctx), &loginWithHash).
+				Set(`"password_hash" = ?`, newHash).
+				WherePK().
+				Update(); err != nil {
+				return errors.Wrap(err, "failed to upgrade password hash")
+			}
+		}
+
 		return txn.ModelContext(c.getContext(ctx), &login).
 			Relation("Users").
 			Relation("Users.Account").
-			Where(`"login"."email" = ? AND "login"."password_hash" = ?`, loginRequest.Email, hashedPassword).
+			Where(`"login"."login_id" = ?`, loginWithHash.LoginId).
 			Limit(1).
 			Select(&login)
 	}); err != nil {
 		if err == pg.ErrNoRows {
+			c.loginFailed(ctx, loginRequest.Email)
 			c.returnError(ctx, http.StatusForbidden, "invalid email and password")
 			return
 		}
@@ -83,6 +132,20 @@ func (c *Controller) loginEndpoint(ctx iris.Context) {
 		return
 	}
 
+	c.loginSucceeded(ctx, loginRequest.Email)
+
+	if !login.IsEnabled {
+		c.returnError(ctx, http.StatusForbidden, "login is disabled")
+		return
+	}
+
+	c.respondWithLogin(ctx, login)
+}
+
+// respondWithLogin will return a token to the client for the provided login once it has been authenticated. If the
+// login has a single user then the token will grant access to that user's account, if it has multiple users then the
+// client will need to pick which account to use.
+func (c *Controller) respondWithLogin(ctx iris.Context, login models.Login) {
 	switch len(login.Users) {
 	case 0:
 		// TODO (elliotcourant) Should we allow them to create an account?
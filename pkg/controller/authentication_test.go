@@ -3,10 +3,12 @@ package controller_test
 import (
 	"github.com/brianvoe/gofakeit/v6"
 	"github.com/jarcoal/httpmock"
+	"github.com/monetr/rest-api/pkg/config"
 	"github.com/monetr/rest-api/pkg/internal/mock_stripe"
 	"github.com/monetr/rest-api/pkg/swag"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestLogin(t *testing.T) {
@@ -142,3 +144,86 @@ func TestLogin(t *testing.T) {
 		response.JSON().Path("$.error").Equal("malformed json: invalid character 'b' looking for beginning of object key string")
 	})
 }
+
+func TestLoginRateLimit(t *testing.T) {
+	t.Run("lockout", func(t *testing.T) {
+		conf := NewTestApplicationConfig(t)
+		conf.RateLimit = config.RateLimit{
+			Enabled:            true,
+			PerIPLimit:         100,
+			PerIPWindow:        time.Minute,
+			PerEmailLimit:      100,
+			PerEmailWindow:     time.Minute,
+			LockoutThreshold:   2,
+			LockoutDuration:    time.Minute,
+			MaxLockoutDuration: time.Hour,
+		}
+		e := NewTestApplicationWithConfig(t, conf)
+		email, password := GivenIHaveLogin(t, e)
+
+		for i := 0; i < 2; i++ {
+			response := e.POST("/authentication/login").
+				WithJSON(swag.LoginRequest{
+					Email:    email,
+					Password: password + "wrong",
+				}).
+				Expect()
+
+			response.Status(http.StatusForbidden)
+			response.JSON().Path("$.error").String().Equal("invalid email and password")
+		}
+
+		// Now that the login is locked out, even the correct password should be rejected.
+		response := e.POST("/authentication/login").
+			WithJSON(swag.LoginRequest{
+				Email:    email,
+				Password: password,
+			}).
+			Expect()
+
+		response.Status(http.StatusTooManyRequests)
+		response.Header("Retry-After").Equal("60")
+		response.JSON().Path("$.error").String().Equal("too many failed login attempts, login is temporarily locked")
+	})
+
+	t.Run("per ip", func(t *testing.T) {
+		conf := NewTestApplicationConfig(t)
+		conf.RateLimit = config.RateLimit{
+			Enabled:     true,
+			PerIPLimit:  1,
+			PerIPWindow: time.Minute,
+		}
+		e := NewTestApplicationWithConfig(t, conf)
+
+		response := e.POST("/authentication/login").
+			WithJSON(swag.LoginRequest{
+				Email:    "notan.email",
+				Password: "atLeastThisIsAPassword",
+			}).
+			Expect()
+		response.Status(http.StatusBadRequest)
+
+		response = e.POST("/authentication/login").
+			WithJSON(swag.LoginRequest{
+				Email:    "notan.email",
+				Password: "atLeastThisIsAPassword",
+			}).
+			Expect()
+		response.Status(http.StatusTooManyRequests)
+		response.Header("Retry-After").NotEmpty()
+		response.JSON().Path("$.error").String().Equal("too many requests, please try again later")
+	})
+
+	t.Run("unlock with bad token", func(t *testing.T) {
+		e := NewTestApplication(t)
+
+		response := e.POST("/authentication/unlock").
+			WithJSON(swag.UnlockLoginRequest{
+				Token: "not a real token",
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().Equal("unlock token is not valid or has expired")
+	})
+}
@@ -3,8 +3,9 @@ package controller
 import (
 	"github.com/kataras/iris/v12"
 	"github.com/kataras/iris/v12/context"
+	"github.com/monetr/rest-api/pkg/currency"
 	"github.com/monetr/rest-api/pkg/models"
-	_ "github.com/monetr/rest-api/pkg/swag"
+	"github.com/monetr/rest-api/pkg/swag"
 	"net/http"
 	"strings"
 	"time"
@@ -14,6 +15,7 @@ func (c *Controller) handleBankAccounts(p iris.Party) {
 	p.Get("/", c.getBankAccounts)
 	p.Get("/{bankAccountId:uint64}/balances", c.getBalances)
 	p.Post("/", c.postBankAccounts)
+	p.Put("/{bankAccountId:uint64}/payment_account", c.putPaymentBankAccount)
 }
 
 // List All Bank Accounts
@@ -104,6 +106,12 @@ func (c *Controller) postBankAccounts(ctx *context.Context) {
 		return
 	}
 
+	bankAccount.Currency = currency.Normalize(bankAccount.Currency)
+	if !currency.IsValid(bankAccount.Currency) {
+		c.badRequest(ctx, "bank account currency must be a valid ISO 4217 currency code")
+		return
+	}
+
 	repo := c.mustGetAuthenticatedRepository(ctx)
 
 	// Bank accounts can only be created this way when they are associated with a link that allows manual
@@ -126,3 +134,78 @@ func (c *Controller) postBankAccounts(ctx *context.Context) {
 
 	ctx.JSON(bankAccount)
 }
+
+// Update Payment Bank Account
+// @Summary Update Payment Bank Account
+// @ID update-payment-bank-account
+// @tags Bank Accounts
+// @description Set the depository bank account that a credit account is paid from. The amount owed on the credit account will be set aside in the payment bank account and will not be included in its Safe-To-Spend balance. Specify a null payment bank account Id to stop setting money aside for the credit account.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param paymentBankAccount body swag.UpdatePaymentBankAccountRequest true "Payment Bank Account"
+// @Router /bank_accounts/{bankAccountId}/payment_account [put]
+// @Success 200 {object} swag.BankAccountResponse
+// @Failure 400 {object} ApiError The bank account is not a credit account or the payment bank account is not a depository account.
+// @Failure 402 {object} SubscriptionNotActiveError The user's subscription is not active.
+// @Failure 404 {object} ApiError The bank account does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) putPaymentBankAccount(ctx *context.Context) {
+	bankAccountId := ctx.Params().GetUint64Default("bankAccountId", 0)
+	if bankAccountId == 0 {
+		c.returnError(ctx, http.StatusBadRequest, "must specify valid bank account Id")
+		return
+	}
+
+	var request swag.UpdatePaymentBankAccountRequest
+	if err := ctx.ReadJSON(&request); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed JSON")
+		return
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	bankAccount, err := repo.GetBankAccount(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		c.wrapPgError(ctx, err, "failed to retrieve bank account")
+		return
+	}
+
+	if bankAccount.Type != models.CreditBankAccountType {
+		c.returnError(ctx, http.StatusBadRequest, "payment bank account can only be set for credit accounts")
+		return
+	}
+
+	if request.PaymentBankAccountId != nil {
+		if *request.PaymentBankAccountId == bankAccountId {
+			c.returnError(ctx, http.StatusBadRequest, "credit account cannot be paid from itself")
+			return
+		}
+
+		paymentBankAccount, err := repo.GetBankAccount(c.getContext(ctx), *request.PaymentBankAccountId)
+		if err != nil {
+			c.wrapPgError(ctx, err, "failed to retrieve payment bank account")
+			return
+		}
+
+		if paymentBankAccount.Type != models.DepositoryBankAccountType {
+			c.returnError(ctx, http.StatusBadRequest, "payment bank account must be a depository account")
+			return
+		}
+
+		if err = currency.Check(bankAccount.Currency, paymentBankAccount.Currency); err != nil {
+			c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "payment bank account must be in the same currency")
+			return
+		}
+	}
+
+	if err = repo.UpdatePaymentBankAccount(c.getContext(ctx), bankAccountId, request.PaymentBankAccountId); err != nil {
+		c.wrapPgError(ctx, err, "failed to update payment bank account")
+		return
+	}
+
+	bankAccount.PaymentBankAccountId = request.PaymentBankAccountId
+
+	ctx.JSON(bankAccount)
+}
@@ -0,0 +1,167 @@
+package controller_test
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/monetr/rest-api/pkg/models"
+)
+
+func givenIHaveABankAccount(t *testing.T, e *httptest.Expect, token string, linkId uint64, bankAccount models.BankAccount) uint64 {
+	bankAccount.LinkId = linkId
+	response := e.POST("/bank_accounts").
+		WithHeader("M-Token", token).
+		WithJSON(bankAccount).
+		Expect()
+
+	response.Status(http.StatusOK)
+	return uint64(response.JSON().Path("$.bankAccountId").Number().Gt(0).Raw())
+}
+
+func TestPutPaymentBankAccount(t *testing.T) {
+	e := NewTestApplication(t)
+	token := GivenIHaveToken(t, e)
+
+	var linkId uint64
+	{
+		response := e.POST("/links").
+			WithHeader("M-Token", token).
+			WithJSON(models.Link{
+				InstitutionName: "U.S. Bank",
+			}).
+			Expect()
+
+		response.Status(http.StatusOK)
+		linkId = uint64(response.JSON().Path("$.linkId").Number().Raw())
+	}
+
+	checkingId := givenIHaveABankAccount(t, e, token, linkId, models.BankAccount{
+		AvailableBalance: 400000,
+		CurrentBalance:   400000,
+		Name:             "Checking",
+		Type:             models.DepositoryBankAccountType,
+		SubType:          models.CheckingBankAccountSubType,
+	})
+	creditCardId := givenIHaveABankAccount(t, e, token, linkId, models.BankAccount{
+		AvailableBalance: 250000,
+		CurrentBalance:   250000,
+		Name:             "Credit Card",
+		Type:             models.CreditBankAccountType,
+		SubType:          models.CreditCartBankAccountSubType,
+	})
+	otherCreditCardId := givenIHaveABankAccount(t, e, token, linkId, models.BankAccount{
+		AvailableBalance: 100000,
+		CurrentBalance:   0,
+		Name:             "Other Credit Card",
+		Type:             models.CreditBankAccountType,
+		SubType:          models.CreditCartBankAccountSubType,
+	})
+
+	t.Run("not a credit account", func(t *testing.T) {
+		response := e.PUT(fmt.Sprintf("/bank_accounts/%d/payment_account", checkingId)).
+			WithHeader("M-Token", token).
+			WithJSON(map[string]interface{}{
+				"paymentBankAccountId": creditCardId,
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().Equal("payment bank account can only be set for credit accounts")
+	})
+
+	t.Run("paid from itself", func(t *testing.T) {
+		response := e.PUT(fmt.Sprintf("/bank_accounts/%d/payment_account", creditCardId)).
+			WithHeader("M-Token", token).
+			WithJSON(map[string]interface{}{
+				"paymentBankAccountId": creditCardId,
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().Equal("credit account cannot be paid from itself")
+	})
+
+	t.Run("paid from another credit account", func(t *testing.T) {
+		response := e.PUT(fmt.Sprintf("/bank_accounts/%d/payment_account", creditCardId)).
+			WithHeader("M-Token", token).
+			WithJSON(map[string]interface{}{
+				"paymentBankAccountId": otherCreditCardId,
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().Equal("payment bank account must be a depository account")
+	})
+
+	t.Run("payment account does not exist", func(t *testing.T) {
+		response := e.PUT(fmt.Sprintf("/bank_accounts/%d/payment_account", creditCardId)).
+			WithHeader("M-Token", token).
+			WithJSON(map[string]interface{}{
+				"paymentBankAccountId": uint64(math.MaxInt32),
+			}).
+			Expect()
+
+		response.Status(http.StatusNotFound)
+	})
+
+	t.Run("bank account does not exist", func(t *testing.T) {
+		response := e.PUT(fmt.Sprintf("/bank_accounts/%d/payment_account", uint64(math.MaxInt32))).
+			WithHeader("M-Token", token).
+			WithJSON(map[string]interface{}{
+				"paymentBankAccountId": checkingId,
+			}).
+			Expect()
+
+		response.Status(http.StatusNotFound)
+	})
+
+	t.Run("set and clear the payment account", func(t *testing.T) {
+		{
+			response := e.PUT(fmt.Sprintf("/bank_accounts/%d/payment_account", creditCardId)).
+				WithHeader("M-Token", token).
+				WithJSON(map[string]interface{}{
+					"paymentBankAccountId": checkingId,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.bankAccountId").Number().Equal(creditCardId)
+			response.JSON().Path("$.paymentBankAccountId").Number().Equal(checkingId)
+		}
+
+		{ // The amount owed on the card should now be set aside in the checking account.
+			response := e.GET(fmt.Sprintf("/bank_accounts/%d/balances", checkingId)).
+				WithHeader("M-Token", token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.creditReserve").Number().Equal(250000)
+			response.JSON().Path("$.safe").Number().Equal(150000)
+		}
+
+		{
+			response := e.PUT(fmt.Sprintf("/bank_accounts/%d/payment_account", creditCardId)).
+				WithHeader("M-Token", token).
+				WithJSON(map[string]interface{}{
+					"paymentBankAccountId": nil,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.paymentBankAccountId").Null()
+		}
+
+		{ // Once the payment account is cleared nothing should be reserved.
+			response := e.GET(fmt.Sprintf("/bank_accounts/%d/balances", checkingId)).
+				WithHeader("M-Token", token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.creditReserve").Number().Equal(0)
+			response.JSON().Path("$.safe").Number().Equal(400000)
+		}
+	})
+}
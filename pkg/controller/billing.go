@@ -3,6 +3,7 @@ package controller
 import (
 	"fmt"
 	"github.com/monetr/rest-api/pkg/crumbs"
+	"github.com/monetr/rest-api/pkg/feature"
 	"github.com/monetr/rest-api/pkg/internal/myownsanity"
 	"github.com/monetr/rest-api/pkg/internal/stripe_helper"
 	"net/http"
@@ -12,7 +13,9 @@ import (
 	"github.com/kataras/iris/v12"
 	"github.com/monetr/rest-api/pkg/build"
 	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/models"
 	"github.com/monetr/rest-api/pkg/swag"
+	"github.com/pkg/errors"
 	"github.com/stripe/stripe-go/v72"
 )
 
@@ -20,6 +23,38 @@ func (c *Controller) handleBilling(p iris.Party) {
 	p.Post("/create_checkout", c.handlePostCreateCheckout)
 	p.Get("/checkout/{checkoutSessionId:string}", c.handleGetAfterCheckout)
 	p.Get("/portal", c.handleGetStripePortal)
+	p.Get("/plans", c.handleGetPlans)
+	p.Put("/plan", c.handlePutPlan)
+}
+
+// getSelectablePlan will return the plan with the provided price Id if it is one that customers are able to choose.
+func (c *Controller) getSelectablePlan(priceId string) (config.Plan, bool) {
+	for _, plan := range c.configuration.Stripe.GetVisiblePlans() {
+		if plan.StripePriceId == priceId {
+			return plan, true
+		}
+	}
+
+	return config.Plan{}, false
+}
+
+// getCurrentPriceId returns the price Id of the account's current subscription. Accounts that subscribed before the
+// price was stored on the account will not have one, so for those the price is retrieved from Stripe instead.
+func (c *Controller) getCurrentPriceId(ctx iris.Context, account *models.Account) (string, error) {
+	if account.StripePriceId != nil {
+		return *account.StripePriceId, nil
+	}
+
+	if account.StripeSubscriptionId == nil || !account.IsSubscriptionActive() {
+		return "", nil
+	}
+
+	subscription, err := c.stripe.GetSubscription(c.getContext(ctx), *account.StripeSubscriptionId)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to retrieve subscription details from stripe")
+	}
+
+	return stripe_helper.SubscriptionPriceId(*subscription), nil
 }
 
 // Create Checkout Session
@@ -60,35 +95,23 @@ func (c *Controller) handlePostCreateCheckout(ctx iris.Context) {
 	log := c.getLog(ctx)
 
 	var plan config.Plan
-	var priceId string
-	if request.PriceId != nil {
-		priceId = *request.PriceId
-	}
-
-	if priceId == "" && c.configuration.Stripe.InitialPlan != nil {
-		priceId = c.configuration.Stripe.InitialPlan.StripePriceId
-		plan = *c.configuration.Stripe.InitialPlan
-	} else {
-		if priceId == "" {
+	if request.PriceId == nil || *request.PriceId == "" {
+		defaultPlan, ok := c.configuration.Stripe.GetDefaultPlan()
+		if !ok {
 			c.badRequest(ctx, "must provide a price id")
 			return
 		}
 
-		{ // Validate the price against our configuration.
-			var foundValidPlan bool
-			for _, planItem := range c.configuration.Stripe.Plans {
-				if planItem.StripePriceId == priceId {
-					foundValidPlan = true
-					plan = planItem
-					break
-				}
-			}
-
-			if !foundValidPlan {
-				c.badRequest(ctx, "invalid price Id provided")
-				return
-			}
+		plan = defaultPlan
+	} else {
+		// Validate the price against our configuration.
+		selectedPlan, ok := c.getSelectablePlan(*request.PriceId)
+		if !ok {
+			c.badRequest(ctx, "invalid price Id provided")
+			return
 		}
+
+		plan = selectedPlan
 	}
 
 	crumbs.Debug(c.getContext(ctx), "Creating checkout session for price", map[string]interface{}{
@@ -272,6 +295,7 @@ func (c *Controller) handleGetAfterCheckout(ctx iris.Context) {
 		account,
 		subscription.Customer.ID,
 		subscription.ID,
+		stripe_helper.SubscriptionPriceId(*subscription),
 		validUntil,
 		time.Now(),
 	); err != nil {
@@ -374,3 +398,184 @@ func (c *Controller) handleGetStripePortal(ctx iris.Context) {
 		URL: session.URL,
 	})
 }
+
+// List Plans
+// @Summary List Plans
+// @id list-plans
+// @tags Billing
+// @description Lists the plans that can be subscribed to, with their current price from Stripe. If the account is
+// @description subscribed to a plan that is no longer offered then that plan is included as well.
+// @Security ApiKeyAuth
+// @Produce json
+// @Router /billing/plans [get]
+// @Success 200 {array} swag.BillingPlan
+// @Failure 500 {object} ApiError Something went wrong on our end or when communicating with Stripe.
+func (c *Controller) handleGetPlans(ctx iris.Context) {
+	account, err := c.accounts.GetAccount(c.getContext(ctx), c.mustGetAccountId(ctx))
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to retrieve account details")
+		return
+	}
+
+	currentPriceId, err := c.getCurrentPriceId(ctx, account)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to determine current plan")
+		return
+	}
+
+	plans := c.configuration.Stripe.GetVisiblePlans()
+	if currentPriceId != "" {
+		if _, ok := c.getSelectablePlan(currentPriceId); !ok {
+			if currentPlan, ok := c.configuration.Stripe.GetPlanByPriceId(currentPriceId); ok {
+				plans = append(plans, currentPlan)
+			}
+		}
+	}
+
+	priceIds := make([]string, len(plans))
+	for i, plan := range plans {
+		priceIds[i] = plan.StripePriceId
+	}
+
+	prices, err := c.stripe.GetPricesById(c.getContext(ctx), priceIds)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to retrieve plan prices")
+		return
+	}
+
+	defaultPlan, _ := c.configuration.Stripe.GetDefaultPlan()
+
+	result := make([]swag.BillingPlan, len(plans))
+	for i, plan := range plans {
+		price := prices[i]
+		result[i] = swag.BillingPlan{
+			PriceId:       plan.StripePriceId,
+			Name:          price.Nickname,
+			UnitAmount:    price.UnitAmount,
+			Currency:      string(price.Currency),
+			FreeTrialDays: plan.FreeTrialDays,
+			Features:      plan.Features,
+			IsDefault:     plan.StripePriceId == defaultPlan.StripePriceId,
+			IsCurrent:     plan.StripePriceId == currentPriceId,
+		}
+		if result[i].Features == nil {
+			result[i].Features = []feature.Feature{}
+		}
+
+		if price.Recurring != nil {
+			result[i].Interval = string(price.Recurring.Interval)
+			result[i].IntervalCount = price.Recurring.IntervalCount
+		}
+	}
+
+	ctx.JSON(result)
+}
+
+// Change Plan
+// @Summary Change Plan
+// @id change-plan
+// @tags Billing
+// @description Move the account's active subscription to a different plan. The change takes effect immediately, and
+// @description Stripe will prorate the difference in price on the next invoice. The account's features are updated to
+// @description match the new plan right away.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param Request body swag.ChangePlanRequest true "Change plan request."
+// @Router /billing/plan [put]
+// @Success 200 {object} swag.ChangePlanResponse
+// @Failure 400 {object} ApiError The price is not valid or the account is already on that plan.
+// @Failure 402 {object} SubscriptionNotActiveError The account does not have an active subscription to change.
+// @Failure 500 {object} ApiError Something went wrong on our end or when communicating with Stripe.
+func (c *Controller) handlePutPlan(ctx iris.Context) {
+	var request swag.ChangePlanRequest
+	if err := ctx.ReadJSON(&request); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed JSON")
+		return
+	}
+
+	plan, ok := c.getSelectablePlan(request.PriceId)
+	if !ok {
+		c.badRequest(ctx, "invalid price Id provided")
+		return
+	}
+
+	account, err := c.accounts.GetAccount(c.getContext(ctx), c.mustGetAccountId(ctx))
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to retrieve account details")
+		return
+	}
+
+	// A plan can only be changed for a subscription that already exists, new subscriptions are created through a
+	// checkout session.
+	if !account.IsSubscriptionActive() || account.StripeSubscriptionId == nil {
+		c.returnError(ctx, http.StatusPaymentRequired, "subscription is not active")
+		return
+	}
+
+	currentPriceId, err := c.getCurrentPriceId(ctx, account)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to determine current plan")
+		return
+	}
+
+	if currentPriceId == plan.StripePriceId {
+		c.badRequest(ctx, "account is already subscribed to this plan")
+		return
+	}
+
+	subscription, err := c.stripe.GetSubscription(c.getContext(ctx), *account.StripeSubscriptionId)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to retrieve subscription details from stripe")
+		return
+	}
+
+	if subscription.Items == nil || len(subscription.Items.Data) == 0 {
+		c.wrapAndReturnError(ctx, errors.New("subscription has no items"), http.StatusInternalServerError, "failed to change plan")
+		return
+	}
+
+	crumbs.Debug(c.getContext(ctx), "Changing subscription plan", map[string]interface{}{
+		"previousPriceId": stripe_helper.SubscriptionPriceId(*subscription),
+		"priceId":         plan.StripePriceId,
+	})
+
+	// monetr subscriptions only have a single item, so swapping the price of that item changes the plan.
+	subscription, err = c.stripe.UpdateSubscription(c.getContext(ctx), subscription.ID, &stripe.SubscriptionParams{
+		Items: []*stripe.SubscriptionItemsParams{
+			{
+				ID:    stripe.String(subscription.Items.Data[0].ID),
+				Price: stripe.String(plan.StripePriceId),
+			},
+		},
+		ProrationBehavior: stripe.String(string(stripe.SubscriptionProrationBehaviorCreateProrations)),
+	})
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to change subscription plan")
+		return
+	}
+
+	var validUntil *time.Time
+	if stripe_helper.SubscriptionIsActive(*subscription) {
+		validUntil = myownsanity.TimeP(time.Unix(subscription.CurrentPeriodEnd, 0))
+	}
+
+	if err = c.billing.UpdateCustomerSubscription(
+		c.getContext(ctx),
+		account,
+		subscription.Customer.ID,
+		subscription.ID,
+		stripe_helper.SubscriptionPriceId(*subscription),
+		validUntil,
+		time.Now(),
+	); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to update subscription state")
+		return
+	}
+
+	ctx.JSON(swag.ChangePlanResponse{
+		PriceId:  plan.StripePriceId,
+		IsActive: account.IsSubscriptionActive(),
+		Features: account.GetFeatures(),
+	})
+}
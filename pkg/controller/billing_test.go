@@ -5,10 +5,16 @@ import (
 	"github.com/brianvoe/gofakeit/v6"
 	"github.com/jarcoal/httpmock"
 	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/feature"
 	"github.com/monetr/rest-api/pkg/internal/mock_stripe"
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/monetr/rest-api/pkg/models"
 	"github.com/monetr/rest-api/pkg/swag"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v72"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestGetAfterCheckout(t *testing.T) {
@@ -31,7 +37,10 @@ func TestGetAfterCheckout(t *testing.T) {
 			FreeTrialDays: 0,
 			Visible:       true,
 			StripePriceId: mock_stripe.FakeStripePriceId(t),
-			Default:       true,
+			Features: []feature.Feature{
+				feature.FeatureManualBudgeting,
+			},
+			Default: true,
 		}
 
 		e := NewTestApplicationWithConfig(t, conf)
@@ -68,5 +77,272 @@ func TestGetAfterCheckout(t *testing.T) {
 			result.JSON().Path("$.isActive").Boolean().True()
 			result.JSON().Path("$.nextUrl").String().Equal("/")
 		}
+
+		{ // The account should now have the features of the plan that was purchased.
+			result := e.GET("/users/me").
+				WithHeader("M-Token", token).
+				Expect()
+
+			result.Status(http.StatusOK)
+			result.JSON().Path("$.features").Array().Equal([]string{
+				string(feature.FeatureManualBudgeting),
+			})
+		}
+
+		{ // But the plan does not include linked budgeting, so Plaid cannot be used.
+			result := e.GET("/plaid/link/token/new").
+				WithHeader("M-Token", token).
+				Expect()
+
+			result.Status(http.StatusForbidden)
+			result.JSON().Path("$.error").String().Equal("your plan does not include this feature")
+		}
+	})
+}
+
+func TestChangePlan(t *testing.T) {
+	t.Run("upgrade", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		stripeMock := mock_stripe.NewMockStripeHelper(t)
+
+		stripeMock.MockStripeCreateCustomerSuccess(t)
+		stripeMock.MockNewCheckoutSession(t)
+		stripeMock.MockGetCheckoutSession(t)
+		stripeMock.MockGetSubscription(t)
+		stripeMock.MockUpdateSubscription(t)
+		mock_stripe.MockStripeGetPriceSuccess(t)
+
+		premiumPriceId := mock_stripe.FakeStripePriceId(t)
+
+		conf := NewTestApplicationConfig(t)
+		conf.Stripe.Enabled = true
+		conf.Stripe.BillingEnabled = true
+		conf.Stripe.APIKey = gofakeit.UUID()
+		conf.Stripe.InitialPlan = &config.Plan{
+			Visible:       true,
+			StripePriceId: mock_stripe.FakeStripePriceId(t),
+			Features: []feature.Feature{
+				feature.FeatureManualBudgeting,
+			},
+			Default: true,
+		}
+		conf.Stripe.Plans = []config.Plan{
+			{
+				Visible:       true,
+				StripePriceId: premiumPriceId,
+				Features: []feature.Feature{
+					feature.FeatureManualBudgeting,
+					feature.FeatureLinkedBudgeting,
+				},
+			},
+			{
+				Visible:       false,
+				StripePriceId: mock_stripe.FakeStripePriceId(t),
+			},
+		}
+
+		e := NewTestApplicationWithConfig(t, conf)
+
+		token := GivenIHaveToken(t, e)
+
+		{ // Before the account is subscribed the plan cannot be changed.
+			result := e.PUT("/billing/plan").
+				WithHeader("M-Token", token).
+				WithJSON(swag.ChangePlanRequest{
+					PriceId: premiumPriceId,
+				}).
+				Expect()
+
+			result.Status(http.StatusPaymentRequired)
+		}
+
+		var checkoutSessionId string
+		{ // Subscribe to the default plan.
+			result := e.POST("/billing/create_checkout").
+				WithHeader("M-Token", token).
+				WithJSON(swag.CreateCheckoutSessionRequest{}).
+				Expect()
+
+			result.Status(http.StatusOK)
+			checkoutSessionId = result.JSON().Path("$.sessionId").String().Raw()
+		}
+
+		stripeMock.CompleteCheckoutSession(t, checkoutSessionId)
+
+		{
+			result := e.GET(fmt.Sprintf("/billing/checkout/%s", checkoutSessionId)).
+				WithHeader("M-Token", token).
+				Expect()
+
+			result.Status(http.StatusOK)
+			result.JSON().Path("$.isActive").Boolean().True()
+		}
+
+		{ // Hidden plans should not be listed, and the default plan should be the current one.
+			result := e.GET("/billing/plans").
+				WithHeader("M-Token", token).
+				Expect()
+
+			result.Status(http.StatusOK)
+			result.JSON().Array().Length().Equal(2)
+			result.JSON().Path("$[0].priceId").String().Equal(conf.Stripe.InitialPlan.StripePriceId)
+			result.JSON().Path("$[0].isCurrent").Boolean().True()
+			result.JSON().Path("$[0].isDefault").Boolean().True()
+			result.JSON().Path("$[0].unitAmount").Number().Equal(199)
+			result.JSON().Path("$[1].priceId").String().Equal(premiumPriceId)
+			result.JSON().Path("$[1].isCurrent").Boolean().False()
+		}
+
+		{ // Hidden plans cannot be chosen.
+			result := e.PUT("/billing/plan").
+				WithHeader("M-Token", token).
+				WithJSON(swag.ChangePlanRequest{
+					PriceId: conf.Stripe.Plans[1].StripePriceId,
+				}).
+				Expect()
+
+			result.Status(http.StatusBadRequest)
+			result.JSON().Path("$.error").String().Equal("invalid price Id provided")
+		}
+
+		{ // Upgrade to the premium plan.
+			result := e.PUT("/billing/plan").
+				WithHeader("M-Token", token).
+				WithJSON(swag.ChangePlanRequest{
+					PriceId: premiumPriceId,
+				}).
+				Expect()
+
+			result.Status(http.StatusOK)
+			result.JSON().Path("$.priceId").String().Equal(premiumPriceId)
+			result.JSON().Path("$.isActive").Boolean().True()
+			result.JSON().Path("$.features").Array().Equal([]string{
+				string(feature.FeatureManualBudgeting),
+				string(feature.FeatureLinkedBudgeting),
+			})
+		}
+
+		{ // Changing to the same plan again is not allowed.
+			result := e.PUT("/billing/plan").
+				WithHeader("M-Token", token).
+				WithJSON(swag.ChangePlanRequest{
+					PriceId: premiumPriceId,
+				}).
+				Expect()
+
+			result.Status(http.StatusBadRequest)
+			result.JSON().Path("$.error").String().Equal("account is already subscribed to this plan")
+		}
+
+		{ // The current user should now have the premium features.
+			result := e.GET("/users/me").
+				WithHeader("M-Token", token).
+				Expect()
+
+			result.Status(http.StatusOK)
+			result.JSON().Path("$.features").Array().Length().Equal(2)
+		}
+	})
+
+	t.Run("subscribed before prices were stored", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		stripeMock := mock_stripe.NewMockStripeHelper(t)
+
+		stripeMock.MockGetSubscription(t)
+		mock_stripe.MockStripeGetPriceSuccess(t)
+
+		conf := NewTestApplicationConfig(t)
+		conf.Stripe.Enabled = true
+		conf.Stripe.BillingEnabled = true
+		conf.Stripe.APIKey = gofakeit.UUID()
+		conf.Stripe.InitialPlan = &config.Plan{
+			Visible:       true,
+			StripePriceId: mock_stripe.FakeStripePriceId(t),
+			Features: []feature.Feature{
+				feature.FeatureManualBudgeting,
+			},
+			Default: true,
+		}
+		conf.Stripe.Plans = []config.Plan{
+			{
+				Visible:       true,
+				StripePriceId: mock_stripe.FakeStripePriceId(t),
+				Features: []feature.Feature{
+					feature.FeatureManualBudgeting,
+					feature.FeatureLinkedBudgeting,
+				},
+			},
+		}
+
+		e := NewTestApplicationWithConfig(t, conf)
+
+		email, _, token := register(t, e)
+
+		subscription := &stripe.Subscription{
+			CurrentPeriodEnd: time.Now().Add(24 * time.Hour).Unix(),
+			Customer: &stripe.Customer{
+				ID: mock_stripe.FakeStripeCustomerId(t),
+			},
+			Items: &stripe.SubscriptionItemList{
+				Data: []*stripe.SubscriptionItem{
+					{
+						ID: mock_stripe.FakeStripeSubscriptionItemId(t),
+						Price: &stripe.Price{
+							ID: conf.Stripe.InitialPlan.StripePriceId,
+						},
+						Quantity: 1,
+					},
+				},
+			},
+			Status: stripe.SubscriptionStatusActive,
+		}
+		stripeMock.CreateSubscription(t, subscription)
+
+		// Accounts that subscribed before the price was stored on the account have an active subscription, but no
+		// price Id.
+		db := testutils.GetPgDatabase(t)
+		var user models.User
+		require.NoError(t, db.Model(&user).
+			Join(`INNER JOIN "logins" AS "login"`).
+			JoinOn(`"login"."login_id" = "user"."login_id"`).
+			Where(`"login"."email" = ?`, email).
+			Limit(1).
+			Select(&user), "must retrieve user")
+		_, err := db.Model(&models.Account{}).
+			Set(`"stripe_customer_id" = ?`, subscription.Customer.ID).
+			Set(`"stripe_subscription_id" = ?`, subscription.ID).
+			Set(`"subscription_active_until" = ?`, time.Unix(subscription.CurrentPeriodEnd, 0)).
+			Set(`"stripe_price_id" = NULL`).
+			Where(`"account"."account_id" = ?`, user.AccountId).
+			Update()
+		require.NoError(t, err, "must setup legacy subscription")
+
+		{ // The current plan should be determined from the subscription.
+			result := e.GET("/billing/plans").
+				WithHeader("M-Token", token).
+				Expect()
+
+			result.Status(http.StatusOK)
+			result.JSON().Array().Length().Equal(2)
+			result.JSON().Path("$[0].priceId").String().Equal(conf.Stripe.InitialPlan.StripePriceId)
+			result.JSON().Path("$[0].isCurrent").Boolean().True()
+			result.JSON().Path("$[1].isCurrent").Boolean().False()
+		}
+
+		{ // And the account should not be able to change to the plan it is already on.
+			result := e.PUT("/billing/plan").
+				WithHeader("M-Token", token).
+				WithJSON(swag.ChangePlanRequest{
+					PriceId: conf.Stripe.InitialPlan.StripePriceId,
+				}).
+				Expect()
+
+			result.Status(http.StatusBadRequest)
+			result.JSON().Path("$.error").String().Equal("account is already subscribed to this plan")
+		}
 	})
 }
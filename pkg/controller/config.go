@@ -2,6 +2,7 @@ package controller
 
 import (
 	"github.com/kataras/iris/v12/context"
+	"github.com/monetr/rest-api/pkg/config"
 )
 
 // Application Configuration
@@ -23,6 +24,8 @@ func (c *Controller) configEndpoint(ctx *context.Context) {
 		VerifyLogin         bool         `json:"verifyLogin"`
 		VerifyRegister      bool         `json:"verifyRegister"`
 		ReCAPTCHAKey        string       `json:"ReCAPTCHAKey,omitempty"`
+		CaptchaProvider     string       `json:"captchaProvider,omitempty"`
+		CaptchaVersion      int          `json:"captchaVersion,omitempty"`
 		StripePublicKey     string       `json:"stripePublicKey,omitempty"`
 		AllowSignUp         bool         `json:"allowSignUp"`
 		AllowForgotPassword bool         `json:"allowForgotPassword"`
@@ -30,12 +33,22 @@ func (c *Controller) configEndpoint(ctx *context.Context) {
 		RequireBetaCode     bool         `json:"requireBetaCode"`
 		InitialPlan         *InitialPlan `json:"initialPlan"`
 		BillingEnabled      bool         `json:"billingEnabled"`
+		OIDCEnabled         bool         `json:"oidcEnabled"`
+		VerifyPhoneNumber   bool         `json:"verifyPhoneNumber"`
+		OIDCName            string       `json:"oidcName,omitempty"`
+		AllowSimpleFIN      bool         `json:"allowSimpleFIN"`
+		FakePlaid           bool         `json:"fakePlaid"`
 	}
 
 	// If ReCAPTCHA is enabled then we want to provide the UI our public key as
-	// well as whether or not we want it to verify logins and registrations.
+	// well as whether or not we want it to verify logins and registrations. The
+	// provider tells the UI which captcha widget it needs to load.
 	if c.configuration.ReCAPTCHA.Enabled {
 		configuration.ReCAPTCHAKey = c.configuration.ReCAPTCHA.PublicKey
+		configuration.CaptchaProvider = string(c.captcha.Provider())
+		if c.captcha.Provider() == config.CaptchaProviderReCAPTCHA {
+			configuration.CaptchaVersion = c.configuration.ReCAPTCHA.Version
+		}
 		configuration.VerifyLogin = c.configuration.ReCAPTCHA.VerifyLogin
 		configuration.VerifyRegister = c.configuration.ReCAPTCHA.VerifyRegister
 	}
@@ -48,6 +61,19 @@ func (c *Controller) configEndpoint(ctx *context.Context) {
 
 	configuration.AllowSignUp = c.configuration.AllowSignUp
 
+	// Phone numbers can only be verified if we are able to send text messages.
+	configuration.VerifyPhoneNumber = c.configuration.SMS.Enabled
+
+	configuration.AllowSimpleFIN = c.configuration.SimpleFIN.Enabled
+
+	// When Plaid is fake the UI should use the simulated link flow instead of loading Plaid Link.
+	configuration.FakePlaid = c.configuration.Plaid.Fake
+
+	if c.configuration.OIDC.Enabled {
+		configuration.OIDCEnabled = true
+		configuration.OIDCName = c.configuration.OIDC.Name
+	}
+
 	if c.configuration.Plaid.EnableReturningUserExperience {
 		configuration.RequireLegalName = true
 		configuration.RequirePhoneNumber = true
@@ -56,17 +82,17 @@ func (c *Controller) configEndpoint(ctx *context.Context) {
 	if c.configuration.Stripe.Enabled {
 		configuration.StripePublicKey = c.configuration.Stripe.PublicKey
 
-		if c.configuration.Stripe.IsBillingEnabled() && c.configuration.Stripe.InitialPlan != nil {
+		if defaultPlan, ok := c.configuration.Stripe.GetDefaultPlan(); c.configuration.Stripe.IsBillingEnabled() && ok {
 			price, err := c.stripe.GetPriceById(
 				c.getContext(ctx),
-				c.configuration.Stripe.InitialPlan.StripePriceId,
+				defaultPlan.StripePriceId,
 			)
 			if err != nil {
 				c.getLog(ctx).Warn("failed to retrieve stripe price for initial plan")
 			} else {
 				configuration.InitialPlan = &InitialPlan{
 					Price:         price.UnitAmount,
-					FreeTrialDays: c.configuration.Stripe.InitialPlan.FreeTrialDays,
+					FreeTrialDays: defaultPlan.FreeTrialDays,
 				}
 			}
 		}
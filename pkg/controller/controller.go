@@ -14,19 +14,28 @@ import (
 	"github.com/gomodule/redigo/redis"
 	"github.com/kataras/iris/v12"
 	"github.com/kataras/iris/v12/core/router"
+	"github.com/monetr/rest-api/pkg/admin"
 	"github.com/monetr/rest-api/pkg/billing"
 	"github.com/monetr/rest-api/pkg/build"
 	"github.com/monetr/rest-api/pkg/cache"
+	"github.com/monetr/rest-api/pkg/captcha"
+	"github.com/monetr/rest-api/pkg/communication"
 	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/internal/aggregator"
+	"github.com/monetr/rest-api/pkg/internal/oidc"
 	"github.com/monetr/rest-api/pkg/internal/platypus"
+	"github.com/monetr/rest-api/pkg/internal/simplefin"
 	"github.com/monetr/rest-api/pkg/internal/stripe_helper"
 	"github.com/monetr/rest-api/pkg/jobs"
+	"github.com/monetr/rest-api/pkg/mail"
 	"github.com/monetr/rest-api/pkg/metrics"
+	"github.com/monetr/rest-api/pkg/models"
 	"github.com/monetr/rest-api/pkg/pubsub"
+	"github.com/monetr/rest-api/pkg/ratelimit"
 	"github.com/monetr/rest-api/pkg/secrets"
+	"github.com/monetr/rest-api/pkg/sms"
 	"github.com/sirupsen/logrus"
 	"github.com/xlzd/gotp"
-	"gopkg.in/ezzarghili/recaptcha-go.v4"
 )
 
 const (
@@ -36,7 +45,7 @@ const (
 type Controller struct {
 	db                       *pg.DB
 	configuration            config.Configuration
-	captcha                  *recaptcha.ReCAPTCHA
+	captcha                  captcha.Verifier
 	plaid                    platypus.Platypus
 	plaidWebhookVerification platypus.WebhookVerification
 	plaidSecrets             secrets.PlaidSecretsProvider
@@ -52,6 +61,15 @@ type Controller struct {
 	paywall                  billing.BasicPayWall
 	billing                  billing.BasicBilling
 	stripeWebhooks           billing.StripeWebhookHandler
+	limiter                  ratelimit.Limiter
+	lockout                  ratelimit.Lockout
+	communication            communication.UserCommunication
+	oidc                     oidc.Provider
+	phoneVerification        sms.PhoneVerification
+	simpleFIN                simplefin.SimpleFIN
+	simpleFINSecrets         secrets.SimpleFINSecretsProvider
+	providers                aggregator.Providers
+	admin                    admin.Admin
 }
 
 func NewController(
@@ -65,28 +83,80 @@ func NewController(
 	cachePool *redis.Pool,
 	plaidSecrets secrets.PlaidSecretsProvider,
 	basicPaywall billing.BasicPayWall,
+	simpleFINSecrets secrets.SimpleFINSecretsProvider,
+	simpleFINBridge simplefin.SimpleFIN,
 ) *Controller {
-	var captcha recaptcha.ReCAPTCHA
+	var captchaVerifier captcha.Verifier
 	var err error
 	if configuration.ReCAPTCHA.Enabled {
-		captcha, err = recaptcha.NewReCAPTCHA(
-			configuration.ReCAPTCHA.PrivateKey,
-			recaptcha.V2,
-			30*time.Second,
-		)
+		captchaVerifier, err = captcha.NewVerifier(configuration.ReCAPTCHA)
 		if err != nil {
 			panic(err)
 		}
 	}
 
-	accountsRepo := billing.NewAccountRepository(log, cache.NewCache(log, cachePool), db)
+	cacheClient := cache.NewCache(log, cachePool)
+	accountsRepo := billing.NewAccountRepository(log, cacheClient, db)
 	pubSub := pubsub.NewPostgresPubSub(log, db)
-	basicBilling := billing.NewBasicBilling(log, accountsRepo, pubSub)
+	basicBilling := billing.NewBasicBilling(log, accountsRepo, pubSub, configuration.Stripe)
 
 	plaidWebhookVerification := platypus.NewInMemoryWebhookVerification(log, plaidClient, 5*time.Minute)
 
+	var userCommunication communication.UserCommunication
+	if configuration.EMail.Enabled {
+		userCommunication = communication.NewUserCommunication(
+			log,
+			configuration.EMail,
+			mail.NewSMTPCommunication(log, configuration.EMail.SMTP),
+		)
+	}
+
+	var oidcProvider oidc.Provider
+	if configuration.OIDC.Enabled {
+		oidcProvider = oidc.NewProvider(log, configuration.OIDC)
+	}
+
+	var phoneVerification sms.PhoneVerification
+	if configuration.SMS.Enabled {
+		sender, err := sms.NewSender(log, configuration.SMS)
+		if err != nil {
+			panic(err)
+		}
+
+		phoneVerification = sms.NewPhoneVerification(
+			log,
+			cacheClient,
+			sender,
+			configuration.SMS.CodeLifetime,
+			configuration.SMS.MaxAttempts,
+			configuration.SMS.ResendCooldown,
+		)
+	}
+
+	// Every event from Stripe is recorded before it is handled so that duplicate deliveries are skipped.
+	stripeWebhooks := billing.NewIdempotentStripeWebhookHandler(
+		log,
+		billing.NewStripeEventRepository(log, db),
+		billing.NewStripeWebhookHandler(log, accountsRepo, basicBilling, pubSub, userCommunication, configuration),
+	)
+
+	// Links for any of these providers can be removed or have their data retrieved through the provider generically.
+	providers := aggregator.Providers{}
+	if plaidClient != nil {
+		providers[models.PlaidLinkType] = plaidClient
+	}
+	if simpleFINBridge != nil {
+		providers[models.SimpleFINLinkType] = simpleFINBridge
+	}
+
+	var adminService admin.Admin
+	if configuration.Admin.Enabled {
+		adminService = admin.NewAdmin(log, db, cacheClient, job)
+	}
+
 	return &Controller{
-		captcha:                  &captcha,
+		admin:                    adminService,
+		captcha:                  captchaVerifier,
 		configuration:            configuration,
 		db:                       db,
 		plaid:                    plaidClient,
@@ -101,7 +171,21 @@ func NewController(
 		accounts:                 accountsRepo,
 		paywall:                  basicPaywall,
 		billing:                  basicBilling,
-		stripeWebhooks:           billing.NewStripeWebhookHandler(log, accountsRepo, basicBilling, pubSub),
+		stripeWebhooks:           stripeWebhooks,
+		limiter:                  ratelimit.NewSlidingWindowLimiter(log, cacheClient),
+		lockout: ratelimit.NewProgressiveLockout(
+			log,
+			cacheClient,
+			configuration.RateLimit.LockoutThreshold,
+			configuration.RateLimit.LockoutDuration,
+			configuration.RateLimit.MaxLockoutDuration,
+		),
+		communication:     userCommunication,
+		oidc:              oidcProvider,
+		phoneVerification: phoneVerification,
+		simpleFIN:         simpleFINBridge,
+		simpleFINSecrets:  simpleFINSecrets,
+		providers:         providers,
 	}
 }
 
@@ -250,14 +334,23 @@ func (c *Controller) RegisterRoutes(app *iris.Application) {
 			repoParty.Get("/config", c.configEndpoint)
 
 			repoParty.PartyFunc("/authentication", func(repoParty router.Party) {
-				repoParty.Post("/login", c.loginEndpoint)
-				repoParty.Post("/register", c.registerEndpoint)
+				repoParty.Post("/login", c.ipRateLimitMiddleware("login"), c.loginEndpoint)
+				repoParty.Post("/register", c.ipRateLimitMiddleware("register"), c.registerEndpoint)
+				repoParty.Post("/unlock", c.ipRateLimitMiddleware("unlock"), c.unlockEndpoint)
+				repoParty.Post("/verify/email", c.ipRateLimitMiddleware("verify"), c.verifyEmailChange)
+				if c.configuration.OIDC.Enabled {
+					repoParty.PartyFunc("/oidc", c.handleOIDC)
+				}
 				//repoParty.Post("/verify", c.verifyEndpoint)
 			})
 
 			repoParty.Use(c.authenticationMiddleware)
 
 			repoParty.PartyFunc("/users", c.handleUsers)
+			if c.configuration.Admin.Enabled {
+				// Admins do not need an active subscription, so this needs to be declared before the paywall.
+				repoParty.PartyFunc("/admin", c.handleAdmin)
+			}
 			if c.configuration.Stripe.Enabled {
 				repoParty.PartyFunc("/billing", c.handleBilling)
 
@@ -267,6 +360,8 @@ func (c *Controller) RegisterRoutes(app *iris.Application) {
 				}
 			}
 
+			repoParty.PartyFunc("/institutions", c.handleInstitutions)
+			repoParty.PartyFunc("/jobs", c.handleJobs)
 			repoParty.PartyFunc("/links", c.linksController)
 			repoParty.PartyFunc("/bank_accounts", func(bankParty router.Party) {
 				c.handleBankAccounts(bankParty)
@@ -277,6 +372,14 @@ func (c *Controller) RegisterRoutes(app *iris.Application) {
 
 			repoParty.PartyFunc("/plaid/link", c.handlePlaidLinkEndpoints)
 
+			if c.configuration.Plaid.Fake {
+				repoParty.PartyFunc("/plaid/fake", c.handleFakePlaidEndpoints)
+			}
+
+			if c.simpleFIN != nil {
+				repoParty.PartyFunc("/simplefin/link", c.handleSimpleFINLinkEndpoints)
+			}
+
 			if c.configuration.Environment != "production" {
 				repoParty.Get("/test/error", func(ctx iris.Context) {
 					c.badRequest(ctx, "this endpoint is meant to be used to test error reporting to sentry")
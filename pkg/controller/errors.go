@@ -6,6 +6,7 @@ import (
 	"github.com/go-pg/pg/v10"
 	"github.com/kataras/iris/v12/context"
 	"github.com/monetr/rest-api/pkg/crumbs"
+	"github.com/monetr/rest-api/pkg/currency"
 	"github.com/pkg/errors"
 	"net/http"
 )
@@ -30,6 +31,8 @@ func (c *Controller) wrapPgError(ctx *context.Context, err error, msg string, ar
 		crumbs.Error(c.getContext(ctx), fmt.Sprintf(msg, args...), c.configuration.APIDomainName, map[string]interface{}{
 			"error": ctx.GetErr().Error(),
 		})
+	case currency.ErrMismatch:
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, msg, args...)
 	default:
 		switch actualErr := errors.Cause(err).(type) {
 		case pg.Error:
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"github.com/kataras/iris/v12"
+)
+
+func (c *Controller) handleInstitutions(p iris.Party) {
+	p.Get("/{institutionId:uint64}", c.getInstitution)
+}
+
+// Get Institution
+// @Summary Get Institution
+// @id get-institution
+// @tags Institutions
+// @description Retrieve the details of a single institution, including whether the institution is currently healthy.
+// @description This can be used to warn the user that their bank is having problems before their data stops being
+// @description updated.
+// @Produce json
+// @Security ApiKeyAuth
+// @Router /institutions/{institutionId} [get]
+// @Param institutionId path int true "Institution ID"
+// @Success 200 {object} swag.InstitutionResponse
+// @Failure 400 {object} ApiError Invalid institution Id.
+// @Failure 402 {object} SubscriptionNotActiveError The user's subscription is not active.
+// @Failure 404 {object} ApiError The institution does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getInstitution(ctx iris.Context) {
+	institutionId := ctx.Params().GetUint64Default("institutionId", 0)
+	if institutionId == 0 {
+		c.badRequest(ctx, "must specify an institution Id")
+		return
+	}
+
+	institution, err := c.mustGetAuthenticatedRepository(ctx).GetInstitution(c.getContext(ctx), institutionId)
+	if err != nil {
+		c.wrapPgError(ctx, err, "failed to retrieve institution")
+		return
+	}
+
+	ctx.JSON(institution)
+}
@@ -0,0 +1,30 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGetInstitution(t *testing.T) {
+	t.Run("not found", func(t *testing.T) {
+		e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+
+		response := e.GET("/institutions/{institutionId}", 999999999).
+			WithHeader("M-Token", token).
+			Expect()
+
+		response.Status(http.StatusNotFound)
+		response.JSON().Path("$.error").String().Equal("failed to retrieve institution: record does not exist")
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		e := NewTestApplication(t)
+
+		response := e.GET("/institutions/{institutionId}", 1).
+			Expect()
+
+		response.Status(http.StatusForbidden)
+		response.JSON().Path("$.error").String().Equal("token must be provided")
+	})
+}
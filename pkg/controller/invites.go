@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/monetr/rest-api/pkg/repository"
+	"github.com/monetr/rest-api/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// betaInviteLifetime is how long a beta code created by a user to invite someone else will be valid for.
+const betaInviteLifetime = 14 * 24 * time.Hour
+
+type betaInvite struct {
+	models.Beta
+	Status models.BetaStatus `json:"status"`
+}
+
+func newBetaInvites(betas []models.Beta) []betaInvite {
+	now := time.Now()
+	invites := make([]betaInvite, len(betas))
+	for i, beta := range betas {
+		invites[i] = betaInvite{
+			Beta:   beta,
+			Status: beta.Status(now),
+		}
+	}
+
+	return invites
+}
+
+// List Beta Invites
+// @Summary List Beta Invites
+// @id list-beta-invites
+// @tags User
+// @description Lists the beta codes that the current user has created to invite other people, as well as how many more
+// @description codes they are allowed to create.
+// @Security ApiKeyAuth
+// @Produce json
+// @Router /users/invites [get]
+// @Success 200 {object} swag.BetaInvitesResponse
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) listBetaInvites(ctx iris.Context) {
+	betas, quota, err := repository.NewBetaRepository(c.mustGetDatabase(ctx)).
+		GetBetaInvites(c.getContext(ctx), c.mustGetUserId(ctx))
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to retrieve beta invites")
+		return
+	}
+
+	remaining := quota - len(betas)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	ctx.JSON(map[string]interface{}{
+		"quota":     quota,
+		"remaining": remaining,
+		"invites":   newBetaInvites(betas),
+	})
+}
+
+// Create Beta Invite
+// @Summary Create Beta Invite
+// @id create-beta-invite
+// @tags User
+// @description Creates a beta code that the current user can give to someone else so that they can register. The code
+// @description is only returned once and cannot be retrieved again. Users can only create as many codes as their quota
+// @description allows.
+// @Security ApiKeyAuth
+// @Produce json
+// @Router /users/invites [post]
+// @Success 200 {object} swag.CreateBetaInviteResponse
+// @Failure 403 {object} ApiError The user has already created as many invites as they are allowed to.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) createBetaInvite(ctx iris.Context) {
+	expiresAt := util.MidnightInLocal(time.Now().Add(betaInviteLifetime), time.UTC)
+
+	code, beta, err := repository.NewBetaRepository(c.mustGetDatabase(ctx)).
+		CreateBetaInvite(c.getContext(ctx), c.mustGetUserId(ctx), expiresAt)
+	if err != nil {
+		if errors.Is(err, repository.ErrBetaInviteQuotaExceeded) {
+			c.returnError(ctx, http.StatusForbidden, "you have already created as many invites as you are allowed to")
+			return
+		}
+
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create beta invite")
+		return
+	}
+
+	ctx.JSON(map[string]interface{}{
+		"code":   code,
+		"invite": newBetaInvites([]models.Beta{*beta})[0],
+	})
+}
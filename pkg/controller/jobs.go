@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"github.com/kataras/iris/v12/context"
+	"github.com/kataras/iris/v12/core/router"
+)
+
+func (c *Controller) handleJobs(p router.Party) {
+	p.Get("/", c.getJobs)
+	p.Get("/{jobId:string}", c.getJob)
+}
+
+// List Jobs
+// @Summary List Jobs
+// @id list-jobs
+// @tags Jobs
+// @description Lists the background jobs that have been run for the current account. Jobs are returned sorted by the
+// @description time they were enqueued (descending).
+// @Security ApiKeyAuth
+// @Produce json
+// @Param limit query int false "Specifies the number of jobs to return in the result, default is 25. Max is 100."
+// @Param offset query int false "The number of jobs to skip before returning any."
+// @Router /jobs [get]
+// @Success 200 {array} swag.JobResponse
+// @Failure 400 {object} ApiError Invalid limit or offset.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getJobs(ctx *context.Context) {
+	limit := ctx.URLParamIntDefault("limit", 25)
+	offset := ctx.URLParamIntDefault("offset", 0)
+
+	if limit < 1 {
+		c.badRequest(ctx, "limit must be at least 1")
+		return
+	} else if limit > 100 {
+		c.badRequest(ctx, "limit cannot be greater than 100")
+		return
+	}
+
+	if offset < 0 {
+		c.badRequest(ctx, "offset cannot be less than 0")
+		return
+	}
+
+	jobs, err := c.mustGetAuthenticatedRepository(ctx).GetJobs(c.getContext(ctx), limit, offset)
+	if err != nil {
+		c.wrapPgError(ctx, err, "failed to retrieve jobs")
+		return
+	}
+
+	ctx.JSON(jobs)
+}
+
+// Get Job
+// @Summary Get Job
+// @id get-job
+// @tags Jobs
+// @description Retrieve the status of a single background job by its Id. This can be used to check whether a sync has
+// @description completed, or why it failed.
+// @Security ApiKeyAuth
+// @Produce json
+// @Param jobId path string true "The Id of the job to retrieve."
+// @Router /jobs/{jobId} [get]
+// @Success 200 {object} swag.JobResponse
+// @Failure 400 {object} ApiError Invalid job Id.
+// @Failure 404 {object} ApiError The job does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) getJob(ctx *context.Context) {
+	jobId := ctx.Params().GetStringDefault("jobId", "")
+	if jobId == "" {
+		c.badRequest(ctx, "must specify a job Id")
+		return
+	}
+
+	job, err := c.mustGetAuthenticatedRepository(ctx).GetJob(c.getContext(ctx), jobId)
+	if err != nil {
+		c.wrapPgError(ctx, err, "failed to retrieve job")
+		return
+	}
+
+	ctx.JSON(job)
+}
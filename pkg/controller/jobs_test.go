@@ -0,0 +1,57 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGetJobs(t *testing.T) {
+	t.Run("no jobs", func(t *testing.T) {
+		e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+
+		response := e.GET("/jobs").
+			WithHeader("M-Token", token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().Empty()
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+
+		response := e.GET("/jobs").
+			WithHeader("M-Token", token).
+			WithQuery("limit", 101).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().Equal("limit cannot be greater than 100")
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		e := NewTestApplication(t)
+
+		response := e.GET("/jobs").
+			Expect()
+
+		response.Status(http.StatusForbidden)
+		response.JSON().Path("$.error").String().Equal("token must be provided")
+	})
+}
+
+func TestGetJob(t *testing.T) {
+	t.Run("not found", func(t *testing.T) {
+		e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+
+		response := e.GET("/jobs/{jobId}", "abc123").
+			WithHeader("M-Token", token).
+			Expect()
+
+		response.Status(http.StatusNotFound)
+		response.JSON().Path("$.error").String().Equal("failed to retrieve job: record does not exist")
+	})
+}
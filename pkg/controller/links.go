@@ -9,8 +9,11 @@ import (
 	"github.com/getsentry/sentry-go"
 	"github.com/kataras/iris/v12"
 	"github.com/monetr/rest-api/pkg/crumbs"
+	"github.com/monetr/rest-api/pkg/feature"
+	"github.com/monetr/rest-api/pkg/internal/aggregator"
 	"github.com/monetr/rest-api/pkg/models"
 	"github.com/monetr/rest-api/pkg/swag"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
@@ -18,9 +21,9 @@ func (c *Controller) linksController(p iris.Party) {
 	// GET will list all the links in the current account.
 	p.Get("/", c.getLinks)
 	p.Get("/{linkId:uint64}", c.getLink)
-	p.Post("/", c.postLinks)
+	p.Post("/", c.requireFeatureMiddleware(feature.FeatureManualBudgeting), c.postLinks)
 	p.Put("/{linkId:uint64}", c.putLink)
-	p.Put("/convert/{linkId:uint64}", c.convertLink)
+	p.Put("/convert/{linkId:uint64}", c.requireFeatureMiddleware(feature.FeatureManualBudgeting), c.convertLink)
 	p.Delete("/{linkId:uint64}", c.deleteLink)
 	p.Get("/wait/{linkId:uint64}", c.waitForDeleteLink)
 }
@@ -93,6 +96,7 @@ func (c *Controller) getLink(ctx iris.Context) {
 // @Success 200 {object} swag.LinkResponse "Newly created manual link"
 // @Failure 400 {object} MalformedJSONError "Malformed JSON."
 // @Failure 402 {object} SubscriptionNotActiveError The user's subscription is not active.
+// @Failure 403 {object} ApiError The user's plan does not include manual budgeting.
 // @Failure 500 {object} ApiError "Something went wrong on our end."
 func (c *Controller) postLinks(ctx iris.Context) {
 	var link models.Link
@@ -185,6 +189,7 @@ func (c *Controller) putLink(ctx iris.Context) {
 // @Success 200 {object} swag.LinkResponse "New link object after being converted to a manual link."
 // @Failure 400 {object} ApiError "The link specified is already a manual link."
 // @Failure 402 {object} SubscriptionNotActiveError The user's subscription is not active.
+// @Failure 403 {object} ApiError The user's plan does not include manual budgeting.
 // @Failure 500 {object} ApiError "Something went wrong on our end."
 func (c *Controller) convertLink(ctx iris.Context) {
 	linkId := ctx.Params().GetUint64Default("linkId", 0)
@@ -251,41 +256,52 @@ func (c *Controller) deleteLink(ctx iris.Context) {
 		return
 	}
 
-	if link.LinkType == models.PlaidLinkType {
-		if link.PlaidLink == nil {
-			crumbs.Error(c.getContext(ctx), "BUG: Plaid Link object was missing on the link object", "bug", map[string]interface{}{
-				"linkId": link.LinkId,
-			})
-			c.returnError(ctx, http.StatusInternalServerError, "missing plaid data to remove link")
-			return
-		}
+	if link.LinkType == models.PlaidLinkType && link.PlaidLink == nil {
+		crumbs.Error(c.getContext(ctx), "BUG: Plaid Link object was missing on the link object", "bug", map[string]interface{}{
+			"linkId": link.LinkId,
+		})
+		c.returnError(ctx, http.StatusInternalServerError, "missing plaid data to remove link")
+		return
+	}
 
-		accessToken, err := c.plaidSecrets.GetAccessTokenForPlaidLinkId(c.getContext(ctx), repo.AccountId(), link.PlaidLink.ItemId)
-		if err != nil {
-			crumbs.Error(c.getContext(ctx), "Failed to retrieve access token for plaid link.", "secrets", map[string]interface{}{
-				"linkId": link.LinkId,
-				"itemId": link.PlaidLink.ItemId,
+	// Links that are maintained by a provider need to have our access revoked at the provider before they are removed.
+	// Manual links, or links for a provider that is no longer enabled, do not have anything to revoke.
+	client, err := c.providers.GetClientForLink(c.getContext(ctx), link)
+	switch errors.Cause(err) {
+	case nil:
+		if err = client.RemoveItem(c.getContext(ctx)); err != nil {
+			crumbs.Error(c.getContext(ctx), "Failed to remove item", "provider", map[string]interface{}{
+				"linkId":   link.LinkId,
+				"linkType": link.LinkType,
+				"error":    err.Error(),
 			})
-			c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to retrieve access token for removal")
+			c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to remove item from provider")
 			return
 		}
+	case aggregator.ErrUnsupportedLinkType:
+		crumbs.Debug(c.getContext(ctx), "Link does not have a provider, nothing to revoke", map[string]interface{}{
+			"linkId":   link.LinkId,
+			"linkType": link.LinkType,
+		})
+	default:
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create client to remove link")
+		return
+	}
 
-		client, err := c.plaid.NewClient(c.getContext(ctx), link, accessToken)
-		if err != nil {
-			c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create plaid client")
-			return
+	switch link.LinkType {
+	case models.SimpleFINLinkType:
+		if link.SimpleFINLinkId == nil {
+			break
 		}
 
-		if err = client.RemoveItem(c.getContext(ctx)); err != nil {
-			crumbs.Error(c.getContext(ctx), "Failed to remove item", "plaid", map[string]interface{}{
+		if err = c.simpleFINSecrets.RemoveAccessUrlForSimpleFINLink(c.getContext(ctx), repo.AccountId(), *link.SimpleFINLinkId); err != nil {
+			crumbs.Error(c.getContext(ctx), "Failed to remove access url", "secrets", map[string]interface{}{
 				"linkId": link.LinkId,
-				"itemId": link.PlaidLink.ItemId,
 				"error":  err.Error(),
 			})
-			c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to remove item from Plaid")
-			return
+			// Like Plaid access tokens, a stray access url is left over if this fails. The link is still removed.
 		}
-
+	case models.PlaidLinkType:
 		if err = c.plaidSecrets.RemoveAccessTokenForPlaidLink(c.getContext(ctx), repo.AccountId(), link.PlaidLink.ItemId); err != nil {
 			crumbs.Error(c.getContext(ctx), "Failed to remove access token", "secrets", map[string]interface{}{
 				"linkId": link.LinkId,
@@ -2,7 +2,16 @@ package controller_test
 
 import (
 	"fmt"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/jarcoal/httpmock"
+	"github.com/monetr/rest-api/pkg/internal/mock_jobs"
+	"github.com/monetr/rest-api/pkg/internal/mock_plaid"
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/monetr/rest-api/pkg/jobs"
 	"github.com/monetr/rest-api/pkg/models"
+	"github.com/plaid/plaid-go/plaid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"math"
 	"net/http"
 	"testing"
@@ -404,3 +413,109 @@ func TestDeleteLink(t *testing.T) {
 		}
 	})
 }
+
+func TestSyncPlaidLink(t *testing.T) {
+	t.Run("manual link", func(t *testing.T) {
+		e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+
+		var linkId uint64
+		{
+			response := e.POST("/links").
+				WithHeader("M-Token", token).
+				WithJSON(models.Link{
+					InstitutionName: "U.S. Bank",
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			linkId = uint64(response.JSON().Path("$.linkId").Number().Raw())
+		}
+
+		response := e.POST("/plaid/link/sync/{linkId}", linkId).
+			WithHeader("M-Token", token).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().Equal("cannot sync a non-Plaid link")
+	})
+
+	t.Run("failed to trigger", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		jobManager := mock_jobs.NewMockJobManager()
+		e := NewTestApplicationWithJobManager(t, NewTestApplicationConfig(t), jobManager)
+		token := GivenIHaveToken(t, e)
+		linkId := givenIHaveAPlaidLink(t, e, token, gofakeit.UUID(), []plaid.AccountBase{
+			mock_plaid.BankAccountFixture(t),
+		})
+
+		// The link is only setup once the initial pull has completed, which the mock job manager does not run.
+		db := testutils.GetPgDatabase(t)
+		_, err := db.Model(&models.Link{}).
+			Set(`"link_status" = ?`, models.LinkStatusSetup).
+			Where(`"link"."link_id" = ?`, linkId).
+			Update()
+		require.NoError(t, err, "must mark link as setup")
+
+		{ // If the job cannot be enqueued then the sync should fail.
+			jobManager.ShouldFail[jobs.PullLatestTransactions] = true
+			response := e.POST("/plaid/link/sync/{linkId}", linkId).
+				WithHeader("M-Token", token).
+				Expect()
+
+			response.Status(http.StatusInternalServerError)
+		}
+
+		{ // But the cooldown should not be held, so the user can retry right away.
+			jobManager.ShouldFail[jobs.PullLatestTransactions] = false
+			response := e.POST("/plaid/link/sync/{linkId}", linkId).
+				WithHeader("M-Token", token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.jobId").String().NotEmpty()
+		}
+
+		{ // Once a sync has started the cooldown applies.
+			response := e.POST("/plaid/link/sync/{linkId}", linkId).
+				WithHeader("M-Token", token).
+				Expect()
+
+			response.Status(http.StatusTooManyRequests)
+			response.Header("Retry-After").NotEmpty()
+		}
+
+		assert.Len(t, jobManager.GetTriggered(jobs.PullLatestTransactions), 1, "should only trigger one sync")
+	})
+
+	t.Run("already syncing", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		jobManager := mock_jobs.NewMockJobManager()
+		e := NewTestApplicationWithJobManager(t, NewTestApplicationConfig(t), jobManager)
+		token := GivenIHaveToken(t, e)
+		linkId := givenIHaveAPlaidLink(t, e, token, gofakeit.UUID(), []plaid.AccountBase{
+			mock_plaid.BankAccountFixture(t),
+		})
+
+		db := testutils.GetPgDatabase(t)
+		_, err := db.Model(&models.Link{}).
+			Set(`"link_status" = ?`, models.LinkStatusSetup).
+			Where(`"link"."link_id" = ?`, linkId).
+			Update()
+		require.NoError(t, err, "must mark link as setup")
+
+		// A webhook has already queued a sync for this link, so the same job cannot be enqueued again.
+		jobManager.AlreadyQueued[jobs.PullLatestTransactions] = true
+		response := e.POST("/plaid/link/sync/{linkId}", linkId).
+			WithHeader("M-Token", token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.jobId").Null()
+		response.JSON().Path("$.alreadySyncing").Boolean().True()
+	})
+}
@@ -15,11 +15,11 @@ import (
 	"github.com/monetr/rest-api/pkg/controller"
 	"github.com/monetr/rest-api/pkg/internal/mock_secrets"
 	"github.com/monetr/rest-api/pkg/internal/platypus"
+	"github.com/monetr/rest-api/pkg/internal/simplefin"
 	"github.com/monetr/rest-api/pkg/internal/stripe_helper"
 	"github.com/monetr/rest-api/pkg/internal/testutils"
 	"github.com/monetr/rest-api/pkg/jobs"
 	"github.com/monetr/rest-api/pkg/repository"
-	"github.com/monetr/rest-api/pkg/secrets"
 	"github.com/plaid/plaid-go/plaid"
 	"github.com/stretchr/testify/require"
 )
@@ -57,11 +57,22 @@ func NewTestApplication(t *testing.T) *httptest.Expect {
 }
 
 func NewTestApplicationWithConfig(t *testing.T, configuration config.Configuration) *httptest.Expect {
+	return NewTestApplicationWithJobManager(t, configuration, nil)
+}
+
+// NewTestApplicationWithJobManager creates a test application that uses the provided job manager. If the job manager
+// is nil then a non-distributed job manager is used, like NewTestApplicationWithConfig.
+func NewTestApplicationWithJobManager(t *testing.T, configuration config.Configuration, jobManager jobs.JobManager) *httptest.Expect {
 	log := testutils.GetLog(t)
 	db := testutils.GetPgDatabase(t)
-	secretProvider := secrets.NewPostgresPlaidSecretsProvider(log, db)
+	plaidSecrets := mock_secrets.NewMockPlaidSecrets()
 	plaidRepo := repository.NewPlaidRepository(db)
-	plaidClient := platypus.NewPlaid(log, secretProvider, plaidRepo, configuration.Plaid)
+	var plaidClient platypus.Platypus
+	if configuration.Plaid.Fake {
+		plaidClient = platypus.NewFakePlaid(log, plaidRepo)
+	} else {
+		plaidClient = platypus.NewPlaid(log, plaidSecrets, plaidRepo, configuration.Plaid)
+	}
 
 	miniRedis := miniredis.NewMiniRedis()
 	require.NoError(t, miniRedis.Start())
@@ -75,28 +86,38 @@ func NewTestApplicationWithConfig(t *testing.T, configuration config.Configurati
 		require.NoError(t, redisPool.Close())
 		miniRedis.Close()
 	})
-	plaidSecrets := mock_secrets.NewMockPlaidSecrets()
 
-	mockJobManager := jobs.NewNonDistributedJobManager(
-		log,
-		redisPool,
-		db,
-		plaidClient,
-		nil,
-		plaidSecrets,
-	)
+	if jobManager == nil {
+		jobManager = jobs.NewNonDistributedJobManager(
+			log,
+			redisPool,
+			db,
+			plaidClient,
+			nil,
+			plaidSecrets,
+		)
+	}
+
+	simpleFINSecrets := mock_secrets.NewMockSimpleFINSecrets()
+	var simpleFINBridge simplefin.SimpleFIN
+	if configuration.SimpleFIN.Enabled {
+		// Use the default transport so requests to the bridge can be mocked.
+		simpleFINBridge = simplefin.NewSimpleFINWithHTTPClient(log, simpleFINSecrets, configuration.SimpleFIN, &http.Client{})
+	}
 
 	c := controller.NewController(
 		log,
 		configuration,
 		db,
-		mockJobManager,
+		jobManager,
 		plaidClient,
 		nil,
 		stripe_helper.NewStripeHelper(log, gofakeit.UUID()),
 		redisPool,
 		plaidSecrets,
-		billing.NewBasicPaywall(log, billing.NewAccountRepository(log, cache.NewCache(log, redisPool), db)),
+		billing.NewBasicPaywall(log, billing.NewAccountRepository(log, cache.NewCache(log, redisPool), db), configuration.Stripe.GracePeriod),
+		simpleFINSecrets,
+		simpleFINBridge,
 	)
 	app := application.NewApp(configuration, c)
 	return httptest.New(t, app)
@@ -108,13 +129,17 @@ func GivenIHaveToken(t *testing.T, e *httptest.Expect) string {
 }
 
 func register(t *testing.T, e *httptest.Expect) (email, password, token string) {
+	return registerWithEmail(t, e, testutils.GivenIHaveAnEmail(t))
+}
+
+func registerWithEmail(t *testing.T, e *httptest.Expect, email string) (_, password, token string) {
 	var registerRequest struct {
 		Email     string `json:"email"`
 		Password  string `json:"password"`
 		FirstName string `json:"firstName"`
 		LastName  string `json:"lastName"`
 	}
-	registerRequest.Email = testutils.GivenIHaveAnEmail(t)
+	registerRequest.Email = email
 	registerRequest.Password = gofakeit.Password(true, true, true, true, false, 32)
 	registerRequest.FirstName = gofakeit.FirstName()
 	registerRequest.LastName = gofakeit.LastName()
@@ -12,6 +12,8 @@ import (
 	"github.com/form3tech-oss/jwt-go"
 	"github.com/go-pg/pg/v10"
 	"github.com/kataras/iris/v12/context"
+	"github.com/monetr/rest-api/pkg/feature"
+	"github.com/monetr/rest-api/pkg/models"
 	"github.com/monetr/rest-api/pkg/repository"
 	"github.com/pkg/errors"
 )
@@ -154,6 +156,22 @@ func (c *Controller) authenticationMiddleware(ctx *context.Context) {
 		return
 	}
 
+	// Tokens are valid for a long time, so we need to make sure that the login has not been disabled since the token
+	// was issued.
+	enabled, err := c.mustGetDatabase(ctx).ModelContext(c.getContext(ctx), &models.Login{}).
+		Where(`"login"."login_id" = ?`, c.mustGetLoginId(ctx)).
+		Where(`"login"."is_enabled" = ?`, true).
+		Exists()
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to verify login")
+		return
+	}
+
+	if !enabled {
+		c.returnError(ctx, http.StatusForbidden, "login is disabled")
+		return
+	}
+
 	ctx.Next()
 }
 
@@ -175,6 +193,46 @@ func (c *Controller) requireActiveSubscriptionMiddleware(ctx *context.Context) {
 	ctx.Next()
 }
 
+// requireFeatureMiddleware returns a middleware that will only allow the request to continue if the current account is
+// entitled to the provided feature. Entitlements come from the account's billing plan, so when billing is not enabled
+// every account is entitled to every feature. Features granted or revoked by an admin apply either way.
+func (c *Controller) requireFeatureMiddleware(item feature.Feature) iris.Handler {
+	return func(ctx *context.Context) {
+		account, err := c.accounts.GetAccount(c.getContext(ctx), c.mustGetAccountId(ctx))
+		if err != nil {
+			c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to verify account features")
+			return
+		}
+
+		hasFeature := account.HasFeature(item)
+		if !c.configuration.Stripe.IsBillingEnabled() {
+			hasFeature = account.HasFeatureByDefault(item)
+		}
+
+		if !hasFeature {
+			c.getSpan(ctx).Status = sentry.SpanStatusPermissionDenied
+			c.returnError(ctx, http.StatusForbidden, "your plan does not include this feature")
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// getAccountFeatures returns the features that the current account is entitled to.
+func (c *Controller) getAccountFeatures(ctx *context.Context) ([]feature.Feature, error) {
+	account, err := c.accounts.GetAccount(c.getContext(ctx), c.mustGetAccountId(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if !c.configuration.Stripe.IsBillingEnabled() {
+		return account.GetFeaturesByDefault(), nil
+	}
+
+	return account.GetFeatures(), nil
+}
+
 func (c *Controller) loggingMiddleware(ctx *context.Context) {
 	ctx.Next()
 
@@ -219,6 +277,15 @@ func (c *Controller) mustGetUserId(ctx *context.Context) uint64 {
 	return userId
 }
 
+func (c *Controller) mustGetLoginId(ctx *context.Context) uint64 {
+	loginId := ctx.Values().GetUint64Default(loginIdContextKey, 0)
+	if loginId == 0 {
+		panic("unauthorized")
+	}
+
+	return loginId
+}
+
 func (c *Controller) mustGetAccountId(ctx *context.Context) uint64 {
 	accountId := ctx.Values().GetUint64Default(accountIdContextKey, 0)
 	if accountId == 0 {
@@ -0,0 +1,303 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/gomodule/redigo/redis"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/core/router"
+	"github.com/monetr/rest-api/pkg/hash"
+	"github.com/monetr/rest-api/pkg/internal/oidc"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/pkg/errors"
+)
+
+// oidcStateLifetime is how long a user has to authenticate with the identity provider before they need to start over.
+const oidcStateLifetime = 10 * time.Minute
+
+type oidcState struct {
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"codeVerifier"`
+}
+
+func (c *Controller) handleOIDC(p router.Party) {
+	p.Get("/authorize", c.oidcAuthorize)
+	p.Post("/callback", c.ipRateLimitMiddleware("oidc"), c.oidcCallback)
+}
+
+func (c *Controller) getOIDCStateKey(state string) string {
+	return fmt.Sprintf("oidc:state:%s", state)
+}
+
+// OIDC Authorize
+// @Summary OIDC Authorize
+// @id oidc-authorize
+// @tags Authentication
+// @description Returns the URL that the user should be sent to in order to authenticate with the configured OpenID
+// @description Connect identity provider. The identity provider will send the user back to the configured redirect URL
+// @description with a code and state that should be sent to the callback endpoint.
+// @Produce json
+// @Router /authentication/oidc/authorize [get]
+// @Success 200 {object} swag.OIDCAuthorizeResponse
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) oidcAuthorize(ctx iris.Context) {
+	state, err := oidc.NewCodeVerifier()
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to generate state")
+		return
+	}
+
+	nonce, err := oidc.NewCodeVerifier()
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to generate nonce")
+		return
+	}
+
+	codeVerifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to generate code verifier")
+		return
+	}
+
+	authorizationUrl, err := c.oidc.AuthorizationURL(c.getContext(ctx), state, nonce, codeVerifier)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create authorization url")
+		return
+	}
+
+	data, err := json.Marshal(oidcState{
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+	})
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to encode state")
+		return
+	}
+
+	cache, err := c.cache.GetContext(c.getContext(ctx))
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to get cache connection")
+		return
+	}
+	defer cache.Close()
+
+	if _, err = cache.Do("SET", c.getOIDCStateKey(state), data, "EX", int64(oidcStateLifetime.Seconds())); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to store state")
+		return
+	}
+
+	ctx.JSON(map[string]interface{}{
+		"url": authorizationUrl,
+	})
+}
+
+// consumeOIDCState will retrieve the state that was stored when the authorization URL was created. The state is
+// removed so that it cannot be used again.
+func (c *Controller) consumeOIDCState(ctx iris.Context, state string) (*oidcState, error) {
+	cache, err := c.cache.GetContext(c.getContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cache connection")
+	}
+	defer cache.Close()
+
+	data, err := redis.Bytes(cache.Do("GET", c.getOIDCStateKey(state)))
+	if err == redis.ErrNil {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve state")
+	}
+
+	if _, err = cache.Do("DEL", c.getOIDCStateKey(state)); err != nil {
+		return nil, errors.Wrap(err, "failed to remove state")
+	}
+
+	var result oidcState
+	if err = json.Unmarshal(data, &result); err != nil {
+		return nil, errors.Wrap(err, "failed to decode state")
+	}
+
+	return &result, nil
+}
+
+// OIDC Callback
+// @Summary OIDC Callback
+// @id oidc-callback
+// @tags Authentication
+// @description Completes authentication with the OpenID Connect identity provider. The identity provider must report
+// @description that the user's email address is verified. If a login already exists with that email then the user is
+// @description authenticated as that login, otherwise a new login is created if sign up is allowed.
+// @Accept json
+// @Produce json
+// @Param Request body swag.OIDCCallbackRequest true "OIDC callback request."
+// @Router /authentication/oidc/callback [post]
+// @Success 200 {object} swag.LoginResponse
+// @Failure 400 {object} ApiError The state is not valid or has expired.
+// @Failure 403 {object} ApiError The identity provider did not verify the user, or sign up is not allowed.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) oidcCallback(ctx iris.Context) {
+	var callbackRequest struct {
+		Code     string  `json:"code"`
+		State    string  `json:"state"`
+		Timezone string  `json:"timezone"`
+		BetaCode *string `json:"betaCode"`
+	}
+	if err := ctx.ReadJSON(&callbackRequest); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed json")
+		return
+	}
+
+	if callbackRequest.Code == "" || callbackRequest.State == "" {
+		c.badRequest(ctx, "code and state are required")
+		return
+	}
+
+	state, err := c.consumeOIDCState(ctx, callbackRequest.State)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to verify state")
+		return
+	}
+
+	if state == nil {
+		c.badRequest(ctx, "state is not valid or has expired")
+		return
+	}
+
+	claims, err := c.oidc.Exchange(c.getContext(ctx), callbackRequest.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		if errors.Cause(err) == oidc.ErrInvalidIdToken {
+			c.wrapAndReturnError(ctx, err, http.StatusForbidden, "failed to authenticate with identity provider")
+			return
+		}
+
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to authenticate with identity provider")
+		return
+	}
+
+	// We only link identities to logins by email, so we must be sure that the user actually owns the email address.
+	if claims.Email == "" || !claims.EmailVerified {
+		c.returnError(ctx, http.StatusForbidden, "identity provider did not provide a verified email address")
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	log := c.getLog(ctx).WithField("subject", claims.Subject)
+
+	var login models.Login
+	err = c.mustGetDatabase(ctx).ModelContext(c.getContext(ctx), &login).
+		Relation("Users").
+		Relation("Users.Account").
+		Where(`"login"."email" = ?`, email).
+		Limit(1).
+		Select(&login)
+	switch err {
+	case nil:
+		log.WithField("loginId", login.LoginId).Debug("authenticated existing login with identity provider")
+	case pg.ErrNoRows:
+		if !c.configuration.AllowSignUp {
+			c.returnError(ctx, http.StatusForbidden, "sign up is not enabled on this server")
+			return
+		}
+
+		newLogin, ok := c.createOIDCLogin(ctx, email, claims, callbackRequest.Timezone, callbackRequest.BetaCode)
+		if !ok {
+			return
+		}
+
+		log.WithField("loginId", newLogin.LoginId).Info("created new login from identity provider")
+		login = *newLogin
+	default:
+		c.wrapPgError(ctx, err, "failed to retrieve login")
+		return
+	}
+
+	if !login.IsEnabled {
+		c.returnError(ctx, http.StatusForbidden, "login is disabled")
+		return
+	}
+
+	c.respondWithLogin(ctx, login)
+}
+
+// createOIDCLogin will create a new login, account and user for someone who has authenticated with the identity
+// provider but does not have a login yet. If this fails then an error is returned to the client and ok is false.
+func (c *Controller) createOIDCLogin(
+	ctx iris.Context,
+	email string,
+	claims *oidc.Claims,
+	timezoneName string,
+	betaCode *string,
+) (_ *models.Login, ok bool) {
+	repo := c.mustGetUnauthenticatedRepository(ctx)
+
+	var beta *models.Beta
+	if c.configuration.Beta.EnableBetaCodes {
+		if betaCode == nil || strings.TrimSpace(*betaCode) == "" {
+			c.badRequest(ctx, "beta code required for registration")
+			return nil, false
+		}
+
+		var err error
+		beta, err = repo.ValidateBetaCode(c.getContext(ctx), strings.TrimSpace(*betaCode))
+		if err != nil {
+			c.wrapPgError(ctx, err, "could not verify beta code")
+			return nil, false
+		}
+	}
+
+	timezone, err := time.LoadLocation(timezoneName)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "failed to parse timezone")
+		return nil, false
+	}
+
+	firstName, lastName := claims.GivenName, claims.FamilyName
+	if firstName == "" {
+		firstName = claims.Name
+	}
+	if firstName == "" {
+		firstName = strings.Split(email, "@")[0]
+	}
+
+	// Logins created through the identity provider do not have a password. But the login still needs a password hash,
+	// so we use the hash of a random value that no one knows.
+	password, err := oidc.NewCodeVerifier()
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to generate password")
+		return nil, false
+	}
+
+	hashedPassword, err := hash.NewPasswordHash(password)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to hash password")
+		return nil, false
+	}
+
+	login, err := repo.CreateLogin(c.getContext(ctx), email, hashedPassword, firstName, lastName, true)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create login")
+		return nil, false
+	}
+
+	user, account, err := c.createAccountForLogin(ctx, repo, login, timezone)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to register")
+		return nil, false
+	}
+
+	if beta != nil {
+		if err = repo.UseBetaCode(c.getContext(ctx), beta.BetaID, user.UserId); err != nil {
+			c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to use beta code")
+			return nil, false
+		}
+	}
+
+	user.Account = account
+	login.Users = []models.User{*user}
+
+	return login, true
+}
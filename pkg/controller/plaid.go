@@ -10,9 +10,11 @@ import (
 	"github.com/getsentry/sentry-go"
 	"github.com/kataras/iris/v12"
 	"github.com/monetr/rest-api/pkg/crumbs"
+	"github.com/monetr/rest-api/pkg/feature"
 	"github.com/monetr/rest-api/pkg/internal/myownsanity"
 	"github.com/monetr/rest-api/pkg/internal/platypus"
 	"github.com/monetr/rest-api/pkg/models"
+	"github.com/monetr/rest-api/pkg/repository"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
@@ -22,11 +24,16 @@ import (
 func (c *Controller) handlePlaidLinkEndpoints(p router.Party) {
 	p.Put("/update/{linkId:uint64}", c.updatePlaidLink)
 	p.Post("/update/callback", c.updatePlaidTokenCallback)
-	p.Get("/token/new", c.newPlaidToken)
-	p.Post("/token/callback", c.plaidTokenCallback)
+	p.Get("/token/new", c.requireFeatureMiddleware(feature.FeatureLinkedBudgeting), c.newPlaidToken)
+	p.Post("/token/callback", c.requireFeatureMiddleware(feature.FeatureLinkedBudgeting), c.plaidTokenCallback)
 	p.Get("/setup/wait/{linkId:uint64}", c.waitForPlaid)
+	p.Post("/sync/{linkId:uint64}", c.syncPlaidLink)
 }
 
+// plaidManualSyncCooldown is the minimum amount of time that must pass between manual syncs of a single Plaid link.
+// Each sync costs us a request to Plaid, so we don't want users to be able to spam this.
+const plaidManualSyncCooldown = 30 * time.Minute
+
 func (c *Controller) storeLinkTokenInCache(ctx context.Context, log *logrus.Entry, userId uint64, linkToken string, expiration time.Time) error {
 	span := sentry.StartSpan(ctx, "StoreLinkTokenInCache")
 	defer span.Finish()
@@ -67,6 +74,7 @@ func (c *Controller) removeLinkTokenFromCache(ctx context.Context, log *logrus.E
 // @Router /plaid/token/new [get]
 // @Param use_cache query bool false "If true, the API will check and see if a plaid link token already exists for the current user. If one is present then it is returned instead of creating a new link token."
 // @Success 200 {object} swag.PlaidNewLinkTokenResponse
+// @Failure 403 {object} ApiError The user's plan does not include linked budgeting.
 // @Failure 500 {object} ApiError Something went wrong on our end.
 func (c *Controller) newPlaidToken(ctx iris.Context) {
 	// Retrieve the user's details. We need to pass some of these along to
@@ -211,7 +219,7 @@ func (c *Controller) updatePlaidLink(ctx iris.Context) {
 		return
 	}
 
-	token, err := client.UpdateItem(c.getContext(ctx))
+	token, err := client.UpdateItem(c.getContext(ctx), link.NewAccountsAvailable)
 	if err != nil {
 		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create link token to update Plaid link")
 		return
@@ -240,8 +248,9 @@ func (c *Controller) updatePlaidLink(ctx iris.Context) {
 // @Failure 500 {object} ApiError Something went wrong on our end.
 func (c *Controller) updatePlaidTokenCallback(ctx iris.Context) {
 	var callbackRequest struct {
-		LinkId      uint64 `json:"linkId"`
-		PublicToken string `json:"publicToken"`
+		LinkId      uint64   `json:"linkId"`
+		PublicToken string   `json:"publicToken"`
+		AccountIds  []string `json:"accountIds"`
 	}
 	if err := ctx.ReadJSON(&callbackRequest); err != nil {
 		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed json")
@@ -289,8 +298,20 @@ func (c *Controller) updatePlaidTokenCallback(ctx iris.Context) {
 		log.Info("access token for link has not changed")
 	}
 
+	// If the link was updated because new accounts are available then the user may have selected some of those
+	// accounts. Any accounts that we are not already tracking need to be created.
+	if link.NewAccountsAvailable {
+		if err = c.addNewPlaidBankAccounts(ctx, repo, link, result.AccessToken, callbackRequest.AccountIds); err != nil {
+			c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to add new bank accounts")
+			return
+		}
+	}
+
 	link.LinkStatus = models.LinkStatusSetup
+	link.ErrorType = nil
 	link.ErrorCode = nil
+	link.ErrorMessage = nil
+	link.NewAccountsAvailable = false
 	if err = repo.UpdateLink(c.getContext(ctx), link); err != nil {
 		c.wrapPgError(ctx, err, "failed to update link status")
 		return
@@ -304,6 +325,73 @@ func (c *Controller) updatePlaidTokenCallback(ctx iris.Context) {
 	ctx.JSON(link)
 }
 
+// addNewPlaidBankAccounts will create bank accounts for any of the Plaid accounts on the provided link that we are not
+// already tracking. If accountIds is not empty then only those Plaid accounts will be considered.
+func (c *Controller) addNewPlaidBankAccounts(
+	ctx iris.Context,
+	repo repository.Repository,
+	link *models.Link,
+	accessToken string,
+	accountIds []string,
+) error {
+	existingBankAccounts, err := repo.GetBankAccountsByLinkId(c.getContext(ctx), link.LinkId)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve existing bank accounts")
+	}
+
+	existingPlaidIds := map[string]struct{}{}
+	for _, bankAccount := range existingBankAccounts {
+		existingPlaidIds[bankAccount.PlaidAccountId] = struct{}{}
+	}
+
+	client, err := c.plaid.NewClient(c.getContext(ctx), link, accessToken)
+	if err != nil {
+		return errors.Wrap(err, "failed to create Plaid client")
+	}
+
+	plaidAccounts, err := client.GetAccounts(c.getContext(ctx), accountIds...)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve accounts")
+	}
+
+	now := time.Now().UTC()
+	accounts := make([]models.BankAccount, 0, len(plaidAccounts))
+	for _, plaidAccount := range plaidAccounts {
+		if _, ok := existingPlaidIds[plaidAccount.GetAccountId()]; ok {
+			continue
+		}
+
+		bankAccount := models.BankAccount{
+			AccountId:         repo.AccountId(),
+			LinkId:            link.LinkId,
+			PlaidAccountId:    plaidAccount.GetAccountId(),
+			Name:              plaidAccount.GetName(),
+			Mask:              plaidAccount.GetMask(),
+			PlaidName:         plaidAccount.GetName(),
+			PlaidOfficialName: plaidAccount.GetOfficialName(),
+			Type:              models.BankAccountType(plaidAccount.GetType()),
+			SubType:           models.BankAccountSubType(plaidAccount.GetSubType()),
+			LastUpdated:       now,
+			Currency:          plaidAccount.GetBalances().GetCurrency(),
+		}
+		bankAccount.SetBalances(
+			plaidAccount.GetBalances().GetAvailable(),
+			plaidAccount.GetBalances().GetCurrent(),
+			plaidAccount.GetBalances().GetLimit(),
+		)
+		accounts = append(accounts, bankAccount)
+	}
+
+	if len(accounts) == 0 {
+		c.getLog(ctx).Debug("no new bank accounts were selected for link")
+		return nil
+	}
+
+	c.getLog(ctx).Infof("adding %d new bank account(s) to link", len(accounts))
+
+	return errors.Wrap(repo.CreateBankAccounts(c.getContext(ctx), accounts...), "failed to create bank accounts")
+}
+
 // Plaid Token Callback
 // @Summary Plaid Token Callback
 // @id plaid-token-callback
@@ -315,6 +403,7 @@ func (c *Controller) updatePlaidTokenCallback(ctx iris.Context) {
 // @Param Request body swag.NewPlaidTokenCallbackRequest true "New token callback request."
 // @Router /plaid/token/callback [post]
 // @Success 200 {object} swag.PlaidTokenCallbackResponse
+// @Failure 403 {object} ApiError The user's plan does not include linked budgeting.
 // @Failure 500 {object} ApiError Something went wrong on our end.
 func (c *Controller) plaidTokenCallback(ctx iris.Context) {
 	var callbackRequest struct {
@@ -423,8 +512,6 @@ func (c *Controller) plaidTokenCallback(ctx iris.Context) {
 			AccountId:         repo.AccountId(),
 			LinkId:            link.LinkId,
 			PlaidAccountId:    plaidAccount.GetAccountId(),
-			AvailableBalance:  plaidAccount.GetBalances().GetAvailable(),
-			CurrentBalance:    plaidAccount.GetBalances().GetCurrent(),
 			Name:              plaidAccount.GetName(),
 			Mask:              plaidAccount.GetMask(),
 			PlaidName:         plaidAccount.GetName(),
@@ -432,7 +519,13 @@ func (c *Controller) plaidTokenCallback(ctx iris.Context) {
 			Type:              models.BankAccountType(plaidAccount.GetType()),
 			SubType:           models.BankAccountSubType(plaidAccount.GetSubType()),
 			LastUpdated:       now,
+			Currency:          plaidAccount.GetBalances().GetCurrency(),
 		}
+		accounts[i].SetBalances(
+			plaidAccount.GetBalances().GetAvailable(),
+			plaidAccount.GetBalances().GetCurrent(),
+			plaidAccount.GetBalances().GetLimit(),
+		)
 	}
 	if err = repo.CreateBankAccounts(c.getContext(ctx), accounts...); err != nil {
 		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create bank accounts")
@@ -531,3 +624,144 @@ func (c *Controller) waitForPlaid(ctx iris.Context) {
 		return
 	}
 }
+
+func plaidSyncCooldownKey(accountId, linkId uint64) string {
+	return fmt.Sprintf("plaid:sync:cooldown:%d:%d", accountId, linkId)
+}
+
+// acquirePlaidSyncCooldown will attempt to start a cooldown for manually syncing the specified link. If the link is
+// already in a cooldown then false is returned along with the amount of time remaining until it can be synced again.
+func (c *Controller) acquirePlaidSyncCooldown(ctx context.Context, accountId, linkId uint64) (bool, time.Duration, error) {
+	span := sentry.StartSpan(ctx, "AcquirePlaidSyncCooldown")
+	defer span.Finish()
+
+	cache, err := c.cache.GetContext(ctx)
+	if err != nil {
+		return false, 0, errors.Wrap(err, "failed to get cache connection")
+	}
+	defer cache.Close()
+
+	key := plaidSyncCooldownKey(accountId, linkId)
+	result, err := cache.Do("SET", key, time.Now().Unix(), "NX", "EX", int64(plaidManualSyncCooldown.Seconds()))
+	if err != nil {
+		return false, 0, errors.Wrap(err, "failed to set sync cooldown")
+	}
+
+	// When NX is specified and the key already exists, redis returns nil rather than OK.
+	if result != nil {
+		return true, 0, nil
+	}
+
+	ttl, err := cache.Do("TTL", key)
+	if err != nil {
+		return false, 0, errors.Wrap(err, "failed to retrieve sync cooldown")
+	}
+
+	remaining, ok := ttl.(int64)
+	if !ok || remaining < 0 {
+		remaining = int64(plaidManualSyncCooldown.Seconds())
+	}
+
+	return false, time.Duration(remaining) * time.Second, nil
+}
+
+// releasePlaidSyncCooldown will remove the cooldown for manually syncing the specified link. This is used when the
+// sync could not be started, so the user is not locked out of retrying.
+func (c *Controller) releasePlaidSyncCooldown(ctx context.Context, accountId, linkId uint64) error {
+	span := sentry.StartSpan(ctx, "ReleasePlaidSyncCooldown")
+	defer span.Finish()
+
+	cache, err := c.cache.GetContext(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get cache connection")
+	}
+	defer cache.Close()
+
+	if _, err = cache.Do("DEL", plaidSyncCooldownKey(accountId, linkId)); err != nil {
+		return errors.Wrap(err, "failed to remove sync cooldown")
+	}
+
+	return nil
+}
+
+// Sync Plaid Link
+// @Summary Sync Plaid Link
+// @id sync-plaid-link
+// @tags Plaid
+// @description Manually trigger a sync of the latest transactions for a Plaid link. A link can only be synced manually
+// @description once every 30 minutes, if a sync is requested during that cooldown a 429 is returned with a Retry-After
+// @description header indicating how many seconds until the link can be synced again. If a sync for the link is already
+// @description queued then no new job is enqueued and `alreadySyncing` will be true.
+// @Security ApiKeyAuth
+// @Produce json
+// @Router /plaid/link/sync/{linkId} [post]
+// @Param linkId path uint64 true "The Link Id that you wish to sync, must be a Plaid link."
+// @Success 200 {object} swag.PlaidSyncResponse
+// @Failure 400 {object} ApiError The link is not a Plaid link or is not in a state that can be synced.
+// @Failure 404 {object} ApiError The link does not exist.
+// @Failure 429 {object} ApiError The link has been synced too recently.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) syncPlaidLink(ctx iris.Context) {
+	linkId := ctx.Params().GetUint64Default("linkId", 0)
+	if linkId == 0 {
+		c.badRequest(ctx, "must specify a link Id")
+		return
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	link, err := repo.GetLink(c.getContext(ctx), linkId)
+	if err != nil {
+		c.wrapPgError(ctx, err, "failed to retrieve link")
+		return
+	}
+
+	if link.LinkType != models.PlaidLinkType {
+		c.badRequest(ctx, "cannot sync a non-Plaid link")
+		return
+	}
+
+	switch link.LinkStatus {
+	case models.LinkStatusSetup, models.LinkStatusPendingExpiration:
+	default:
+		c.badRequest(ctx, "link is not in a state where it can be synced")
+		return
+	}
+
+	ok, remaining, err := c.acquirePlaidSyncCooldown(c.getContext(ctx), repo.AccountId(), linkId)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to check sync cooldown")
+		return
+	}
+
+	if !ok {
+		ctx.Header("Retry-After", strconv.FormatInt(int64(remaining.Seconds()), 10))
+		c.returnError(ctx, http.StatusTooManyRequests, "link has been synced too recently")
+		return
+	}
+
+	jobId, err := c.job.TriggerPullLatestTransactions(repo.AccountId(), linkId, 0)
+	if err != nil {
+		// The sync never started, so the cooldown should not prevent the user from trying again.
+		if cooldownErr := c.releasePlaidSyncCooldown(c.getContext(ctx), repo.AccountId(), linkId); cooldownErr != nil {
+			c.getLog(ctx).WithError(cooldownErr).Warn("failed to release sync cooldown")
+		}
+
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to trigger sync")
+		return
+	}
+
+	// If the job is already queued, like from a webhook, then the link will be synced without a new job.
+	if jobId == "" {
+		ctx.JSON(map[string]interface{}{
+			"jobId":          nil,
+			"alreadySyncing": true,
+		})
+		return
+	}
+
+	ctx.JSON(map[string]interface{}{
+		"jobId":          jobId,
+		"alreadySyncing": false,
+	})
+}
@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/core/router"
+	"github.com/monetr/rest-api/pkg/feature"
+	"github.com/monetr/rest-api/pkg/internal/platypus"
+	"github.com/monetr/rest-api/pkg/models"
+)
+
+// handleFakePlaidEndpoints provides a simulated Plaid Link flow and webhook triggers for when Plaid is running in fake
+// mode. These endpoints are only registered when fake mode is enabled.
+func (c *Controller) handleFakePlaidEndpoints(p router.Party) {
+	p.Get("/institutions", c.getFakeInstitutions)
+	p.Post("/link", c.requireFeatureMiddleware(feature.FeatureLinkedBudgeting), c.createFakePublicToken)
+	p.Post("/webhook/{linkId:uint64}", c.requireFeatureMiddleware(feature.FeatureLinkedBudgeting), c.triggerFakeWebhook)
+}
+
+func (c *Controller) mustGetFakePlaid(ctx iris.Context) (*platypus.FakePlaid, bool) {
+	fake, ok := c.plaid.(*platypus.FakePlaid)
+	if !ok {
+		c.returnError(ctx, http.StatusNotFound, "fake plaid is not enabled")
+		return nil, false
+	}
+
+	return fake, true
+}
+
+// Get Fake Institutions
+// @Summary Get Fake Institutions
+// @id get-fake-institutions
+// @tags Plaid
+// @description Lists the institutions that can be linked when Plaid is running in fake mode.
+// @Security ApiKeyAuth
+// @Produce json
+// @Router /plaid/fake/institutions [get]
+// @Success 200 {array} swag.FakeInstitutionResponse
+// @Failure 404 {object} ApiError Fake Plaid is not enabled.
+func (c *Controller) getFakeInstitutions(ctx iris.Context) {
+	if _, ok := c.mustGetFakePlaid(ctx); !ok {
+		return
+	}
+
+	institutions := make([]map[string]interface{}, len(platypus.FakeInstitutions))
+	for i, institution := range platypus.FakeInstitutions {
+		institutions[i] = map[string]interface{}{
+			"institutionId": institution.InstitutionId,
+			"name":          institution.Name,
+		}
+	}
+
+	ctx.JSON(institutions)
+}
+
+// Create Fake Public Token
+// @Summary Create Fake Public Token
+// @id create-fake-public-token
+// @tags Plaid
+// @description Simulates a user authenticating with a fake institution through Plaid Link. The response can be passed to the token callback endpoint to create the link, just like the result of Plaid Link.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param Request body swag.FakePublicTokenRequest true "Fake institution"
+// @Router /plaid/fake/link [post]
+// @Success 200 {object} swag.NewPlaidTokenCallbackRequest
+// @Failure 400 {object} ApiError The institution does not exist.
+// @Failure 404 {object} ApiError Fake Plaid is not enabled.
+func (c *Controller) createFakePublicToken(ctx iris.Context) {
+	fake, ok := c.mustGetFakePlaid(ctx)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		InstitutionId string `json:"institutionId"`
+	}
+	if err := ctx.ReadJSON(&request); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed json")
+		return
+	}
+
+	institution, err := fake.GetInstitution(c.getContext(ctx), strings.TrimSpace(request.InstitutionId))
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "invalid institution")
+		return
+	}
+
+	publicToken, accountIds, err := fake.CreatePublicToken(c.getContext(ctx), institution.InstitutionId)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create public token")
+		return
+	}
+
+	ctx.JSON(map[string]interface{}{
+		"publicToken":     publicToken,
+		"institutionId":   institution.InstitutionId,
+		"institutionName": institution.Name,
+		"accountIds":      accountIds,
+	})
+}
+
+// Trigger Fake Webhook
+// @Summary Trigger Fake Webhook
+// @id trigger-fake-webhook
+// @tags Plaid
+// @description Handles a Plaid webhook for the specified link as if it had been sent by Plaid. The body is the same as a webhook from Plaid, the item Id is taken from the link. This allows webhooks like `TRANSACTIONS.DEFAULT_UPDATE` or `ITEM.ERROR` to be scripted when Plaid is running in fake mode.
+// @Security ApiKeyAuth
+// @Accept json
+// @Param linkId path int true "Link ID"
+// @Param Request body swag.FakeWebhookRequest true "Webhook"
+// @Router /plaid/fake/webhook/{linkId} [post]
+// @Success 200
+// @Failure 400 {object} ApiError The link is not a Plaid link.
+// @Failure 404 {object} ApiError Fake Plaid is not enabled, or the link does not exist.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) triggerFakeWebhook(ctx iris.Context) {
+	if _, ok := c.mustGetFakePlaid(ctx); !ok {
+		return
+	}
+
+	linkId := ctx.Params().GetUint64Default("linkId", 0)
+	if linkId == 0 {
+		c.badRequest(ctx, "must specify a link Id")
+		return
+	}
+
+	link, err := c.mustGetAuthenticatedRepository(ctx).GetLink(c.getContext(ctx), linkId)
+	if err != nil {
+		c.wrapPgError(ctx, err, "failed to retrieve link")
+		return
+	}
+
+	if link.LinkType != models.PlaidLinkType || link.PlaidLink == nil {
+		c.badRequest(ctx, "webhooks can only be triggered for plaid links")
+		return
+	}
+
+	body, err := ctx.GetBody()
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "failed to read body")
+		return
+	}
+
+	var hook PlaidWebhook
+	var rawHook map[string]interface{}
+	if err = json.Unmarshal(body, &hook); err != nil {
+		c.badRequest(ctx, "malformed JSON")
+		return
+	}
+	if err = json.Unmarshal(body, &rawHook); err != nil {
+		c.badRequest(ctx, "malformed JSON")
+		return
+	}
+
+	if hook.WebhookType == "" || hook.WebhookCode == "" {
+		c.badRequest(ctx, "webhook type and code are required")
+		return
+	}
+
+	hook.ItemId = link.PlaidLink.ItemId
+	rawHook["item_id"] = link.PlaidLink.ItemId
+
+	if err = c.recordAndProcessWebhook(ctx, hook, rawHook); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to handle webhook")
+		return
+	}
+}
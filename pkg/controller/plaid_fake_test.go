@@ -0,0 +1,102 @@
+package controller_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/monetr/rest-api/pkg/internal/mock_jobs"
+	"github.com/monetr/rest-api/pkg/internal/platypus"
+	"github.com/monetr/rest-api/pkg/jobs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakePlaid(t *testing.T) {
+	t.Run("link and trigger webhook", func(t *testing.T) {
+		configuration := NewTestApplicationConfig(t)
+		configuration.Plaid.Fake = true
+		jobManager := mock_jobs.NewMockJobManager()
+		e := NewTestApplicationWithJobManager(t, configuration, jobManager)
+		token := GivenIHaveToken(t, e)
+
+		{ // The fake institutions should be listed so the UI can present them.
+			response := e.GET("/plaid/fake/institutions").
+				WithHeader("M-Token", token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().Equal(len(platypus.FakeInstitutions))
+		}
+
+		var callback map[string]interface{}
+		{ // Simulate the user going through Plaid Link.
+			response := e.POST("/plaid/fake/link").
+				WithHeader("M-Token", token).
+				WithJSON(map[string]interface{}{
+					"institutionId": platypus.FakeInstitutions[0].InstitutionId,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.publicToken").String().NotEmpty()
+			response.JSON().Path("$.institutionName").String().Equal(platypus.FakeInstitutions[0].Name)
+			response.JSON().Path("$.accountIds").Array().NotEmpty()
+			callback = response.JSON().Object().Raw()
+		}
+
+		var linkId uint64
+		{ // The result of the fake link can be used with the regular token callback.
+			response := e.POST("/plaid/link/token/callback").
+				WithHeader("M-Token", token).
+				WithJSON(callback).
+				Expect()
+
+			response.Status(http.StatusOK)
+			linkId = uint64(response.JSON().Path("$.linkId").Number().Gt(0).Raw())
+		}
+
+		{
+			response := e.POST(fmt.Sprintf("/plaid/fake/webhook/%d", linkId)).
+				WithHeader("M-Token", token).
+				WithJSON(map[string]interface{}{
+					"webhook_type":     "TRANSACTIONS",
+					"webhook_code":     "DEFAULT_UPDATE",
+					"new_transactions": 5,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+		}
+
+		triggered := jobManager.GetTriggered(jobs.PullLatestTransactions)
+		require.Len(t, triggered, 1, "webhook should pull the latest transactions")
+		assert.Equal(t, linkId, triggered[0].Args["linkId"], "should pull transactions for the fake link")
+		assert.EqualValues(t, 5, triggered[0].Args["numberOfTransactions"], "should pass along the number of transactions")
+	})
+
+	t.Run("not enabled", func(t *testing.T) {
+		e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+
+		{
+			response := e.GET("/plaid/fake/institutions").
+				WithHeader("M-Token", token).
+				Expect()
+
+			response.Status(http.StatusNotFound)
+		}
+
+		{
+			response := e.POST("/plaid/fake/webhook/1").
+				WithHeader("M-Token", token).
+				WithJSON(map[string]interface{}{
+					"webhook_type": "TRANSACTIONS",
+					"webhook_code": "DEFAULT_UPDATE",
+				}).
+				Expect()
+
+			response.Status(http.StatusNotFound)
+		}
+	})
+}
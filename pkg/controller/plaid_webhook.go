@@ -1,12 +1,16 @@
 package controller
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/form3tech-oss/jwt-go"
 	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
 	"github.com/kataras/iris/v12"
 	"github.com/monetr/rest-api/pkg/crumbs"
 	"github.com/monetr/rest-api/pkg/internal/myownsanity"
+	"github.com/monetr/rest-api/pkg/jobs"
 	"github.com/monetr/rest-api/pkg/models"
 	"github.com/monetr/rest-api/pkg/repository"
 	"github.com/pkg/errors"
@@ -15,8 +19,6 @@ import (
 	"strconv"
 	"strings"
 	"time"
-
-	"github.com/kataras/iris/v12/context"
 )
 
 type PlaidWebhook struct {
@@ -30,6 +32,21 @@ type PlaidWebhook struct {
 	ConsentExpirationTime *time.Time             `json:"consent_expiration_time"`
 }
 
+// getErrorField returns a single string field from the error object of the webhook. If the webhook does not have an
+// error, or the field is missing or empty then nil is returned.
+func (h PlaidWebhook) getErrorField(name string) *string {
+	if h.Error == nil {
+		return nil
+	}
+
+	value, ok := h.Error[name].(string)
+	if !ok || value == "" {
+		return nil
+	}
+
+	return &value
+}
+
 type PlaidClaims struct {
 	jwt.StandardClaims
 }
@@ -67,7 +84,7 @@ func (c PlaidClaims) Valid() error {
 	return vErr
 }
 
-func (c *Controller) handlePlaidWebhook(ctx *context.Context) {
+func (c *Controller) handlePlaidWebhook(ctx iris.Context) {
 	verification := ctx.GetHeader("Plaid-Verification")
 	if strings.TrimSpace(verification) == "" {
 		c.returnError(ctx, http.StatusUnauthorized, "unauthorized")
@@ -123,20 +140,134 @@ func (c *Controller) handlePlaidWebhook(ctx *context.Context) {
 		return
 	}
 
+	body, err := ctx.GetBody()
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "failed to read body")
+		return
+	}
+
 	var hook PlaidWebhook
-	if err = ctx.ReadJSON(&hook); err != nil {
+	var rawHook map[string]interface{}
+	if err = json.Unmarshal(body, &hook); err != nil {
+		c.badRequest(ctx, "malformed JSON")
+		return
+	}
+	if err = json.Unmarshal(body, &rawHook); err != nil {
 		c.badRequest(ctx, "malformed JSON")
 		return
 	}
 
-	if err = c.processWebhook(ctx, hook); err != nil {
+	if err = c.recordAndProcessWebhook(ctx, hook, rawHook); err != nil {
 		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to handle webhook")
 		return
 	}
 }
 
-func (c *Controller) processWebhook(ctx iris.Context, hook PlaidWebhook) error {
-	log := c.log.WithFields(logrus.Fields{
+// recordAndProcessWebhook will store the webhook and then process it. The record is updated with the result of
+// processing the webhook.
+func (c *Controller) recordAndProcessWebhook(ctx iris.Context, hook PlaidWebhook, rawHook map[string]interface{}) error {
+	// Every webhook is recorded before we process it so that failures can be debugged later. This is done
+	// outside of the request's transaction so that the record is kept even if processing the webhook fails.
+	plaidRepo := repository.NewPlaidRepository(c.db)
+	record := models.PlaidWebhook{
+		ItemId:      hook.ItemId,
+		WebhookType: hook.WebhookType,
+		WebhookCode: hook.WebhookCode,
+		Body:        rawHook,
+		ReceivedAt:  time.Now().UTC(),
+	}
+	recorded := true
+	if err := plaidRepo.CreatePlaidWebhook(c.getContext(ctx), &record); err != nil {
+		c.getLog(ctx).WithError(err).Warn("failed to record plaid webhook")
+		recorded = false
+	}
+
+	err := processPlaidWebhook(
+		c.getContext(ctx),
+		c.log,
+		c.mustGetDatabase(ctx).(*pg.Tx),
+		c.job,
+		hook,
+		&record,
+	)
+
+	if recorded {
+		finishPlaidWebhook(c.getContext(ctx), c.getLog(ctx), plaidRepo, &record, err)
+	}
+
+	return err
+}
+
+// finishPlaidWebhook records the outcome of processing a webhook on its stored record.
+func finishPlaidWebhook(ctx context.Context, log *logrus.Entry, plaidRepo repository.PlaidRepository, record *models.PlaidWebhook, err error) {
+	record.ProcessedAt = myownsanity.TimeP(time.Now().UTC())
+	record.Error = nil
+	if err != nil {
+		record.Error = myownsanity.StringP(err.Error())
+	}
+
+	if updateErr := plaidRepo.UpdatePlaidWebhook(ctx, record); updateErr != nil {
+		log.WithError(updateErr).Warn("failed to update plaid webhook record")
+	}
+}
+
+// PlaidWebhookFromRecord rebuilds the webhook that Plaid sent from the body that was stored when it was received.
+func PlaidWebhookFromRecord(record *models.PlaidWebhook) (PlaidWebhook, error) {
+	var hook PlaidWebhook
+	body, err := json.Marshal(record.Body)
+	if err != nil {
+		return hook, errors.Wrap(err, "failed to encode stored plaid webhook")
+	}
+
+	if err = json.Unmarshal(body, &hook); err != nil {
+		return hook, errors.Wrap(err, "failed to decode stored plaid webhook")
+	}
+
+	return hook, nil
+}
+
+// ReplayPlaidWebhook will process a stored Plaid webhook again, the same way that it was processed when it was
+// received. This is done even if the webhook was already processed successfully. The outcome of the replay is recorded
+// on the stored webhook.
+func ReplayPlaidWebhook(ctx context.Context, log *logrus.Entry, db *pg.DB, jobManager jobs.JobEnqueuer, plaidWebhookId uint64) error {
+	plaidRepo := repository.NewPlaidRepository(db)
+	record, err := plaidRepo.GetPlaidWebhook(ctx, plaidWebhookId)
+	if err != nil {
+		return err
+	}
+
+	hook, err := PlaidWebhookFromRecord(record)
+	if err != nil {
+		return err
+	}
+
+	log = log.WithFields(logrus.Fields{
+		"plaidWebhookId": record.PlaidWebhookId,
+		"webhookType":    record.WebhookType,
+		"webhookCode":    record.WebhookCode,
+	})
+	log.Info("replaying plaid webhook")
+
+	err = db.RunInTransaction(ctx, func(txn *pg.Tx) error {
+		return processPlaidWebhook(ctx, log, txn, jobManager, hook, record)
+	})
+
+	finishPlaidWebhook(ctx, log, plaidRepo, record, err)
+
+	return err
+}
+
+// processPlaidWebhook applies a webhook from Plaid to the link for the webhook's item. Any changes are made with the
+// provided transaction. The link that the webhook is for is stored on the record.
+func processPlaidWebhook(
+	ctx context.Context,
+	log *logrus.Entry,
+	txn *pg.Tx,
+	jobManager jobs.JobEnqueuer,
+	hook PlaidWebhook,
+	record *models.PlaidWebhook,
+) error {
+	log = log.WithFields(logrus.Fields{
 		"webhookType": hook.WebhookType,
 		"webhookCode": hook.WebhookCode,
 	})
@@ -153,10 +284,10 @@ func (c *Controller) processWebhook(ctx iris.Context, hook PlaidWebhook) error {
 		case "TRANSACTIONS.TRANSACTIONS_REMOVED":
 			fields["removedTransactions"] = hook.RemovedTransactions
 		}
-		crumbs.Debug(c.getContext(ctx), "Handling webhook from Plaid.", fields)
+		crumbs.Debug(ctx, "Handling webhook from Plaid.", fields)
 	}
 
-	if hub := sentry.GetHubFromContext(c.getContext(ctx)); hub != nil {
+	if hub := sentry.GetHubFromContext(ctx); hub != nil {
 		hub.ConfigureScope(func(scope *sentry.Scope) {
 			scope.SetTag("webhook", "plaid")
 			scope.SetTag("plaid.item_id", hook.ItemId)
@@ -165,12 +296,12 @@ func (c *Controller) processWebhook(ctx iris.Context, hook PlaidWebhook) error {
 		})
 	}
 
-	repo := c.mustGetUnauthenticatedRepository(ctx)
+	repo := repository.NewUnauthenticatedRepository(txn)
 
 	log.Trace("retrieving link for webhook")
-	link, err := repo.GetLinksForItem(c.getContext(ctx), hook.ItemId)
+	link, err := repo.GetLinksForItem(ctx, hook.ItemId)
 	if err != nil {
-		crumbs.Error(c.getContext(ctx),
+		crumbs.Error(ctx,
 			"Failed to retrieve a link for the item Id provided by the Plaid webhook.",
 			"plaid",
 			map[string]interface{}{
@@ -182,7 +313,7 @@ func (c *Controller) processWebhook(ctx iris.Context, hook PlaidWebhook) error {
 	}
 
 	// Set the user for this webhook for sentry.
-	if hub := sentry.GetHubFromContext(c.getContext(ctx)); hub != nil {
+	if hub := sentry.GetHubFromContext(ctx); hub != nil {
 		hub.ConfigureScope(func(scope *sentry.Scope) {
 			scope.SetUser(sentry.User{
 				ID:       strconv.FormatUint(link.AccountId, 10),
@@ -193,7 +324,10 @@ func (c *Controller) processWebhook(ctx iris.Context, hook PlaidWebhook) error {
 		})
 	}
 
-	log = c.log.WithFields(logrus.Fields{
+	record.AccountId = &link.AccountId
+	record.LinkId = &link.LinkId
+
+	log = log.WithFields(logrus.Fields{
 		"accountId": link.AccountId,
 		"linkId":    link.LinkId,
 	})
@@ -203,52 +337,92 @@ func (c *Controller) processWebhook(ctx iris.Context, hook PlaidWebhook) error {
 	authenticatedRepo := repository.NewRepositoryFromSession(
 		link.CreatedByUserId,
 		link.AccountId,
-		c.mustGetDatabase(ctx),
+		txn,
 	)
 
 	if hook.Error != nil {
-		crumbs.Warn(c.getContext(ctx), "Webhook has an error", "plaid", hook.Error)
+		crumbs.Warn(ctx, "Webhook has an error", "plaid", hook.Error)
 	}
 
 	switch hook.WebhookType {
 	case "TRANSACTIONS":
 		switch hook.WebhookCode {
 		case "INITIAL_UPDATE":
-			_, err = c.job.TriggerPullInitialTransactions(link.AccountId, link.CreatedByUserId, link.LinkId)
+			_, err = jobManager.TriggerPullInitialTransactions(link.AccountId, link.CreatedByUserId, link.LinkId)
 		case "HISTORICAL_UPDATE":
-			_, err = c.job.TriggerPullHistoricalTransactions(link.AccountId, link.LinkId)
-		case "DEFAULT_UPDATE":
-			_, err = c.job.TriggerPullLatestTransactions(link.AccountId, link.LinkId, hook.NewTransactions)
+			_, err = jobManager.TriggerPullHistoricalTransactions(link.AccountId, link.LinkId)
+		case "DEFAULT_UPDATE", "SYNC_UPDATES_AVAILABLE":
+			_, err = jobManager.TriggerPullLatestTransactions(link.AccountId, link.LinkId, hook.NewTransactions)
 		case "TRANSACTIONS_REMOVED":
-			_, err = c.job.TriggerRemoveTransactions(link.AccountId, link.LinkId, hook.RemovedTransactions)
+			// Links that sync with a cursor receive removed transactions along with the transactions that replace
+			// them. Removing them here could delete a pending transaction before the posted transaction is there to
+			// take over the changes the user made to it, so a sync is triggered instead.
+			if link.PlaidLink.TransactionsCursor != nil {
+				_, err = jobManager.TriggerPullLatestTransactions(link.AccountId, link.LinkId, 0)
+				break
+			}
+
+			_, err = jobManager.TriggerRemoveTransactions(link.AccountId, link.LinkId, hook.RemovedTransactions)
 		default:
-			crumbs.Warn(c.getContext(ctx), "Plaid webhook will not be handled, it is not implemented.", "plaid", nil)
+			crumbs.Warn(ctx, "Plaid webhook will not be handled, it is not implemented.", "plaid", nil)
 		}
 	case "ITEM":
 		switch hook.WebhookCode {
 		case "ERROR":
-			code := hook.Error["error_code"]
 			link.LinkStatus = models.LinkStatusError
-			link.ErrorCode = myownsanity.StringP(code.(string))
-			log.Warn("link is in an error state, updating")
-			err = authenticatedRepo.UpdateLink(c.getContext(ctx), link)
+			link.ErrorType = hook.getErrorField("error_type")
+			link.ErrorCode = hook.getErrorField("error_code")
+			// Prefer the display message as it is meant to be shown to the end user.
+			link.ErrorMessage = hook.getErrorField("display_message")
+			if link.ErrorMessage == nil {
+				link.ErrorMessage = hook.getErrorField("error_message")
+			}
+			log.WithField("errorCode", myownsanity.StringDefault(link.ErrorCode, "")).
+				Warn("link is in an error state, updating")
+			err = authenticatedRepo.UpdateLink(ctx, link)
+		case "LOGIN_REPAIRED":
+			// The user has fixed their login outside of monetr (likely through another app using Plaid). So we can
+			// clear the error state on the link and retrieve any transactions we missed while it was broken.
+			if link.LinkStatus == models.LinkStatusError || link.LinkStatus == models.LinkStatusPendingExpiration {
+				log.Info("link login has been repaired, clearing error state")
+				link.LinkStatus = models.LinkStatusSetup
+				link.ErrorType = nil
+				link.ErrorCode = nil
+				link.ErrorMessage = nil
+				link.ExpirationDate = nil
+				if err = authenticatedRepo.UpdateLink(ctx, link); err != nil {
+					break
+				}
+			}
+			_, err = jobManager.TriggerPullLatestTransactions(link.AccountId, link.LinkId, 0)
+		case "NEW_ACCOUNTS_AVAILABLE":
+			// We cannot add the accounts ourselves, the user needs to select which accounts they want to add through
+			// Plaid link in update mode. Flag the link so that the UI can prompt the user.
+			log.Info("new accounts are available for link")
+			link.NewAccountsAvailable = true
+			err = authenticatedRepo.UpdateLink(ctx, link)
 		case "PENDING_EXPIRATION":
 			link.LinkStatus = models.LinkStatusPendingExpiration
 			link.ExpirationDate = hook.ConsentExpirationTime
 			log.Warn("link is pending expiration")
-			err = authenticatedRepo.UpdateLink(c.getContext(ctx), link)
+			err = authenticatedRepo.UpdateLink(ctx, link)
 		case "USER_PERMISSION_REVOKED":
-			code := hook.Error["error_code"]
 			link.LinkStatus = models.LinkStatusRevoked
-			link.ErrorCode = myownsanity.StringP(code.(string))
-			err = authenticatedRepo.UpdateLink(c.getContext(ctx), link)
+			link.ErrorType = hook.getErrorField("error_type")
+			link.ErrorCode = hook.getErrorField("error_code")
+			link.ErrorMessage = hook.getErrorField("error_message")
+			err = authenticatedRepo.UpdateLink(ctx, link)
 		case "WEBHOOK_UPDATE_ACKNOWLEDGED":
-			_, err = c.job.TriggerPullInitialTransactions(link.AccountId, link.CreatedByUserId, link.LinkId)
+			_, err = jobManager.TriggerPullInitialTransactions(link.AccountId, link.CreatedByUserId, link.LinkId)
 		default:
-			crumbs.Warn(c.getContext(ctx), "Plaid webhook will not be handled, it is not implemented.", "plaid", nil)
+			crumbs.Warn(ctx, "Plaid webhook will not be handled, it is not implemented.", "plaid", nil)
 		}
+	case "HOLDINGS", "INVESTMENTS_TRANSACTIONS", "AUTH":
+		// We do not request these products from Plaid, but they can still be sent for some items. They are recorded
+		// above but there is nothing for us to do with them.
+		log.Debug("ignoring webhook for product that is not used")
 	default:
-		crumbs.Warn(c.getContext(ctx), "Plaid webhook will not be handled, it is not implemented.", "plaid", nil)
+		crumbs.Warn(ctx, "Plaid webhook will not be handled, it is not implemented.", "plaid", nil)
 	}
 
 	return err
@@ -0,0 +1,344 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/jarcoal/httpmock"
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/controller"
+	"github.com/monetr/rest-api/pkg/internal/mock_jobs"
+	"github.com/monetr/rest-api/pkg/internal/mock_plaid"
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/monetr/rest-api/pkg/jobs"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/plaid/plaid-go/plaid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPlaidWebhookTestConfig(t *testing.T) config.Configuration {
+	configuration := NewTestApplicationConfig(t)
+	configuration.Plaid.WebhooksEnabled = true
+	configuration.Plaid.WebhooksDomain = "monetr.local"
+	return configuration
+}
+
+func givenIHaveAPlaidLink(t *testing.T, e *httptest.Expect, token, itemId string, accounts []plaid.AccountBase) uint64 {
+	publicToken := mock_plaid.MockExchangePublicTokenForItem(t, itemId)
+	mock_plaid.MockGetAccounts(t, accounts)
+
+	accountIds := make([]string, len(accounts))
+	for i, account := range accounts {
+		accountIds[i] = account.GetAccountId()
+	}
+
+	response := e.POST("/plaid/link/token/callback").
+		WithHeader("M-Token", token).
+		WithJSON(map[string]interface{}{
+			"publicToken":     publicToken,
+			"institutionId":   "123",
+			"institutionName": gofakeit.Company(),
+			"accountIds":      accountIds,
+		}).
+		Expect()
+
+	response.Status(http.StatusOK)
+	return uint64(response.JSON().Path("$.linkId").Number().Gt(0).Raw())
+}
+
+func givenPlaidSendsAWebhook(t *testing.T, e *httptest.Expect, signer *mock_plaid.WebhookSigner, webhook map[string]interface{}) {
+	body, err := json.Marshal(webhook)
+	require.NoError(t, err, "must encode webhook")
+
+	response := e.POST("/plaid/webhook").
+		WithHeader("Plaid-Verification", signer.Sign(t, body)).
+		WithHeader("Content-Type", "application/json").
+		WithBytes(body).
+		Expect()
+
+	response.Status(http.StatusOK)
+}
+
+func TestPlaidWebhook(t *testing.T) {
+	t.Run("unsigned", func(t *testing.T) {
+		e := NewTestApplicationWithConfig(t, newPlaidWebhookTestConfig(t))
+
+		response := e.POST("/plaid/webhook").
+			WithJSON(map[string]interface{}{
+				"webhook_type": "TRANSACTIONS",
+				"webhook_code": "DEFAULT_UPDATE",
+				"item_id":      gofakeit.UUID(),
+			}).
+			Expect()
+
+		response.Status(http.StatusUnauthorized)
+	})
+
+	t.Run("error and login repaired", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		jobManager := mock_jobs.NewMockJobManager()
+		e := NewTestApplicationWithJobManager(t, newPlaidWebhookTestConfig(t), jobManager)
+		token := GivenIHaveToken(t, e)
+		signer := mock_plaid.MockGetWebhookVerificationKey(t)
+
+		itemId := gofakeit.UUID()
+		linkId := givenIHaveAPlaidLink(t, e, token, itemId, []plaid.AccountBase{
+			mock_plaid.BankAccountFixture(t),
+		})
+
+		givenPlaidSendsAWebhook(t, e, signer, map[string]interface{}{
+			"webhook_type": "ITEM",
+			"webhook_code": "ERROR",
+			"item_id":      itemId,
+			"error": map[string]interface{}{
+				"error_type":      "ITEM_ERROR",
+				"error_code":      "ITEM_LOGIN_REQUIRED",
+				"error_message":   "the login details of this item have changed",
+				"display_message": "Your bank login has changed.",
+			},
+		})
+
+		{ // The link should now be in an error state.
+			response := e.GET(fmt.Sprintf("/links/%d", linkId)).
+				WithHeader("M-Token", token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.linkStatus").Number().Equal(models.LinkStatusError)
+			response.JSON().Path("$.errorType").String().Equal("ITEM_ERROR")
+			response.JSON().Path("$.errorCode").String().Equal("ITEM_LOGIN_REQUIRED")
+			response.JSON().Path("$.errorMessage").String().Equal("Your bank login has changed.")
+		}
+
+		givenPlaidSendsAWebhook(t, e, signer, map[string]interface{}{
+			"webhook_type": "ITEM",
+			"webhook_code": "LOGIN_REPAIRED",
+			"item_id":      itemId,
+		})
+
+		{ // Once the login is repaired the error should be cleared.
+			response := e.GET(fmt.Sprintf("/links/%d", linkId)).
+				WithHeader("M-Token", token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.linkStatus").Number().Equal(models.LinkStatusSetup)
+			response.JSON().Object().NotContainsKey("errorType")
+			response.JSON().Object().NotContainsKey("errorCode")
+			response.JSON().Object().NotContainsKey("errorMessage")
+		}
+
+		triggered := jobManager.GetTriggered(jobs.PullLatestTransactions)
+		require.Len(t, triggered, 1, "should pull any transactions missed while the login was broken")
+		assert.Equal(t, linkId, triggered[0].Args["linkId"], "should pull transactions for the repaired link")
+	})
+
+	t.Run("new accounts available", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		jobManager := mock_jobs.NewMockJobManager()
+		e := NewTestApplicationWithJobManager(t, newPlaidWebhookTestConfig(t), jobManager)
+		token := GivenIHaveToken(t, e)
+		signer := mock_plaid.MockGetWebhookVerificationKey(t)
+
+		itemId := gofakeit.UUID()
+		existingAccount := mock_plaid.BankAccountFixture(t)
+		linkId := givenIHaveAPlaidLink(t, e, token, itemId, []plaid.AccountBase{
+			existingAccount,
+		})
+
+		{ // Without new accounts the link should be updated without account selection.
+			mock_plaid.MockUpdateLinkToken(t, false)
+
+			response := e.PUT(fmt.Sprintf("/plaid/link/update/%d", linkId)).
+				WithHeader("M-Token", token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.linkToken").String().NotEmpty()
+		}
+
+		givenPlaidSendsAWebhook(t, e, signer, map[string]interface{}{
+			"webhook_type": "ITEM",
+			"webhook_code": "NEW_ACCOUNTS_AVAILABLE",
+			"item_id":      itemId,
+		})
+
+		{ // The link should be flagged so the user can be prompted to add the new accounts.
+			response := e.GET(fmt.Sprintf("/links/%d", linkId)).
+				WithHeader("M-Token", token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.newAccountsAvailable").Boolean().True()
+		}
+
+		{ // Now that new accounts are available, updating the link should enable account selection.
+			mock_plaid.MockUpdateLinkToken(t, true)
+
+			response := e.PUT(fmt.Sprintf("/plaid/link/update/%d", linkId)).
+				WithHeader("M-Token", token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.linkToken").String().NotEmpty()
+		}
+
+		newAccount := mock_plaid.BankAccountFixture(t)
+		{ // Finishing the update should add only the account that is new.
+			publicToken := mock_plaid.MockExchangePublicTokenForItem(t, itemId)
+			mock_plaid.MockGetAccounts(t, []plaid.AccountBase{
+				existingAccount,
+				newAccount,
+			})
+
+			response := e.POST("/plaid/link/update/callback").
+				WithHeader("M-Token", token).
+				WithJSON(map[string]interface{}{
+					"linkId":      linkId,
+					"publicToken": publicToken,
+					"accountIds": []string{
+						existingAccount.GetAccountId(),
+						newAccount.GetAccountId(),
+					},
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.newAccountsAvailable").Boolean().False()
+		}
+
+		{
+			response := e.GET("/bank_accounts").
+				WithHeader("M-Token", token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().Equal(2)
+			names := []string{
+				response.JSON().Path("$[0].originalName").String().Raw(),
+				response.JSON().Path("$[1].originalName").String().Raw(),
+			}
+			assert.ElementsMatch(t, []string{existingAccount.GetName(), newAccount.GetName()}, names, "should have both accounts")
+		}
+
+		assert.Len(t, jobManager.GetTriggered(jobs.PullLatestTransactions), 1, "should pull transactions for the new accounts")
+	})
+
+	t.Run("transactions removed", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		jobManager := mock_jobs.NewMockJobManager()
+		e := NewTestApplicationWithJobManager(t, newPlaidWebhookTestConfig(t), jobManager)
+		token := GivenIHaveToken(t, e)
+		signer := mock_plaid.MockGetWebhookVerificationKey(t)
+
+		itemId := gofakeit.UUID()
+		linkId := givenIHaveAPlaidLink(t, e, token, itemId, []plaid.AccountBase{
+			mock_plaid.BankAccountFixture(t),
+		})
+
+		removed := map[string]interface{}{
+			"webhook_type":         "TRANSACTIONS",
+			"webhook_code":         "TRANSACTIONS_REMOVED",
+			"item_id":              itemId,
+			"removed_transactions": []string{gofakeit.UUID()},
+		}
+
+		// Before the link has been synced the removed transactions are deleted directly.
+		givenPlaidSendsAWebhook(t, e, signer, removed)
+		triggered := jobManager.GetTriggered(jobs.RemoveTransactions)
+		require.Len(t, triggered, 1, "should remove transactions for a link that has not been synced")
+		assert.Equal(t, linkId, triggered[0].Args["linkId"], "should remove transactions for the link")
+
+		db := testutils.GetPgDatabase(t)
+		_, err := db.Model(&models.PlaidLink{}).
+			Set(`"transactions_cursor" = ?`, "offset:1").
+			Where(`"plaid_link"."item_id" = ?`, itemId).
+			Update()
+		require.NoError(t, err, "must store a transactions cursor for the link")
+
+		// Once the link syncs with a cursor, the removed pending transaction might not have been replaced by its posted
+		// transaction yet. Deleting it now would lose the spending and custom name the user set on it, so a sync is
+		// triggered instead which will apply the removal along with the posted transaction.
+		pullsBefore := len(jobManager.GetTriggered(jobs.PullLatestTransactions))
+		givenPlaidSendsAWebhook(t, e, signer, removed)
+		assert.Len(t, jobManager.GetTriggered(jobs.RemoveTransactions), 1, "should not remove transactions for a link with a cursor")
+		triggered = jobManager.GetTriggered(jobs.PullLatestTransactions)
+		require.Len(t, triggered, pullsBefore+1, "should sync the link instead")
+		assert.Equal(t, linkId, triggered[pullsBefore].Args["linkId"], "should sync the link the transactions were removed from")
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		jobManager := mock_jobs.NewMockJobManager()
+		e := NewTestApplicationWithJobManager(t, newPlaidWebhookTestConfig(t), jobManager)
+		token := GivenIHaveToken(t, e)
+		signer := mock_plaid.MockGetWebhookVerificationKey(t)
+
+		itemId := gofakeit.UUID()
+		linkId := givenIHaveAPlaidLink(t, e, token, itemId, []plaid.AccountBase{
+			mock_plaid.BankAccountFixture(t),
+		})
+
+		givenPlaidSendsAWebhook(t, e, signer, map[string]interface{}{
+			"webhook_type": "ITEM",
+			"webhook_code": "NEW_ACCOUNTS_AVAILABLE",
+			"item_id":      itemId,
+		})
+
+		db := testutils.GetPgDatabase(t)
+		var record models.PlaidWebhook
+		require.NoError(t, db.Model(&record).
+			Where(`"plaid_webhook"."item_id" = ?`, itemId).
+			Limit(1).
+			Select(&record), "must retrieve recorded webhook")
+		require.NotNil(t, record.ProcessedAt, "webhook should have been processed")
+		assert.Equal(t, &linkId, record.LinkId, "webhook should be recorded for the link")
+
+		// Clear the flag so that it is only set again if the replay is processed.
+		_, err := db.Model(&models.Link{}).
+			Set(`"new_accounts_available" = ?`, false).
+			Where(`"link"."link_id" = ?`, linkId).
+			Update()
+		require.NoError(t, err, "must clear new accounts flag")
+
+		err = controller.ReplayPlaidWebhook(context.Background(), testutils.GetLog(t), db, jobManager, record.PlaidWebhookId)
+		assert.NoError(t, err, "should replay the webhook")
+
+		{
+			response := e.GET(fmt.Sprintf("/links/%d", linkId)).
+				WithHeader("M-Token", token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.newAccountsAvailable").Boolean().True()
+		}
+
+		var replayed models.PlaidWebhook
+		require.NoError(t, db.Model(&replayed).
+			Where(`"plaid_webhook"."plaid_webhook_id" = ?`, record.PlaidWebhookId).
+			Limit(1).
+			Select(&replayed), "must retrieve replayed webhook")
+		require.NotNil(t, replayed.ProcessedAt, "replay should be recorded")
+		assert.True(t, replayed.ProcessedAt.After(*record.ProcessedAt), "processed at should be updated by the replay")
+		assert.Nil(t, replayed.Error, "replay should not have failed")
+
+		{ // Replaying a webhook that does not exist should fail.
+			err = controller.ReplayPlaidWebhook(context.Background(), testutils.GetLog(t), db, jobManager, record.PlaidWebhookId+1000)
+			assert.Error(t, err, "should not replay a webhook that does not exist")
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package controller
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/monetr/rest-api/pkg/communication"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/monetr/rest-api/pkg/ratelimit"
+	"github.com/pkg/errors"
+)
+
+// tooManyRequests will return a 429 to the client with a Retry-After header indicating how many seconds the client
+// should wait before trying again.
+func (c *Controller) tooManyRequests(ctx iris.Context, retryAfter time.Duration, msg string, args ...interface{}) {
+	ctx.Header("Retry-After", strconv.FormatInt(int64(math.Ceil(retryAfter.Seconds())), 10))
+	c.returnError(ctx, http.StatusTooManyRequests, msg, args...)
+}
+
+// ipRateLimitMiddleware returns a middleware that limits the number of requests a single IP address can make to the
+// endpoint within the configured window. The name is used to keep the attempts for each endpoint separate.
+func (c *Controller) ipRateLimitMiddleware(name string) iris.Handler {
+	return func(ctx iris.Context) {
+		if !c.configuration.RateLimit.Enabled {
+			ctx.Next()
+			return
+		}
+
+		key := fmt.Sprintf("%s:ip:%s", name, ctx.RemoteAddr())
+		allowed, retryAfter, err := c.limiter.Attempt(
+			c.getContext(ctx),
+			key,
+			c.configuration.RateLimit.PerIPLimit,
+			c.configuration.RateLimit.PerIPWindow,
+		)
+		if err != nil {
+			// If we cannot reach the cache then we don't want to prevent people from logging in entirely.
+			c.getLog(ctx).WithError(err).Warn("failed to check rate limit for request, request will be allowed")
+			ctx.Next()
+			return
+		}
+
+		if !allowed {
+			c.tooManyRequests(ctx, retryAfter, "too many requests, please try again later")
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+func (c *Controller) getLoginLockoutKey(email string) string {
+	return fmt.Sprintf("login:%s", strings.ToLower(email))
+}
+
+// checkLoginRateLimit will verify that the provided email is not locked out and has not made too many login attempts
+// recently. If the login should not proceed then an error is returned to the client and false is returned.
+func (c *Controller) checkLoginRateLimit(ctx iris.Context, email string) bool {
+	if !c.configuration.RateLimit.Enabled {
+		return true
+	}
+
+	log := c.getLog(ctx)
+	key := c.getLoginLockoutKey(email)
+
+	lockedFor, err := c.lockout.Check(c.getContext(ctx), key)
+	if err != nil {
+		log.WithError(err).Warn("failed to check login lockout, login will be allowed")
+	} else if lockedFor > 0 {
+		c.tooManyRequests(ctx, lockedFor, "too many failed login attempts, login is temporarily locked")
+		return false
+	}
+
+	allowed, retryAfter, err := c.limiter.Attempt(
+		c.getContext(ctx),
+		fmt.Sprintf("login:email:%s", strings.ToLower(email)),
+		c.configuration.RateLimit.PerEmailLimit,
+		c.configuration.RateLimit.PerEmailWindow,
+	)
+	if err != nil {
+		log.WithError(err).Warn("failed to check login rate limit, login will be allowed")
+		return true
+	}
+
+	if !allowed {
+		c.tooManyRequests(ctx, retryAfter, "too many login attempts, please try again later")
+		return false
+	}
+
+	return true
+}
+
+// loginFailed will record a failed login attempt for the provided email. If this causes the email to be locked out
+// then the owner of the email (if there is one) will be sent an email with a link to unlock their login.
+func (c *Controller) loginFailed(ctx iris.Context, email string) {
+	if !c.configuration.RateLimit.Enabled {
+		return
+	}
+
+	log := c.getLog(ctx)
+
+	lockedFor, err := c.lockout.Failure(c.getContext(ctx), c.getLoginLockoutKey(email))
+	if err != nil {
+		log.WithError(err).Warn("failed to record failed login attempt")
+		return
+	}
+
+	if lockedFor == 0 {
+		return
+	}
+
+	log.WithField("lockedFor", lockedFor.String()).Warn("login has been locked due to too many failed attempts")
+
+	if c.communication == nil {
+		return
+	}
+
+	if err = c.sendUnlockEmail(ctx, email); err != nil {
+		log.WithError(err).Warn("failed to send unlock email")
+	}
+}
+
+// loginSucceeded will clear any failed login attempts for the provided email.
+func (c *Controller) loginSucceeded(ctx iris.Context, email string) {
+	if !c.configuration.RateLimit.Enabled {
+		return
+	}
+
+	if err := c.lockout.Reset(c.getContext(ctx), c.getLoginLockoutKey(email)); err != nil {
+		c.getLog(ctx).WithError(err).Warn("failed to reset failed login attempts")
+	}
+}
+
+func (c *Controller) sendUnlockEmail(ctx iris.Context, email string) error {
+	var login models.Login
+	if err := c.db.ModelContext(c.getContext(ctx), &login).
+		Where(`"login"."email" = ?`, strings.ToLower(email)).
+		Limit(1).
+		Select(&login); err != nil {
+		// If there is no login for this email then there is no one to send an email to.
+		return errors.Wrap(err, "failed to retrieve login for unlock email")
+	}
+
+	token, err := c.lockout.CreateUnlockToken(c.getContext(ctx), c.getLoginLockoutKey(email))
+	if err != nil {
+		return err
+	}
+
+	return c.communication.SendUnlockAccountEmail(c.getContext(ctx), communication.UnlockAccountParams{
+		Login:     login,
+		UnlockURL: fmt.Sprintf("https://%s/login/unlock?token=%s", c.configuration.UIDomainName, url.QueryEscape(token)),
+	})
+}
+
+// Unlock Login
+// @Summary Unlock Login
+// @id unlock-login
+// @tags Authentication
+// @description Removes the lockout from a login that has had too many failed login attempts. The token is sent to the
+// @description login's email address when the login is locked.
+// @Accept json
+// @Produce json
+// @Param Request body swag.UnlockLoginRequest true "Unlock request."
+// @Router /authentication/unlock [post]
+// @Success 200
+// @Failure 400 {object} ApiError The unlock token is not valid or has expired.
+func (c *Controller) unlockEndpoint(ctx iris.Context) {
+	var unlockRequest struct {
+		Token string `json:"token"`
+	}
+	if err := ctx.ReadJSON(&unlockRequest); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed json")
+		return
+	}
+
+	key, err := c.lockout.Unlock(c.getContext(ctx), strings.TrimSpace(unlockRequest.Token))
+	if err != nil {
+		if errors.Cause(err) == ratelimit.ErrInvalidUnlockToken {
+			c.badRequest(ctx, "unlock token is not valid or has expired")
+			return
+		}
+
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to unlock login")
+		return
+	}
+
+	// Also clear the recent attempts for the email so that the user can log in right away.
+	email := strings.TrimPrefix(key, "login:")
+	if err = c.limiter.Reset(c.getContext(ctx), fmt.Sprintf("login:email:%s", email)); err != nil {
+		c.getLog(ctx).WithError(err).Warn("failed to reset login rate limit")
+	}
+
+	ctx.StatusCode(http.StatusOK)
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/monetr/rest-api/pkg/build"
 	"github.com/monetr/rest-api/pkg/hash"
 	"github.com/monetr/rest-api/pkg/models"
+	"github.com/monetr/rest-api/pkg/repository"
 	"github.com/pkg/errors"
 	"github.com/stripe/stripe-go/v72"
 )
@@ -35,6 +36,7 @@ type RegistrationClaims struct {
 // @Success 200 {object} swag.RegisterResponse
 // @Failure 400 {object} ApiError Required data is missing.
 // @Failure 403 {object} ApiError Invalid credentials.
+// @Failure 429 {object} ApiError Too many attempts, the Retry-After header indicates when to try again.
 // @Failure 500 {object} ApiError Something went wrong on our end.
 func (c *Controller) registerEndpoint(ctx iris.Context) {
 	var registerRequest struct {
@@ -114,9 +116,11 @@ func (c *Controller) registerEndpoint(ctx iris.Context) {
 	//  email.
 
 	// Hash the user's password so that we can store it securely.
-	hashedPassword := hash.HashPassword(
-		registerRequest.Email, registerRequest.Password,
-	)
+	hashedPassword, err := hash.NewPasswordHash(registerRequest.Password)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to hash password")
+		return
+	}
 
 	// Create the user's login record in the database, this will return the login
 	// record including the new login's loginId which we will need below. If SMTP
@@ -137,78 +141,9 @@ func (c *Controller) registerEndpoint(ctx iris.Context) {
 		return
 	}
 
-	var stripeCustomerId *string
-	if c.configuration.Stripe.Enabled {
-		stripeSpan := sentry.StartSpan(c.getContext(ctx), "Create Stripe Customer")
-		c.log.Debug("creating stripe customer for new user")
-		name := registerRequest.FirstName + " " + registerRequest.LastName
-		result, err := c.stripe.CreateCustomer(c.getContext(ctx), stripe.CustomerParams{
-			Email: &registerRequest.Email,
-			Name:  &name,
-			Params: stripe.Params{
-				Metadata: map[string]string{
-					"environment": c.configuration.Environment,
-					"revision":    build.Revision,
-					"release":     build.Release,
-				},
-			},
-		})
-		if err != nil {
-			stripeSpan.Status = sentry.SpanStatusInternalError
-			stripeSpan.Finish()
-			c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create stripe customer")
-			return
-		}
-		stripeSpan.Status = sentry.SpanStatusOK
-		stripeSpan.Finish()
-
-		stripeCustomerId = &result.ID
-	}
-
-	account := models.Account{
-		Timezone:             timezone.String(),
-		StripeCustomerId:     stripeCustomerId,
-		StripeSubscriptionId: nil,
-	}
-	// Now that the login exists we can create the account, at the time of
-	// writing this we are only using the local time zone of the server, but in
-	// the future I want to have it somehow use the user's timezone.
-	if err = repository.CreateAccountV2(c.getContext(ctx), &account); err != nil {
-		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError,
-			"failed to create account",
-		)
-		return
-	}
-
-	if hub := sentry.GetHubFromContext(c.getContext(ctx)); hub != nil {
-		hub.ConfigureScope(func(scope *sentry.Scope) {
-			scope.SetUser(sentry.User{
-				ID:       strconv.FormatUint(account.AccountId, 10),
-				Username: fmt.Sprintf("account:%d", account.AccountId),
-			})
-		})
-	}
-
-	user := models.User{
-		LoginId:          login.LoginId,
-		AccountId:        account.AccountId,
-		FirstName:        registerRequest.FirstName,
-		LastName:         registerRequest.LastName,
-		StripeCustomerId: stripeCustomerId,
-	}
-
-	// Now that we have an accountId we can create the user object which will
-	// bind the login and the account together.
-	err = repository.CreateUser(
-		c.getContext(ctx),
-		login.LoginId,
-		account.AccountId,
-		&user,
-	)
+	user, account, err := c.createAccountForLogin(ctx, repository, login, timezone)
 	if err != nil {
-		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError,
-			"failed to create user",
-		)
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to register")
 		return
 	}
 
@@ -240,7 +175,7 @@ func (c *Controller) registerEndpoint(ctx iris.Context) {
 	}
 
 	user.Login = login
-	user.Account = &account
+	user.Account = account
 
 	if !c.configuration.Stripe.IsBillingEnabled() {
 		ctx.JSON(map[string]interface{}{
@@ -354,10 +289,7 @@ func (c *Controller) validateCaptchaMaybe(ctx context.Context, captcha string) e
 		return errors.Errorf("captcha is not valid")
 	}
 
-	span := sentry.StartSpan(ctx, "ReCAPTCHA")
-	defer span.Finish()
-
-	return c.captcha.Verify(captcha)
+	return c.captcha.Verify(ctx, captcha)
 }
 
 func (c *Controller) generateRegistrationToken(registrationId string) (string, error) {
@@ -385,3 +317,81 @@ func (c *Controller) generateRegistrationToken(registrationId string) (string, e
 
 	return signedToken, nil
 }
+
+// createAccountForLogin will create a new account for the provided login, as well as the user that grants the login
+// access to that account. If Stripe is enabled then a customer will also be created for the account.
+func (c *Controller) createAccountForLogin(
+	ctx iris.Context,
+	repo repository.UnauthenticatedRepository,
+	login *models.Login,
+	timezone *time.Location,
+) (*models.User, *models.Account, error) {
+	var stripeCustomerId *string
+	if c.configuration.Stripe.Enabled {
+		stripeSpan := sentry.StartSpan(c.getContext(ctx), "Create Stripe Customer")
+		c.log.Debug("creating stripe customer for new user")
+		name := login.FirstName + " " + login.LastName
+		result, err := c.stripe.CreateCustomer(c.getContext(ctx), stripe.CustomerParams{
+			Email: &login.Email,
+			Name:  &name,
+			Params: stripe.Params{
+				Metadata: map[string]string{
+					"environment": c.configuration.Environment,
+					"revision":    build.Revision,
+					"release":     build.Release,
+				},
+			},
+		})
+		if err != nil {
+			stripeSpan.Status = sentry.SpanStatusInternalError
+			stripeSpan.Finish()
+			return nil, nil, errors.Wrap(err, "failed to create stripe customer")
+		}
+		stripeSpan.Status = sentry.SpanStatusOK
+		stripeSpan.Finish()
+
+		stripeCustomerId = &result.ID
+	}
+
+	account := models.Account{
+		Timezone:             timezone.String(),
+		StripeCustomerId:     stripeCustomerId,
+		StripeSubscriptionId: nil,
+	}
+	// Now that the login exists we can create the account, at the time of
+	// writing this we are only using the local time zone of the server, but in
+	// the future I want to have it somehow use the user's timezone.
+	if err := repo.CreateAccountV2(c.getContext(ctx), &account); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create account")
+	}
+
+	if hub := sentry.GetHubFromContext(c.getContext(ctx)); hub != nil {
+		hub.ConfigureScope(func(scope *sentry.Scope) {
+			scope.SetUser(sentry.User{
+				ID:       strconv.FormatUint(account.AccountId, 10),
+				Username: fmt.Sprintf("account:%d", account.AccountId),
+			})
+		})
+	}
+
+	user := models.User{
+		LoginId:          login.LoginId,
+		AccountId:        account.AccountId,
+		FirstName:        login.FirstName,
+		LastName:         login.LastName,
+		StripeCustomerId: stripeCustomerId,
+	}
+
+	// Now that we have an accountId we can create the user object which will
+	// bind the login and the account together.
+	if err := repo.CreateUser(
+		c.getContext(ctx),
+		login.LoginId,
+		account.AccountId,
+		&user,
+	); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create user")
+	}
+
+	return &user, &account, nil
+}
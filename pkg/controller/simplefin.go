@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/core/router"
+	"github.com/monetr/rest-api/pkg/feature"
+	"github.com/monetr/rest-api/pkg/models"
+)
+
+func (c *Controller) handleSimpleFINLinkEndpoints(p router.Party) {
+	p.Post("/", c.requireFeatureMiddleware(feature.FeatureLinkedBudgeting), c.newSimpleFINLink)
+}
+
+// New SimpleFIN Link
+// @Summary New SimpleFIN Link
+// @id new-simplefin-link
+// @tags SimpleFIN
+// @description Claims a SimpleFIN Bridge setup token and creates a link for the bridge's accounts. SimpleFIN links are synced on a schedule, the initial sync is started immediately.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param Request body swag.NewSimpleFINLinkRequest true "SimpleFIN setup token"
+// @Router /simplefin/link [post]
+// @Success 200 {object} swag.NewSimpleFINLinkResponse
+// @Failure 400 {object} ApiError The setup token is not valid or has already been claimed.
+// @Failure 403 {object} ApiError The user's plan does not include linked budgeting.
+// @Failure 502 {object} ApiError The SimpleFIN Bridge could not provide the accounts for the link.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) newSimpleFINLink(ctx iris.Context) {
+	var request struct {
+		SetupToken      string   `json:"setupToken"`
+		InstitutionName string   `json:"institutionName"`
+		AccountIds      []string `json:"accountIds"`
+	}
+	if err := ctx.ReadJSON(&request); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed json")
+		return
+	}
+
+	request.SetupToken = strings.TrimSpace(request.SetupToken)
+	if request.SetupToken == "" {
+		c.badRequest(ctx, "setup token is required")
+		return
+	}
+
+	log := c.getLog(ctx)
+
+	log.Debug("claiming simplefin setup token")
+	// The setup token points at a server chosen by the user, so errors from claiming it or talking to the bridge are only
+	// logged. Returning them could expose details about whatever the token pointed at.
+	accessUrl, err := c.simpleFIN.Claim(c.getContext(ctx), request.SetupToken)
+	if err != nil {
+		log.WithError(err).Warn("failed to claim simplefin setup token")
+		c.badRequest(ctx, "invalid setup token")
+		return
+	}
+
+	client, err := c.simpleFIN.NewClient(c.getContext(ctx), accessUrl)
+	if err != nil {
+		log.WithError(err).Warn("failed to create simplefin client")
+		c.badRequest(ctx, "invalid setup token")
+		return
+	}
+
+	simpleFINAccounts, err := client.GetAccounts(c.getContext(ctx), request.AccountIds...)
+	if err != nil {
+		log.WithError(err).Warn("failed to retrieve accounts from simplefin bridge")
+		c.returnError(ctx, http.StatusBadGateway, "failed to retrieve accounts from SimpleFIN Bridge")
+		return
+	}
+
+	if len(simpleFINAccounts) == 0 {
+		c.badRequest(ctx, "SimpleFIN Bridge does not have any accounts")
+		return
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	simpleFINLink := models.SimpleFINLink{}
+	if err = repo.CreateSimpleFINLink(c.getContext(ctx), &simpleFINLink); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create SimpleFIN link")
+		return
+	}
+
+	// The access URL includes the credentials for the user's bridge, it is stored the same way that Plaid access tokens
+	// are rather than on the link itself.
+	if err = c.simpleFINSecrets.UpdateAccessUrlForSimpleFINLink(
+		c.getContext(ctx),
+		repo.AccountId(),
+		simpleFINLink.SimpleFINLinkId,
+		accessUrl,
+	); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to store credentials")
+		return
+	}
+
+	institutionName := strings.TrimSpace(request.InstitutionName)
+	if institutionName == "" {
+		institutionName = "SimpleFIN"
+	}
+
+	// There is no asynchronous setup for SimpleFIN, once the token has been claimed the link can be synced.
+	link := models.Link{
+		AccountId:       repo.AccountId(),
+		SimpleFINLinkId: &simpleFINLink.SimpleFINLinkId,
+		LinkType:        models.SimpleFINLinkType,
+		LinkStatus:      models.LinkStatusSetup,
+		InstitutionName: institutionName,
+		CreatedByUserId: repo.UserId(),
+	}
+	if err = repo.CreateLink(c.getContext(ctx), &link); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create link")
+		return
+	}
+
+	now := time.Now().UTC()
+	accounts := make([]models.BankAccount, len(simpleFINAccounts))
+	for i, simpleFINAccount := range simpleFINAccounts {
+		accounts[i] = models.BankAccount{
+			AccountId:          repo.AccountId(),
+			LinkId:             link.LinkId,
+			SimpleFINAccountId: simpleFINAccount.GetAccountId(),
+			Name:               simpleFINAccount.GetName(),
+			Mask:               simpleFINAccount.GetMask(),
+			PlaidName:          simpleFINAccount.GetName(),
+			PlaidOfficialName:  simpleFINAccount.GetOfficialName(),
+			Type:               models.BankAccountType(simpleFINAccount.GetType()),
+			SubType:            models.BankAccountSubType(simpleFINAccount.GetSubType()),
+			LastUpdated:        now,
+			Currency:           simpleFINAccount.GetBalances().GetCurrency(),
+		}
+		accounts[i].SetBalances(
+			simpleFINAccount.GetBalances().GetAvailable(),
+			simpleFINAccount.GetBalances().GetCurrent(),
+			simpleFINAccount.GetBalances().GetLimit(),
+		)
+	}
+	if err = repo.CreateBankAccounts(c.getContext(ctx), accounts...); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create bank accounts")
+		return
+	}
+
+	jobId, err := c.job.TriggerSyncSimpleFINLink(link.AccountId, link.LinkId)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to sync SimpleFIN link")
+		return
+	}
+
+	ctx.JSON(map[string]interface{}{
+		"success": true,
+		"linkId":  link.LinkId,
+		"jobId":   jobId,
+	})
+}
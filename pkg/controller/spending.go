@@ -4,6 +4,7 @@ import (
 	"github.com/getsentry/sentry-go"
 	"github.com/kataras/iris/v12"
 	"github.com/kataras/iris/v12/context"
+	"github.com/monetr/rest-api/pkg/currency"
 	"github.com/monetr/rest-api/pkg/models"
 	"net/http"
 	"strings"
@@ -16,6 +17,7 @@ func (c *Controller) handleSpending(p iris.Party) {
 	p.Get("/{bankAccountId:uint64}/spending", c.getSpending)
 	p.Post("/{bankAccountId:uint64}/spending", c.postSpending)
 	p.Post("/{bankAccountId:uint64}/spending/transfer", c.postSpendingTransfer)
+	p.Post("/{bankAccountId:uint64}/spending/{spendingId:uint64}/contribute", c.postSpendingContribution)
 	p.Put("/{bankAccountId:uint64}/spending/{expenseId:uint64}", c.putSpending)
 	p.Delete("/{bankAccountId:uint64}/spending/{spendingId:uint64}", c.deleteSpending)
 }
@@ -24,11 +26,12 @@ func (c *Controller) handleSpending(p iris.Party) {
 // @id list-spending
 // @tags Spending
 // @Summary List Spending
-// @description List all of the spending for the specified bank account.
+// @description List all of the spending for the specified bank account. Archived goals are not included unless they are requested.
 // @Security ApiKeyAuth
 // @Accept json
 // @Produce json
 // @Param bankAccountId path int true "Bank Account ID"
+// @Param archived query bool false "Include archived goals."
 // @Router /bank_accounts/{bankAccountId}/spending [get]
 // @Success 200 {array} swag.SpendingResponse
 // @Failure 400 {object} InvalidBankAccountIdError Invalid Bank Account ID.
@@ -49,6 +52,17 @@ func (c *Controller) getSpending(ctx *context.Context) {
 		return
 	}
 
+	// Archived goals are kept so that their history is not lost, but they are hidden unless they are asked for.
+	if includeArchived, err := ctx.URLParamBool("archived"); err != nil || !includeArchived {
+		visible := make([]models.Spending, 0, len(expenses))
+		for _, spending := range expenses {
+			if !spending.IsArchived {
+				visible = append(visible, spending)
+			}
+		}
+		expenses = visible
+	}
+
 	ctx.JSON(expenses)
 }
 
@@ -103,6 +117,16 @@ func (c *Controller) postSpending(ctx *context.Context) {
 
 	repo := c.mustGetAuthenticatedRepository(ctx)
 
+	// Spending objects are always in the currency of the bank account they belong to.
+	bankAccount, err := repo.GetBankAccount(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		requestSpan.Status = sentry.SpanStatusNotFound
+		c.wrapPgError(ctx, err, "could not find bank account specified")
+		return
+	}
+
+	spending.Currency = bankAccount.Currency
+
 	// We need to calculate what the next contribution will be for this new spending. So we need to retrieve it's funding
 	// schedule. This also helps us validate that the user has provided a valid funding schedule id.
 	fundingSchedule, err := repo.GetFundingSchedule(c.getContext(ctx), bankAccountId, spending.FundingScheduleId)
@@ -122,6 +146,9 @@ func (c *Controller) postSpending(ctx *context.Context) {
 	}
 
 	spending.LastRecurrence = nil
+	spending.IsComplete = false
+	spending.IsArchived = false
+	spending.DateCompleted = nil
 
 	var next time.Time
 
@@ -130,9 +157,14 @@ func (c *Controller) postSpending(ctx *context.Context) {
 		// If this is an expense then we need to figure out when it happens next.
 		next = spending.RecurrenceRule.After(time.Now(), false)
 	case models.SpendingTypeGoal:
-		// If the spending is a goal, then we don't need the rule at all.
+		// If the spending is a goal, then we don't need the rule at all. The next recurrence is the date that the goal
+		// should be funded by.
 		next = spending.NextRecurrence
-		if next.Before(time.Now()) {
+		if next.IsZero() {
+			requestSpan.Status = sentry.SpanStatusInvalidArgument
+			c.badRequest(ctx, "goals must have a target date")
+			return
+		} else if next.Before(time.Now()) {
 			requestSpan.Status = sentry.SpanStatusInvalidArgument
 			c.badRequest(ctx, "due date cannot be in the past")
 			return
@@ -219,6 +251,13 @@ func (c *Controller) postSpendingTransfer(ctx *context.Context) {
 		return
 	}
 
+	c.transferSpending(ctx, bankAccountId, *transfer)
+}
+
+// transferSpending moves allocated funds between two spending objects, or between the bank account's safe-to-spend
+// balance and a spending object when one side of the transfer is nil. Both the source and the destination are
+// recalculated, and the updated spending objects and balances are written to the response.
+func (c *Controller) transferSpending(ctx *context.Context, bankAccountId uint64, transfer SpendingTransfer) {
 	repo := c.mustGetAuthenticatedRepository(ctx)
 
 	balances, err := repo.GetBalances(c.getContext(ctx), bankAccountId)
@@ -282,6 +321,21 @@ func (c *Controller) postSpendingTransfer(ctx *context.Context) {
 			return
 		}
 
+		// Completed goals do not receive any more funds. If the user wants to put more money towards a completed goal
+		// then they need to raise its target amount first.
+		if toExpense.SpendingType == models.SpendingTypeGoal && toExpense.IsComplete {
+			c.badRequest(ctx, "cannot transfer to a goal that has already been completed")
+			return
+		}
+
+		// Allocations cannot be moved between spending objects that are in different currencies.
+		if len(spendingToUpdate) > 0 {
+			if err = currency.Check(spendingToUpdate[0].Currency, toExpense.Currency); err != nil {
+				c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "cannot transfer between goals/expenses in different currencies")
+				return
+			}
+		}
+
 		// If the funding schedule that we already have put aside is not the same as the one we need for this spending
 		// then we need to retrieve the proper one.
 		if fundingSchedule == nil || fundingSchedule.FundingScheduleId != toExpense.FundingScheduleId {
@@ -324,6 +378,72 @@ func (c *Controller) postSpendingTransfer(ctx *context.Context) {
 	})
 }
 
+type SpendingContribution struct {
+	Amount int64 `json:"amount"`
+}
+
+// Contribute To Goal
+// @id contribute-spending
+// @tags Spending
+// @Summary Contribute To Goal
+// @description Make a one-off contribution to a goal from the bank account's safe-to-spend balance. If the contribution brings the goal to its target amount then the goal is marked as complete and archived.
+// @security ApiKeyAuth
+// @accept json
+// @produce json
+// @Param bankAccountId path int true "Bank Account ID"
+// @Param spendingId path int true "Spending ID of the goal"
+// @Param Contribution body SpendingContribution true "Contribution"
+// @Router /bank_accounts/{bankAccountId}/spending/{spendingId}/contribute [post]
+// @Success 200 {object} swag.TransferResponse
+// @Failure 400 {object} InvalidBankAccountIdError "Invalid Bank Account ID."
+// @Failure 400 {object} ApiError "Malformed JSON or the spending object is not an incomplete goal."
+// @Failure 402 {object} SubscriptionNotActiveError The user's subscription is not active.
+// @Failure 500 {object} ApiError "Failed to persist data."
+func (c *Controller) postSpendingContribution(ctx *context.Context) {
+	bankAccountId := ctx.Params().GetUint64Default("bankAccountId", 0)
+	if bankAccountId == 0 {
+		c.returnError(ctx, http.StatusBadRequest, "must specify valid bank account Id")
+		return
+	}
+
+	spendingId := ctx.Params().GetUint64Default("spendingId", 0)
+	if spendingId == 0 {
+		c.returnError(ctx, http.StatusBadRequest, "must specify valid spending Id")
+		return
+	}
+
+	contribution := &SpendingContribution{}
+	if err := ctx.ReadJSON(contribution); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed JSON")
+		return
+	}
+
+	if contribution.Amount <= 0 {
+		c.badRequest(ctx, "contribution amount must be greater than 0")
+		return
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	goal, err := repo.GetSpendingById(c.getContext(ctx), bankAccountId, spendingId)
+	if err != nil {
+		c.wrapPgError(ctx, err, "failed to retrieve goal for contribution")
+		return
+	}
+
+	if goal.SpendingType != models.SpendingTypeGoal {
+		c.badRequest(ctx, "contributions can only be made to goals")
+		return
+	}
+
+	// A contribution is a transfer from safe-to-spend to the goal.
+	c.transferSpending(ctx, bankAccountId, SpendingTransfer{
+		FromSpendingId: nil,
+		ToSpendingId:   &spendingId,
+		Amount:         contribution.Amount,
+	})
+}
+
 // Update Spending
 // @id update-spending
 // @tags Spending
@@ -378,12 +498,19 @@ func (c *Controller) putSpending(ctx *context.Context) {
 	updatedSpending.UsedAmount = existingSpending.UsedAmount
 	updatedSpending.CurrentAmount = existingSpending.CurrentAmount
 	updatedSpending.BankAccountId = existingSpending.BankAccountId
+	updatedSpending.Currency = existingSpending.Currency
 	updatedSpending.IsBehind = existingSpending.IsBehind
 	updatedSpending.LastRecurrence = existingSpending.LastRecurrence
 	updatedSpending.NextContributionAmount = existingSpending.NextContributionAmount
+	updatedSpending.IsComplete = existingSpending.IsComplete
+	updatedSpending.IsArchived = existingSpending.IsArchived
+	updatedSpending.DateCompleted = existingSpending.DateCompleted
 
 	if updatedSpending.SpendingType == models.SpendingTypeGoal {
 		updatedSpending.RecurrenceRule = nil
+
+		// If the target of a completed goal is raised then the goal needs to start receiving contributions again.
+		updatedSpending.ResetCompletion()
 	}
 
 	recalculateSpending := false
@@ -0,0 +1,245 @@
+package controller_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kataras/iris/v12/httptest"
+	"github.com/monetr/rest-api/pkg/models"
+)
+
+func givenIHaveAManualBankAccount(t *testing.T, e *httptest.Expect, token string, balance int64) uint64 {
+	response := e.POST("/links").
+		WithHeader("M-Token", token).
+		WithJSON(models.Link{
+			InstitutionName: "U.S. Bank",
+		}).
+		Expect()
+
+	response.Status(http.StatusOK)
+	linkId := uint64(response.JSON().Path("$.linkId").Number().Raw())
+
+	return givenIHaveABankAccount(t, e, token, linkId, models.BankAccount{
+		AvailableBalance: balance,
+		CurrentBalance:   balance,
+		Name:             "Checking",
+		Type:             models.DepositoryBankAccountType,
+		SubType:          models.CheckingBankAccountSubType,
+	})
+}
+
+func givenIHaveAFundingSchedule(t *testing.T, e *httptest.Expect, token string, bankAccountId uint64) uint64 {
+	response := e.POST(fmt.Sprintf("/bank_accounts/%d/funding_schedules", bankAccountId)).
+		WithHeader("M-Token", token).
+		WithJSON(map[string]interface{}{
+			"name": "Payday",
+			"rule": "FREQ=WEEKLY;BYDAY=FR",
+		}).
+		Expect()
+
+	response.Status(http.StatusOK)
+	return uint64(response.JSON().Path("$.fundingScheduleId").Number().Gt(0).Raw())
+}
+
+func givenIHaveAGoal(t *testing.T, e *httptest.Expect, token string, bankAccountId, fundingScheduleId uint64, name string, target int64) uint64 {
+	response := e.POST(fmt.Sprintf("/bank_accounts/%d/spending", bankAccountId)).
+		WithHeader("M-Token", token).
+		WithJSON(map[string]interface{}{
+			"name":              name,
+			"fundingScheduleId": fundingScheduleId,
+			"spendingType":      models.SpendingTypeGoal,
+			"targetAmount":      target,
+			"nextRecurrence":    time.Now().AddDate(0, 2, 0),
+		}).
+		Expect()
+
+	response.Status(http.StatusOK)
+	response.JSON().Path("$.isComplete").Boolean().False()
+	return uint64(response.JSON().Path("$.spendingId").Number().Gt(0).Raw())
+}
+
+func TestPostSpendingContribution(t *testing.T) {
+	t.Run("contribute", func(t *testing.T) {
+		e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+		bankAccountId := givenIHaveAManualBankAccount(t, e, token, 50000)
+		fundingScheduleId := givenIHaveAFundingSchedule(t, e, token, bankAccountId)
+		goalId := givenIHaveAGoal(t, e, token, bankAccountId, fundingScheduleId, "Vacation", 10000)
+
+		response := e.POST(fmt.Sprintf("/bank_accounts/%d/spending/%d/contribute", bankAccountId, goalId)).
+			WithHeader("M-Token", token).
+			WithJSON(map[string]interface{}{
+				"amount": 2500,
+			}).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.spending").Array().Length().Equal(1)
+		response.JSON().Path("$.spending[0].spendingId").Number().Equal(goalId)
+		response.JSON().Path("$.spending[0].currentAmount").Number().Equal(2500)
+		response.JSON().Path("$.spending[0].isComplete").Boolean().False()
+		response.JSON().Path("$.spending[0].nextContributionAmount").Number().Gt(0)
+		response.JSON().Path("$.balance.safe").Number().Equal(47500)
+		response.JSON().Path("$.balance.goals").Number().Equal(2500)
+	})
+
+	t.Run("auto-complete", func(t *testing.T) {
+		e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+		bankAccountId := givenIHaveAManualBankAccount(t, e, token, 50000)
+		fundingScheduleId := givenIHaveAFundingSchedule(t, e, token, bankAccountId)
+		goalId := givenIHaveAGoal(t, e, token, bankAccountId, fundingScheduleId, "Vacation", 10000)
+		otherGoalId := givenIHaveAGoal(t, e, token, bankAccountId, fundingScheduleId, "New Car", 20000)
+
+		{
+			response := e.POST(fmt.Sprintf("/bank_accounts/%d/spending/%d/contribute", bankAccountId, goalId)).
+				WithHeader("M-Token", token).
+				WithJSON(map[string]interface{}{
+					"amount": 10000,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.spending[0].currentAmount").Number().Equal(10000)
+			response.JSON().Path("$.spending[0].isComplete").Boolean().True()
+			response.JSON().Path("$.spending[0].isArchived").Boolean().True()
+			response.JSON().Path("$.spending[0].dateCompleted").NotNull()
+			response.JSON().Path("$.spending[0].nextContributionAmount").Number().Equal(0)
+		}
+
+		{ // Archived goals should not be listed by default.
+			response := e.GET(fmt.Sprintf("/bank_accounts/%d/spending", bankAccountId)).
+				WithHeader("M-Token", token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().Equal(1)
+			response.JSON().Path("$[0].spendingId").Number().Equal(otherGoalId)
+		}
+
+		{ // But they can be requested.
+			response := e.GET(fmt.Sprintf("/bank_accounts/%d/spending", bankAccountId)).
+				WithHeader("M-Token", token).
+				WithQuery("archived", true).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().Equal(2)
+		}
+	})
+
+	t.Run("reject when complete", func(t *testing.T) {
+		e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+		bankAccountId := givenIHaveAManualBankAccount(t, e, token, 50000)
+		fundingScheduleId := givenIHaveAFundingSchedule(t, e, token, bankAccountId)
+		goalId := givenIHaveAGoal(t, e, token, bankAccountId, fundingScheduleId, "Vacation", 10000)
+
+		{
+			response := e.POST(fmt.Sprintf("/bank_accounts/%d/spending/%d/contribute", bankAccountId, goalId)).
+				WithHeader("M-Token", token).
+				WithJSON(map[string]interface{}{
+					"amount": 10000,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.spending[0].isComplete").Boolean().True()
+		}
+
+		{
+			response := e.POST(fmt.Sprintf("/bank_accounts/%d/spending/%d/contribute", bankAccountId, goalId)).
+				WithHeader("M-Token", token).
+				WithJSON(map[string]interface{}{
+					"amount": 100,
+				}).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().Equal("cannot transfer to a goal that has already been completed")
+		}
+
+		{ // The transfer endpoint should reject it the same way.
+			response := e.POST(fmt.Sprintf("/bank_accounts/%d/spending/transfer", bankAccountId)).
+				WithHeader("M-Token", token).
+				WithJSON(map[string]interface{}{
+					"toSpendingId": goalId,
+					"amount":       100,
+				}).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().Equal("cannot transfer to a goal that has already been completed")
+		}
+
+		{ // Moving money out of the completed goal should put it back into progress.
+			response := e.POST(fmt.Sprintf("/bank_accounts/%d/spending/transfer", bankAccountId)).
+				WithHeader("M-Token", token).
+				WithJSON(map[string]interface{}{
+					"fromSpendingId": goalId,
+					"amount":         5000,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.spending[0].currentAmount").Number().Equal(5000)
+			response.JSON().Path("$.spending[0].isComplete").Boolean().False()
+			response.JSON().Path("$.spending[0].isArchived").Boolean().False()
+			response.JSON().Path("$.spending[0].dateCompleted").Null()
+		}
+	})
+
+	t.Run("more than safe to spend", func(t *testing.T) {
+		e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+		bankAccountId := givenIHaveAManualBankAccount(t, e, token, 5000)
+		fundingScheduleId := givenIHaveAFundingSchedule(t, e, token, bankAccountId)
+		goalId := givenIHaveAGoal(t, e, token, bankAccountId, fundingScheduleId, "Vacation", 10000)
+
+		response := e.POST(fmt.Sprintf("/bank_accounts/%d/spending/%d/contribute", bankAccountId, goalId)).
+			WithHeader("M-Token", token).
+			WithJSON(map[string]interface{}{
+				"amount": 5001,
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().Equal("cannot transfer more than is available in safe to spend")
+	})
+
+	t.Run("not a goal", func(t *testing.T) {
+		e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+		bankAccountId := givenIHaveAManualBankAccount(t, e, token, 50000)
+		fundingScheduleId := givenIHaveAFundingSchedule(t, e, token, bankAccountId)
+
+		var expenseId uint64
+		{
+			response := e.POST(fmt.Sprintf("/bank_accounts/%d/spending", bankAccountId)).
+				WithHeader("M-Token", token).
+				WithJSON(map[string]interface{}{
+					"name":              "Rent",
+					"fundingScheduleId": fundingScheduleId,
+					"spendingType":      models.SpendingTypeExpense,
+					"targetAmount":      10000,
+					"recurrenceRule":    "FREQ=MONTHLY;BYMONTHDAY=1",
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			expenseId = uint64(response.JSON().Path("$.spendingId").Number().Raw())
+		}
+
+		response := e.POST(fmt.Sprintf("/bank_accounts/%d/spending/%d/contribute", bankAccountId, expenseId)).
+			WithHeader("M-Token", token).
+			WithJSON(map[string]interface{}{
+				"amount": 100,
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().Equal("contributions can only be made to goals")
+	})
+}
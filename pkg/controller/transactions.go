@@ -3,6 +3,7 @@ package controller
 import (
 	"github.com/kataras/iris/v12"
 	"github.com/kataras/iris/v12/context"
+	"github.com/monetr/rest-api/pkg/currency"
 	"github.com/monetr/rest-api/pkg/internal/myownsanity"
 	"github.com/monetr/rest-api/pkg/models"
 	"github.com/sirupsen/logrus"
@@ -189,6 +190,22 @@ func (c *Controller) postTransactions(ctx *context.Context) {
 		return
 	}
 
+	bankAccount, err := repo.GetBankAccount(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		c.wrapPgError(ctx, err, "failed to retrieve bank account for transaction")
+		return
+	}
+
+	// Transactions are always in the currency of their bank account. If the client specified a currency then it must
+	// match, otherwise the bank account's currency is used.
+	if transaction.Currency != "" {
+		if err = currency.Check(transaction.Currency, bankAccount.Currency); err != nil {
+			c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "transaction currency must match the bank account")
+			return
+		}
+	}
+	transaction.Currency = bankAccount.Currency
+
 	var updatedExpense *models.Spending
 
 	if transaction.SpendingId != nil && *transaction.SpendingId > 0 {
@@ -199,7 +216,7 @@ func (c *Controller) postTransactions(ctx *context.Context) {
 		}
 
 		if err = repo.AddExpenseToTransaction(c.getContext(ctx), &transaction, updatedExpense); err != nil {
-			c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to add expense to transaction")
+			c.wrapPgError(ctx, err, "failed to add expense to transaction")
 			return
 		}
 
@@ -282,6 +299,7 @@ func (c *Controller) putTransactions(ctx *context.Context) {
 	}
 
 	transaction.PlaidTransactionId = existingTransaction.PlaidTransactionId
+	transaction.Currency = existingTransaction.Currency
 
 	if !isManual {
 		// Prevent the user from attempting to change a transaction's amount if we are on a plaid link.
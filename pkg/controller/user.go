@@ -2,13 +2,30 @@ package controller
 
 import (
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/kataras/iris/v12/context"
 	"github.com/kataras/iris/v12/core/router"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/monetr/rest-api/pkg/sms"
+	"github.com/pkg/errors"
 )
 
 func (c *Controller) handleUsers(p router.Party) {
 	p.Get("/me", c.getMe)
+	p.Put("/me", c.updateMe)
+	p.Put("/timezone", c.updateTimezone)
+	p.Put("/security/password", c.ipRateLimitMiddleware("password"), c.changePassword)
+	p.Put("/security/email", c.ipRateLimitMiddleware("password"), c.changeEmail)
+	if c.configuration.Beta.EnableBetaCodes {
+		p.Get("/invites", c.listBetaInvites)
+		p.Post("/invites", c.createBetaInvite)
+	}
+	if c.phoneVerification != nil {
+		p.Post("/phone/send", c.sendPhoneVerification)
+		p.Post("/phone/verify", c.verifyPhoneNumber)
+	}
 }
 
 func (c *Controller) getMe(ctx *context.Context) {
@@ -30,11 +47,18 @@ func (c *Controller) getMe(ctx *context.Context) {
 		return
 	}
 
+	features, err := c.getAccountFeatures(ctx)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "could not retrieve account features")
+		return
+	}
+
 	if !c.configuration.Stripe.IsBillingEnabled() {
 		ctx.JSON(map[string]interface{}{
 			"user":     user,
 			"isSetup":  isSetup,
 			"isActive": true,
+			"features": features,
 		})
 		return
 	}
@@ -51,6 +75,27 @@ func (c *Controller) getMe(ctx *context.Context) {
 			"isSetup":  isSetup,
 			"isActive": subscriptionIsActive,
 			"nextUrl":  "/account/subscribe",
+			"features": features,
+		})
+		return
+	}
+
+	account, err := c.accounts.GetAccount(c.getContext(ctx), user.AccountId)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "could not retrieve account details")
+		return
+	}
+
+	// If a payment has failed then the account can still be used for a little while, but the user should be warned
+	// that they need to update their payment method.
+	if account.PaymentFailedAt != nil {
+		ctx.JSON(map[string]interface{}{
+			"user":              user,
+			"isSetup":           isSetup,
+			"isActive":          subscriptionIsActive,
+			"features":          features,
+			"paymentFailed":     true,
+			"gracePeriodEndsAt": account.GetGracePeriodEndsAt(c.configuration.Stripe.GracePeriod),
 		})
 		return
 	}
@@ -59,5 +104,239 @@ func (c *Controller) getMe(ctx *context.Context) {
 		"user":     user,
 		"isSetup":  isSetup,
 		"isActive": subscriptionIsActive,
+		"features": features,
+	})
+}
+
+// Update Me
+// @Summary Update Me
+// @id update-me
+// @tags User
+// @description Updates the current user's name.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param Request body swag.UpdateMeRequest true "Update user request."
+// @Router /users/me [put]
+// @Success 200 {object} models.User
+// @Failure 400 {object} ApiError The first name is missing.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) updateMe(ctx *context.Context) {
+	var request struct {
+		FirstName string `json:"firstName"`
+		LastName  string `json:"lastName"`
+	}
+	if err := ctx.ReadJSON(&request); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed json")
+		return
+	}
+
+	request.FirstName = strings.TrimSpace(request.FirstName)
+	request.LastName = strings.TrimSpace(request.LastName)
+
+	if request.FirstName == "" {
+		c.badRequest(ctx, "first name cannot be blank")
+		return
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	user, err := repo.GetMe(c.getContext(ctx))
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "cannot retrieve user details")
+		return
+	}
+
+	user.FirstName = request.FirstName
+	user.LastName = request.LastName
+
+	if err = repo.UpdateUser(c.getContext(ctx), user); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to update user")
+		return
+	}
+
+	ctx.JSON(user)
+}
+
+// Update Timezone
+// @Summary Update Timezone
+// @id update-timezone
+// @tags User
+// @description Updates the timezone of the current account. Funding schedules and spending objects are due at midnight
+// @description in the account's timezone, so a job is started to move them into the new timezone and recalculate
+// @description contributions. The ID of that job is returned and can be used to check its status.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param Request body swag.UpdateTimezoneRequest true "Update timezone request."
+// @Router /users/timezone [put]
+// @Success 200 {object} swag.UpdateTimezoneResponse
+// @Failure 400 {object} ApiError The timezone is not valid.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) updateTimezone(ctx *context.Context) {
+	var request struct {
+		Timezone string `json:"timezone"`
+	}
+	if err := ctx.ReadJSON(&request); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed json")
+		return
+	}
+
+	request.Timezone = strings.TrimSpace(request.Timezone)
+	if request.Timezone == "" {
+		c.badRequest(ctx, "timezone is required")
+		return
+	}
+
+	timezone, err := time.LoadLocation(request.Timezone)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "invalid timezone")
+		return
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	account, err := repo.GetAccount(c.getContext(ctx))
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to retrieve account")
+		return
+	}
+
+	previousTimezone := account.Timezone
+	if previousTimezone == timezone.String() {
+		ctx.JSON(map[string]interface{}{
+			"account": account,
+		})
+		return
+	}
+
+	account.Timezone = timezone.String()
+	if err = c.accounts.UpdateAccount(c.getContext(ctx), account); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to update account timezone")
+		return
+	}
+
+	jobId, err := c.job.TriggerRecalculateTimezone(account.AccountId, repo.UserId(), previousTimezone)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to recalculate for new timezone")
+		return
+	}
+
+	result := map[string]interface{}{
+		"account": account,
+	}
+
+	// The recalculation may already be queued from a previous change, in which case there is no new job.
+	if jobId != "" {
+		result["jobId"] = jobId
+	}
+
+	ctx.JSON(result)
+}
+
+// Send Phone Verification
+// @Summary Send Phone Verification
+// @id send-phone-verification
+// @tags User
+// @description Sends a 6 digit verification code to the provided phone number over SMS. The code must then be provided
+// @description to the verify endpoint to verify the phone number. Sending a new code replaces any code that was sent
+// @description before.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param Request body swag.SendPhoneVerificationRequest true "Send phone verification request."
+// @Router /users/phone/send [post]
+// @Success 200
+// @Failure 400 {object} ApiError The phone number is not valid.
+// @Failure 429 {object} ApiError A code was sent too recently, the Retry-After header indicates when to try again.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) sendPhoneVerification(ctx *context.Context) {
+	var request struct {
+		PhoneNumber string `json:"phoneNumber"`
+	}
+	if err := ctx.ReadJSON(&request); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed json")
+		return
+	}
+
+	phoneNumber, err := models.ParsePhoneNumber(strings.TrimSpace(request.PhoneNumber))
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "invalid phone number")
+		return
+	}
+
+	retryAfter, err := c.phoneVerification.SendCode(c.getContext(ctx), c.mustGetLoginId(ctx), phoneNumber.E164())
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to send verification code")
+		return
+	}
+
+	if retryAfter > 0 {
+		c.tooManyRequests(ctx, retryAfter, "a verification code was sent recently, please wait before requesting another")
+		return
+	}
+
+	ctx.StatusCode(http.StatusOK)
+}
+
+// Verify Phone Number
+// @Summary Verify Phone Number
+// @id verify-phone-number
+// @tags User
+// @description Verifies the code that was sent to the user's phone number. If the code is correct then the phone number
+// @description is stored on the user's login and marked as verified. Only a few incorrect codes can be provided before
+// @description a new code must be requested.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param Request body swag.VerifyPhoneNumberRequest true "Verify phone number request."
+// @Router /users/phone/verify [post]
+// @Success 200 {object} swag.VerifyPhoneNumberResponse
+// @Failure 400 {object} ApiError The code is not valid or has expired.
+// @Failure 429 {object} ApiError Too many incorrect codes were provided, a new code must be requested.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) verifyPhoneNumber(ctx *context.Context) {
+	var request struct {
+		Code string `json:"code"`
+	}
+	if err := ctx.ReadJSON(&request); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed json")
+		return
+	}
+
+	request.Code = strings.TrimSpace(request.Code)
+	if request.Code == "" {
+		c.badRequest(ctx, "verification code is required")
+		return
+	}
+
+	e164, err := c.phoneVerification.VerifyCode(c.getContext(ctx), c.mustGetLoginId(ctx), request.Code)
+	if err != nil {
+		switch errors.Cause(err) {
+		case sms.ErrInvalidCode, sms.ErrNoPendingVerification:
+			c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "failed to verify phone number")
+		case sms.ErrTooManyAttempts:
+			c.wrapAndReturnError(ctx, err, http.StatusTooManyRequests, "failed to verify phone number")
+		default:
+			c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to verify phone number")
+		}
+		return
+	}
+
+	phoneNumber, err := models.ParsePhoneNumber(e164)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to parse verified phone number")
+		return
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	if err = repo.SetLoginPhoneNumberVerified(c.getContext(ctx), phoneNumber); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to update phone number")
+		return
+	}
+
+	ctx.JSON(map[string]interface{}{
+		"phoneNumber":     phoneNumber,
+		"isPhoneVerified": true,
 	})
 }
@@ -0,0 +1,401 @@
+package controller
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/gomodule/redigo/redis"
+	"github.com/kataras/iris/v12"
+	"github.com/monetr/rest-api/pkg/communication"
+	"github.com/monetr/rest-api/pkg/hash"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/pkg/errors"
+)
+
+// emailChangeLifetime is how long the user has to click the link sent to their new email address before they need to
+// request the change again.
+const emailChangeLifetime = 24 * time.Hour
+
+type emailChange struct {
+	LoginId uint64 `json:"loginId"`
+	Email   string `json:"email"`
+}
+
+func (c *Controller) getEmailChangeKey(token string) string {
+	return fmt.Sprintf("email:change:%s", token)
+}
+
+// getLoginWithHash will retrieve the current user's login along with their password hash. This should only be used
+// when the user needs to confirm their current password.
+func (c *Controller) getLoginWithHash(ctx iris.Context) (*models.LoginWithHash, error) {
+	var login models.LoginWithHash
+	if err := c.mustGetDatabase(ctx).ModelContext(c.getContext(ctx), &login).
+		Where(`"login_with_hash"."login_id" = ?`, c.mustGetLoginId(ctx)).
+		Limit(1).
+		Select(&login); err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve login")
+	}
+
+	return &login, nil
+}
+
+// checkCurrentPassword will verify the password provided by the client against the password hash of the current login.
+// If the password is not correct then an error will be returned to the client and false is returned.
+func (c *Controller) checkCurrentPassword(ctx iris.Context, login *models.LoginWithHash, password string) bool {
+	valid, _, err := hash.VerifyPassword(login.Email, password, login.PasswordHash)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to verify current password")
+		return false
+	}
+
+	if !valid {
+		c.returnError(ctx, http.StatusForbidden, "current password is not correct")
+		return false
+	}
+
+	return true
+}
+
+// Change Password
+// @Summary Change Password
+// @id change-password
+// @tags User
+// @description Changes the password of the current user's login. The current password must be provided.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param Request body swag.ChangePasswordRequest true "Change password request."
+// @Router /users/security/password [put]
+// @Success 200
+// @Failure 400 {object} ApiError The new password is not valid.
+// @Failure 403 {object} ApiError The current password is not correct.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) changePassword(ctx iris.Context) {
+	var request struct {
+		CurrentPassword string `json:"currentPassword"`
+		NewPassword     string `json:"newPassword"`
+	}
+	if err := ctx.ReadJSON(&request); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed json")
+		return
+	}
+
+	request.CurrentPassword = strings.TrimSpace(request.CurrentPassword)
+	request.NewPassword = strings.TrimSpace(request.NewPassword)
+
+	if len(request.NewPassword) < 8 {
+		c.badRequest(ctx, "password must be at least 8 characters")
+		return
+	}
+
+	login, err := c.getLoginWithHash(ctx)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to change password")
+		return
+	}
+
+	if !c.checkCurrentPassword(ctx, login, request.CurrentPassword) {
+		return
+	}
+
+	passwordHash, err := hash.NewPasswordHash(request.NewPassword)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to hash password")
+		return
+	}
+
+	if _, err = c.mustGetDatabase(ctx).ModelContext(c.getContext(ctx), login).
+		Set(`"password_hash" = ?`, passwordHash).
+		WherePK().
+		Update(); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to change password")
+		return
+	}
+
+	ctx.StatusCode(http.StatusOK)
+}
+
+// Change Email
+// @Summary Change Email
+// @id change-email
+// @tags User
+// @description Changes the email address of the current user's login. The current password must be provided. If email
+// @description verification is enabled then the email will not be changed until the user clicks the link that is sent
+// @description to the new email address.
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param Request body swag.ChangeEmailRequest true "Change email request."
+// @Router /users/security/email [put]
+// @Success 200 {object} swag.ChangeEmailResponse
+// @Failure 400 {object} ApiError The new email is not valid or is already in use.
+// @Failure 403 {object} ApiError The current password is not correct.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) changeEmail(ctx iris.Context) {
+	var request struct {
+		Email           string `json:"email"`
+		CurrentPassword string `json:"currentPassword"`
+	}
+	if err := ctx.ReadJSON(&request); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed json")
+		return
+	}
+
+	request.Email = strings.ToLower(strings.TrimSpace(request.Email))
+	request.CurrentPassword = strings.TrimSpace(request.CurrentPassword)
+
+	if address, err := mail.ParseAddress(request.Email); err != nil || address.Address != request.Email {
+		c.badRequest(ctx, "email is not valid")
+		return
+	}
+
+	login, err := c.getLoginWithHash(ctx)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to change email")
+		return
+	}
+
+	if !c.checkCurrentPassword(ctx, login, request.CurrentPassword) {
+		return
+	}
+
+	if login.Email == request.Email {
+		c.badRequest(ctx, "new email must be different from the current email")
+		return
+	}
+
+	inUse, err := c.mustGetDatabase(ctx).ModelContext(c.getContext(ctx), &models.Login{}).
+		Where(`"login"."email" = ?`, request.Email).
+		Exists()
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to verify email is not in use")
+		return
+	}
+
+	if inUse {
+		c.badRequest(ctx, "email is already in use")
+		return
+	}
+
+	// Legacy password hashes are derived from the email address, so they would no longer be valid once the email is
+	// changed. The current password has already been verified, so we can replace the hash with one that does not
+	// depend on the email.
+	var passwordHash *string
+	if hash.IsLegacyPasswordHash(login.PasswordHash) {
+		newHash, err := hash.NewPasswordHash(request.CurrentPassword)
+		if err != nil {
+			c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to hash password")
+			return
+		}
+
+		passwordHash = &newHash
+	}
+
+	if !c.configuration.EMail.ShouldVerifyEmails() || c.communication == nil {
+		if err = c.updateLoginEmail(ctx, c.mustGetDatabase(ctx), login.LoginId, request.Email, false, passwordHash); err != nil {
+			c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to change email")
+			return
+		}
+
+		ctx.JSON(map[string]interface{}{
+			"verificationRequired": false,
+		})
+		return
+	}
+
+	// The new hash is not tied to either email, so it can be stored now. This way the email change can be verified
+	// later without the password.
+	if passwordHash != nil {
+		if _, err = c.mustGetDatabase(ctx).ModelContext(c.getContext(ctx), login).
+			Set(`"password_hash" = ?`, *passwordHash).
+			WherePK().
+			Update(); err != nil {
+			c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to update password hash")
+			return
+		}
+	}
+
+	token, err := c.createEmailChange(ctx, login.LoginId, request.Email)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create email verification")
+		return
+	}
+
+	// The verification email is sent to the new address, so that we know the user actually has access to it.
+	recipient := login.Login
+	recipient.Email = request.Email
+	if err = c.communication.SendVerificationEmail(c.getContext(ctx), communication.VerifyEmailParams{
+		Login:     recipient,
+		VerifyURL: fmt.Sprintf("https://%s/account/verify/email?token=%s", c.configuration.UIDomainName, url.QueryEscape(token)),
+	}); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to send verification email")
+		return
+	}
+
+	ctx.JSON(map[string]interface{}{
+		"verificationRequired": true,
+	})
+}
+
+// Verify Email Change
+// @Summary Verify Email Change
+// @id verify-email-change
+// @tags Authentication
+// @description Completes an email change using the token that was sent to the new email address. Once verified the
+// @description login's email is updated and the new email must be used to log in.
+// @Accept json
+// @Produce json
+// @Param Request body swag.VerifyEmailChangeRequest true "Verify email change request."
+// @Router /authentication/verify/email [post]
+// @Success 200
+// @Failure 400 {object} ApiError The token is not valid, has expired, the email is now in use, or the change must be requested again.
+// @Failure 500 {object} ApiError Something went wrong on our end.
+func (c *Controller) verifyEmailChange(ctx iris.Context) {
+	var request struct {
+		Token string `json:"token"`
+	}
+	if err := ctx.ReadJSON(&request); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed json")
+		return
+	}
+
+	request.Token = strings.TrimSpace(request.Token)
+	if request.Token == "" {
+		c.badRequest(ctx, "token is required")
+		return
+	}
+
+	change, err := c.consumeEmailChange(ctx, request.Token)
+	if err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to verify email")
+		return
+	}
+
+	if change == nil {
+		c.badRequest(ctx, "token is not valid or has expired")
+		return
+	}
+
+	var login models.LoginWithHash
+	if err = c.mustGetDatabase(ctx).ModelContext(c.getContext(ctx), &login).
+		Where(`"login_with_hash"."login_id" = ?`, change.LoginId).
+		Limit(1).
+		Select(&login); err != nil {
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to retrieve login")
+		return
+	}
+
+	// Legacy hashes are replaced when the change is requested. But a change requested before that was done could still
+	// have a legacy hash, and changing the email would leave the login unable to authenticate.
+	if hash.IsLegacyPasswordHash(login.PasswordHash) {
+		c.badRequest(ctx, "email change must be requested again")
+		return
+	}
+
+	if err = c.updateLoginEmail(ctx, c.mustGetDatabase(ctx), change.LoginId, change.Email, true, nil); err != nil {
+		if pgErr, ok := errors.Cause(err).(pg.Error); ok && pgErr.IntegrityViolation() {
+			c.badRequest(ctx, "email is already in use")
+			return
+		}
+
+		c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to verify email")
+		return
+	}
+
+	ctx.StatusCode(http.StatusOK)
+}
+
+// updateLoginEmail will change the email of the specified login. If a password hash is provided then it is stored along
+// with the new email, this is used to replace legacy password hashes that are derived from the old email.
+func (c *Controller) updateLoginEmail(
+	ctx iris.Context,
+	db pg.DBI,
+	loginId uint64,
+	email string,
+	verified bool,
+	passwordHash *string,
+) error {
+	query := db.ModelContext(c.getContext(ctx), &models.Login{}).
+		Set(`"email" = ?`, email).
+		Set(`"is_email_verified" = ?`, verified).
+		Where(`"login"."login_id" = ?`, loginId)
+	if passwordHash != nil {
+		query = query.Set(`"password_hash" = ?`, *passwordHash)
+	}
+
+	result, err := query.Update()
+	if err != nil {
+		return errors.Wrap(err, "failed to update login email")
+	}
+
+	if affected := result.RowsAffected(); affected != 1 {
+		return errors.Errorf("invalid number of login(s) updated; expected: 1 updated: %d", affected)
+	}
+
+	return nil
+}
+
+// createEmailChange will store the pending email change and return the token that must be provided to complete it.
+func (c *Controller) createEmailChange(ctx iris.Context, loginId uint64, email string) (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", errors.Wrap(err, "failed to generate token")
+	}
+	token := hex.EncodeToString(random)
+
+	data, err := json.Marshal(emailChange{
+		LoginId: loginId,
+		Email:   email,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode email change")
+	}
+
+	cache, err := c.cache.GetContext(c.getContext(ctx))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get cache connection")
+	}
+	defer cache.Close()
+
+	if _, err = cache.Do("SET", c.getEmailChangeKey(token), data, "EX", int64(emailChangeLifetime.Seconds())); err != nil {
+		return "", errors.Wrap(err, "failed to store email change")
+	}
+
+	return token, nil
+}
+
+// consumeEmailChange will retrieve the pending email change for the provided token. The change is removed so that the
+// token cannot be used again. If there is no pending change for the token then nil is returned.
+func (c *Controller) consumeEmailChange(ctx iris.Context, token string) (*emailChange, error) {
+	cache, err := c.cache.GetContext(c.getContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cache connection")
+	}
+	defer cache.Close()
+
+	data, err := redis.Bytes(cache.Do("GET", c.getEmailChangeKey(token)))
+	if err == redis.ErrNil {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve email change")
+	}
+
+	if _, err = cache.Do("DEL", c.getEmailChangeKey(token)); err != nil {
+		return nil, errors.Wrap(err, "failed to remove email change")
+	}
+
+	var result emailChange
+	if err = json.Unmarshal(data, &result); err != nil {
+		return nil, errors.Wrap(err, "failed to decode email change")
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,271 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/monetr/rest-api/pkg/feature"
+	"github.com/monetr/rest-api/pkg/hash"
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateMe(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+
+		response := e.PUT("/users/me").
+			WithHeader("M-Token", token).
+			WithJSON(map[string]interface{}{
+				"firstName": "Elliot",
+				"lastName":  "Courant",
+			}).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.firstName").String().Equal("Elliot")
+		response.JSON().Path("$.lastName").String().Equal("Courant")
+	})
+
+	t.Run("blank first name", func(t *testing.T) {
+		e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+
+		response := e.PUT("/users/me").
+			WithHeader("M-Token", token).
+			WithJSON(map[string]interface{}{
+				"firstName": "   ",
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().Equal("first name cannot be blank")
+	})
+}
+
+func TestChangePassword(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		e := NewTestApplication(t)
+		email, password, token := register(t, e)
+		newPassword := gofakeit.Password(true, true, true, true, false, 32)
+
+		response := e.PUT("/users/security/password").
+			WithHeader("M-Token", token).
+			WithJSON(map[string]interface{}{
+				"currentPassword": password,
+				"newPassword":     newPassword,
+			}).
+			Expect()
+		response.Status(http.StatusOK)
+
+		// The old password should no longer work, but the new one should.
+		e.POST("/authentication/login").
+			WithJSON(map[string]interface{}{
+				"email":    email,
+				"password": password,
+			}).
+			Expect().
+			Status(http.StatusForbidden)
+
+		e.POST("/authentication/login").
+			WithJSON(map[string]interface{}{
+				"email":    email,
+				"password": newPassword,
+			}).
+			Expect().
+			Status(http.StatusOK)
+	})
+
+	t.Run("wrong current password", func(t *testing.T) {
+		e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+
+		response := e.PUT("/users/security/password").
+			WithHeader("M-Token", token).
+			WithJSON(map[string]interface{}{
+				"currentPassword": "notMyPassword",
+				"newPassword":     "aBrandNewPassword",
+			}).
+			Expect()
+
+		response.Status(http.StatusForbidden)
+		response.JSON().Path("$.error").String().Equal("current password is not correct")
+	})
+}
+
+func TestChangeEmail(t *testing.T) {
+	t.Run("without verification", func(t *testing.T) {
+		e := NewTestApplication(t)
+		_, password, token := register(t, e)
+		newEmail := testutils.GivenIHaveAnEmail(t)
+
+		response := e.PUT("/users/security/email").
+			WithHeader("M-Token", token).
+			WithJSON(map[string]interface{}{
+				"email":           newEmail,
+				"currentPassword": password,
+			}).
+			Expect()
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.verificationRequired").Boolean().False()
+
+		e.POST("/authentication/login").
+			WithJSON(map[string]interface{}{
+				"email":    newEmail,
+				"password": password,
+			}).
+			Expect().
+			Status(http.StatusOK)
+	})
+
+	t.Run("legacy password hash", func(t *testing.T) {
+		e := NewTestApplication(t)
+		email, password, token := register(t, e)
+		newEmail := testutils.GivenIHaveAnEmail(t)
+
+		// Logins created before passwords were salted have a hash that is derived from their email.
+		db := testutils.GetPgDatabase(t)
+		_, err := db.Model(&models.LoginWithHash{}).
+			Set(`"password_hash" = ?`, hash.HashPassword(email, password)).
+			Where(`"login_with_hash"."email" = ?`, email).
+			Update()
+		require.NoError(t, err, "must set legacy password hash")
+
+		response := e.PUT("/users/security/email").
+			WithHeader("M-Token", token).
+			WithJSON(map[string]interface{}{
+				"email":           newEmail,
+				"currentPassword": password,
+			}).
+			Expect()
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.verificationRequired").Boolean().False()
+
+		var login models.LoginWithHash
+		require.NoError(t, db.Model(&login).
+			Where(`"login_with_hash"."email" = ?`, newEmail).
+			Limit(1).
+			Select(&login), "must retrieve login")
+		assert.False(t, hash.IsLegacyPasswordHash(login.PasswordHash), "legacy hash should have been replaced")
+
+		e.POST("/authentication/login").
+			WithJSON(map[string]interface{}{
+				"email":    newEmail,
+				"password": password,
+			}).
+			Expect().
+			Status(http.StatusOK)
+	})
+
+	t.Run("email in use", func(t *testing.T) {
+		e := NewTestApplication(t)
+		otherEmail, _ := GivenIHaveLogin(t, e)
+		_, password, token := register(t, e)
+
+		response := e.PUT("/users/security/email").
+			WithHeader("M-Token", token).
+			WithJSON(map[string]interface{}{
+				"email":           otherEmail,
+				"currentPassword": password,
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().Equal("email is already in use")
+	})
+}
+
+func TestUpdateTimezone(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+
+		response := e.PUT("/users/timezone").
+			WithHeader("M-Token", token).
+			WithJSON(map[string]interface{}{
+				"timezone": "America/Chicago",
+			}).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.account.timezone").String().Equal("America/Chicago")
+		response.JSON().Path("$.jobId").String().NotEmpty()
+	})
+
+	t.Run("invalid timezone", func(t *testing.T) {
+		e := NewTestApplication(t)
+		token := GivenIHaveToken(t, e)
+
+		response := e.PUT("/users/timezone").
+			WithHeader("M-Token", token).
+			WithJSON(map[string]interface{}{
+				"timezone": "Not/A_Timezone",
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().Contains("invalid timezone")
+	})
+}
+
+func TestGetMe(t *testing.T) {
+	t.Run("feature overrides without billing", func(t *testing.T) {
+		adminEmail := testutils.GivenIHaveAnEmail(t)
+		configuration := NewTestApplicationConfig(t)
+		configuration.Admin.Enabled = true
+		configuration.Admin.Emails = []string{adminEmail}
+		require.False(t, configuration.Stripe.IsBillingEnabled(), "billing must not be enabled for this test")
+		e := NewTestApplicationWithConfig(t, configuration)
+		_, _, adminToken := registerWithEmail(t, e, adminEmail)
+		token := GivenIHaveToken(t, e)
+
+		var accountId uint64
+		{ // Without billing, every feature is available by default.
+			response := e.GET("/users/me").
+				WithHeader("M-Token", token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.features").Array().Length().Equal(len(feature.All()))
+			accountId = uint64(response.JSON().Path("$.user.accountId").Number().Gt(0).Raw())
+		}
+
+		{ // An admin revokes manual budgeting for the account.
+			response := e.PUT("/admin/accounts/{accountId}/features/{feature}", accountId, feature.FeatureManualBudgeting).
+				WithHeader("M-Token", adminToken).
+				WithJSON(map[string]interface{}{
+					"enabled": false,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+		}
+
+		{ // The revoked feature should no longer be included.
+			response := e.GET("/users/me").
+				WithHeader("M-Token", token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.features").Array().Equal([]string{
+				string(feature.FeatureLinkedBudgeting),
+			})
+		}
+
+		{ // And endpoints that require it should be rejected.
+			response := e.POST("/links").
+				WithHeader("M-Token", token).
+				WithJSON(map[string]interface{}{
+					"institutionName": "Manual",
+				}).
+				Expect()
+
+			response.Status(http.StatusForbidden)
+			response.JSON().Path("$.error").String().Equal("your plan does not include this feature")
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package currency
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Default is the currency that is assumed when an institution does not provide one. Everything that was stored before
+// monetr kept track of currencies is also in this currency.
+const Default = "USD"
+
+// ErrMismatch is returned when an operation would combine amounts that are in different currencies. monetr does not
+// convert between currencies, so these operations are rejected.
+var ErrMismatch = errors.New("currencies do not match")
+
+// minorUnits is the number of decimal places for currencies that do not use 2. Any currency that is not listed here
+// is assumed to use 2 decimal places. Based on ISO 4217.
+var minorUnits = map[string]int{
+	"BIF": 0,
+	"CLP": 0,
+	"DJF": 0,
+	"GNF": 0,
+	"ISK": 0,
+	"JPY": 0,
+	"KMF": 0,
+	"KRW": 0,
+	"PYG": 0,
+	"RWF": 0,
+	"UGX": 0,
+	"UYI": 0,
+	"VND": 0,
+	"VUV": 0,
+	"XAF": 0,
+	"XOF": 0,
+	"XPF": 0,
+	"BHD": 3,
+	"IQD": 3,
+	"JOD": 3,
+	"KWD": 3,
+	"LYD": 3,
+	"OMR": 3,
+	"TND": 3,
+	"CLF": 4,
+	"UYW": 4,
+}
+
+// Normalize will return the currency code in upper case. If the provided code is blank then the Default currency is
+// returned.
+func Normalize(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return Default
+	}
+
+	return code
+}
+
+// IsValid returns true if the provided code looks like an ISO 4217 currency code, three letters.
+func IsValid(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Resolve will return the currency code that should be stored for an amount from an institution. The ISO currency
+// code is preferred, but some currencies (like cryptocurrencies) only have an unofficial code. If neither is present
+// then the Default currency is returned.
+func Resolve(isoCurrencyCode, unofficialCurrencyCode string) string {
+	if code := strings.TrimSpace(isoCurrencyCode); code != "" {
+		return Normalize(code)
+	}
+
+	return Normalize(unofficialCurrencyCode)
+}
+
+// MinorUnits returns the number of decimal places used by the provided currency.
+func MinorUnits(code string) int {
+	if units, ok := minorUnits[Normalize(code)]; ok {
+		return units
+	}
+
+	return 2
+}
+
+// ToMinorUnits converts an amount from an institution into the smallest unit of the provided currency. For USD this
+// is cents, for JPY this is whole yen. The amount is rounded to avoid floating point truncation, 10.29 * 100 would
+// otherwise be stored as 1028.
+func ToMinorUnits(amount float64, code string) int64 {
+	return int64(math.Round(amount * math.Pow10(MinorUnits(code))))
+}
+
+// Format returns a human readable representation of an amount that is stored in the smallest unit of the provided
+// currency, like 10.29 USD or 1500 JPY. This is intended for things like emails where the client cannot format the
+// amount itself.
+func Format(amount int64, code string) string {
+	code = Normalize(code)
+	units := MinorUnits(code)
+	if units == 0 {
+		return fmt.Sprintf("%d %s", amount, code)
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	divisor := int64(math.Pow10(units))
+
+	return fmt.Sprintf("%s%d.%0*d %s", sign, amount/divisor, units, amount%divisor, code)
+}
+
+// Check returns ErrMismatch if the two currencies are not the same.
+func Check(a, b string) error {
+	if Normalize(a) != Normalize(b) {
+		return errors.Wrapf(ErrMismatch, "%s and %s cannot be combined", Normalize(a), Normalize(b))
+	}
+
+	return nil
+}
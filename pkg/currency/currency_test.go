@@ -0,0 +1,47 @@
+package currency
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	assert.Equal(t, "CAD", Resolve("CAD", ""), "should use the ISO currency code")
+	assert.Equal(t, "EUR", Resolve("eur", "USD"), "should prefer the ISO currency code")
+	assert.Equal(t, "BTC", Resolve("", "BTC"), "should fall back to the unofficial currency code")
+	assert.Equal(t, Default, Resolve("", ""), "should fall back to the default currency")
+}
+
+func TestIsValid(t *testing.T) {
+	assert.True(t, IsValid("USD"), "USD should be valid")
+	assert.False(t, IsValid("usd"), "lower case codes should not be valid")
+	assert.False(t, IsValid("US"), "two letter codes should not be valid")
+	assert.False(t, IsValid("U5D"), "codes with numbers should not be valid")
+}
+
+func TestToMinorUnits(t *testing.T) {
+	assert.Equal(t, int64(1029), ToMinorUnits(10.29, "USD"), "USD should have 2 decimal places")
+	assert.Equal(t, int64(-1500), ToMinorUnits(-15, "EUR"), "EUR should have 2 decimal places")
+	assert.Equal(t, int64(1500), ToMinorUnits(1500, "JPY"), "JPY should not have any decimal places")
+	assert.Equal(t, int64(1500), ToMinorUnits(1.5, "KWD"), "KWD should have 3 decimal places")
+	assert.Equal(t, int64(150), ToMinorUnits(1.5, ""), "blank currency should use the default")
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "10.29 USD", Format(1029, "USD"), "USD should have 2 decimal places")
+	assert.Equal(t, "0.05 USD", Format(5, ""), "blank currency should use the default")
+	assert.Equal(t, "-15.00 EUR", Format(-1500, "eur"), "negative amounts should keep their sign")
+	assert.Equal(t, "1500 JPY", Format(1500, "JPY"), "JPY should not have any decimal places")
+	assert.Equal(t, "1.500 KWD", Format(1500, "KWD"), "KWD should have 3 decimal places")
+}
+
+func TestCheck(t *testing.T) {
+	assert.NoError(t, Check("USD", "usd"), "same currency should not fail")
+	assert.NoError(t, Check("", "USD"), "blank currency should be treated as the default")
+
+	err := Check("USD", "JPY")
+	assert.EqualError(t, err, "USD and JPY cannot be combined: currencies do not match")
+	assert.Equal(t, ErrMismatch, errors.Cause(err), "error should be a mismatch")
+}
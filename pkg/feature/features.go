@@ -6,3 +6,22 @@ const (
 	FeatureManualBudgeting Feature = "ManualBudgeting"
 	FeatureLinkedBudgeting Feature = "LinkedBudgeting"
 )
+
+// All returns every feature that an account can be entitled to.
+func All() []Feature {
+	return []Feature{
+		FeatureManualBudgeting,
+		FeatureLinkedBudgeting,
+	}
+}
+
+// IsValid returns true if the feature is one that monetr knows about.
+func (f Feature) IsValid() bool {
+	for _, item := range All() {
+		if item == f {
+			return true
+		}
+	}
+
+	return false
+}
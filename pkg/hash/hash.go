@@ -33,6 +33,10 @@ func HashEmail(email string) string {
 // HashPassword will return a one way hash of the provided user's credentials.
 // The email is always converted to lowercase for this hash but the password is
 // not modified.
+//
+// This hash is not salted and should not be used to store new passwords, use
+// NewPasswordHash instead. It is kept so that legacy password hashes can be
+// verified, and for values like beta codes that must be looked up by their hash.
 func HashPassword(email, password string) string {
 	email = strings.ToLower(email)
 	hash := sha256.New()
@@ -1,12 +1,14 @@
 package hash
 
 import (
+	"encoding/base64"
 	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/brianvoe/gofakeit/v6"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/argon2"
 )
 
 func TestHashEmail(t *testing.T) {
@@ -51,3 +53,61 @@ func TestHashEmail(t *testing.T) {
 	t.Run("1000", testEmails(1000))
 	t.Run("10000", testEmails(10000))
 }
+
+func TestVerifyPassword(t *testing.T) {
+	t.Run("argon2id", func(t *testing.T) {
+		email, password := gofakeit.Email(), gofakeit.Password(true, true, true, true, false, 16)
+		passwordHash, err := NewPasswordHash(password)
+		assert.NoError(t, err, "must be able to hash password")
+		assert.True(t, strings.HasPrefix(passwordHash, "$argon2id$v=19$"), "hash must be versioned")
+		assert.False(t, IsLegacyPasswordHash(passwordHash), "hash must not be a legacy hash")
+
+		otherHash, err := NewPasswordHash(password)
+		assert.NoError(t, err, "must be able to hash password")
+		assert.NotEqual(t, passwordHash, otherHash, "hashes of the same password must be salted")
+
+		valid, needsRehash, err := VerifyPassword(email, password, passwordHash)
+		assert.NoError(t, err, "must verify password")
+		assert.True(t, valid, "password must be valid")
+		assert.False(t, needsRehash, "current hash should not need to be rehashed")
+
+		valid, needsRehash, err = VerifyPassword(email, password+"bad", passwordHash)
+		assert.NoError(t, err, "must verify password")
+		assert.False(t, valid, "password must not be valid")
+		assert.False(t, needsRehash, "invalid password should not be rehashed")
+	})
+
+	t.Run("legacy", func(t *testing.T) {
+		email, password := gofakeit.Email(), gofakeit.Password(true, true, true, true, false, 16)
+		legacyHash := HashPassword(email, password)
+		assert.True(t, IsLegacyPasswordHash(legacyHash), "hash must be a legacy hash")
+
+		valid, needsRehash, err := VerifyPassword(strings.ToUpper(email), password, legacyHash)
+		assert.NoError(t, err, "must verify password")
+		assert.True(t, valid, "password must be valid")
+		assert.True(t, needsRehash, "legacy hash must be rehashed")
+
+		valid, needsRehash, err = VerifyPassword(email, password+"bad", legacyHash)
+		assert.NoError(t, err, "must verify password")
+		assert.False(t, valid, "password must not be valid")
+		assert.False(t, needsRehash, "invalid password should not be rehashed")
+	})
+
+	t.Run("outdated parameters", func(t *testing.T) {
+		passwordHash := "$argon2id$v=19$m=16384,t=2,p=1$c29tZXNhbHRzb21lc2FsdA$"
+		salt := []byte("somesaltsomesalt")
+		key := argon2.IDKey([]byte("password"), salt, 2, 16384, 1, 32)
+		passwordHash += base64.RawStdEncoding.EncodeToString(key)
+
+		valid, needsRehash, err := VerifyPassword("", "password", passwordHash)
+		assert.NoError(t, err, "must verify password")
+		assert.True(t, valid, "password must be valid")
+		assert.True(t, needsRehash, "outdated parameters must be rehashed")
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		valid, _, err := VerifyPassword("", "password", "$argon2id$v=19$nope")
+		assert.ErrorIs(t, err, ErrInvalidPasswordHash)
+		assert.False(t, valid, "malformed hash must not be valid")
+	})
+}
@@ -0,0 +1,138 @@
+package hash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	argon2idVersionPrefix = "$argon2id$"
+
+	// These are the parameters used for all new password hashes. If they are changed then existing hashes will still
+	// be verified using the parameters stored with them, and will be rehashed with the new parameters the next time
+	// the user logs in.
+	argon2idTime    uint32 = 1
+	argon2idMemory  uint32 = 64 * 1024
+	argon2idThreads uint8  = 4
+	argon2idKeySize uint32 = 32
+	argon2idSalt           = 16
+)
+
+var (
+	ErrInvalidPasswordHash = errors.New("password hash is not valid")
+)
+
+// DummyPasswordHash is a valid argon2id hash that does not belong to any login. It can be verified against when a login
+// does not exist so that the time it takes to respond is the same whether or not the login exists.
+const DummyPasswordHash = "$argon2id$v=19$m=65536,t=1,p=4$7tf/tUmH5N9JMRGiKKZ8xw$EqrmLwDhdeZFQGwKyIpl0YcuM/wy2Uki9BUZVYcSXsg"
+
+// argon2idParameters are the parameters that were used to generate a single argon2id hash. They are stored in the
+// hash itself so that hashes can still be verified if the defaults change.
+type argon2idParameters struct {
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+}
+
+// NewPasswordHash will return a salted argon2id hash of the provided password. The hash is encoded using the PHC
+// string format, which includes the algorithm, version and parameters used. This way we can tell how a password was
+// hashed when we need to verify it later.
+func NewPasswordHash(password string) (string, error) {
+	salt := make([]byte, argon2idSalt)
+	if _, err := rand.Read(salt); err != nil {
+		return "", errors.Wrap(err, "failed to generate salt for password")
+	}
+
+	key := argon2.IDKey([]byte(password), salt, argon2idTime, argon2idMemory, argon2idThreads, argon2idKeySize)
+
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idVersionPrefix,
+		argon2.Version,
+		argon2idMemory,
+		argon2idTime,
+		argon2idThreads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// IsLegacyPasswordHash will return true if the provided password hash was created by HashPassword. Legacy hashes are
+// derived from the email address as well as the password, so they are no longer valid once the email changes.
+func IsLegacyPasswordHash(passwordHash string) bool {
+	return !strings.HasPrefix(passwordHash, argon2idVersionPrefix)
+}
+
+// VerifyPassword will check the provided credentials against the stored password hash. The stored hash can either be
+// an argon2id hash created by NewPasswordHash, or a legacy hash created by HashPassword. If the password is valid but
+// the stored hash is a legacy hash or was created with outdated parameters then needsRehash will be true, the caller
+// should then store a new hash of the password.
+func VerifyPassword(email, password, passwordHash string) (valid bool, needsRehash bool, err error) {
+	if IsLegacyPasswordHash(passwordHash) {
+		// Legacy hashes are a hex encoded SHA-256 of the email and password.
+		legacyHash := HashPassword(email, password)
+		valid = subtle.ConstantTimeCompare([]byte(legacyHash), []byte(passwordHash)) == 1
+		return valid, valid, nil
+	}
+
+	parameters, salt, key, err := decodeArgon2idHash(passwordHash)
+	if err != nil {
+		return false, false, err
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, parameters.Time, parameters.Memory, parameters.Threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return false, false, nil
+	}
+
+	needsRehash = parameters.Time != argon2idTime ||
+		parameters.Memory != argon2idMemory ||
+		parameters.Threads != argon2idThreads ||
+		uint32(len(key)) != argon2idKeySize ||
+		len(salt) != argon2idSalt
+
+	return true, needsRehash, nil
+}
+
+func decodeArgon2idHash(passwordHash string) (parameters argon2idParameters, salt, key []byte, err error) {
+	// The hash should look like: $argon2id$v=19$m=65536,t=1,p=4$<salt>$<key>
+	parts := strings.Split(passwordHash, "$")
+	if len(parts) != 6 {
+		return parameters, nil, nil, ErrInvalidPasswordHash
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return parameters, nil, nil, errors.Wrap(ErrInvalidPasswordHash, "invalid version")
+	}
+
+	if version != argon2.Version {
+		return parameters, nil, nil, errors.Wrapf(ErrInvalidPasswordHash, "unsupported argon2 version: %d", version)
+	}
+
+	if _, err = fmt.Sscanf(
+		parts[3],
+		"m=%d,t=%d,p=%d",
+		&parameters.Memory,
+		&parameters.Time,
+		&parameters.Threads,
+	); err != nil {
+		return parameters, nil, nil, errors.Wrap(ErrInvalidPasswordHash, "invalid parameters")
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return parameters, nil, nil, errors.Wrap(ErrInvalidPasswordHash, "invalid salt")
+	}
+
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(key) == 0 {
+		return parameters, nil, nil, errors.Wrap(ErrInvalidPasswordHash, "invalid key")
+	}
+
+	return parameters, salt, key, nil
+}
@@ -0,0 +1,120 @@
+package aggregator
+
+import (
+	"context"
+	"time"
+
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/pkg/errors"
+)
+
+// ErrUnsupportedLinkType is returned when a client is requested for a link that does not have a provider, like a manual
+// link.
+var ErrUnsupportedLinkType = errors.New("link type does not have a provider")
+
+type (
+	// Provider is a service that monetr can retrieve bank account data from, like Plaid. Each link is associated with a
+	// single provider by its link type.
+	Provider interface {
+		// GetClientForLink returns a client that can retrieve the data for the provided link. The link must include
+		// the provider specific data for the link, like the PlaidLink.
+		GetClientForLink(ctx context.Context, link *models.Link) (Client, error)
+		// SupportsWebhooks returns true if the provider notifies monetr with webhooks when a link's data changes. Links
+		// for providers that do not support webhooks are synced on a schedule instead.
+		SupportsWebhooks() bool
+	}
+
+	// Client retrieves data for a single link from a provider.
+	Client interface {
+		// GetAccounts returns the bank accounts for the link along with their current balances. If account Ids are
+		// provided then only those accounts are returned.
+		GetAccounts(ctx context.Context, accountIds ...string) ([]BankAccount, error)
+		// GetAllTransactions returns every transaction between the start and end dates for the provided accounts.
+		GetAllTransactions(ctx context.Context, start, end time.Time, accountIds []string) ([]Transaction, error)
+		// RemoveItem revokes monetr's access to the link at the provider. This is called when the link is removed.
+		RemoveItem(ctx context.Context) error
+	}
+
+	// StatementBalanceClient is implemented by clients that can retrieve the statement balances of credit accounts.
+	// Not every provider supports this, so callers should check for it on the Client.
+	StatementBalanceClient interface {
+		// GetStatementBalances returns the balance as of the last statement for each of the provided credit accounts
+		// in the currency's minor units, keyed by the provider's account Id. Accounts that do not have a statement are
+		// omitted.
+		GetStatementBalances(ctx context.Context, accountIds ...string) (map[string]int64, error)
+	}
+
+	BankAccount interface {
+		// GetAccountId will return the provider's unique identifier for the bank account.
+		GetAccountId() string
+		// GetBalances will return the bank account's balances.
+		GetBalances() BankAccountBalances
+		// GetMask typically returns the last 4 of the bank account number. This could technically return anything that
+		// represents a small portion of the bank account's identification. I don't currently know enough about this to know
+		// what other values this might have.
+		GetMask() string
+		// GetName will return the name of the account specified by the user or the financial institution itself.
+		GetName() string
+		// GetOfficialName will return the name of the account specified by the financial institution itself.
+		GetOfficialName() string
+		// GetType will return the type of the account. For our use case this is typically "depository".
+		GetType() string
+		// GetSubType will return the sub-type of the account. This can be something like "checking" or "savings".
+		GetSubType() string
+	}
+
+	BankAccountBalances interface {
+		// GetAvailable returns the total amount available for the bank account in the currency's minor units (cents for
+		// USD).
+		GetAvailable() int64
+		// GetCurrent returns the current bank account balance in the currency's minor units. This is typically the total
+		// account value excluding pending transactions.
+		GetCurrent() int64
+		// GetLimit returns the limit of the account (this applies for credit accounts) in the currency's minor units.
+		GetLimit() int64
+		// GetCurrency returns the currency of the balances. The ISO currency code is preferred but the unofficial currency
+		// code will be returned if the ISO code is not present.
+		GetCurrency() string
+		GetIsoCurrencyCode() string
+		GetUnofficialCurrencyCode() string
+	}
+
+	Transaction interface {
+		// GetAmount returns the amount of the transaction in the currency's minor units. Like Plaid, a positive amount
+		// is money leaving the account and a negative amount is money being deposited.
+		GetAmount() int64
+		GetBankAccountId() string
+		GetCategory() []string
+		// GetCurrency returns the currency of the transaction's amount. The ISO currency code is preferred but the
+		// unofficial currency code will be returned if the ISO code is not present.
+		GetCurrency() string
+		GetDate() time.Time
+		GetDateLocal(timezone *time.Location) time.Time
+		GetISOCurrencyCode() string
+		GetIsPending() bool
+		GetMerchantName() string
+		GetName() string
+		GetOriginalDescription() string
+		GetPendingTransactionId() *string
+		GetTransactionId() string
+		GetUnofficialCurrencyCode() string
+	}
+)
+
+// Providers maps each type of link to the provider that its data is retrieved from.
+type Providers map[models.LinkType]Provider
+
+// GetClientForLink will return a client from the provider for the link's type. If there is no provider for the link
+// then ErrUnsupportedLinkType is returned.
+func (p Providers) GetClientForLink(ctx context.Context, link *models.Link) (Client, error) {
+	if link == nil {
+		return nil, errors.New("cannot create client without link")
+	}
+
+	provider, ok := p[link.LinkType]
+	if !ok || provider == nil {
+		return nil, errors.Wrapf(ErrUnsupportedLinkType, "link type %d", link.LinkType)
+	}
+
+	return provider.GetClientForLink(ctx, link)
+}
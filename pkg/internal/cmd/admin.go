@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/rest-api/pkg/admin"
+	"github.com/monetr/rest-api/pkg/cache"
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/feature"
+	"github.com/monetr/rest-api/pkg/internal/myownsanity"
+	"github.com/monetr/rest-api/pkg/jobs"
+	"github.com/monetr/rest-api/pkg/logging"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RootCommand.AddCommand(AdminCommand)
+	AdminCommand.AddCommand(AdminLookupCommand)
+	AdminCommand.AddCommand(AdminAccountCommand)
+	AdminCommand.AddCommand(AdminResyncLinkCommand)
+	AdminCommand.AddCommand(AdminExtendSubscriptionCommand)
+	AdminCommand.AddCommand(AdminDisableLoginCommand)
+	AdminCommand.AddCommand(AdminEnableLoginCommand)
+	AdminCommand.AddCommand(AdminSetFeatureCommand)
+
+	AdminCommand.PersistentFlags().StringVarP(&postgresAddress, "host", "H", "localhost", "PostgreSQL host address.")
+	AdminCommand.PersistentFlags().IntVarP(&postgresPort, "port", "P", 5432, "PostgreSQL port.")
+	AdminCommand.PersistentFlags().StringVarP(&postgresUsername, "username", "U", "postgres", "PostgreSQL user.")
+	AdminCommand.PersistentFlags().StringVarP(&postgresPassword, "password", "W", "", "PostgreSQL password.")
+	AdminCommand.PersistentFlags().StringVarP(&postgresDatabase, "database", "d", "postgres", "PostgreSQL database.")
+	AdminCommand.PersistentFlags().StringVar(&redisAddress, "redis-host", "localhost", "Redis host address.")
+	AdminCommand.PersistentFlags().IntVar(&redisPort, "redis-port", 6379, "Redis port.")
+	AdminCommand.PersistentFlags().StringVar(&jobsNamespace, "namespace", "harder", "The namespace that jobs are enqueued in.")
+	AdminCommand.PersistentFlags().StringVar(&adminActor, "actor", "", "The name recorded in the audit log for actions taken, defaults to the current operating system user.")
+}
+
+var (
+	adminActor = ""
+)
+
+// runAdminCommand will connect to PostgreSQL and redis and then provide an admin interface to the callback. The actor
+// that is recorded on audit logs is the current operating system user unless one is specified.
+func runAdminCommand(callback func(ctx context.Context, log *logrus.Entry, service admin.Admin, actor admin.Actor) error) error {
+	log := logging.NewLogger()
+
+	actor := admin.Actor{
+		Name: adminActor,
+	}
+	if actor.Name == "" {
+		current, err := user.Current()
+		if err != nil {
+			return errors.Wrap(err, "failed to determine current user, an actor must be specified")
+		}
+
+		hostname, _ := os.Hostname()
+		actor.Name = fmt.Sprintf("cli:%s@%s", current.Username, hostname)
+	}
+
+	options := getDatabaseCommandConfiguration()
+
+	db := pg.Connect(options)
+	defer db.Close()
+
+	redisController, err := cache.NewRedisCache(log, config.Redis{
+		Enabled: true,
+		Address: redisAddress,
+		Port:    redisPort,
+	})
+	if err != nil {
+		log.WithError(err).Error("failed to connect to redis")
+		return errors.Wrap(err, "failed to connect to redis")
+	}
+	defer redisController.Close()
+
+	service := admin.NewAdmin(
+		log,
+		db,
+		cache.NewCache(log, redisController.Pool()),
+		jobs.NewJobEnqueuer(log, redisController.Pool(), jobsNamespace),
+	)
+
+	return callback(context.Background(), log.WithField("actor", actor.Name), service, actor)
+}
+
+func printJSON(object interface{}) error {
+	data, err := json.MarshalIndent(object, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode result")
+	}
+
+	fmt.Println(string(data))
+
+	return nil
+}
+
+func parseIdArgument(name, value string) (uint64, error) {
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, errors.Errorf("%s must be a number", name)
+	}
+
+	return id, nil
+}
+
+var (
+	AdminCommand = &cobra.Command{
+		Use:   "admin",
+		Short: "Support tasks for operators, every action taken is recorded in the audit log.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	AdminLookupCommand = &cobra.Command{
+		Use:   "lookup [email]",
+		Short: "Show the account(s) that the login with the provided email belongs to.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAdminCommand(func(ctx context.Context, log *logrus.Entry, service admin.Admin, actor admin.Actor) error {
+				details, err := service.LookupAccountsByEmail(ctx, actor, args[0])
+				if err != nil {
+					log.WithError(err).Error("failed to lookup accounts")
+					return err
+				}
+
+				return printJSON(details)
+			})
+		},
+	}
+
+	AdminAccountCommand = &cobra.Command{
+		Use:   "account [accountId]",
+		Short: "Show the users, links, recent jobs and subscription state of an account.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			accountId, err := parseIdArgument("account id", args[0])
+			if err != nil {
+				return err
+			}
+
+			return runAdminCommand(func(ctx context.Context, log *logrus.Entry, service admin.Admin, actor admin.Actor) error {
+				details, err := service.GetAccountDetails(ctx, actor, accountId)
+				if err != nil {
+					log.WithError(err).Error("failed to retrieve account")
+					return err
+				}
+
+				return printJSON(details)
+			})
+		},
+	}
+
+	AdminResyncLinkCommand = &cobra.Command{
+		Use:   "resync [accountId] [linkId]",
+		Short: "Enqueue a job to retrieve the latest transactions and balances for a Plaid link.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			accountId, err := parseIdArgument("account id", args[0])
+			if err != nil {
+				return err
+			}
+
+			linkId, err := parseIdArgument("link id", args[1])
+			if err != nil {
+				return err
+			}
+
+			return runAdminCommand(func(ctx context.Context, log *logrus.Entry, service admin.Admin, actor admin.Actor) error {
+				jobId, err := service.ResyncLink(ctx, actor, accountId, linkId)
+				if err != nil {
+					log.WithError(err).Error("failed to resync link")
+					return err
+				}
+
+				if jobId == "" {
+					log.Info("link is already being resynced, no new job was enqueued")
+					return nil
+				}
+
+				log.WithField("jobId", jobId).Info("successfully enqueued link resync")
+
+				return nil
+			})
+		},
+	}
+
+	AdminExtendSubscriptionCommand = &cobra.Command{
+		Use:   "extend-subscription [accountId] [YYYY-MM-DD]",
+		Short: "Set the date an account's subscription is active until. The change is overwritten by the next Stripe webhook for the account.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			accountId, err := parseIdArgument("account id", args[0])
+			if err != nil {
+				return err
+			}
+
+			activeUntil, err := time.ParseInLocation("2006-01-02", args[1], time.UTC)
+			if err != nil {
+				return errors.Wrap(err, "active until must be a date formatted as YYYY-MM-DD")
+			}
+
+			if !activeUntil.After(time.Now()) {
+				return errors.New("active until must be in the future")
+			}
+
+			return runAdminCommand(func(ctx context.Context, log *logrus.Entry, service admin.Admin, actor admin.Actor) error {
+				account, err := service.ExtendSubscription(ctx, actor, accountId, activeUntil)
+				if err != nil {
+					log.WithError(err).Error("failed to extend subscription")
+					return err
+				}
+
+				log.WithField("activeUntil", account.SubscriptionActiveUntil).Info("successfully extended subscription")
+
+				return nil
+			})
+		},
+	}
+
+	AdminDisableLoginCommand = &cobra.Command{
+		Use:   "disable-login [loginId]",
+		Short: "Disable a login, it will no longer be able to authenticate or use existing tokens.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setLoginEnabled(args[0], false)
+		},
+	}
+
+	AdminEnableLoginCommand = &cobra.Command{
+		Use:   "enable-login [loginId]",
+		Short: "Enable a login that was previously disabled.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setLoginEnabled(args[0], true)
+		},
+	}
+
+	AdminSetFeatureCommand = &cobra.Command{
+		Use:   "set-feature [accountId] [feature] [on|off|default]",
+		Short: "Grant or revoke a feature for an account regardless of its plan, default removes the override.",
+		Args:  cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			accountId, err := parseIdArgument("account id", args[0])
+			if err != nil {
+				return err
+			}
+
+			item := feature.Feature(args[1])
+			if !item.IsValid() {
+				return errors.Errorf("feature must be one of %v", feature.All())
+			}
+
+			var enabled *bool
+			switch args[2] {
+			case "on":
+				enabled = myownsanity.BoolP(true)
+			case "off":
+				enabled = myownsanity.BoolP(false)
+			case "default":
+				enabled = nil
+			default:
+				return errors.New("override must be on, off or default")
+			}
+
+			return runAdminCommand(func(ctx context.Context, log *logrus.Entry, service admin.Admin, actor admin.Actor) error {
+				account, err := service.SetFeatureOverride(ctx, actor, accountId, item, enabled)
+				if err != nil {
+					log.WithError(err).Error("failed to override feature")
+					return err
+				}
+
+				log.WithField("features", account.GetFeatures()).Info("successfully updated feature override")
+
+				return nil
+			})
+		},
+	}
+)
+
+func setLoginEnabled(loginIdArgument string, enabled bool) error {
+	loginId, err := parseIdArgument("login id", loginIdArgument)
+	if err != nil {
+		return err
+	}
+
+	return runAdminCommand(func(ctx context.Context, log *logrus.Entry, service admin.Admin, actor admin.Actor) error {
+		if err := service.SetLoginEnabled(ctx, actor, loginId, enabled); err != nil {
+			log.WithError(err).Error("failed to update login")
+			return err
+		}
+
+		log.WithFields(logrus.Fields{
+			"loginId": loginId,
+			"enabled": enabled,
+		}).Info("successfully updated login")
+
+		return nil
+	})
+}
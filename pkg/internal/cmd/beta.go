@@ -1,30 +1,46 @@
 package cmd
 
 import (
-	"crypto/rand"
+	"context"
 	"fmt"
+	"strconv"
+	"time"
+
 	"github.com/go-pg/pg/v10"
-	"github.com/monetr/rest-api/pkg/hash"
 	"github.com/monetr/rest-api/pkg/logging"
 	"github.com/monetr/rest-api/pkg/models"
+	"github.com/monetr/rest-api/pkg/repository"
 	"github.com/monetr/rest-api/pkg/util"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	"strings"
-	"time"
 )
 
 func init() {
 	RootCommand.AddCommand(BetaCommand)
 	BetaCommand.AddCommand(NewBetaCodeCommand)
+	BetaCommand.AddCommand(ListBetaCodesCommand)
+	BetaCommand.AddCommand(RevokeBetaCodeCommand)
+	BetaCommand.AddCommand(SetBetaInviteQuotaCommand)
 
 	BetaCommand.PersistentFlags().StringVarP(&postgresAddress, "host", "H", "localhost", "PostgreSQL host address.")
 	BetaCommand.PersistentFlags().IntVarP(&postgresPort, "port", "P", 5432, "PostgreSQL port.")
 	BetaCommand.PersistentFlags().StringVarP(&postgresUsername, "username", "U", "postgres", "PostgreSQL user.")
 	BetaCommand.PersistentFlags().StringVarP(&postgresPassword, "password", "W", "", "PostgreSQL password.")
 	BetaCommand.PersistentFlags().StringVarP(&postgresDatabase, "database", "d", "postgres", "PostgreSQL database.")
+
+	NewBetaCodeCommand.Flags().IntVarP(&betaCodeCount, "count", "n", 1, "Number of beta codes to generate.")
+	NewBetaCodeCommand.Flags().DurationVarP(&betaCodeExpiresIn, "expires-in", "e", 14*24*time.Hour, "How long the beta code(s) will be valid for, the expiration is rounded to midnight.")
+
+	ListBetaCodesCommand.Flags().BoolVarP(&betaListAll, "all", "a", false, "Include used, expired and revoked beta codes.")
 }
 
+var (
+	betaCodeCount     = 0
+	betaCodeExpiresIn = time.Duration(0)
+	betaListAll       = false
+)
+
 var (
 	BetaCommand = &cobra.Command{
 		Use:   "beta",
@@ -45,20 +61,15 @@ var (
 
 			log.Infof("found %d beta(s)", len(betas))
 
-			var used, unused, expired uint32
+			counts := map[models.BetaStatus]uint32{}
 			now := time.Now()
 			for _, beta := range betas {
-				if beta.UsedByUserId != nil {
-					used++
-				} else if now.Before(beta.ExpiresAt) {
-					unused++
-				} else {
-					expired++
-				}
+				counts[beta.Status(now)]++
 			}
-			log.Infof("used: %d", used)
-			log.Infof("unused: %d", unused)
-			log.Infof("expired: %d", expired)
+			log.Infof("used: %d", counts[models.BetaStatusUsed])
+			log.Infof("unused: %d", counts[models.BetaStatusUnused])
+			log.Infof("expired: %d", counts[models.BetaStatusExpired])
+			log.Infof("revoked: %d", counts[models.BetaStatusRevoked])
 
 			return nil
 		},
@@ -66,7 +77,58 @@ var (
 
 	NewBetaCodeCommand = &cobra.Command{
 		Use:   "new-code",
-		Short: "Generates a beta code and returns the code, the code is encrypted then added to the database.",
+		Short: "Generates beta code(s) and returns the code(s), the codes are hashed then added to the database.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log := logging.NewLogger()
+
+			if betaCodeCount < 1 {
+				return errors.New("count must be at least 1")
+			}
+
+			if betaCodeExpiresIn <= 0 {
+				return errors.New("expires-in must be greater than 0")
+			}
+
+			options := getDatabaseCommandConfiguration()
+
+			db := pg.Connect(options)
+			defer db.Close()
+
+			expires := util.MidnightInLocal(time.Now().Add(betaCodeExpiresIn), time.Local)
+
+			codes := make([]string, 0, betaCodeCount)
+			if err := db.RunInTransaction(context.Background(), func(txn *pg.Tx) error {
+				betas := repository.NewBetaRepository(txn)
+				for i := 0; i < betaCodeCount; i++ {
+					code, _, err := betas.CreateBetaCode(context.Background(), expires, nil)
+					if err != nil {
+						return err
+					}
+
+					codes = append(codes, code)
+				}
+
+				return nil
+			}); err != nil {
+				log.WithError(err).Error("failed to generate beta code(s)")
+				return errors.Wrap(err, "failed to generate beta code(s)")
+			}
+
+			fmt.Println("NEW BETA CODE(S):")
+			fmt.Println()
+			for _, code := range codes {
+				fmt.Println(code)
+			}
+			fmt.Println()
+			fmt.Println("Code(s) expire on: ", expires)
+
+			return nil
+		},
+	}
+
+	ListBetaCodesCommand = &cobra.Command{
+		Use:   "list",
+		Short: "List beta codes along with their status and who redeemed them. Only unused codes are shown by default.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			log := logging.NewLogger()
 
@@ -75,31 +137,95 @@ var (
 			db := pg.Connect(options)
 			defer db.Close()
 
-			random := make([]byte, 8)
-			_, err := rand.Read(random)
+			betas, err := repository.NewBetaRepository(db).GetBetaCodes(context.Background())
+			if err != nil {
+				log.WithError(err).Error("failed to retrieve beta(s)")
+				return err
+			}
+
+			now := time.Now()
+			for _, beta := range betas {
+				status := beta.Status(now)
+				if !betaListAll && status != models.BetaStatusUnused {
+					continue
+				}
+
+				redeemedBy := ""
+				if beta.UsedByUser != nil && beta.UsedByUser.Login != nil {
+					redeemedBy = fmt.Sprintf("%s (user: %d)", beta.UsedByUser.Login.Email, beta.UsedByUser.UserId)
+				}
+
+				createdBy := "admin"
+				if beta.CreatedByUserId != nil {
+					createdBy = fmt.Sprintf("user: %d", *beta.CreatedByUserId)
+				}
+
+				fmt.Printf("%d\t%s\t%s\t%s\t%s\n", beta.BetaID, status, beta.ExpiresAt.Format("2006-01-02 15:04:05"), createdBy, redeemedBy)
+			}
+
+			return nil
+		},
+	}
+
+	RevokeBetaCodeCommand = &cobra.Command{
+		Use:   "revoke [betaId]",
+		Short: "Revoke an unused beta code so that it can no longer be used to register.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log := logging.NewLogger()
+
+			betaId, err := strconv.ParseUint(args[0], 10, 64)
 			if err != nil {
-				log.WithError(err).Error("failed to read random data")
-				return errors.Wrap(err, "failed to read random data")
+				return errors.Wrap(err, "beta id must be a number")
+			}
+
+			options := getDatabaseCommandConfiguration()
+
+			db := pg.Connect(options)
+			defer db.Close()
+
+			if err = repository.NewBetaRepository(db).RevokeBetaCode(context.Background(), betaId); err != nil {
+				log.WithError(err).Error("failed to revoke beta code")
+				return err
 			}
 
-			betaCode := fmt.Sprintf("%X-%X", random[:4], random[4:])
+			log.WithField("betaId", betaId).Info("successfully revoked beta code")
+
+			return nil
+		},
+	}
+
+	SetBetaInviteQuotaCommand = &cobra.Command{
+		Use:   "set-invite-quota [userId] [quota]",
+		Short: "Set the number of beta codes a user is allowed to create to invite other people.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log := logging.NewLogger()
+
+			userId, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return errors.Wrap(err, "user id must be a number")
+			}
 
-			expires := util.MidnightInLocal(time.Now().Add(14*24*time.Hour), time.Local)
-			beta := models.Beta{
-				CodeHash:  hash.HashPassword(strings.ToLower(betaCode), betaCode),
-				ExpiresAt: expires,
+			quota, err := strconv.Atoi(args[1])
+			if err != nil || quota < 0 {
+				return errors.New("quota must be a number that is at least 0")
 			}
 
-			if _, err := db.Model(&beta).Insert(&beta); err != nil {
-				log.WithError(err).Error("failed to generate beta code")
-				return errors.Wrap(err, "failed to generate beta code")
+			options := getDatabaseCommandConfiguration()
+
+			db := pg.Connect(options)
+			defer db.Close()
+
+			if err = repository.NewBetaRepository(db).SetBetaInviteQuota(context.Background(), userId, quota); err != nil {
+				log.WithError(err).Error("failed to set beta invite quota")
+				return err
 			}
 
-			fmt.Println("NEW BETA CODE:")
-			fmt.Println()
-			fmt.Println(betaCode)
-			fmt.Println()
-			fmt.Println("Code expires on: ", expires)
+			log.WithFields(logrus.Fields{
+				"userId": userId,
+				"quota":  quota,
+			}).Info("successfully set beta invite quota")
 
 			return nil
 		},
@@ -10,6 +10,7 @@ import (
 	"github.com/monetr/rest-api/pkg/config"
 	"github.com/monetr/rest-api/pkg/controller"
 	"github.com/monetr/rest-api/pkg/internal/platypus"
+	"github.com/monetr/rest-api/pkg/internal/simplefin"
 	"github.com/monetr/rest-api/pkg/internal/stripe_helper"
 	"github.com/monetr/rest-api/pkg/jobs"
 	"github.com/monetr/rest-api/pkg/metrics"
@@ -28,6 +29,8 @@ func getControllers(
 	cache *redis.Pool,
 	plaidSecrets secrets.PlaidSecretsProvider,
 	basicPaywall billing.BasicPayWall,
+	simpleFINSecrets secrets.SimpleFINSecretsProvider,
+	simpleFIN simplefin.SimpleFIN,
 ) []application.Controller {
 	return []application.Controller{
 		controller.NewController(
@@ -41,6 +44,8 @@ func getControllers(
 			cache,
 			plaidSecrets,
 			basicPaywall,
+			simpleFINSecrets,
+			simpleFIN,
 		),
 	}
 }
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/rest-api/pkg/cache"
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/jobs"
+	"github.com/monetr/rest-api/pkg/logging"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RootCommand.AddCommand(JobsCommand)
+	JobsCommand.AddCommand(DeadJobsCommand)
+	DeadJobsCommand.AddCommand(ListDeadJobsCommand)
+	DeadJobsCommand.AddCommand(InspectDeadJobCommand)
+	DeadJobsCommand.AddCommand(RetryDeadJobCommand)
+
+	JobsCommand.PersistentFlags().StringVarP(&postgresAddress, "host", "H", "localhost", "PostgreSQL host address.")
+	JobsCommand.PersistentFlags().IntVarP(&postgresPort, "port", "P", 5432, "PostgreSQL port.")
+	JobsCommand.PersistentFlags().StringVarP(&postgresUsername, "username", "U", "postgres", "PostgreSQL user.")
+	JobsCommand.PersistentFlags().StringVarP(&postgresPassword, "password", "W", "", "PostgreSQL password.")
+	JobsCommand.PersistentFlags().StringVarP(&postgresDatabase, "database", "d", "postgres", "PostgreSQL database.")
+
+	RetryDeadJobCommand.Flags().StringVar(&redisAddress, "redis-host", "localhost", "Redis host address.")
+	RetryDeadJobCommand.Flags().IntVar(&redisPort, "redis-port", 6379, "Redis port.")
+	RetryDeadJobCommand.Flags().StringVar(&jobsNamespace, "namespace", "harder", "The namespace that jobs are enqueued in.")
+}
+
+var (
+	redisAddress  = ""
+	redisPort     = 0
+	jobsNamespace = ""
+)
+
+var (
+	JobsCommand = &cobra.Command{
+		Use:   "jobs",
+		Short: "Manage background jobs",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	DeadJobsCommand = &cobra.Command{
+		Use:   "dead",
+		Short: "Manage jobs that have failed too many times and will no longer be retried.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	ListDeadJobsCommand = &cobra.Command{
+		Use:   "list",
+		Short: "List all of the jobs that are currently dead.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log := logging.NewLogger()
+
+			options := getDatabaseCommandConfiguration()
+
+			db := pg.Connect(options)
+			defer db.Close()
+
+			deadJobs := make([]models.Job, 0)
+			if err := db.Model(&deadJobs).
+				Where(`"job"."dead_at" IS NOT NULL`).
+				Order(`dead_at DESC`).
+				Select(&deadJobs); err != nil {
+				log.WithError(err).Error("failed to retrieve dead job(s)")
+				return errors.Wrap(err, "failed to retrieve dead job(s)")
+			}
+
+			log.Infof("found %d dead job(s)", len(deadJobs))
+
+			for _, job := range deadJobs {
+				message := ""
+				if job.Error != nil {
+					message = *job.Error
+				}
+
+				fmt.Printf("%s\t%s\t%d\t%s\t%s\n", job.JobId, job.Name, job.AccountId, job.DeadAt.Format("2006-01-02 15:04:05"), message)
+			}
+
+			return nil
+		},
+	}
+
+	InspectDeadJobCommand = &cobra.Command{
+		Use:   "inspect [jobId]",
+		Short: "Show the arguments, error, stack trace and attempt history of a job.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log := logging.NewLogger()
+
+			options := getDatabaseCommandConfiguration()
+
+			db := pg.Connect(options)
+			defer db.Close()
+
+			var job models.Job
+			if err := db.Model(&job).
+				Where(`"job"."job_id" = ?`, args[0]).
+				Limit(1).
+				Select(&job); err != nil {
+				log.WithError(err).Error("failed to retrieve job")
+				return errors.Wrap(err, "failed to retrieve job")
+			}
+
+			arguments, err := json.MarshalIndent(job.Args, "", "  ")
+			if err != nil {
+				return errors.Wrap(err, "failed to encode job arguments")
+			}
+
+			fmt.Println("Job Id:     ", job.JobId)
+			fmt.Println("Name:       ", job.Name)
+			fmt.Println("Account Id: ", job.AccountId)
+			fmt.Println("Enqueued At:", job.EnqueuedAt)
+			if job.DeadAt != nil {
+				fmt.Println("Dead At:    ", *job.DeadAt)
+			}
+			fmt.Println()
+			fmt.Println("Arguments:")
+			fmt.Println(string(arguments))
+
+			fmt.Println()
+			fmt.Println("Attempts:")
+			for _, attempt := range job.Attempts {
+				result := "succeeded"
+				if attempt.Error != nil {
+					result = *attempt.Error
+				}
+
+				fmt.Printf("  #%d\t%s\t%s\t%s\n", attempt.Attempt, attempt.StartedAt, attempt.FinishedAt.Sub(attempt.StartedAt), result)
+			}
+
+			if job.Stack != nil {
+				fmt.Println()
+				fmt.Println("Stack:")
+				fmt.Println(*job.Stack)
+			}
+
+			return nil
+		},
+	}
+
+	RetryDeadJobCommand = &cobra.Command{
+		Use:   "retry [jobId]",
+		Short: "Re-enqueue a dead job so that it will be run again.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log := logging.NewLogger()
+
+			options := getDatabaseCommandConfiguration()
+
+			db := pg.Connect(options)
+			defer db.Close()
+
+			redisController, err := cache.NewRedisCache(log, config.Redis{
+				Enabled: true,
+				Address: redisAddress,
+				Port:    redisPort,
+			})
+			if err != nil {
+				log.WithError(err).Error("failed to connect to redis")
+				return errors.Wrap(err, "failed to connect to redis")
+			}
+			defer redisController.Close()
+
+			jobId, err := jobs.RetryDeadJob(context.Background(), log, db, redisController.Pool(), jobsNamespace, args[0])
+			if err != nil {
+				log.WithError(err).Error("failed to retry dead job")
+				return err
+			}
+
+			log.WithField("jobId", jobId).Info("successfully re-enqueued job")
+
+			return nil
+		},
+	}
+)
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/rest-api/pkg/cache"
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/controller"
+	"github.com/monetr/rest-api/pkg/jobs"
+	"github.com/monetr/rest-api/pkg/logging"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RootCommand.AddCommand(PlaidCommand)
+	PlaidCommand.AddCommand(PlaidWebhooksCommand)
+	PlaidWebhooksCommand.AddCommand(PlaidReplayWebhookCommand)
+
+	PlaidCommand.PersistentFlags().StringVarP(&configFilePath, "config", "c", "", "Specify a config file to use, if omitted ./config.yaml or /etc/monetr/config.yaml will be used.")
+}
+
+var (
+	PlaidCommand = &cobra.Command{
+		Use:   "plaid",
+		Short: "Debugging tools for Plaid links.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	PlaidWebhooksCommand = &cobra.Command{
+		Use:   "webhooks",
+		Short: "Manage the webhooks that have been received from Plaid.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	PlaidReplayWebhookCommand = &cobra.Command{
+		Use:   "replay [webhookId]",
+		Short: "Handle a stored Plaid webhook again, even if it was already processed successfully.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plaidWebhookId, err := parseIdArgument("webhookId", args[0])
+			if err != nil {
+				return err
+			}
+
+			return replayPlaidWebhook(plaidWebhookId)
+		},
+	}
+)
+
+// replayPlaidWebhook will retrieve the stored Plaid webhook and pass it through the same handler that the API uses.
+// Jobs triggered by the webhook are enqueued for the workers of a running server. The outcome of the replay is recorded
+// on the stored webhook.
+func replayPlaidWebhook(plaidWebhookId uint64) error {
+	var configPath *string
+	if len(configFilePath) > 0 {
+		configPath = &configFilePath
+	}
+
+	configuration := config.LoadConfiguration(configPath)
+
+	log := logging.NewLoggerWithLevel(configuration.Logging.Level).WithField("plaidWebhookId", plaidWebhookId)
+
+	db := pg.Connect(&pg.Options{
+		Addr: fmt.Sprintf("%s:%d",
+			configuration.PostgreSQL.Address,
+			configuration.PostgreSQL.Port,
+		),
+		User:            configuration.PostgreSQL.Username,
+		Password:        configuration.PostgreSQL.Password,
+		Database:        configuration.PostgreSQL.Database,
+		ApplicationName: "monetr",
+	})
+	defer db.Close()
+
+	redisController, err := cache.NewRedisCache(log, configuration.Redis)
+	if err != nil {
+		log.WithError(err).Error("failed to connect to redis")
+		return errors.Wrap(err, "failed to connect to redis")
+	}
+	defer redisController.Close()
+
+	namespace := configuration.Jobs.Namespace
+	if namespace == "" {
+		namespace = "harder"
+	}
+
+	jobEnqueuer := jobs.NewJobEnqueuer(log, redisController.Pool(), namespace)
+
+	start := time.Now()
+	if err = controller.ReplayPlaidWebhook(context.Background(), log, db, jobEnqueuer, plaidWebhookId); err != nil {
+		log.WithError(err).Error("failed to handle replayed plaid webhook")
+		return err
+	}
+
+	log.WithField("took", time.Since(start)).Info("successfully replayed plaid webhook")
+
+	return nil
+}
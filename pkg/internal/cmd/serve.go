@@ -13,15 +13,18 @@ import (
 	"github.com/monetr/rest-api/pkg/billing"
 	"github.com/monetr/rest-api/pkg/build"
 	"github.com/monetr/rest-api/pkg/cache"
+	"github.com/monetr/rest-api/pkg/communication"
 	"github.com/monetr/rest-api/pkg/config"
 	"github.com/monetr/rest-api/pkg/internal/certhelper"
 	"github.com/monetr/rest-api/pkg/internal/migrations"
 	"github.com/monetr/rest-api/pkg/internal/myownsanity"
 	"github.com/monetr/rest-api/pkg/internal/platypus"
+	"github.com/monetr/rest-api/pkg/internal/simplefin"
 	"github.com/monetr/rest-api/pkg/internal/stripe_helper"
 	"github.com/monetr/rest-api/pkg/internal/vault_helper"
 	"github.com/monetr/rest-api/pkg/jobs"
 	"github.com/monetr/rest-api/pkg/logging"
+	"github.com/monetr/rest-api/pkg/mail"
 	"github.com/monetr/rest-api/pkg/metrics"
 	"github.com/monetr/rest-api/pkg/repository"
 	"github.com/monetr/rest-api/pkg/secrets"
@@ -312,7 +315,7 @@ func RunServer() error {
 			db,
 		)
 
-		basicPaywall = billing.NewBasicPaywall(log, accountRepo)
+		basicPaywall = billing.NewBasicPaywall(log, accountRepo, configuration.Stripe.GracePeriod)
 	}
 
 	if configuration.Plaid.WebhooksEnabled {
@@ -324,21 +327,51 @@ func RunServer() error {
 	}
 
 	var plaidSecrets secrets.PlaidSecretsProvider
+	var simpleFINSecrets secrets.SimpleFINSecretsProvider
 	if configuration.Vault.Enabled {
 		plaidSecrets = secrets.NewVaultPlaidSecretsProvider(log, vault)
+		simpleFINSecrets = secrets.NewVaultSimpleFINSecretsProvider(log, vault)
 	} else {
 		plaidSecrets = secrets.NewPostgresPlaidSecretsProvider(log, db)
+		simpleFINSecrets = secrets.NewPostgresSimpleFINSecretsProvider(log, db)
 	}
 
-	plaidClient := platypus.NewPlaid(log, plaidSecrets, repository.NewPlaidRepository(db), configuration.Plaid)
+	var plaidClient platypus.Platypus
+	if configuration.Plaid.Fake {
+		if configuration.Environment == "production" {
+			log.Warn("fake plaid is enabled in a production environment, real bank accounts cannot be linked")
+		}
+
+		log.Info("using fake plaid, no requests will be made to plaid")
+		plaidClient = platypus.NewFakePlaid(log, repository.NewPlaidRepository(db))
+	} else {
+		plaidClient = platypus.NewPlaid(log, plaidSecrets, repository.NewPlaidRepository(db), configuration.Plaid)
+	}
+
+	var userCommunication communication.UserCommunication
+	if configuration.EMail.Enabled {
+		userCommunication = communication.NewUserCommunication(
+			log,
+			configuration.EMail,
+			mail.NewSMTPCommunication(log, configuration.EMail.SMTP),
+		)
+	}
+
+	var simpleFIN simplefin.SimpleFIN
+	if configuration.SimpleFIN.Enabled {
+		simpleFIN = simplefin.NewSimpleFIN(log, simpleFINSecrets, configuration.SimpleFIN)
+	}
 
 	jobManager := jobs.NewJobManager(
 		log,
+		configuration.Jobs,
 		redisController.Pool(),
 		db,
 		plaidClient,
 		stats,
 		plaidSecrets,
+		userCommunication,
+		simpleFIN,
 	)
 	defer jobManager.Close()
 
@@ -353,6 +386,8 @@ func RunServer() error {
 		redisController.Pool(),
 		plaidSecrets,
 		basicPaywall,
+		simpleFINSecrets,
+		simpleFIN,
 	)...)
 
 	unixSocket := false
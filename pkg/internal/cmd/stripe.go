@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/rest-api/pkg/billing"
+	"github.com/monetr/rest-api/pkg/cache"
+	"github.com/monetr/rest-api/pkg/communication"
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/logging"
+	"github.com/monetr/rest-api/pkg/mail"
+	"github.com/monetr/rest-api/pkg/pubsub"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RootCommand.AddCommand(StripeCommand)
+	StripeCommand.AddCommand(StripeReplayEventCommand)
+
+	StripeCommand.PersistentFlags().StringVarP(&configFilePath, "config", "c", "", "Specify a config file to use, if omitted ./config.yaml or /etc/monetr/config.yaml will be used.")
+}
+
+var (
+	StripeCommand = &cobra.Command{
+		Use:   "stripe",
+		Short: "Debugging tools for Stripe billing.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	StripeReplayEventCommand = &cobra.Command{
+		Use:   "replay [eventId]",
+		Short: "Handle a stored Stripe event again, even if it was already processed successfully.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return replayStripeEvent(args[0])
+		},
+	}
+)
+
+// replayStripeEvent will retrieve the stored Stripe event and pass it through the same webhook handler that the API
+// uses. The duplicate check is bypassed, but the outcome of the replay is recorded on the stored event.
+func replayStripeEvent(stripeEventId string) error {
+	var configPath *string
+	if len(configFilePath) > 0 {
+		configPath = &configFilePath
+	}
+
+	configuration := config.LoadConfiguration(configPath)
+
+	log := logging.NewLoggerWithLevel(configuration.Logging.Level).WithField("eventId", stripeEventId)
+
+	db := pg.Connect(&pg.Options{
+		Addr: fmt.Sprintf("%s:%d",
+			configuration.PostgreSQL.Address,
+			configuration.PostgreSQL.Port,
+		),
+		User:            configuration.PostgreSQL.Username,
+		Password:        configuration.PostgreSQL.Password,
+		Database:        configuration.PostgreSQL.Database,
+		ApplicationName: "monetr",
+	})
+	defer db.Close()
+
+	// The account repository caches accounts in redis, the same redis as the API needs to be used so that any changes
+	// made to the account by the replayed event are seen by the API.
+	redisController, err := cache.NewRedisCache(log, configuration.Redis)
+	if err != nil {
+		log.WithError(err).Error("failed to connect to redis")
+		return errors.Wrap(err, "failed to connect to redis")
+	}
+	defer redisController.Close()
+
+	ctx := context.Background()
+
+	events := billing.NewStripeEventRepository(log, db)
+	record, err := events.GetEvent(ctx, stripeEventId)
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve stripe event")
+		return err
+	}
+
+	event, err := billing.StripeEventFromRecord(record)
+	if err != nil {
+		log.WithError(err).Error("failed to read stored stripe event")
+		return err
+	}
+
+	var userCommunication communication.UserCommunication
+	if configuration.EMail.Enabled {
+		userCommunication = communication.NewUserCommunication(
+			log,
+			configuration.EMail,
+			mail.NewSMTPCommunication(log, configuration.EMail.SMTP),
+		)
+	}
+
+	accountRepo := billing.NewAccountRepository(log, cache.NewCache(log, redisController.Pool()), db)
+	pubSub := pubsub.NewPostgresPubSub(log, db)
+	handler := billing.NewStripeWebhookHandler(
+		log,
+		accountRepo,
+		billing.NewBasicBilling(log, accountRepo, pubSub, configuration.Stripe),
+		pubSub,
+		userCommunication,
+		configuration,
+	)
+
+	log.WithField("eventType", event.Type).Info("replaying stripe event")
+
+	start := time.Now()
+	handleErr := handler.HandleWebhook(ctx, event)
+	if err = events.FinishEvent(ctx, stripeEventId, handleErr); err != nil {
+		log.WithError(err).Warn("failed to record outcome of replayed stripe event")
+	}
+
+	if handleErr != nil {
+		log.WithError(handleErr).Error("failed to handle replayed stripe event")
+		return handleErr
+	}
+
+	log.WithField("took", time.Since(start)).Info("successfully replayed stripe event")
+
+	return nil
+}
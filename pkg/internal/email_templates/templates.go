@@ -7,7 +7,11 @@ import (
 )
 
 const (
-	VerifyEmailTemplate = "templates/verify.html"
+	VerifyEmailTemplate    = "templates/verify.html"
+	UnlockAccountTemplate  = "templates/unlock.html"
+	PaymentFailedTemplate  = "templates/payment_failed.html"
+	TrialEndingTemplate    = "templates/trial_ending.html"
+	PendingRemovedTemplate = "templates/pending_removed.html"
 )
 
 //go:embed templates/*.html
@@ -26,4 +30,4 @@ func GetEmailTemplate(name string) (*template.Template, error) {
 	}
 
 	return emailTemplate, nil
-}
\ No newline at end of file
+}
@@ -11,7 +11,31 @@ func TestGetEmailTemplate(t *testing.T) {
 		assert.NoError(t, err, "should succeed")
 		assert.NotNil(t, verifyEmailTemplate, "should return a valid template")
 	})
-	
+
+	t.Run("unlock account", func(t *testing.T) {
+		unlockTemplate, err := GetEmailTemplate(UnlockAccountTemplate)
+		assert.NoError(t, err, "should succeed")
+		assert.NotNil(t, unlockTemplate, "should return a valid template")
+	})
+
+	t.Run("payment failed", func(t *testing.T) {
+		paymentFailedTemplate, err := GetEmailTemplate(PaymentFailedTemplate)
+		assert.NoError(t, err, "should succeed")
+		assert.NotNil(t, paymentFailedTemplate, "should return a valid template")
+	})
+
+	t.Run("trial ending", func(t *testing.T) {
+		trialEndingTemplate, err := GetEmailTemplate(TrialEndingTemplate)
+		assert.NoError(t, err, "should succeed")
+		assert.NotNil(t, trialEndingTemplate, "should return a valid template")
+	})
+
+	t.Run("pending removed", func(t *testing.T) {
+		pendingRemovedTemplate, err := GetEmailTemplate(PendingRemovedTemplate)
+		assert.NoError(t, err, "should succeed")
+		assert.NotNil(t, pendingRemovedTemplate, "should return a valid template")
+	})
+
 	t.Run("missing template", func(t *testing.T) {
 		verifyEmailTemplate, err := GetEmailTemplate("templates/i_dont_exist.html")
 		assert.EqualError(t, err, "failed to open email template (templates/i_dont_exist.html): open templates/i_dont_exist.html: file does not exist")
@@ -0,0 +1 @@
+ALTER TABLE "jobs" DROP COLUMN "error";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "jobs" ADD COLUMN "error" TEXT NULL;
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "ix_jobs_dead_at";
+
+ALTER TABLE "jobs" DROP COLUMN "dead_at";
+ALTER TABLE "jobs" DROP COLUMN "attempts";
+ALTER TABLE "jobs" DROP COLUMN "stack";
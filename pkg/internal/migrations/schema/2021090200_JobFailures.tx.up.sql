@@ -0,0 +1,5 @@
+ALTER TABLE "jobs" ADD COLUMN "stack" TEXT NULL;
+ALTER TABLE "jobs" ADD COLUMN "attempts" JSONB NULL;
+ALTER TABLE "jobs" ADD COLUMN "dead_at" TIMESTAMPTZ NULL;
+
+CREATE INDEX IF NOT EXISTS "ix_jobs_dead_at" ON "jobs" ("dead_at") WHERE "dead_at" IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE "plaid_links" DROP COLUMN "transactions_cursor";
\ No newline at end of file
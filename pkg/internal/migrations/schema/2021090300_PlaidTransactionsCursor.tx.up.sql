@@ -0,0 +1 @@
+ALTER TABLE "plaid_links" ADD COLUMN "transactions_cursor" TEXT NULL;
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "plaid_webhooks";
+
+ALTER TABLE "links" DROP COLUMN "new_accounts_available";
+ALTER TABLE "links" DROP COLUMN "error_message";
+ALTER TABLE "links" DROP COLUMN "error_type";
@@ -0,0 +1,21 @@
+ALTER TABLE "links" ADD COLUMN "error_type" TEXT NULL;
+ALTER TABLE "links" ADD COLUMN "error_message" TEXT NULL;
+ALTER TABLE "links" ADD COLUMN "new_accounts_available" BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS "plaid_webhooks"
+(
+    "plaid_webhook_id" BIGSERIAL   NOT NULL,
+    "item_id"          TEXT        NOT NULL,
+    "account_id"       BIGINT,
+    "link_id"          BIGINT,
+    "webhook_type"     TEXT        NOT NULL,
+    "webhook_code"     TEXT        NOT NULL,
+    "body"             JSONB       NOT NULL,
+    "received_at"      TIMESTAMPTZ NOT NULL,
+    "processed_at"     TIMESTAMPTZ,
+    "error"            TEXT,
+    CONSTRAINT "pk_plaid_webhooks" PRIMARY KEY ("plaid_webhook_id"),
+    CONSTRAINT "fk_plaid_webhooks_accounts_account_id" FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "ix_plaid_webhooks_item_id" ON "plaid_webhooks" ("item_id");
@@ -0,0 +1,8 @@
+ALTER TABLE "users" DROP COLUMN "beta_invite_quota";
+
+DROP INDEX IF EXISTS "ix_betas_created_by_user_id";
+
+ALTER TABLE "betas" DROP CONSTRAINT "fk_betas_users_created_by_user_id";
+ALTER TABLE "betas" DROP COLUMN "revoked_at";
+ALTER TABLE "betas" DROP COLUMN "created_at";
+ALTER TABLE "betas" DROP COLUMN "created_by_user_id";
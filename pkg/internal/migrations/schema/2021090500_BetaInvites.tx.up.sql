@@ -0,0 +1,8 @@
+ALTER TABLE "betas" ADD COLUMN "created_by_user_id" BIGINT NULL;
+ALTER TABLE "betas" ADD COLUMN "created_at" TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE "betas" ADD COLUMN "revoked_at" TIMESTAMPTZ NULL;
+ALTER TABLE "betas" ADD CONSTRAINT "fk_betas_users_created_by_user_id" FOREIGN KEY ("created_by_user_id") REFERENCES "users" ("user_id") ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS "ix_betas_created_by_user_id" ON "betas" ("created_by_user_id");
+
+ALTER TABLE "users" ADD COLUMN "beta_invite_quota" INT NOT NULL DEFAULT 0;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "audit_logs"
+(
+    "audit_log_id"      BIGSERIAL   NOT NULL,
+    "actor"             TEXT        NOT NULL,
+    "actor_login_id"    BIGINT,
+    "action"            TEXT        NOT NULL,
+    "target_account_id" BIGINT,
+    "target_login_id"   BIGINT,
+    "details"           JSONB,
+    "created_at"        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT "pk_audit_logs" PRIMARY KEY ("audit_log_id"),
+    CONSTRAINT "fk_audit_logs_logins_actor_login_id" FOREIGN KEY ("actor_login_id") REFERENCES "logins" ("login_id") ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS "ix_audit_logs_target_account_id" ON "audit_logs" ("target_account_id");
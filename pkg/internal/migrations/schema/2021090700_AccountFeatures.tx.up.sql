@@ -0,0 +1,6 @@
+ALTER TABLE "accounts" ADD COLUMN "features" TEXT[] NULL;
+ALTER TABLE "accounts" ADD COLUMN "feature_overrides" JSONB NULL;
+
+-- Until now every account has had access to every feature, so existing accounts keep that access until their plan
+-- features are derived from the next subscription webhook.
+UPDATE "accounts" SET "features" = ARRAY['ManualBudgeting', 'LinkedBudgeting'];
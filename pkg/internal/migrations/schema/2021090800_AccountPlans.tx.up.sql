@@ -0,0 +1 @@
+ALTER TABLE "accounts" ADD COLUMN "stripe_price_id" TEXT NULL;
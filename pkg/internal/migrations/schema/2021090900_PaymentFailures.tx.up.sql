@@ -0,0 +1 @@
+ALTER TABLE "accounts" ADD COLUMN "payment_failed_at" TIMESTAMPTZ NULL;
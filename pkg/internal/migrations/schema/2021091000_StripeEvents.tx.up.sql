@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "stripe_events"
+(
+    "stripe_event_id" TEXT        NOT NULL,
+    "type"            TEXT        NOT NULL,
+    "payload"         JSONB       NOT NULL,
+    "payload_hash"    TEXT        NOT NULL,
+    "outcome"         TEXT        NOT NULL,
+    "error"           TEXT,
+    "attempts"        INT         NOT NULL DEFAULT 0,
+    "received_at"     TIMESTAMPTZ NOT NULL,
+    "processed_at"    TIMESTAMPTZ,
+    CONSTRAINT "pk_stripe_events" PRIMARY KEY ("stripe_event_id")
+);
+
+CREATE INDEX IF NOT EXISTS "ix_stripe_events_type" ON "stripe_events" ("type");
@@ -0,0 +1,3 @@
+ALTER TABLE "institutions" DROP COLUMN "updated_at";
+ALTER TABLE "institutions" DROP COLUMN "product_statuses";
+ALTER TABLE "institutions" DROP COLUMN "status";
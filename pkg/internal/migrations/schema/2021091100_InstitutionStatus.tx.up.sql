@@ -0,0 +1,3 @@
+ALTER TABLE "institutions" ADD COLUMN "status" TEXT NULL;
+ALTER TABLE "institutions" ADD COLUMN "product_statuses" JSONB NULL;
+ALTER TABLE "institutions" ADD COLUMN "updated_at" TIMESTAMPTZ NULL;
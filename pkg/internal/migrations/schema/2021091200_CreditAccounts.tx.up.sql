@@ -0,0 +1,49 @@
+ALTER TABLE "bank_accounts" ADD COLUMN "limit_balance" BIGINT NULL;
+ALTER TABLE "bank_accounts" ADD COLUMN "original_balance" BIGINT NULL;
+ALTER TABLE "bank_accounts" ADD COLUMN "payment_bank_account_id" BIGINT NULL;
+
+CREATE INDEX IF NOT EXISTS "ix_bank_accounts_payment_bank_account_id" ON "bank_accounts" ("account_id", "payment_bank_account_id");
+
+-- Credit card balances are set aside in the bank account that the card is paid from. As card transactions post the
+-- amount owed on the card goes up, and that amount is no longer safe to spend in the paying account.
+DROP VIEW IF EXISTS "balances";
+CREATE VIEW "balances" AS (
+SELECT "bank_account"."bank_account_id",
+       "bank_account"."account_id",
+       "bank_account"."current_balance"                                                    AS "current",
+       "bank_account"."available_balance"                                                  AS "available",
+       "bank_account"."available_balance" - SUM(COALESCE("expense"."current_amount", 0)) -
+       SUM(COALESCE("goal"."current_amount", 0)) - SUM(COALESCE("reserve"."current_amount", 0)) AS "safe",
+       SUM(COALESCE("expense"."current_amount", 0))                                        AS "expenses",
+       SUM(COALESCE("goal"."current_amount", 0))                                           AS "goals",
+       SUM(COALESCE("reserve"."current_amount", 0))                                        AS "credit_reserve",
+       "bank_account"."limit_balance"                                                      AS "limit",
+       CASE
+           WHEN "bank_account"."account_type" = 'loan' AND "bank_account"."original_balance" IS NOT NULL
+               THEN "bank_account"."original_balance" - "bank_account"."current_balance"
+           END                                                                             AS "paid_off"
+FROM "bank_accounts" AS "bank_account"
+LEFT JOIN (
+    SELECT spending.bank_account_id, spending.account_id, SUM(spending.current_amount) as "current_amount"
+    FROM spending
+    WHERE spending.spending_type = 0
+    GROUP BY spending.bank_account_id, spending.account_id
+) AS "expense"
+ON "expense"."bank_account_id" = "bank_account"."bank_account_id" AND
+   "expense"."account_id" = "bank_account"."account_id"
+LEFT JOIN (
+    SELECT spending.bank_account_id, spending.account_id, SUM(spending.current_amount) as "current_amount"
+    FROM spending
+    WHERE spending.spending_type = 1
+    GROUP BY spending.bank_account_id, spending.account_id
+) AS "goal" ON "goal"."bank_account_id" = "bank_account"."bank_account_id" AND
+               "goal"."account_id" = "bank_account"."account_id"
+LEFT JOIN (
+    SELECT credit.payment_bank_account_id AS "bank_account_id", credit.account_id, SUM(credit.current_balance) as "current_amount"
+    FROM bank_accounts AS credit
+    WHERE credit.account_type = 'credit' AND credit.payment_bank_account_id IS NOT NULL AND credit.current_balance > 0
+    GROUP BY credit.payment_bank_account_id, credit.account_id
+) AS "reserve" ON "reserve"."bank_account_id" = "bank_account"."bank_account_id" AND
+                  "reserve"."account_id" = "bank_account"."account_id"
+GROUP BY "bank_account"."bank_account_id", "bank_account"."account_id"
+);
@@ -0,0 +1,3 @@
+ALTER TABLE "spending" DROP COLUMN "currency";
+ALTER TABLE "transactions" DROP COLUMN "currency";
+ALTER TABLE "bank_accounts" DROP COLUMN "currency";
@@ -0,0 +1,3 @@
+ALTER TABLE "bank_accounts" ADD COLUMN "currency" TEXT NOT NULL DEFAULT 'USD';
+ALTER TABLE "transactions" ADD COLUMN "currency" TEXT NOT NULL DEFAULT 'USD';
+ALTER TABLE "spending" ADD COLUMN "currency" TEXT NOT NULL DEFAULT 'USD';
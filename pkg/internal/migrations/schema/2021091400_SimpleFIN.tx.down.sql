@@ -0,0 +1,2 @@
+ALTER TABLE "links" DROP COLUMN "simplefin_link_id";
+DROP TABLE IF EXISTS "simplefin_links";
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS "simplefin_links"
+(
+    "simplefin_link_id" BIGSERIAL NOT NULL,
+    "access_url"        TEXT      NOT NULL,
+    CONSTRAINT "pk_simplefin_links" PRIMARY KEY ("simplefin_link_id")
+);
+
+ALTER TABLE "links" ADD COLUMN "simplefin_link_id" BIGINT NULL;
+ALTER TABLE "links" ADD CONSTRAINT "fk_links_simplefin_links_simplefin_link_id" FOREIGN KEY ("simplefin_link_id") REFERENCES "simplefin_links" ("simplefin_link_id") ON DELETE SET NULL;
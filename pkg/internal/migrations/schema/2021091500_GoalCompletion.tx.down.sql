@@ -0,0 +1,3 @@
+ALTER TABLE "spending" DROP COLUMN "date_completed";
+ALTER TABLE "spending" DROP COLUMN "is_archived";
+ALTER TABLE "spending" DROP COLUMN "is_complete";
@@ -0,0 +1,3 @@
+ALTER TABLE "spending" ADD COLUMN "is_complete" BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE "spending" ADD COLUMN "is_archived" BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE "spending" ADD COLUMN "date_completed" TIMESTAMPTZ NULL;
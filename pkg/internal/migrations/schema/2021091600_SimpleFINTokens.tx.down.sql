@@ -0,0 +1,10 @@
+ALTER TABLE "simplefin_links" ADD COLUMN "access_url" TEXT NOT NULL DEFAULT '';
+
+UPDATE "simplefin_links" AS simplefin_link
+SET access_url = token.access_url
+FROM simplefin_tokens AS token
+WHERE token.simplefin_link_id = simplefin_link.simplefin_link_id;
+
+ALTER TABLE "simplefin_links" ALTER COLUMN "access_url" DROP DEFAULT;
+
+DROP TABLE IF EXISTS "simplefin_tokens";
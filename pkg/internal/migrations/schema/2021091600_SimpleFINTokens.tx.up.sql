@@ -0,0 +1,18 @@
+CREATE TABLE "simplefin_tokens" (
+    simplefin_link_id BIGINT NOT NULL,
+    account_id BIGINT NOT NULL,
+    access_url TEXT NOT NULL,
+    CONSTRAINT pk_simplefin_tokens PRIMARY KEY (account_id, simplefin_link_id),
+    CONSTRAINT fk_simplefin_tokens_account FOREIGN KEY (account_id) REFERENCES accounts (account_id) ON DELETE CASCADE,
+    CONSTRAINT fk_simplefin_tokens_simplefin_link FOREIGN KEY (simplefin_link_id) REFERENCES simplefin_links (simplefin_link_id) ON DELETE CASCADE
+);
+
+INSERT INTO "simplefin_tokens"(simplefin_link_id, access_url, account_id)
+SELECT
+    simplefin_link.simplefin_link_id,
+    simplefin_link.access_url,
+    link.account_id
+FROM simplefin_links AS simplefin_link
+INNER JOIN links AS link ON link.simplefin_link_id = simplefin_link.simplefin_link_id;
+
+ALTER TABLE "simplefin_links" DROP COLUMN "access_url";
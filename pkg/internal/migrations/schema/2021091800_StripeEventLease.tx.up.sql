@@ -0,0 +1,3 @@
+ALTER TABLE "stripe_events" ADD COLUMN "claimed_at" TIMESTAMPTZ NULL;
+UPDATE "stripe_events" SET "claimed_at" = "received_at";
+ALTER TABLE "stripe_events" ALTER COLUMN "claimed_at" SET NOT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "ix_plaid_webhooks_link_id";
+ALTER TABLE "plaid_webhooks" DROP CONSTRAINT IF EXISTS "fk_plaid_webhooks_links_link_id_account_id";
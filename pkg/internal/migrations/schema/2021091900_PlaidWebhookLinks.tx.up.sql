@@ -0,0 +1,15 @@
+-- Webhooks may have been recorded for links that have since been removed, those records can no longer reference a link.
+UPDATE "plaid_webhooks"
+SET "link_id" = NULL
+WHERE "link_id" IS NOT NULL
+  AND NOT EXISTS(
+        SELECT 1
+        FROM "links"
+        WHERE "links"."link_id" = "plaid_webhooks"."link_id"
+          AND "links"."account_id" = "plaid_webhooks"."account_id"
+    );
+
+ALTER TABLE "plaid_webhooks"
+    ADD CONSTRAINT "fk_plaid_webhooks_links_link_id_account_id" FOREIGN KEY ("link_id", "account_id") REFERENCES "links" ("link_id", "account_id") ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS "ix_plaid_webhooks_link_id" ON "plaid_webhooks" ("link_id");
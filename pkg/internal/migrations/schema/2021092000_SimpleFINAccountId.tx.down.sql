@@ -0,0 +1,5 @@
+UPDATE "bank_accounts"
+SET "plaid_account_id" = "simplefin_account_id"
+WHERE "simplefin_account_id" IS NOT NULL;
+
+ALTER TABLE "bank_accounts" DROP COLUMN "simplefin_account_id";
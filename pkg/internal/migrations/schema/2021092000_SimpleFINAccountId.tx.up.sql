@@ -0,0 +1,10 @@
+ALTER TABLE "bank_accounts" ADD COLUMN "simplefin_account_id" TEXT NULL;
+
+-- SimpleFIN account Ids were previously stored in the Plaid account Id column, move them to their own column.
+UPDATE "bank_accounts"
+SET "simplefin_account_id" = "bank_accounts"."plaid_account_id",
+    "plaid_account_id"     = NULL
+FROM "links"
+WHERE "links"."link_id" = "bank_accounts"."link_id"
+  AND "links"."account_id" = "bank_accounts"."account_id"
+  AND "links"."link_type" = 3;
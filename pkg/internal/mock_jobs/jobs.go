@@ -0,0 +1,133 @@
+package mock_jobs
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/monetr/rest-api/pkg/jobs"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ jobs.JobManager = &MockJobManager{}
+)
+
+// TriggeredJob is a job that was enqueued with the mock job manager. The job is not run.
+type TriggeredJob struct {
+	JobId string
+	Name  string
+	Args  map[string]interface{}
+}
+
+// MockJobManager records the jobs that are triggered rather than running them. Specific jobs can be made to fail to
+// enqueue with ShouldFail, or can be treated as though an identical job is already queued with AlreadyQueued.
+type MockJobManager struct {
+	lock          sync.Mutex
+	ShouldFail    map[string]bool
+	AlreadyQueued map[string]bool
+	Triggered     []TriggeredJob
+}
+
+func NewMockJobManager() *MockJobManager {
+	return &MockJobManager{
+		ShouldFail:    map[string]bool{},
+		AlreadyQueued: map[string]bool{},
+		Triggered:     make([]TriggeredJob, 0),
+	}
+}
+
+// GetTriggered returns all of the jobs with the provided name that have been triggered.
+func (m *MockJobManager) GetTriggered(name string) []TriggeredJob {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	result := make([]TriggeredJob, 0)
+	for _, job := range m.Triggered {
+		if job.Name == name {
+			result = append(result, job)
+		}
+	}
+
+	return result
+}
+
+func (m *MockJobManager) trigger(name string, args map[string]interface{}) (string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.ShouldFail[name] {
+		return "", errors.Errorf("failed to enqueue %s", name)
+	}
+
+	// Like gocraft, a job that is already queued is not an error but no new job is enqueued.
+	if m.AlreadyQueued[name] {
+		return "", nil
+	}
+
+	jobId := fmt.Sprintf("%s:%d", name, len(m.Triggered)+1)
+	m.Triggered = append(m.Triggered, TriggeredJob{
+		JobId: jobId,
+		Name:  name,
+		Args:  args,
+	})
+
+	return jobId, nil
+}
+
+func (m *MockJobManager) TriggerPullHistoricalTransactions(accountId, linkId uint64) (jobId string, err error) {
+	return m.trigger(jobs.PullHistoricalTransactions, map[string]interface{}{
+		"accountId": accountId,
+		"linkId":    linkId,
+	})
+}
+
+func (m *MockJobManager) TriggerPullInitialTransactions(accountId, userId, linkId uint64) (jobId string, err error) {
+	return m.trigger(jobs.PullInitialTransactions, map[string]interface{}{
+		"accountId": accountId,
+		"userId":    userId,
+		"linkId":    linkId,
+	})
+}
+
+func (m *MockJobManager) TriggerPullLatestTransactions(accountId, linkId uint64, numberOfTransactions int64) (jobId string, err error) {
+	return m.trigger(jobs.PullLatestTransactions, map[string]interface{}{
+		"accountId":            accountId,
+		"linkId":               linkId,
+		"numberOfTransactions": numberOfTransactions,
+	})
+}
+
+func (m *MockJobManager) TriggerRemoveTransactions(accountId, linkId uint64, removedTransactions []string) (jobId string, err error) {
+	return m.trigger(jobs.RemoveTransactions, map[string]interface{}{
+		"accountId":           accountId,
+		"linkId":              linkId,
+		"removedTransactions": removedTransactions,
+	})
+}
+
+func (m *MockJobManager) TriggerRemoveLink(accountId, userId, linkId uint64) (jobId string, err error) {
+	return m.trigger(jobs.RemoveLink, map[string]interface{}{
+		"accountId": accountId,
+		"userId":    userId,
+		"linkId":    linkId,
+	})
+}
+
+func (m *MockJobManager) TriggerSyncSimpleFINLink(accountId, linkId uint64) (jobId string, err error) {
+	return m.trigger(jobs.SyncSimpleFINLink, map[string]interface{}{
+		"accountId": accountId,
+		"linkId":    linkId,
+	})
+}
+
+func (m *MockJobManager) TriggerRecalculateTimezone(accountId, userId uint64, previousTimezone string) (jobId string, err error) {
+	return m.trigger(jobs.RecalculateTimezone, map[string]interface{}{
+		"accountId":        accountId,
+		"userId":           userId,
+		"previousTimezone": previousTimezone,
+	})
+}
+
+func (m *MockJobManager) Close() error {
+	return nil
+}
@@ -0,0 +1,44 @@
+package mock_jobs
+
+import (
+	"testing"
+
+	"github.com/monetr/rest-api/pkg/jobs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockJobManager(t *testing.T) {
+	t.Run("records jobs", func(t *testing.T) {
+		jobManager := NewMockJobManager()
+
+		jobId, err := jobManager.TriggerSyncSimpleFINLink(1, 2)
+		assert.NoError(t, err, "should trigger job")
+		assert.NotEmpty(t, jobId, "should return a job Id")
+
+		triggered := jobManager.GetTriggered(jobs.SyncSimpleFINLink)
+		assert.Len(t, triggered, 1, "should have triggered one job")
+		assert.Equal(t, jobId, triggered[0].JobId, "job Ids should match")
+		assert.EqualValues(t, 2, triggered[0].Args["linkId"], "should record the job's arguments")
+		assert.Empty(t, jobManager.GetTriggered(jobs.RemoveLink), "other jobs should not have been triggered")
+	})
+
+	t.Run("should fail", func(t *testing.T) {
+		jobManager := NewMockJobManager()
+		jobManager.ShouldFail[jobs.PullLatestTransactions] = true
+
+		jobId, err := jobManager.TriggerPullLatestTransactions(1, 2, 0)
+		assert.EqualError(t, err, "failed to enqueue PullLatestTransactions")
+		assert.Empty(t, jobId, "should not return a job Id")
+		assert.Empty(t, jobManager.GetTriggered(jobs.PullLatestTransactions), "failed jobs should not be recorded")
+	})
+
+	t.Run("already queued", func(t *testing.T) {
+		jobManager := NewMockJobManager()
+		jobManager.AlreadyQueued[jobs.PullLatestTransactions] = true
+
+		jobId, err := jobManager.TriggerPullLatestTransactions(1, 2, 0)
+		assert.NoError(t, err, "a job that is already queued should not be an error")
+		assert.Empty(t, jobId, "should not return a job Id")
+		assert.Empty(t, jobManager.GetTriggered(jobs.PullLatestTransactions), "no new job should be recorded")
+	})
+}
@@ -162,3 +162,54 @@ func MockGetAccountsError(t *testing.T, plaidError plaid.Error) {
 		PlaidHeaders,
 	)
 }
+
+// MockGetLiabilities will mock the liabilities endpoint. The statement balances are provided in whole units (dollars for
+// USD) keyed by the Plaid account Id, just like Plaid would return them. Only the accounts requested are returned.
+func MockGetLiabilities(t *testing.T, accounts []plaid.AccountBase, statements map[string]float64) {
+	mock_http_helper.NewHttpMockJsonResponder(
+		t,
+		"POST", Path(t, "/liabilities/get"),
+		func(t *testing.T, request *http.Request) (interface{}, int) {
+			ValidatePlaidAuthentication(t, request, RequireAccessToken)
+			var getLiabilitiesRequest struct {
+				AccessToken string `json:"access_token"`
+				Options     struct {
+					AccountIds []string `json:"account_ids"`
+				} `json:"options"`
+			}
+			require.NoError(t, json.NewDecoder(request.Body).Decode(&getLiabilitiesRequest), "must decode request")
+
+			requested := map[string]struct{}{}
+			for _, accountId := range getLiabilitiesRequest.Options.AccountIds {
+				requested[accountId] = struct{}{}
+			}
+
+			resultAccounts := make([]plaid.AccountBase, 0, len(accounts))
+			credit := make([]map[string]interface{}, 0, len(statements))
+			for _, account := range accounts {
+				if _, ok := requested[account.GetAccountId()]; !ok {
+					continue
+				}
+
+				resultAccounts = append(resultAccounts, account)
+				if statement, ok := statements[account.GetAccountId()]; ok {
+					credit = append(credit, map[string]interface{}{
+						"account_id":             account.GetAccountId(),
+						"last_statement_balance": statement,
+					})
+				}
+			}
+
+			return map[string]interface{}{
+				"accounts": resultAccounts,
+				"liabilities": map[string]interface{}{
+					"credit":   credit,
+					"mortgage": nil,
+					"student":  nil,
+				},
+				"request_id": gofakeit.UUID(),
+			}, http.StatusOK
+		},
+		PlaidHeaders,
+	)
+}
@@ -0,0 +1,80 @@
+package mock_plaid
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/monetr/rest-api/pkg/internal/mock_http_helper"
+	"github.com/monetr/rest-api/pkg/internal/myownsanity"
+	"github.com/plaid/plaid-go/plaid"
+	"github.com/stretchr/testify/require"
+)
+
+// InstitutionFixture returns an institution with the provided Id where the products we use have the provided status.
+func InstitutionFixture(t *testing.T, institutionId string, status string) plaid.Institution {
+	require.NotEmpty(t, institutionId, "institution Id cannot be empty")
+
+	productStatus := plaid.ProductStatus{
+		Status:           status,
+		LastStatusChange: time.Now().Add(-time.Hour).UTC(),
+	}
+
+	return plaid.Institution{
+		InstitutionId: institutionId,
+		Name:          gofakeit.Company() + " Bank",
+		Products: []plaid.Products{
+			plaid.PRODUCTS_TRANSACTIONS,
+		},
+		CountryCodes: []plaid.CountryCode{
+			plaid.COUNTRYCODE_US,
+		},
+		Url:            *plaid.NewNullableString(myownsanity.StringP(gofakeit.URL())),
+		PrimaryColor:   *plaid.NewNullableString(myownsanity.StringP(gofakeit.HexColor())),
+		Logo:           *plaid.NewNullableString(nil),
+		RoutingNumbers: []string{},
+		Oauth:          false,
+		Status: &plaid.InstitutionStatus{
+			ItemLogins:          productStatus,
+			TransactionsUpdates: productStatus,
+		},
+	}
+}
+
+// MockGetInstitution will create an httpmock responder for Plaid's institutions get by Id endpoint. Only the provided
+// institutions can be retrieved, any other institution Id will return an error.
+func MockGetInstitution(t *testing.T, institutions ...plaid.Institution) {
+	mock_http_helper.NewHttpMockJsonResponder(
+		t,
+		"POST", Path(t, "/institutions/get_by_id"),
+		func(t *testing.T, request *http.Request) (interface{}, int) {
+			ValidatePlaidAuthentication(t, request, DoNotRequireAccessToken)
+			var getRequest plaid.InstitutionsGetByIdRequest
+			require.NoError(t, json.NewDecoder(request.Body).Decode(&getRequest), "must decode request")
+
+			requestId := gofakeit.UUID()
+			for _, institution := range institutions {
+				if institution.InstitutionId != getRequest.InstitutionId {
+					continue
+				}
+
+				return plaid.InstitutionsGetByIdResponse{
+					Institution: institution,
+					RequestId:   requestId,
+				}, http.StatusOK
+			}
+
+			return plaid.Error{
+				RequestId:      &requestId,
+				ErrorType:      "INVALID_INPUT",
+				ErrorCode:      "INVALID_INSTITUTION",
+				ErrorMessage:   "invalid institution_id provided",
+				DisplayMessage: *plaid.NewNullableString(nil),
+				Status:         *plaid.NewNullableFloat32(myownsanity.Float32P(float32(http.StatusBadRequest))),
+			}, http.StatusBadRequest
+		},
+		PlaidHeaders,
+	)
+}
@@ -36,3 +36,40 @@ func MockCreateLinkToken(t *testing.T) {
 		PlaidHeaders,
 	)
 }
+
+// MockUpdateLinkToken mocks creating a link token for an existing item. The request must include an access token, and
+// account selection must only be enabled when accountSelection is true.
+func MockUpdateLinkToken(t *testing.T, accountSelection bool) {
+	mock_http_helper.NewHttpMockJsonResponder(
+		t,
+		"POST", Path(t, "/link/token/create"),
+		func(t *testing.T, request *http.Request) (interface{}, int) {
+			ValidatePlaidAuthentication(t, request, DoNotRequireAccessToken)
+			var updateLinkTokenRequest struct {
+				ClientName  string   `json:"client_name"`
+				Language    string   `json:"language"`
+				AccessToken *string  `json:"access_token"`
+				Products    []string `json:"products"`
+				Update      *struct {
+					AccountSelectionEnabled bool `json:"account_selection_enabled"`
+				} `json:"update"`
+			}
+			require.NoError(t, json.NewDecoder(request.Body).Decode(&updateLinkTokenRequest), "must decode request")
+			require.NotEmpty(t, updateLinkTokenRequest.ClientName, "client name is required")
+			require.NotEmpty(t, updateLinkTokenRequest.Language, "language is required")
+			require.NotNil(t, updateLinkTokenRequest.AccessToken, "access token is required when updating a link")
+			require.Empty(t, updateLinkTokenRequest.Products, "products array must be empty when updating a link")
+
+			accountSelectionEnabled := updateLinkTokenRequest.Update != nil &&
+				updateLinkTokenRequest.Update.AccountSelectionEnabled
+			require.Equal(t, accountSelection, accountSelectionEnabled, "account selection does not match")
+
+			return plaid.LinkTokenCreateResponse{
+				LinkToken:  gofakeit.UUID(),
+				Expiration: time.Now().Add(30 * time.Second),
+				RequestId:  gofakeit.UUID(),
+			}, http.StatusOK
+		},
+		PlaidHeaders,
+	)
+}
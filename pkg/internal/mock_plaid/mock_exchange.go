@@ -15,6 +15,12 @@ import (
 // public token that should be provided in the request. If the request's public token does not match the one returned
 // here then an error is returned.
 func MockExchangePublicToken(t *testing.T) string {
+	return MockExchangePublicTokenForItem(t, gofakeit.UUID())
+}
+
+// MockExchangePublicTokenForItem is the same as MockExchangePublicToken, but the exchanged token will belong to the
+// provided item Id. This can be used when the item Id is needed later, like when sending webhooks for the item.
+func MockExchangePublicTokenForItem(t *testing.T, itemId string) string {
 	publicToken := gofakeit.UUID()
 
 	mock_http_helper.NewHttpMockJsonResponder(
@@ -42,7 +48,7 @@ func MockExchangePublicToken(t *testing.T) string {
 			return plaid.ItemPublicTokenExchangeResponse{
 				RequestId:   requestId,
 				AccessToken: gofakeit.UUID(),
-				ItemId:      gofakeit.UUID(),
+				ItemId:      itemId,
 			}, http.StatusOK
 		},
 		PlaidHeaders,
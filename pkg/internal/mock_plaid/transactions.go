@@ -2,6 +2,7 @@ package mock_plaid
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/brianvoe/gofakeit/v6"
 	"github.com/monetr/rest-api/pkg/internal/mock_http_helper"
 	"github.com/monetr/rest-api/pkg/internal/myownsanity"
@@ -41,7 +42,12 @@ func GenerateTransactions(t *testing.T, start, end time.Time, numberOfTransactio
 }
 
 func MockGetRandomTransactions(t *testing.T, start, end time.Time, numberOfTransactions int, bankAccountIds []string) {
-	transactions := GenerateTransactions(t, start, end, numberOfTransactions, bankAccountIds)
+	MockGetTransactions(t, GenerateTransactions(t, start, end, numberOfTransactions, bankAccountIds))
+}
+
+// MockGetTransactions will mock the transactions get endpoint, the provided transactions are paginated based on the
+// count and offset requested.
+func MockGetTransactions(t *testing.T, transactions []plaid.Transaction) {
 	mock_http_helper.NewHttpMockJsonResponder(
 		t,
 		"POST", Path(t, "/transactions/get"),
@@ -85,3 +91,58 @@ func MockGetRandomTransactions(t *testing.T, start, end time.Time, numberOfTrans
 		PlaidHeaders,
 	)
 }
+
+// MockSyncTransactions will mock the transactions sync endpoint. The added transactions are paginated based on the
+// count requested, the modified and removed transactions are returned with the last page. The cursor that is returned
+// with the final page is provided so tests can make sure it was stored.
+func MockSyncTransactions(t *testing.T, added, modified []plaid.Transaction, removed []string) (finalCursor string) {
+	finalCursor = fmt.Sprintf("offset:%d", len(added))
+	mock_http_helper.NewHttpMockJsonResponder(
+		t,
+		"POST", Path(t, "/transactions/sync"),
+		func(t *testing.T, request *http.Request) (interface{}, int) {
+			ValidatePlaidAuthentication(t, request, RequireAccessToken)
+			var syncRequest struct {
+				AccessToken string  `json:"access_token"`
+				Cursor      *string `json:"cursor"`
+				Count       int     `json:"count"`
+			}
+			require.NoError(t, json.NewDecoder(request.Body).Decode(&syncRequest), "must decode request")
+			require.NotZero(t, syncRequest.Count, "count must be provided")
+
+			offset := 0
+			if syncRequest.Cursor != nil {
+				_, err := fmt.Sscanf(*syncRequest.Cursor, "offset:%d", &offset)
+				require.NoError(t, err, "must provide a valid cursor")
+			}
+
+			endingOffset := myownsanity.Min(len(added), offset+syncRequest.Count)
+			hasMore := endingOffset < len(added)
+
+			response := map[string]interface{}{
+				"added":       added[offset:endingOffset],
+				"modified":    []plaid.Transaction{},
+				"removed":     []map[string]string{},
+				"next_cursor": fmt.Sprintf("offset:%d", endingOffset),
+				"has_more":    hasMore,
+				"request_id":  gofakeit.UUID(),
+			}
+
+			if !hasMore {
+				removedItems := make([]map[string]string, len(removed))
+				for i, transactionId := range removed {
+					removedItems[i] = map[string]string{
+						"transaction_id": transactionId,
+					}
+				}
+				response["modified"] = modified
+				response["removed"] = removedItems
+			}
+
+			return response, http.StatusOK
+		},
+		PlaidHeaders,
+	)
+
+	return finalCursor
+}
@@ -1,19 +1,58 @@
 package mock_plaid
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
 	"testing"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
+	"github.com/form3tech-oss/jwt-go"
 	"github.com/monetr/rest-api/pkg/internal/mock_http_helper"
 	"github.com/monetr/rest-api/pkg/internal/myownsanity"
 	"github.com/plaid/plaid-go/plaid"
 	"github.com/stretchr/testify/require"
 )
 
-func MockGetWebhookVerificationKey(t *testing.T) {
+// WebhookSigner holds the private key behind the verification key returned by MockGetWebhookVerificationKey. It can be
+// used to sign webhook bodies the same way that Plaid would.
+type WebhookSigner struct {
+	keyId string
+	key   *ecdsa.PrivateKey
+}
+
+// Sign returns the value for the Plaid-Verification header of a webhook with the provided body.
+func (w *WebhookSigner) Sign(t *testing.T, body []byte) string {
+	hash := sha256.Sum256(body)
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iat":                 time.Now().Unix(),
+		"request_body_sha256": hex.EncodeToString(hash[:]),
+	})
+	token.Header["kid"] = w.keyId
+
+	signed, err := token.SignedString(w.key)
+	require.NoError(t, err, "must sign webhook")
+
+	return signed
+}
+
+// MockGetWebhookVerificationKey will generate a new ES256 key and mock the verification key endpoint to return the
+// public half of it. The returned signer can be used to sign webhooks that will pass verification.
+func MockGetWebhookVerificationKey(t *testing.T) *WebhookSigner {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err, "must generate webhook verification key")
+
+	signer := &WebhookSigner{
+		keyId: gofakeit.UUID(),
+		key:   key,
+	}
+
 	mock_http_helper.NewHttpMockJsonResponder(t,
 		"POST", Path(t, "/webhook_verification_key/get"),
 		func(t *testing.T, request *http.Request) (interface{}, int) {
@@ -21,19 +60,15 @@ func MockGetWebhookVerificationKey(t *testing.T) {
 			var requestBody plaid.WebhookVerificationKeyGetRequest
 			require.NoError(t, json.NewDecoder(request.Body).Decode(&requestBody), "must decode request")
 
-			// TODO webhooks: Properly implement testing for webhook verification keys.
-			// I have little to know idea how to actually implement the key issuing side of this code. I will need to learn
-			// how it actually works and then build a mock system off of it. In the mean time I will leave this blank for
-			// now.
 			return plaid.WebhookVerificationKeyGetResponse{
 				Key: plaid.JWKPublicKey{
-					Alg:       "",
-					Crv:       "",
+					Alg:       "ES256",
+					Crv:       "P-256",
 					Kid:       requestBody.KeyId,
-					Kty:       "",
-					Use:       "",
-					X:         "",
-					Y:         "",
+					Kty:       "EC",
+					Use:       "sig",
+					X:         base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+					Y:         base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
 					CreatedAt: int32(time.Now().Unix()),
 					ExpiredAt: *plaid.NewNullableInt32(myownsanity.Int32P(int32(time.Now().Add(10 * time.Second).Unix()))),
 				},
@@ -43,4 +78,6 @@ func MockGetWebhookVerificationKey(t *testing.T) {
 		},
 		PlaidHeaders,
 	)
+
+	return signer
 }
@@ -0,0 +1,58 @@
+package mock_secrets
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/monetr/rest-api/pkg/secrets"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ secrets.SimpleFINSecretsProvider = &MockSimpleFINSecrets{}
+)
+
+type MockSimpleFINSecrets struct {
+	store map[string]string
+}
+
+func NewMockSimpleFINSecrets() *MockSimpleFINSecrets {
+	return &MockSimpleFINSecrets{
+		store: map[string]string{},
+	}
+}
+
+func (m *MockSimpleFINSecrets) WithSecret(accountId, simpleFINLinkId uint64, accessUrl string) *MockSimpleFINSecrets {
+	m.store[m.getKey(accountId, simpleFINLinkId)] = accessUrl
+
+	return m
+}
+
+func (m *MockSimpleFINSecrets) getKey(accountId, simpleFINLinkId uint64) string {
+	return fmt.Sprintf("secret/simplefin/data/%X/%X", accountId, simpleFINLinkId)
+}
+
+func (m *MockSimpleFINSecrets) UpdateAccessUrlForSimpleFINLink(ctx context.Context, accountId, simpleFINLinkId uint64, accessUrl string) error {
+	m.store[m.getKey(accountId, simpleFINLinkId)] = accessUrl
+
+	return nil
+}
+
+func (m *MockSimpleFINSecrets) GetAccessUrlForSimpleFINLink(ctx context.Context, accountId, simpleFINLinkId uint64) (accessUrl string, err error) {
+	accessUrl, ok := m.store[m.getKey(accountId, simpleFINLinkId)]
+	if !ok {
+		return "", errors.Errorf("failed to retrieve access url")
+	}
+
+	return accessUrl, nil
+}
+
+func (m *MockSimpleFINSecrets) RemoveAccessUrlForSimpleFINLink(ctx context.Context, accountId, simpleFINLinkId uint64) error {
+	delete(m.store, m.getKey(accountId, simpleFINLinkId))
+
+	return nil
+}
+
+func (m *MockSimpleFINSecrets) Close() error {
+	return nil
+}
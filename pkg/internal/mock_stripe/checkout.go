@@ -166,6 +166,20 @@ func (m *MockStripeHelper) CompleteCheckoutSession(t *testing.T, checkoutSession
 
 	customer := m.customers[checkoutSession.Customer.ID]
 
+	// Each line item of the checkout session becomes an item of the subscription.
+	items := &stripe.SubscriptionItemList{
+		Data: make([]*stripe.SubscriptionItem, 0),
+	}
+	if checkoutSession.LineItems != nil {
+		for _, lineItem := range checkoutSession.LineItems.Data {
+			items.Data = append(items.Data, &stripe.SubscriptionItem{
+				ID:       FakeStripeSubscriptionItemId(t),
+				Price:    lineItem.Price,
+				Quantity: lineItem.Quantity,
+			})
+		}
+	}
+
 	subscription := &stripe.Subscription{
 		ApplicationFeePercent: 0,
 		AutomaticTax:          nil,
@@ -185,7 +199,7 @@ func (m *MockStripeHelper) CompleteCheckoutSession(t *testing.T, checkoutSession
 		DefaultTaxRates:               nil,
 		Discount:                      nil,
 		EndedAt:                       0,
-		Items:                         nil,
+		Items:                         items,
 		LatestInvoice:                 nil,
 		Livemode:                      false,
 		Metadata:                      nil,
@@ -47,4 +47,9 @@ func FakeStripeRequestId(t *testing.T) string {
 	id := fmt.Sprintf("req_%s", testutils.MustGenerateRandomString(t, 14))
 	require.NotEmpty(t, id, "stripe request id cannot be empty")
 	return id
-}
\ No newline at end of file
+}
+func FakeStripeSubscriptionItemId(t *testing.T) string {
+	id := fmt.Sprintf("si_%s", testutils.MustGenerateRandomString(t, 14))
+	require.NotEmpty(t, id, "stripe subscription item id cannot be empty")
+	return id
+}
@@ -3,8 +3,11 @@ package mock_stripe
 import (
 	"fmt"
 	"github.com/monetr/rest-api/pkg/internal/mock_http_helper"
+	"github.com/stretchr/testify/require"
 	"github.com/stripe/stripe-go/v72"
+	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 	"testing"
 )
@@ -40,6 +43,62 @@ func (m *MockStripeHelper) MockGetSubscription(t *testing.T) {
 	)
 }
 
+// MockUpdateSubscription will update the price of the subscription's items. Only the price of existing items can be
+// changed, items cannot be added or removed.
+func (m *MockStripeHelper) MockUpdateSubscription(t *testing.T) {
+	mock_http_helper.NewHttpMockJsonResponder(t,
+		"POST", RegexPath(t, `/v1/subscriptions/.+\z`),
+		func(t *testing.T, request *http.Request) (interface{}, int) {
+			subscriptionId := strings.TrimSpace(strings.TrimPrefix(request.URL.String(), Path(t, "/v1/subscriptions/")))
+
+			subscription, ok := m.subscriptions[subscriptionId]
+			if !ok {
+				return NewResourceMissingError(t, subscriptionId, "subscription"), http.StatusNotFound
+			}
+
+			body, err := ioutil.ReadAll(request.Body)
+			require.NoError(t, err, "failed to read request body")
+
+			form, err := url.ParseQuery(string(body))
+			require.NoError(t, err, "failed to parse body")
+
+			stripeForm, err := ParseStripeForm(form)
+			require.NoError(t, err, "stripe form must be valid")
+
+			if itemsForm, ok := stripeForm["items"].(map[string]interface{}); ok {
+				for _, tupleRaw := range itemsForm {
+					tuple, ok := tupleRaw.(map[string]interface{})
+					require.True(t, ok, "must be able to convert tupleRaw into a map")
+
+					itemId, _ := tuple["id"].(string)
+					price, _ := tuple["price"].(string)
+					require.NotNil(t, subscription.Items, "subscription must have items to update")
+
+					found := false
+					for _, item := range subscription.Items.Data {
+						if item.ID != itemId {
+							continue
+						}
+
+						found = true
+						if price != "" {
+							item.Price = &stripe.Price{
+								ID: price,
+							}
+						}
+					}
+					require.Truef(t, found, "subscription item with ID (%s) not in mock helper", itemId)
+				}
+			}
+
+			m.subscriptions[subscriptionId] = subscription
+
+			return subscription, http.StatusOK
+		},
+		StripeHeaders,
+	)
+}
+
 func (m *MockStripeHelper) CreateSubscription(t *testing.T, subscription *stripe.Subscription) {
 	for { // Make sure the subscription ID is unique
 		subscription.ID = FakeStripeSubscriptionId(t)
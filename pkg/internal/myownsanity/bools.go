@@ -0,0 +1,5 @@
+package myownsanity
+
+func BoolP(value bool) *bool {
+	return &value
+}
@@ -0,0 +1,13 @@
+package myownsanity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoolP(t *testing.T) {
+	result := BoolP(true)
+	assert.NotNil(t, result, "resulting pointer should never be nil")
+	assert.True(t, *result, "and the underlying value should match the input")
+}
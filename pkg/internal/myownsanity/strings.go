@@ -21,3 +21,19 @@ func SliceContains(slice []string, item string) bool {
 
 	return false
 }
+
+// StringSlicesEqual returns true if both slices contain the same items in the same order. A nil slice is considered
+// equal to an empty slice.
+func StringSlicesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -38,3 +38,10 @@ func TestSliceContains(t *testing.T) {
 	assert.True(t, SliceContains(data, "Item #1"), "should contain item #1")
 	assert.False(t, SliceContains(data, "Item #3"), "should contain item #3")
 }
+
+func TestStringSlicesEqual(t *testing.T) {
+	assert.True(t, StringSlicesEqual(nil, []string{}), "nil and empty slices should be equal")
+	assert.True(t, StringSlicesEqual([]string{"a", "b"}, []string{"a", "b"}), "same items should be equal")
+	assert.False(t, StringSlicesEqual([]string{"a", "b"}, []string{"b", "a"}), "order should matter")
+	assert.False(t, StringSlicesEqual([]string{"a"}, []string{"a", "b"}), "different lengths should not be equal")
+}
@@ -0,0 +1,378 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MicahParks/keyfunc"
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrInvalidIdToken = errors.New("id token is not valid")
+)
+
+// Provider is a single OpenID Connect identity provider that users can authenticate with.
+type Provider interface {
+	// AuthorizationURL returns the URL that the user should be sent to in order to authenticate with the identity
+	// provider. The state and nonce should be random values that are stored until the user returns, and the code
+	// verifier is used to derive the PKCE code challenge.
+	AuthorizationURL(ctx context.Context, state, nonce, codeVerifier string) (string, error)
+	// Exchange will exchange the authorization code returned by the identity provider for an ID token, verify that
+	// token and return its claims. The nonce must match the nonce used to create the authorization URL.
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Claims, error)
+	Close() error
+}
+
+// Claims are the claims from an ID token that we care about.
+type Claims struct {
+	Issuer        string   `json:"iss"`
+	Subject       string   `json:"sub"`
+	Audience      audience `json:"aud"`
+	ExpiresAt     int64    `json:"exp"`
+	IssuedAt      int64    `json:"iat"`
+	NotBefore     int64    `json:"nbf,omitempty"`
+	Nonce         string   `json:"nonce"`
+	Email         string   `json:"email"`
+	EmailVerified bool     `json:"email_verified"`
+	GivenName     string   `json:"given_name"`
+	FamilyName    string   `json:"family_name"`
+	Name          string   `json:"name"`
+}
+
+// Valid is called by the JWT library after the token's signature has been verified. Issuer, audience and nonce are
+// checked separately as they depend on the provider.
+func (c Claims) Valid() error {
+	vErr := new(jwt.ValidationError)
+	now := jwt.TimeFunc().Unix()
+
+	if c.ExpiresAt == 0 || now > c.ExpiresAt {
+		vErr.Inner = errors.New("token is expired")
+		vErr.Errors |= jwt.ValidationErrorExpired
+	}
+
+	if c.NotBefore != 0 && now < c.NotBefore {
+		vErr.Inner = errors.New("token is not valid yet")
+		vErr.Errors |= jwt.ValidationErrorNotValidYet
+	}
+
+	if vErr.Errors == 0 {
+		return nil
+	}
+
+	return vErr
+}
+
+// audience is the aud claim of a token. It can be either a single string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return errors.Wrap(err, "aud claim must be a string or an array of strings")
+	}
+
+	*a = multiple
+	return nil
+}
+
+func (a audience) contains(value string) bool {
+	for _, item := range a {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewCodeVerifier returns a random PKCE code verifier. It can also be used to generate state and nonce values.
+func NewCodeVerifier() (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", errors.Wrap(err, "failed to generate random value")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// codeChallenge returns the S256 PKCE code challenge for the provided code verifier.
+func codeChallenge(codeVerifier string) string {
+	hash := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+var (
+	_ Provider = &openIdProvider{}
+)
+
+type openIdProvider struct {
+	log           *logrus.Entry
+	configuration config.OIDC
+	client        *http.Client
+
+	lock      sync.Mutex
+	discovery *discoveryDocument
+	jwks      *keyfunc.JWKS
+}
+
+// NewProvider returns an OpenID Connect provider for the provided configuration. The provider's discovery document
+// is not retrieved until it is first needed, this way the API can still start if the identity provider is down.
+func NewProvider(log *logrus.Entry, configuration config.OIDC) Provider {
+	return &openIdProvider{
+		log:           log,
+		configuration: configuration,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+func (o *openIdProvider) getScopes() []string {
+	scopes := []string{"openid", "email", "profile"}
+	for _, scope := range o.configuration.Scopes {
+		found := false
+		for _, existing := range scopes {
+			if strings.EqualFold(scope, existing) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return scopes
+}
+
+// discover will retrieve the discovery document and signing keys for the provider if they have not already been
+// retrieved.
+func (o *openIdProvider) discover(ctx context.Context) (*discoveryDocument, *keyfunc.JWKS, error) {
+	span := sentry.StartSpan(ctx, "OIDC - Discover")
+	defer span.Finish()
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if o.discovery != nil && o.jwks != nil {
+		return o.discovery, o.jwks, nil
+	}
+
+	discoveryUrl := strings.TrimSuffix(o.configuration.Issuer, "/") + "/.well-known/openid-configuration"
+	o.log.WithField("url", discoveryUrl).Debug("retrieving openid connect discovery document")
+
+	request, err := http.NewRequestWithContext(span.Context(), http.MethodGet, discoveryUrl, nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create discovery request")
+	}
+
+	response, err := o.client.Do(request)
+	if err != nil {
+		span.Status = sentry.SpanStatusUnavailable
+		return nil, nil, errors.Wrap(err, "failed to retrieve discovery document")
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		span.Status = sentry.SpanStatusUnavailable
+		return nil, nil, errors.Errorf("failed to retrieve discovery document, status: %d", response.StatusCode)
+	}
+
+	var discovery discoveryDocument
+	if err = json.NewDecoder(response.Body).Decode(&discovery); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to decode discovery document")
+	}
+
+	// The issuer must be exactly the one that was configured (OpenID Connect Discovery 1.0 section 4.3), otherwise a
+	// discovery document could direct us to trust ID tokens from a different issuer.
+	if discovery.Issuer != o.configuration.Issuer {
+		return nil, nil, errors.Errorf("discovery document issuer %q does not match the configured issuer %q", discovery.Issuer, o.configuration.Issuer)
+	}
+
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JwksUri == "" {
+		return nil, nil, errors.New("discovery document is missing required endpoints")
+	}
+
+	refreshUnknownKid := true
+	jwks, err := keyfunc.Get(discovery.JwksUri, keyfunc.Options{
+		Client:            o.client,
+		RefreshUnknownKID: &refreshUnknownKid,
+	})
+	if err != nil {
+		span.Status = sentry.SpanStatusUnavailable
+		return nil, nil, errors.Wrap(err, "failed to retrieve signing keys")
+	}
+
+	o.discovery = &discovery
+	o.jwks = jwks
+
+	return o.discovery, o.jwks, nil
+}
+
+func (o *openIdProvider) AuthorizationURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	span := sentry.StartSpan(ctx, "OIDC - AuthorizationURL")
+	defer span.Finish()
+
+	discovery, _, err := o.discover(span.Context())
+	if err != nil {
+		return "", err
+	}
+
+	authorizationUrl, err := url.Parse(discovery.AuthorizationEndpoint)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse authorization endpoint")
+	}
+
+	query := authorizationUrl.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", o.configuration.ClientID)
+	query.Set("redirect_uri", o.configuration.RedirectURL)
+	query.Set("scope", strings.Join(o.getScopes(), " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+	authorizationUrl.RawQuery = query.Encode()
+
+	return authorizationUrl.String(), nil
+}
+
+func (o *openIdProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Claims, error) {
+	span := sentry.StartSpan(ctx, "OIDC - Exchange")
+	defer span.Finish()
+
+	discovery, jwks, err := o.discover(span.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", o.configuration.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+
+	request, err := http.NewRequestWithContext(
+		span.Context(),
+		http.MethodPost,
+		discovery.TokenEndpoint,
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create token request")
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	request.SetBasicAuth(url.QueryEscape(o.configuration.ClientID), url.QueryEscape(o.configuration.ClientSecret))
+
+	response, err := o.client.Do(request)
+	if err != nil {
+		span.Status = sentry.SpanStatusUnavailable
+		return nil, errors.Wrap(err, "failed to exchange authorization code")
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read token response")
+	}
+
+	var tokenResponse struct {
+		IdToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err = json.Unmarshal(body, &tokenResponse); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode token response, status: %d", response.StatusCode)
+	}
+
+	if response.StatusCode != http.StatusOK || tokenResponse.Error != "" {
+		span.Status = sentry.SpanStatusPermissionDenied
+		return nil, errors.Errorf(
+			"failed to exchange authorization code: %s %s",
+			tokenResponse.Error,
+			tokenResponse.ErrorDescription,
+		)
+	}
+
+	if tokenResponse.IdToken == "" {
+		return nil, errors.Wrap(ErrInvalidIdToken, "token response did not include an id token")
+	}
+
+	return o.verify(jwks, discovery, tokenResponse.IdToken, nonce)
+}
+
+func (o *openIdProvider) verify(jwks *keyfunc.JWKS, discovery *discoveryDocument, idToken, nonce string) (*Claims, error) {
+	var claims Claims
+	result, err := jwt.ParseWithClaims(idToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		// Only allow asymmetric signing methods, this prevents a token being signed with our client secret or with no
+		// signature at all.
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA, *jwt.SigningMethodRSAPSS:
+		default:
+			return nil, errors.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return jwks.KeyFuncF3T(token)
+	})
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidIdToken, err.Error())
+	}
+
+	if !result.Valid {
+		return nil, errors.WithStack(ErrInvalidIdToken)
+	}
+
+	if claims.Issuer != discovery.Issuer {
+		return nil, errors.Wrap(ErrInvalidIdToken, "issuer does not match")
+	}
+
+	if !claims.Audience.contains(o.configuration.ClientID) {
+		return nil, errors.Wrap(ErrInvalidIdToken, "audience does not match")
+	}
+
+	if claims.Nonce != nonce {
+		return nil, errors.Wrap(ErrInvalidIdToken, "nonce does not match")
+	}
+
+	return &claims, nil
+}
+
+func (o *openIdProvider) Close() error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if o.jwks != nil {
+		o.jwks.EndBackground()
+	}
+
+	return nil
+}
@@ -0,0 +1,272 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/jarcoal/httpmock"
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/internal/mock_http_helper"
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIssuer   = "https://sso.monetr.mini"
+	testClientId = "monetr"
+	testKeyId    = "test-key"
+)
+
+// mockIdentityProvider registers responders for the discovery document, signing keys and token endpoint of a fake
+// identity provider. The returned function is used to provide the claims that the next ID token should have.
+func mockIdentityProvider(t *testing.T) func(claims jwt.MapClaims) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err, "must generate signing key")
+
+	mock_http_helper.NewHttpMockJsonResponder(t,
+		"GET", testIssuer+"/.well-known/openid-configuration",
+		func(t *testing.T, request *http.Request) (interface{}, int) {
+			return map[string]interface{}{
+				"issuer":                 testIssuer,
+				"authorization_endpoint": testIssuer + "/authorize",
+				"token_endpoint":         testIssuer + "/token",
+				"jwks_uri":               testIssuer + "/jwks",
+			}, http.StatusOK
+		},
+		func(t *testing.T, request *http.Request, response interface{}, status int) map[string][]string {
+			return nil
+		},
+	)
+
+	mock_http_helper.NewHttpMockJsonResponder(t,
+		"GET", testIssuer+"/jwks",
+		func(t *testing.T, request *http.Request) (interface{}, int) {
+			return map[string]interface{}{
+				"keys": []map[string]interface{}{
+					{
+						"kty": "RSA",
+						"kid": testKeyId,
+						"use": "sig",
+						"alg": "RS256",
+						"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+						"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+					},
+				},
+			}, http.StatusOK
+		},
+		func(t *testing.T, request *http.Request, response interface{}, status int) map[string][]string {
+			return nil
+		},
+	)
+
+	var claims jwt.MapClaims
+	mock_http_helper.NewHttpMockJsonResponder(t,
+		"POST", testIssuer+"/token",
+		func(t *testing.T, request *http.Request) (interface{}, int) {
+			require.NoError(t, request.ParseForm(), "must parse token request")
+			if request.PostForm.Get("code_verifier") == "" {
+				return map[string]interface{}{
+					"error": "invalid_request",
+				}, http.StatusBadRequest
+			}
+
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+			token.Header["kid"] = testKeyId
+			idToken, err := token.SignedString(key)
+			require.NoError(t, err, "must sign id token")
+
+			return map[string]interface{}{
+				"access_token": gofakeit.UUID(),
+				"token_type":   "Bearer",
+				"id_token":     idToken,
+			}, http.StatusOK
+		},
+		func(t *testing.T, request *http.Request, response interface{}, status int) map[string][]string {
+			return nil
+		},
+	)
+
+	return func(next jwt.MapClaims) {
+		claims = next
+	}
+}
+
+func newTestProvider(t *testing.T) Provider {
+	provider := NewProvider(testutils.GetLog(t), config.OIDC{
+		Enabled:      true,
+		Issuer:       testIssuer,
+		ClientID:     testClientId,
+		ClientSecret: gofakeit.UUID(),
+		RedirectURL:  "https://app.monetr.mini/login/oidc",
+	})
+	t.Cleanup(func() {
+		assert.NoError(t, provider.Close(), "must close provider")
+	})
+
+	return provider
+}
+
+func TestOpenIdProvider_AuthorizationURL(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	mockIdentityProvider(t)
+	provider := newTestProvider(t)
+
+	codeVerifier, err := NewCodeVerifier()
+	require.NoError(t, err, "must generate code verifier")
+
+	result, err := provider.AuthorizationURL(context.Background(), "state", "nonce", codeVerifier)
+	assert.NoError(t, err, "must create authorization url")
+
+	authorizationUrl, err := url.Parse(result)
+	require.NoError(t, err, "must parse authorization url")
+	assert.Equal(t, "/authorize", authorizationUrl.Path)
+
+	query := authorizationUrl.Query()
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, testClientId, query.Get("client_id"))
+	assert.Equal(t, "openid email profile", query.Get("scope"))
+	assert.Equal(t, "state", query.Get("state"))
+	assert.Equal(t, "nonce", query.Get("nonce"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(t, codeChallenge(codeVerifier), query.Get("code_challenge"))
+	assert.NotEqual(t, codeVerifier, query.Get("code_challenge"), "code verifier must not be sent")
+}
+
+func TestOpenIdProvider_Discover(t *testing.T) {
+	t.Run("mismatched issuer", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		mock_http_helper.NewHttpMockJsonResponder(t,
+			"GET", testIssuer+"/.well-known/openid-configuration",
+			func(t *testing.T, request *http.Request) (interface{}, int) {
+				return map[string]interface{}{
+					"issuer":                 "https://attacker.monetr.mini",
+					"authorization_endpoint": testIssuer + "/authorize",
+					"token_endpoint":         testIssuer + "/token",
+					"jwks_uri":               testIssuer + "/jwks",
+				}, http.StatusOK
+			},
+			func(t *testing.T, request *http.Request, response interface{}, status int) map[string][]string {
+				return nil
+			},
+		)
+
+		provider := newTestProvider(t)
+
+		codeVerifier, err := NewCodeVerifier()
+		require.NoError(t, err, "must generate code verifier")
+
+		result, err := provider.AuthorizationURL(context.Background(), "state", "nonce", codeVerifier)
+		assert.EqualError(t, err, `discovery document issuer "https://attacker.monetr.mini" does not match the configured issuer "https://sso.monetr.mini"`)
+		assert.Empty(t, result, "should not return an authorization url")
+	})
+}
+
+func TestOpenIdProvider_Exchange(t *testing.T) {
+	newClaims := func(email, nonce string) jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":            testIssuer,
+			"sub":            gofakeit.UUID(),
+			"aud":            testClientId,
+			"exp":            time.Now().Add(time.Minute).Unix(),
+			"iat":            time.Now().Unix(),
+			"nonce":          nonce,
+			"email":          email,
+			"email_verified": true,
+			"given_name":     gofakeit.FirstName(),
+		}
+	}
+
+	t.Run("simple", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		setClaims := mockIdentityProvider(t)
+		provider := newTestProvider(t)
+
+		email := gofakeit.Email()
+		setClaims(newClaims(email, "nonce"))
+
+		claims, err := provider.Exchange(context.Background(), "code", "verifier", "nonce")
+		assert.NoError(t, err, "must exchange code")
+		require.NotNil(t, claims, "claims must be returned")
+		assert.Equal(t, email, claims.Email)
+		assert.True(t, claims.EmailVerified)
+	})
+
+	t.Run("audience array", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		setClaims := mockIdentityProvider(t)
+		provider := newTestProvider(t)
+
+		claims := newClaims(gofakeit.Email(), "nonce")
+		claims["aud"] = []string{"something-else", testClientId}
+		setClaims(claims)
+
+		result, err := provider.Exchange(context.Background(), "code", "verifier", "nonce")
+		assert.NoError(t, err, "must exchange code")
+		assert.NotNil(t, result, "claims must be returned")
+	})
+
+	t.Run("wrong nonce", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		setClaims := mockIdentityProvider(t)
+		provider := newTestProvider(t)
+
+		setClaims(newClaims(gofakeit.Email(), "other nonce"))
+
+		claims, err := provider.Exchange(context.Background(), "code", "verifier", "nonce")
+		assert.Equal(t, ErrInvalidIdToken, errors.Cause(err), "should be an invalid token")
+		assert.Nil(t, claims)
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		setClaims := mockIdentityProvider(t)
+		provider := newTestProvider(t)
+
+		claims := newClaims(gofakeit.Email(), "nonce")
+		claims["aud"] = "another-client"
+		setClaims(claims)
+
+		result, err := provider.Exchange(context.Background(), "code", "verifier", "nonce")
+		assert.Equal(t, ErrInvalidIdToken, errors.Cause(err), "should be an invalid token")
+		assert.Nil(t, result)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		setClaims := mockIdentityProvider(t)
+		provider := newTestProvider(t)
+
+		claims := newClaims(gofakeit.Email(), "nonce")
+		claims["exp"] = time.Now().Add(-time.Minute).Unix()
+		setClaims(claims)
+
+		result, err := provider.Exchange(context.Background(), "code", "verifier", "nonce")
+		assert.Equal(t, ErrInvalidIdToken, errors.Cause(err), "should be an invalid token")
+		assert.Nil(t, result)
+	})
+}
@@ -1,38 +1,16 @@
 package platypus
 
-import "github.com/plaid/plaid-go/plaid"
+import (
+	"github.com/monetr/rest-api/pkg/currency"
+	"github.com/monetr/rest-api/pkg/internal/aggregator"
+	"github.com/plaid/plaid-go/plaid"
+)
 
 type (
-	BankAccount interface {
-		// GetAccountId will return Plaid's unique identifier for the bank account.
-		GetAccountId() string
-		// GetBalances will return the bank account's balances.
-		GetBalances() BankAccountBalances
-		// GetMask typically returns the last 4 of the bank account number. This could technically return anything that
-		// represents a small portion of the bank account's identification. I don't currently know enough about this to know
-		// what other values this might have.
-		GetMask() string
-		// GetName will return the name of the account specified by the user or the financial institution itself.
-		GetName() string
-		// GetOfficialName will return the name of the account specified by the financial institution itself.
-		GetOfficialName() string
-		// GetType will return the plaid type of the account. For our use case this is typically "depository".
-		GetType() string
-		// GetSubType will return the sub-type of the account. This can be something like "checking" or "savings".
-		GetSubType() string
-	}
-
-	BankAccountBalances interface {
-		// GetAvailable returns the total amount available for the bank account in cents.
-		GetAvailable() int64
-		// GetCurrent returns the current bank account balance in cents. This is typically the total account value excluding
-		// pending transactions.
-		GetCurrent() int64
-		// GetLimit returns the limit of the account (this applies for credit accounts) in cents.
-		GetLimit() int64
-		GetIsoCurrencyCode() string
-		GetUnofficialCurrencyCode() string
-	}
+	// BankAccount and BankAccountBalances are provided by every aggregator, they are aliased here so that the Plaid
+	// implementation can continue to refer to them directly.
+	BankAccount         = aggregator.BankAccount
+	BankAccountBalances = aggregator.BankAccountBalances
 )
 
 var (
@@ -40,12 +18,13 @@ var (
 )
 
 func NewPlaidBankAccountBalances(balances plaid.AccountBalance) (PlaidBankAccountBalances, error) {
+	code := currency.Resolve(balances.GetIsoCurrencyCode(), balances.GetUnofficialCurrencyCode())
 	return PlaidBankAccountBalances{
-		// We work with all amounts in cents. So we need to convert all balances to cents in order to make them whole
-		// integers rather than floats.
-		Available:              int64(balances.GetAvailable() * 100),
-		Current:                int64(balances.GetCurrent() * 100),
-		Limit:                  int64(balances.GetLimit() * 100),
+		// We work with all amounts in the currency's minor units (cents for USD). So we need to convert all balances in
+		// order to make them whole integers rather than floats.
+		Available:              currency.ToMinorUnits(float64(balances.GetAvailable()), code),
+		Current:                currency.ToMinorUnits(float64(balances.GetCurrent()), code),
+		Limit:                  currency.ToMinorUnits(float64(balances.GetLimit()), code),
 		IsoCurrencyCode:        balances.GetIsoCurrencyCode(),
 		UnofficialCurrencyCode: balances.GetUnofficialCurrencyCode(),
 	}, nil
@@ -71,6 +50,10 @@ func (p PlaidBankAccountBalances) GetLimit() int64 {
 	return p.Limit
 }
 
+func (p PlaidBankAccountBalances) GetCurrency() string {
+	return currency.Resolve(p.IsoCurrencyCode, p.UnofficialCurrencyCode)
+}
+
 func (p PlaidBankAccountBalances) GetIsoCurrencyCode() string {
 	return p.IsoCurrencyCode
 }
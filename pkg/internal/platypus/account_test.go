@@ -52,6 +52,27 @@ func TestNewPlaidBankAccountBalances(t *testing.T) {
 		assert.EqualValues(t, 0, balances.GetLimit(), "limit should be 0 when no value is present")
 		assert.EqualValues(t, "", balances.GetIsoCurrencyCode(), "ISO currency code should be empty if no value is present")
 		assert.EqualValues(t, "", balances.GetUnofficialCurrencyCode(), "unofficial currency code should be empty if no value is present")
+		assert.EqualValues(t, "USD", balances.GetCurrency(), "currency should default to USD if no value is present")
+	})
+
+	t.Run("zero decimal currency", func(t *testing.T) {
+		var available, current float32
+		available = 15230
+		current = 16000
+
+		plaidBalances := plaid.AccountBalance{
+			Available:              *plaid.NewNullableFloat32(&available),
+			Current:                *plaid.NewNullableFloat32(&current),
+			Limit:                  *plaid.NewNullableFloat32(nil),
+			IsoCurrencyCode:        *plaid.NewNullableString(myownsanity.StringP("JPY")),
+			UnofficialCurrencyCode: *plaid.NewNullableString(nil),
+		}
+
+		balances, err := NewPlaidBankAccountBalances(plaidBalances)
+		assert.NoError(t, err, "must be able to convert balances")
+		assert.EqualValues(t, 15230, balances.GetAvailable(), "available should be in whole yen")
+		assert.EqualValues(t, 16000, balances.GetCurrent(), "current should be in whole yen")
+		assert.EqualValues(t, "JPY", balances.GetCurrency(), "currency should be JPY")
 	})
 }
 
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"github.com/monetr/rest-api/pkg/config"
 	"github.com/monetr/rest-api/pkg/crumbs"
+	"github.com/monetr/rest-api/pkg/currency"
+	"github.com/monetr/rest-api/pkg/internal/aggregator"
 	"github.com/monetr/rest-api/pkg/internal/myownsanity"
 	"strconv"
 	"time"
@@ -16,15 +18,18 @@ import (
 
 type (
 	Client interface {
-		GetAccounts(ctx context.Context, accountIds ...string) ([]BankAccount, error)
-		GetAllTransactions(ctx context.Context, start, end time.Time, accountIds []string) ([]Transaction, error)
-		UpdateItem(ctx context.Context) (LinkToken, error)
-		RemoveItem(ctx context.Context) error
+		aggregator.Client
+		Sync(ctx context.Context, cursor *string) (*SyncResult, error)
+		// UpdateItem creates a link token that can be used to put the item into update mode. If accountSelection is
+		// true then the user will also be able to add new accounts to the item.
+		UpdateItem(ctx context.Context, accountSelection bool) (LinkToken, error)
 	}
 )
 
 var (
-	_ Client = &PlaidClient{}
+	_ Client                            = &PlaidClient{}
+	_ aggregator.Client                 = &PlaidClient{}
+	_ aggregator.StatementBalanceClient = &PlaidClient{}
 )
 
 type PlaidClient struct {
@@ -107,6 +112,69 @@ func (p *PlaidClient) GetAccounts(ctx context.Context, accountIds ...string) ([]
 	return accounts, nil
 }
 
+// GetStatementBalances retrieves the last statement balance of the provided credit accounts from Plaid's liabilities
+// endpoint. The plaid-go client does not include the statement balance in its liabilities model yet, so the request is
+// built by hand. This requires the liabilities product to be enabled for the item.
+func (p *PlaidClient) GetStatementBalances(ctx context.Context, accountIds ...string) (map[string]int64, error) {
+	span := sentry.StartSpan(ctx, "Plaid - GetStatementBalances")
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountIds": accountIds,
+	}
+
+	log := p.getLog(span)
+
+	log.Trace("retrieving statement balances from plaid")
+
+	var result struct {
+		Accounts    []plaid.AccountBase `json:"accounts"`
+		Liabilities struct {
+			Credit []struct {
+				AccountId            *string  `json:"account_id"`
+				LastStatementBalance *float64 `json:"last_statement_balance"`
+			} `json:"credit"`
+		} `json:"liabilities"`
+	}
+	if _, err := doRawRequest(
+		span,
+		p.client,
+		"/liabilities/get",
+		map[string]interface{}{
+			"access_token": p.accessToken,
+			"options": map[string]interface{}{
+				"account_ids": accountIds,
+			},
+		},
+		&result,
+		"Retrieving liabilities from Plaid",
+		"failed to retrieve liabilities from plaid",
+	); err != nil {
+		log.WithError(err).Errorf("failed to retrieve liabilities from plaid")
+		return nil, err
+	}
+
+	currencies := make(map[string]string, len(result.Accounts))
+	for _, account := range result.Accounts {
+		balances := account.GetBalances()
+		currencies[account.GetAccountId()] = currency.Resolve(
+			balances.GetIsoCurrencyCode(),
+			balances.GetUnofficialCurrencyCode(),
+		)
+	}
+
+	statements := make(map[string]int64, len(result.Liabilities.Credit))
+	for _, credit := range result.Liabilities.Credit {
+		if credit.AccountId == nil || credit.LastStatementBalance == nil {
+			continue
+		}
+
+		statements[*credit.AccountId] = currency.ToMinorUnits(*credit.LastStatementBalance, currencies[*credit.AccountId])
+	}
+
+	return statements, nil
+}
+
 func (p *PlaidClient) GetAllTransactions(ctx context.Context, start, end time.Time, accountIds []string) ([]Transaction, error) {
 	span := sentry.StartSpan(ctx, "Plaid - GetAllTransactions")
 	defer span.Finish()
@@ -181,7 +249,7 @@ func (p *PlaidClient) GetTransactions(ctx context.Context, start, end time.Time,
 	return transactions, nil
 }
 
-func (p *PlaidClient) UpdateItem(ctx context.Context) (LinkToken, error) {
+func (p *PlaidClient) UpdateItem(ctx context.Context, accountSelection bool) (LinkToken, error) {
 	span := sentry.StartSpan(ctx, "Plaid - UpdateItem")
 	defer span.Finish()
 
@@ -200,12 +268,48 @@ func (p *PlaidClient) UpdateItem(ctx context.Context) (LinkToken, error) {
 		}
 	}
 
+	if accountSelection {
+		// The plaid-go client does not support the update options for link tokens yet, so when we need account
+		// selection enabled we have to build the request ourselves.
+		var result plaid.LinkTokenCreateResponse
+		if _, err := doRawRequest(
+			span,
+			p.client,
+			"/link/token/create",
+			map[string]interface{}{
+				"client_name":   "monetr",
+				"language":      PlaidLanguage,
+				"country_codes": p.config.GetCountryCodes(),
+				"user": map[string]interface{}{
+					"client_user_id": strconv.FormatUint(p.accountId, 10),
+				},
+				"webhook":      webhooksUrl,
+				"access_token": p.accessToken,
+				"redirect_uri": redirectUri,
+				"update": map[string]interface{}{
+					"account_selection_enabled": true,
+				},
+			},
+			&result,
+			"Updating Plaid link token with account selection",
+			"failed to update Plaid link token",
+		); err != nil {
+			log.WithError(err).Errorf("failed to create link token")
+			return nil, err
+		}
+
+		return PlaidLinkToken{
+			LinkToken: result.LinkToken,
+			Expires:   result.Expiration,
+		}, nil
+	}
+
 	request := p.client.PlaidApi.
 		LinkTokenCreate(span.Context()).
 		LinkTokenCreateRequest(plaid.LinkTokenCreateRequest{
 			ClientName:   "monetr",
 			Language:     PlaidLanguage,
-			CountryCodes: PlaidCountries,
+			CountryCodes: p.config.GetCountryCodes(),
 			User: plaid.LinkTokenCreateRequestUser{
 				ClientUserId: strconv.FormatUint(p.accountId, 10),
 			},
@@ -5,6 +5,7 @@ import (
 	"github.com/brianvoe/gofakeit/v6"
 	"github.com/jarcoal/httpmock"
 	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/internal/aggregator"
 	"github.com/monetr/rest-api/pkg/internal/mock_plaid"
 	"github.com/monetr/rest-api/pkg/internal/testutils"
 	"github.com/monetr/rest-api/pkg/models"
@@ -95,6 +96,58 @@ func TestPlaidClient_GetAllTransactions(t *testing.T) {
 	})
 }
 
+func TestPlaidClient_Sync(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		log := testutils.GetLog(t)
+		accountId := testutils.GetAccountIdForTest(t)
+
+		accessToken := gofakeit.UUID()
+
+		account := mock_plaid.BankAccountFixture(t)
+
+		end := time.Now()
+		start := end.Add(-7 * 24 * time.Hour)
+		added := mock_plaid.GenerateTransactions(t, start, end, 1200, []string{
+			account.GetAccountId(),
+		})
+		modified := mock_plaid.GenerateTransactions(t, start, end, 2, []string{
+			account.GetAccountId(),
+		})
+		removed := []string{
+			gofakeit.Generate("?????????????????????"),
+		}
+		finalCursor := mock_plaid.MockSyncTransactions(t, added, modified, removed)
+
+		platypus := NewPlaid(log, nil, nil, config.Plaid{
+			ClientID:     gofakeit.UUID(),
+			ClientSecret: gofakeit.UUID(),
+			Environment:  plaid.Sandbox,
+		})
+
+		link := &models.Link{
+			LinkId:    1234,
+			AccountId: accountId,
+		}
+
+		client, err := platypus.NewClient(context.Background(), link, accessToken)
+		assert.NoError(t, err, "should create platypus")
+		assert.NotNil(t, client, "should not be nil")
+
+		result, err := client.Sync(context.Background(), nil)
+		assert.NoError(t, err, "should not return an error")
+		assert.Len(t, result.Added, len(added), "should return every added transaction")
+		assert.Len(t, result.Modified, len(modified), "should return every modified transaction")
+		assert.Equal(t, removed, result.Removed, "should return removed transaction Ids")
+		assert.Equal(t, finalCursor, result.NextCursor, "should return the cursor from the last page")
+		assert.Equal(t, map[string]int{
+			"POST https://sandbox.plaid.com/transactions/sync": 3,
+		}, httpmock.GetCallCountInfo(), "API calls should match")
+	})
+}
+
 func TestPlaidClient_UpdateItem(t *testing.T) {
 	t.Run("simple", func(t *testing.T) {
 		httpmock.Activate()
@@ -123,8 +176,63 @@ func TestPlaidClient_UpdateItem(t *testing.T) {
 		assert.NoError(t, err, "should create client")
 		assert.NotNil(t, client, "should not be nil")
 
-		linkToken, err := client.UpdateItem(context.Background())
+		linkToken, err := client.UpdateItem(context.Background(), false)
 		assert.NoError(t, err, "should not return an error creating an update link token")
 		assert.NotEmpty(t, linkToken.Token(), "must not be empty")
 	})
 }
+
+func TestPlaidClient_GetStatementBalances(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		log := testutils.GetLog(t)
+		accountId := testutils.GetAccountIdForTest(t)
+
+		accessToken := gofakeit.UUID()
+
+		creditCard := mock_plaid.BankAccountFixture(t)
+		paidOffCard := mock_plaid.BankAccountFixture(t)
+		noStatementCard := mock_plaid.BankAccountFixture(t)
+
+		mock_plaid.MockGetLiabilities(t, []plaid.AccountBase{
+			creditCard,
+			paidOffCard,
+			noStatementCard,
+		}, map[string]float64{
+			creditCard.GetAccountId():  1708.77,
+			paidOffCard.GetAccountId(): 0,
+		})
+
+		platypus := NewPlaid(log, nil, nil, config.Plaid{
+			ClientID:     gofakeit.UUID(),
+			ClientSecret: gofakeit.UUID(),
+			Environment:  plaid.Sandbox,
+		})
+
+		link := &models.Link{
+			LinkId:    1234,
+			AccountId: accountId,
+		}
+
+		client, err := platypus.NewClient(context.Background(), link, accessToken)
+		assert.NoError(t, err, "should create client")
+		assert.NotNil(t, client, "should not be nil")
+
+		statementClient, ok := client.(aggregator.StatementBalanceClient)
+		assert.True(t, ok, "plaid client should be able to retrieve statement balances")
+
+		statements, err := statementClient.GetStatementBalances(
+			context.Background(),
+			creditCard.GetAccountId(),
+			paidOffCard.GetAccountId(),
+			noStatementCard.GetAccountId(),
+		)
+		assert.NoError(t, err, "should not return an error retrieving statement balances")
+		assert.Equal(t, map[string]int64{
+			creditCard.GetAccountId():  170877,
+			paidOffCard.GetAccountId(): 0,
+		}, statements, "statement balances should be converted to cents")
+	})
+}
@@ -0,0 +1,396 @@
+package platypus
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/rest-api/pkg/currency"
+	"github.com/monetr/rest-api/pkg/internal/aggregator"
+	"github.com/monetr/rest-api/pkg/internal/myownsanity"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/monetr/rest-api/pkg/repository"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	fakePublicTokenPrefix = "public-fake-"
+	fakeItemIdPrefix      = "item-fake-"
+	fakeAccessTokenPrefix = "access-fake-"
+	fakeLinkTokenPrefix   = "link-fake-"
+
+	// fakeHistoryDays is how much transaction history a fake item has when it is first synced.
+	fakeHistoryDays = 90
+	// fakePendingDays is how many days a fake transaction stays pending before it posts.
+	fakePendingDays = 2
+)
+
+// FakeInstitutions are the institutions that can be linked when Plaid is running in fake mode.
+var FakeInstitutions = []Institution{
+	{
+		InstitutionId:   "ins_fake_1",
+		Name:            "First Platypus Bank",
+		Products:        []string{"transactions"},
+		URL:             myownsanity.StringP("https://bank.platypus.example"),
+		PrimaryColor:    myownsanity.StringP("#1f6feb"),
+		ProductStatuses: map[string]models.InstitutionStatus{},
+	},
+	{
+		InstitutionId:   "ins_fake_2",
+		Name:            "Offline Credit Union",
+		Products:        []string{"transactions"},
+		URL:             myownsanity.StringP("https://cu.offline.example"),
+		PrimaryColor:    myownsanity.StringP("#2da44e"),
+		ProductStatuses: map[string]models.InstitutionStatus{},
+	},
+	{
+		InstitutionId:   "ins_fake_3",
+		Name:            "Demo Savings & Loan",
+		Products:        []string{"transactions"},
+		URL:             myownsanity.StringP("https://savings.demo.example"),
+		PrimaryColor:    myownsanity.StringP("#bf8700"),
+		ProductStatuses: map[string]models.InstitutionStatus{},
+	},
+}
+
+var fakeCategories = [][]string{
+	{"Food and Drink", "Restaurants"},
+	{"Food and Drink", "Restaurants", "Coffee Shop"},
+	{"Shops", "Supermarkets and Groceries"},
+	{"Shops", "Computers and Electronics"},
+	{"Travel", "Gas Stations"},
+	{"Service", "Utilities"},
+	{"Recreation", "Gyms and Fitness Centers"},
+}
+
+var (
+	_ Platypus            = &FakePlaid{}
+	_ aggregator.Provider = &FakePlaid{}
+	_ Client              = &FakePlaidClient{}
+)
+
+// FakePlaid implements Platypus without making any network requests. Items, accounts and transactions are generated
+// deterministically from the item's Id, so the same item will always return the same data. New transactions are
+// generated for every day, this way the regular jobs will retrieve new data each day. This is intended for local
+// development and demos, it should never be used in production.
+type FakePlaid struct {
+	log  *logrus.Entry
+	repo repository.PlaidRepository
+}
+
+func NewFakePlaid(log *logrus.Entry, repo repository.PlaidRepository) *FakePlaid {
+	return &FakePlaid{
+		log:  log.WithField("plaid", "fake"),
+		repo: repo,
+	}
+}
+
+func (f *FakePlaid) CreateLinkToken(ctx context.Context, options LinkTokenOptions) (LinkToken, error) {
+	return PlaidLinkToken{
+		LinkToken: fakeLinkTokenPrefix + gofakeit.UUID(),
+		Expires:   time.Now().Add(4 * time.Hour),
+	}, nil
+}
+
+// CreatePublicToken simulates a user authenticating with the provided institution through Plaid Link. The returned
+// public token can be exchanged just like a real one, along with the Ids of the accounts for the item that will be
+// created.
+func (f *FakePlaid) CreatePublicToken(ctx context.Context, institutionId string) (publicToken string, accountIds []string, err error) {
+	if _, err = f.GetInstitution(ctx, institutionId); err != nil {
+		return "", nil, err
+	}
+
+	suffix := fmt.Sprintf("%s-%s", institutionId, gofakeit.UUID())
+	client := f.newFakeClient(fakeItemIdPrefix + suffix)
+	accounts := client.getAccounts()
+	accountIds = make([]string, len(accounts))
+	for i, account := range accounts {
+		accountIds[i] = account.GetAccountId()
+	}
+
+	return fakePublicTokenPrefix + suffix, accountIds, nil
+}
+
+func (f *FakePlaid) ExchangePublicToken(ctx context.Context, publicToken string) (*ItemToken, error) {
+	if !strings.HasPrefix(publicToken, fakePublicTokenPrefix) {
+		return nil, errors.New("public token was not created by fake plaid")
+	}
+
+	suffix := strings.TrimPrefix(publicToken, fakePublicTokenPrefix)
+
+	return &ItemToken{
+		AccessToken: fakeAccessTokenPrefix + suffix,
+		ItemId:      fakeItemIdPrefix + suffix,
+	}, nil
+}
+
+// GetWebhookVerificationKey always returns an error, fake webhooks are triggered through the API directly and are not
+// signed.
+func (f *FakePlaid) GetWebhookVerificationKey(ctx context.Context, keyId string) (*WebhookVerificationKey, error) {
+	return nil, errors.New("webhook verification is not available for fake plaid")
+}
+
+func (f *FakePlaid) GetInstitution(ctx context.Context, institutionId string) (*Institution, error) {
+	for _, institution := range FakeInstitutions {
+		if institution.InstitutionId == institutionId {
+			result := institution
+			return &result, nil
+		}
+	}
+
+	return nil, errors.Errorf("fake institution %s does not exist", institutionId)
+}
+
+func (f *FakePlaid) NewClientFromItemId(ctx context.Context, itemId string) (Client, error) {
+	return f.newFakeClient(itemId), nil
+}
+
+func (f *FakePlaid) NewClientFromLink(ctx context.Context, accountId uint64, linkId uint64) (Client, error) {
+	span := sentry.StartSpan(ctx, "Plaid - NewClientFromLink")
+	defer span.Finish()
+
+	link, err := f.repo.GetLink(span.Context(), accountId, linkId)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create Plaid client from link")
+	}
+
+	return f.NewClient(span.Context(), link, "")
+}
+
+func (f *FakePlaid) NewClient(ctx context.Context, link *models.Link, accessToken string) (Client, error) {
+	if link != nil && link.PlaidLink != nil {
+		return f.newFakeClient(link.PlaidLink.ItemId), nil
+	}
+
+	if !strings.HasPrefix(accessToken, fakeAccessTokenPrefix) {
+		return nil, errors.New("access token was not created by fake plaid")
+	}
+
+	return f.newFakeClient(fakeItemIdPrefix + strings.TrimPrefix(accessToken, fakeAccessTokenPrefix)), nil
+}
+
+func (f *FakePlaid) GetClientForLink(ctx context.Context, link *models.Link) (aggregator.Client, error) {
+	if link == nil || link.PlaidLink == nil {
+		return nil, errors.New("cannot create client without link")
+	}
+
+	return f.newFakeClient(link.PlaidLink.ItemId), nil
+}
+
+// SupportsWebhooks returns true, webhooks for fake links can be triggered manually to imitate Plaid.
+func (f *FakePlaid) SupportsWebhooks() bool {
+	return true
+}
+
+func (f *FakePlaid) Close() error {
+	return nil
+}
+
+func (f *FakePlaid) newFakeClient(itemId string) *FakePlaidClient {
+	return &FakePlaidClient{
+		itemId: itemId,
+		seed:   fakeSeed(itemId),
+		log:    f.log.WithField("itemId", itemId),
+	}
+}
+
+// FakePlaidClient generates the accounts and transactions for a single fake item.
+type FakePlaidClient struct {
+	itemId string
+	seed   int64
+	log    *logrus.Entry
+}
+
+func (f *FakePlaidClient) faker(values ...int64) *gofakeit.Faker {
+	seed := f.seed
+	for _, value := range values {
+		seed = seed*31 + value
+	}
+
+	return gofakeit.New(seed)
+}
+
+func (f *FakePlaidClient) getAccounts() []PlaidBankAccount {
+	faker := f.faker()
+	suffix := fmt.Sprintf("%x", f.seed)
+
+	// Balances change each day, like they would as transactions post.
+	today := fakeDay(time.Now())
+	balances := f.faker(today)
+	checking := currency.ToMinorUnits(balances.Price(500, 5000), currency.Default)
+	savings := currency.ToMinorUnits(balances.Price(1000, 20000), currency.Default)
+
+	return []PlaidBankAccount{
+		{
+			AccountId: "checking-" + suffix,
+			Balances: PlaidBankAccountBalances{
+				Available:       checking,
+				Current:         checking,
+				IsoCurrencyCode: currency.Default,
+			},
+			Mask:         faker.DigitN(4),
+			Name:         "Checking",
+			OfficialName: "Everyday Checking",
+			Type:         "depository",
+			SubType:      "checking",
+		},
+		{
+			AccountId: "savings-" + suffix,
+			Balances: PlaidBankAccountBalances{
+				Available:       savings,
+				Current:         savings,
+				IsoCurrencyCode: currency.Default,
+			},
+			Mask:         faker.DigitN(4),
+			Name:         "Savings",
+			OfficialName: "High Yield Savings",
+			Type:         "depository",
+			SubType:      "savings",
+		},
+	}
+}
+
+func (f *FakePlaidClient) GetAccounts(ctx context.Context, accountIds ...string) ([]BankAccount, error) {
+	result := make([]BankAccount, 0)
+	for _, account := range f.getAccounts() {
+		if len(accountIds) > 0 && !myownsanity.SliceContains(accountIds, account.AccountId) {
+			continue
+		}
+
+		result = append(result, account)
+	}
+
+	return result, nil
+}
+
+// getTransactionsForDay will generate the transactions for a single account on a single day. Transactions from the
+// last few days are pending, once they are old enough they are returned as posted transactions that reference the
+// pending transaction they replace.
+func (f *FakePlaidClient) getTransactionsForDay(account PlaidBankAccount, day int64, today int64) []Transaction {
+	faker := f.faker(day, fakeSeed(account.AccountId))
+	date := time.Unix(day*24*60*60, 0).UTC()
+	pending := today-day < fakePendingDays
+
+	transactions := make([]Transaction, 0)
+	add := func(index int, amount float64, name, merchant string, category []string) {
+		transactionId := fmt.Sprintf("%s-%d-%d", account.AccountId, day, index)
+		pendingId := transactionId + "-pending"
+		transaction := PlaidTransaction{
+			Amount:          currency.ToMinorUnits(amount, currency.Default),
+			BankAccountId:   account.AccountId,
+			Category:        category,
+			Date:            date,
+			ISOCurrencyCode: currency.Default,
+			IsPending:       pending,
+			MerchantName:    merchant,
+			Name:            name,
+		}
+		if pending {
+			transaction.TransactionId = pendingId
+		} else {
+			transaction.TransactionId = transactionId
+			transaction.PendingTransactionId = &pendingId
+		}
+		transaction.OriginalDescription = strings.ToUpper(name)
+
+		transactions = append(transactions, transaction)
+	}
+
+	switch account.SubType {
+	case "checking":
+		// Payday is every other week.
+		if day%14 == 0 {
+			add(0, -faker.Price(1500, 2500), "Payroll Direct Deposit", "", []string{"Transfer", "Payroll"})
+		}
+
+		purchases := faker.Number(0, 3)
+		for i := 1; i <= purchases; i++ {
+			merchant := faker.Company()
+			add(i, faker.Price(2, 120), fmt.Sprintf("%s #%s", merchant, faker.DigitN(4)), merchant, fakeCategories[faker.Number(0, len(fakeCategories)-1)])
+		}
+	case "savings":
+		// Interest is paid at the start of each month.
+		if date.Day() == 1 {
+			add(0, -faker.Price(1, 15), "Interest Payment", "", []string{"Interest", "Interest Earned"})
+		}
+	}
+
+	return transactions
+}
+
+func (f *FakePlaidClient) getTransactions(start, end time.Time, accountIds []string) []Transaction {
+	today := fakeDay(time.Now())
+	first, last := fakeDay(start), fakeDay(end)
+	if last > today {
+		last = today
+	}
+
+	transactions := make([]Transaction, 0)
+	for _, account := range f.getAccounts() {
+		if len(accountIds) > 0 && !myownsanity.SliceContains(accountIds, account.AccountId) {
+			continue
+		}
+
+		for day := first; day <= last; day++ {
+			transactions = append(transactions, f.getTransactionsForDay(account, day, today)...)
+		}
+	}
+
+	return transactions
+}
+
+func (f *FakePlaidClient) GetAllTransactions(ctx context.Context, start, end time.Time, accountIds []string) ([]Transaction, error) {
+	return f.getTransactions(start, end, accountIds), nil
+}
+
+// Sync returns every transaction since a few days before the cursor, the cursor is the date of the last sync. This
+// way pending transactions that have posted since the last sync are returned and reconciled.
+func (f *FakePlaidClient) Sync(ctx context.Context, cursor *string) (*SyncResult, error) {
+	now := time.Now().UTC()
+	start := now.AddDate(0, 0, -fakeHistoryDays)
+	if cursor != nil && *cursor != "" {
+		last, err := time.Parse("2006-01-02", *cursor)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid cursor for fake plaid")
+		}
+
+		start = last.AddDate(0, 0, -fakePendingDays)
+	}
+
+	return &SyncResult{
+		NextCursor: now.Format("2006-01-02"),
+		Added:      f.getTransactions(start, now, nil),
+		Modified:   []Transaction{},
+		Removed:    []string{},
+	}, nil
+}
+
+func (f *FakePlaidClient) UpdateItem(ctx context.Context, accountSelection bool) (LinkToken, error) {
+	return PlaidLinkToken{
+		LinkToken: fakeLinkTokenPrefix + gofakeit.UUID(),
+		Expires:   time.Now().Add(4 * time.Hour),
+	}, nil
+}
+
+func (f *FakePlaidClient) RemoveItem(ctx context.Context) error {
+	return nil
+}
+
+// fakeSeed returns a consistent seed for the provided value, this is used to generate the same data for an item or
+// account every time.
+func fakeSeed(value string) int64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(value))
+
+	return int64(hash.Sum64() >> 1)
+}
+
+// fakeDay returns the number of days since the unix epoch in UTC.
+func fakeDay(input time.Time) int64 {
+	return input.UTC().Unix() / (24 * 60 * 60)
+}
@@ -0,0 +1,70 @@
+package platypus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakePlaid_LinkFlow(t *testing.T) {
+	fake := NewFakePlaid(testutils.GetLog(t), nil)
+
+	publicToken, accountIds, err := fake.CreatePublicToken(context.Background(), "ins_fake_1")
+	require.NoError(t, err, "must create public token")
+	assert.Len(t, accountIds, 2, "fake items should have a checking and savings account")
+
+	token, err := fake.ExchangePublicToken(context.Background(), publicToken)
+	require.NoError(t, err, "must exchange public token")
+	assert.NotEmpty(t, token.ItemId, "item Id must be present")
+	assert.NotEmpty(t, token.AccessToken, "access token must be present")
+
+	client, err := fake.NewClient(context.Background(), nil, token.AccessToken)
+	require.NoError(t, err, "must create client from access token")
+
+	accounts, err := client.GetAccounts(context.Background(), accountIds[0])
+	require.NoError(t, err, "must retrieve accounts")
+	require.Len(t, accounts, 1, "only the requested account should be returned")
+	assert.Equal(t, accountIds[0], accounts[0].GetAccountId(), "accounts should match the public token's accounts")
+	assert.Greater(t, accounts[0].GetBalances().GetCurrent(), int64(0), "account should have a balance")
+
+	_, _, err = fake.CreatePublicToken(context.Background(), "ins_123456")
+	assert.EqualError(t, err, "fake institution ins_123456 does not exist")
+
+	_, err = fake.ExchangePublicToken(context.Background(), "public-sandbox-123")
+	assert.EqualError(t, err, "public token was not created by fake plaid")
+}
+
+func TestFakePlaidClient_Sync(t *testing.T) {
+	fake := NewFakePlaid(testutils.GetLog(t), nil)
+
+	client, err := fake.NewClientFromItemId(context.Background(), "item-fake-ins_fake_1-abc")
+	require.NoError(t, err, "must create client")
+
+	result, err := client.Sync(context.Background(), nil)
+	require.NoError(t, err, "must sync")
+	assert.NotEmpty(t, result.Added, "initial sync should include transaction history")
+	assert.Equal(t, time.Now().UTC().Format("2006-01-02"), result.NextCursor, "cursor should be today")
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for _, transaction := range result.Added {
+		if today.Sub(transaction.GetDate()) < fakePendingDays*24*time.Hour {
+			assert.True(t, transaction.GetIsPending(), "recent transactions should be pending")
+			assert.Nil(t, transaction.GetPendingTransactionId(), "pending transactions should not reference another")
+		} else {
+			assert.False(t, transaction.GetIsPending(), "older transactions should be posted")
+			assert.NotNil(t, transaction.GetPendingTransactionId(), "posted transactions should reference their pending transaction")
+		}
+	}
+
+	again, err := client.Sync(context.Background(), nil)
+	require.NoError(t, err, "must sync again")
+	assert.Equal(t, result.Added, again.Added, "the same item should always generate the same transactions")
+
+	incremental, err := client.Sync(context.Background(), &result.NextCursor)
+	require.NoError(t, err, "must sync from cursor")
+	assert.Less(t, len(incremental.Added), len(result.Added), "syncing from a cursor should only include recent transactions")
+}
@@ -0,0 +1,142 @@
+package platypus
+
+import (
+	"context"
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/rest-api/pkg/internal/myownsanity"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/plaid/plaid-go/plaid"
+)
+
+type Institution struct {
+	InstitutionId string
+	Name          string
+	Products      []string
+	URL           *string
+	PrimaryColor  *string
+	Logo          *string
+	// ProductStatuses is the status of each of the products Plaid provides for the institution, keyed by the product
+	// name that Plaid uses, like "item_logins" or "transactions_updates". This will be empty if the status was not
+	// requested.
+	ProductStatuses map[string]models.InstitutionStatus
+}
+
+// GetStatus returns the worst status of the products that we actually use from Plaid. If any of them are down then
+// the institution is considered to be down, if none of them are down but any are degraded then the institution is
+// considered to be degraded. Nil is returned if there is no status information.
+func (i Institution) GetStatus() *models.InstitutionStatus {
+	var status *models.InstitutionStatus
+	for _, product := range []string{"item_logins", "transactions_updates"} {
+		productStatus, ok := i.ProductStatuses[product]
+		if !ok {
+			continue
+		}
+
+		switch productStatus {
+		case models.Down:
+			return &productStatus
+		case models.Degraded:
+			status = &productStatus
+		case models.Healthy:
+			if status == nil {
+				status = &productStatus
+			}
+		}
+	}
+
+	return status
+}
+
+func NewInstitutionFromPlaid(input plaid.Institution) (Institution, error) {
+	products := make([]string, len(input.GetProducts()))
+	for i, product := range input.GetProducts() {
+		products[i] = string(product)
+	}
+
+	institution := Institution{
+		InstitutionId:   input.GetInstitutionId(),
+		Name:            input.GetName(),
+		Products:        products,
+		URL:             nil,
+		PrimaryColor:    nil,
+		Logo:            nil,
+		ProductStatuses: map[string]models.InstitutionStatus{},
+	}
+
+	if url := input.GetUrl(); url != "" {
+		institution.URL = myownsanity.StringP(url)
+	}
+
+	if primaryColor := input.GetPrimaryColor(); primaryColor != "" {
+		institution.PrimaryColor = myownsanity.StringP(primaryColor)
+	}
+
+	if logo := input.GetLogo(); logo != "" {
+		institution.Logo = myownsanity.StringP(logo)
+	}
+
+	if status, ok := input.GetStatusOk(); ok && status != nil {
+		products := map[string]*plaid.ProductStatus{
+			"item_logins":          &status.ItemLogins,
+			"transactions_updates": &status.TransactionsUpdates,
+			"auth":                 &status.Auth,
+			"balance":              &status.Balance,
+			"identity":             &status.Identity,
+			"investments_updates":  &status.InvestmentsUpdates,
+			"liabilities_updates":  status.LiabilitiesUpdates,
+			"liabilities":          status.Liabilities,
+			"investments":          status.Investments,
+		}
+		for name, productStatus := range products {
+			if productStatus == nil || productStatus.Status == "" {
+				continue
+			}
+
+			institution.ProductStatuses[name] = models.InstitutionStatus(productStatus.Status)
+		}
+	}
+
+	return institution, nil
+}
+
+// GetInstitution will retrieve the metadata and the current status of the institution from Plaid.
+func (p *Plaid) GetInstitution(ctx context.Context, institutionId string) (*Institution, error) {
+	span := sentry.StartSpan(ctx, "Plaid - GetInstitution")
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"institutionId": institutionId,
+	}
+
+	log := p.log.WithField("institutionId", institutionId)
+
+	request := p.client.PlaidApi.
+		InstitutionsGetById(span.Context()).
+		InstitutionsGetByIdRequest(plaid.InstitutionsGetByIdRequest{
+			InstitutionId: institutionId,
+			CountryCodes:  p.config.GetCountryCodes(),
+			Options: &plaid.InstitutionsGetByIdRequestOptions{
+				IncludeOptionalMetadata: myownsanity.BoolP(true),
+				IncludeStatus:           myownsanity.BoolP(true),
+			},
+		})
+
+	result, response, err := request.Execute()
+	if err = after(
+		span,
+		response,
+		err,
+		"Retrieving institution from Plaid",
+		"failed to retrieve institution from Plaid",
+	); err != nil {
+		log.WithError(err).Errorf("failed to retrieve institution from Plaid")
+		return nil, err
+	}
+
+	institution, err := NewInstitutionFromPlaid(result.Institution)
+	if err != nil {
+		return nil, err
+	}
+
+	return &institution, nil
+}
@@ -0,0 +1,72 @@
+package platypus
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/jarcoal/httpmock"
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/internal/mock_plaid"
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/plaid/plaid-go/plaid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstitution_GetStatus(t *testing.T) {
+	t.Run("no status", func(t *testing.T) {
+		institution := Institution{}
+		assert.Nil(t, institution.GetStatus(), "status should be nil without any product statuses")
+	})
+
+	t.Run("worst status wins", func(t *testing.T) {
+		institution := Institution{
+			ProductStatuses: map[string]models.InstitutionStatus{
+				"item_logins":          models.Healthy,
+				"transactions_updates": models.Degraded,
+				"identity":             models.Down,
+			},
+		}
+		status := institution.GetStatus()
+		require.NotNil(t, status, "status should be present")
+		assert.Equal(t, models.Degraded, *status, "products we do not use should not affect the status")
+
+		institution.ProductStatuses["item_logins"] = models.Down
+		status = institution.GetStatus()
+		require.NotNil(t, status, "status should be present")
+		assert.Equal(t, models.Down, *status, "institution should be down if logins are down")
+	})
+}
+
+func TestPlaid_GetInstitution(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		log := testutils.GetLog(t)
+		fixture := mock_plaid.InstitutionFixture(t, "ins_123", "DEGRADED")
+		mock_plaid.MockGetInstitution(t, fixture)
+
+		platypus := NewPlaid(log, nil, nil, config.Plaid{
+			ClientID:     gofakeit.UUID(),
+			ClientSecret: gofakeit.UUID(),
+			Environment:  plaid.Sandbox,
+		})
+
+		institution, err := platypus.GetInstitution(context.Background(), "ins_123")
+		assert.NoError(t, err, "should retrieve institution")
+		require.NotNil(t, institution, "institution should be returned")
+		assert.Equal(t, fixture.InstitutionId, institution.InstitutionId, "institution Id should match")
+		assert.Equal(t, fixture.Name, institution.Name, "institution name should match")
+		assert.Equal(t, []string{"transactions"}, institution.Products, "products should match")
+		assert.NotNil(t, institution.URL, "url should be present")
+		assert.Nil(t, institution.Logo, "logo should not be present")
+		assert.Equal(t, models.Degraded, institution.ProductStatuses["item_logins"], "product status should be present")
+		assert.NotContains(t, institution.ProductStatuses, "auth", "products without a status should be omitted")
+
+		_, err = platypus.GetInstitution(context.Background(), "ins_456")
+		assert.Error(t, err, "should fail to retrieve an unknown institution")
+	})
+}
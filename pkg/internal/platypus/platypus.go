@@ -10,6 +10,7 @@ import (
 	"github.com/getsentry/sentry-go"
 	"github.com/monetr/rest-api/pkg/config"
 	"github.com/monetr/rest-api/pkg/crumbs"
+	"github.com/monetr/rest-api/pkg/internal/aggregator"
 	"github.com/monetr/rest-api/pkg/internal/myownsanity"
 	"github.com/monetr/rest-api/pkg/models"
 	"github.com/monetr/rest-api/pkg/repository"
@@ -20,10 +21,7 @@ import (
 )
 
 var (
-	PlaidLanguage  = "en"
-	PlaidCountries = []plaid.CountryCode{
-		plaid.COUNTRYCODE_US,
-	}
+	PlaidLanguage = "en"
 	PlaidProducts = []plaid.Products{
 		plaid.PRODUCTS_TRANSACTIONS,
 	}
@@ -31,9 +29,11 @@ var (
 
 type (
 	Platypus interface {
+		aggregator.Provider
 		CreateLinkToken(ctx context.Context, options LinkTokenOptions) (LinkToken, error)
 		ExchangePublicToken(ctx context.Context, publicToken string) (*ItemToken, error)
 		GetWebhookVerificationKey(ctx context.Context, keyId string) (*WebhookVerificationKey, error)
+		GetInstitution(ctx context.Context, institutionId string) (*Institution, error)
 		NewClientFromItemId(ctx context.Context, itemId string) (Client, error)
 		NewClientFromLink(ctx context.Context, accountId uint64, linkId uint64) (Client, error)
 		NewClient(ctx context.Context, link *models.Link, accessToken string) (Client, error)
@@ -102,7 +102,8 @@ func after(span *sentry.Span, response *http.Response, err error, message, error
 }
 
 var (
-	_ Platypus = &Plaid{}
+	_ Platypus            = &Plaid{}
+	_ aggregator.Provider = &Plaid{}
 )
 
 func NewPlaid(log *logrus.Entry, secret secrets.PlaidSecretsProvider, repo repository.PlaidRepository, options config.Plaid) *Plaid {
@@ -157,7 +158,7 @@ func (p *Plaid) CreateLinkToken(ctx context.Context, options LinkTokenOptions) (
 		LinkTokenCreateRequest(plaid.LinkTokenCreateRequest{
 			ClientName:   "monetr",
 			Language:     PlaidLanguage,
-			CountryCodes: PlaidCountries,
+			CountryCodes: p.config.GetCountryCodes(),
 			User: plaid.LinkTokenCreateRequestUser{
 				ClientUserId:             options.ClientUserID,
 				LegalName:                &options.LegalName,
@@ -303,6 +304,21 @@ func (p *Plaid) NewClient(ctx context.Context, link *models.Link, accessToken st
 	}, nil
 }
 
+// GetClientForLink will return a Plaid client for the provided link using the link's stored access token.
+func (p *Plaid) GetClientForLink(ctx context.Context, link *models.Link) (aggregator.Client, error) {
+	client, err := p.newClient(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// SupportsWebhooks returns true, Plaid sends webhooks when new transactions are available for an item.
+func (p *Plaid) SupportsWebhooks() bool {
+	return true
+}
+
 func (p *Plaid) newClient(ctx context.Context, link *models.Link) (Client, error) {
 	span := sentry.StartSpan(ctx, "Plaid - newClient")
 	defer span.Finish()
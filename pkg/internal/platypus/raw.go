@@ -0,0 +1,88 @@
+package platypus
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/pkg/errors"
+	"github.com/plaid/plaid-go/plaid"
+)
+
+type plaidErrorResponse struct {
+	ErrorType    string `json:"error_type"`
+	ErrorCode    string `json:"error_code"`
+	ErrorMessage string `json:"error_message"`
+}
+
+// doRawRequest is used for Plaid endpoints (or request options) that are not yet supported by the plaid-go client that
+// we use. The request is built using the same configuration as the API client, so authentication and the environment
+// are handled the same way. If Plaid returns an error then the Plaid error code is returned along with the error.
+func doRawRequest(
+	span *sentry.Span,
+	client *plaid.APIClient,
+	path string,
+	requestBody, result interface{},
+	message, errorMessage string,
+) (errorCode string, _ error) {
+	conf := client.GetConfig()
+	serverUrl, err := conf.ServerURL(0, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to determine plaid server url")
+	}
+
+	body, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode plaid request")
+	}
+
+	request, err := http.NewRequestWithContext(span.Context(), http.MethodPost, serverUrl+path, bytes.NewReader(body))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create plaid request")
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("User-Agent", conf.UserAgent)
+	for header, value := range conf.DefaultHeader {
+		request.Header.Set(header, value)
+	}
+
+	httpClient := conf.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	response, err := httpClient.Do(request)
+	if response != nil {
+		defer response.Body.Close()
+	}
+
+	var responseBody []byte
+	if err == nil {
+		responseBody, err = ioutil.ReadAll(response.Body)
+		// Put the body back so that it can be read again for diagnostics.
+		response.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+	}
+
+	if err == nil && response.StatusCode != http.StatusOK {
+		var plaidError plaidErrorResponse
+		_ = json.Unmarshal(responseBody, &plaidError)
+		errorCode = plaidError.ErrorCode
+		err = errors.Errorf("plaid returned %d: %s %s", response.StatusCode, plaidError.ErrorCode, plaidError.ErrorMessage)
+	}
+
+	if err = after(
+		span,
+		response,
+		err,
+		message,
+		errorMessage,
+	); err != nil {
+		return errorCode, err
+	}
+
+	return "", errors.Wrap(json.Unmarshal(responseBody, result), "failed to decode plaid response")
+}
@@ -0,0 +1,142 @@
+package platypus
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/pkg/errors"
+	"github.com/plaid/plaid-go/plaid"
+)
+
+// plaidSyncPageSize is the maximum number of transaction updates that Plaid will return in a single sync request.
+const plaidSyncPageSize = 500
+
+// plaidSyncMaxRestarts limits how many times we will restart a sync if the item's transactions are modified while we
+// are paginating through the updates.
+const plaidSyncMaxRestarts = 3
+
+// SyncResult contains every change to an item's transactions since the cursor that was provided to Sync. Added and
+// Modified transactions can both be upserted, Removed contains the Plaid transaction Ids of transactions that should
+// be deleted.
+type SyncResult struct {
+	// NextCursor should be stored and provided to the next call to Sync, it should only be stored once all of the
+	// changes in this result have been applied.
+	NextCursor string
+	Added      []Transaction
+	Modified   []Transaction
+	Removed    []string
+}
+
+type transactionsSyncRequest struct {
+	AccessToken string  `json:"access_token"`
+	Cursor      *string `json:"cursor,omitempty"`
+	Count       int32   `json:"count"`
+}
+
+type transactionsSyncResponse struct {
+	Added    []plaid.Transaction `json:"added"`
+	Modified []plaid.Transaction `json:"modified"`
+	Removed  []struct {
+		TransactionId string `json:"transaction_id"`
+	} `json:"removed"`
+	NextCursor string `json:"next_cursor"`
+	HasMore    bool   `json:"has_more"`
+	RequestId  string `json:"request_id"`
+}
+
+// Sync will retrieve all of the changes to the item's transactions since the provided cursor. If the cursor is nil
+// then all of the item's transaction history is returned. All pages are retrieved before returning, if the item is
+// updated while we are paginating then the sync is restarted from the provided cursor.
+func (p *PlaidClient) Sync(ctx context.Context, cursor *string) (*SyncResult, error) {
+	span := sentry.StartSpan(ctx, "Plaid - Sync")
+	defer span.Finish()
+
+	log := p.getLog(span)
+
+	for restarts := 0; ; restarts++ {
+		result := &SyncResult{
+			Added:    make([]Transaction, 0),
+			Modified: make([]Transaction, 0),
+			Removed:  make([]string, 0),
+		}
+
+		pageCursor := cursor
+		mutated := false
+		for {
+			page, errorCode, err := p.syncPage(span.Context(), pageCursor)
+			if errorCode == "TRANSACTIONS_SYNC_MUTATION_DURING_PAGINATION" {
+				mutated = true
+				break
+			}
+			if err != nil {
+				log.WithError(err).Errorf("failed to sync transactions from plaid")
+				return nil, err
+			}
+
+			for _, item := range page.Added {
+				transaction, err := NewTransactionFromPlaid(item)
+				if err != nil {
+					return nil, err
+				}
+				result.Added = append(result.Added, transaction)
+			}
+
+			for _, item := range page.Modified {
+				transaction, err := NewTransactionFromPlaid(item)
+				if err != nil {
+					return nil, err
+				}
+				result.Modified = append(result.Modified, transaction)
+			}
+
+			for _, item := range page.Removed {
+				result.Removed = append(result.Removed, item.TransactionId)
+			}
+
+			result.NextCursor = page.NextCursor
+			pageCursor = &page.NextCursor
+
+			if !page.HasMore {
+				break
+			}
+		}
+
+		if !mutated {
+			return result, nil
+		}
+
+		if restarts >= plaidSyncMaxRestarts {
+			return nil, errors.New("transactions were modified too many times while syncing")
+		}
+
+		log.Debug("transactions were modified while syncing, restarting sync from original cursor")
+	}
+}
+
+// syncPage requests a single page of transaction updates from Plaid. The plaid-go client we use does not yet support
+// the transactions sync endpoint, so the request is built manually. If Plaid returns an error then the Plaid error code
+// is returned as well.
+func (p *PlaidClient) syncPage(ctx context.Context, cursor *string) (*transactionsSyncResponse, string, error) {
+	span := sentry.StartSpan(ctx, "Plaid - SyncPage")
+	defer span.Finish()
+
+	var result transactionsSyncResponse
+	errorCode, err := doRawRequest(
+		span,
+		p.client,
+		"/transactions/sync",
+		transactionsSyncRequest{
+			AccessToken: p.accessToken,
+			Cursor:      cursor,
+			Count:       plaidSyncPageSize,
+		},
+		&result,
+		"Syncing transactions from Plaid",
+		"failed to sync transactions from plaid",
+	)
+	if err != nil {
+		return nil, errorCode, err
+	}
+
+	return &result, "", nil
+}
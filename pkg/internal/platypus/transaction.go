@@ -1,26 +1,16 @@
 package platypus
 
 import (
+	"github.com/monetr/rest-api/pkg/currency"
+	"github.com/monetr/rest-api/pkg/internal/aggregator"
 	"github.com/pkg/errors"
 	"github.com/plaid/plaid-go/plaid"
 	"time"
 )
 
-type Transaction interface {
-	GetAmount() int64
-	GetBankAccountId() string
-	GetCategory() []string
-	GetDate() time.Time
-	GetDateLocal(timezone *time.Location) time.Time
-	GetISOCurrencyCode() string
-	GetIsPending() bool
-	GetMerchantName() string
-	GetName() string
-	GetOriginalDescription() string
-	GetPendingTransactionId() *string
-	GetTransactionId() string
-	GetUnofficialCurrencyCode() string
-}
+// Transaction is provided by every aggregator, it is aliased here so that the Plaid implementation can continue to refer
+// to it directly.
+type Transaction = aggregator.Transaction
 
 var (
 	_ Transaction = PlaidTransaction{}
@@ -47,9 +37,10 @@ func NewTransactionFromPlaid(input plaid.Transaction) (Transaction, error) {
 		return nil, errors.Wrap(err, "failed to parse transaction date")
 	}
 	pendingTransactionId, _ := input.GetPendingTransactionIdOk()
+	code := currency.Resolve(input.GetIsoCurrencyCode(), input.GetUnofficialCurrencyCode())
 
 	transaction := PlaidTransaction{
-		Amount:                 int64(input.GetAmount() * 100),
+		Amount:                 currency.ToMinorUnits(float64(input.GetAmount()), code),
 		BankAccountId:          input.GetAccountId(),
 		Category:               input.GetCategory(),
 		Date:                   date,
@@ -78,6 +69,10 @@ func (p PlaidTransaction) GetCategory() []string {
 	return p.Category
 }
 
+func (p PlaidTransaction) GetCurrency() string {
+	return currency.Resolve(p.ISOCurrencyCode, p.UnofficialCurrencyCode)
+}
+
 func (p PlaidTransaction) GetDate() time.Time {
 	return p.Date
 }
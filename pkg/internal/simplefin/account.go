@@ -0,0 +1,141 @@
+package simplefin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/monetr/rest-api/pkg/currency"
+	"github.com/monetr/rest-api/pkg/internal/aggregator"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ aggregator.BankAccount         = SimpleFINBankAccount{}
+	_ aggregator.BankAccountBalances = SimpleFINBankAccountBalances{}
+)
+
+// getCurrency returns the currency for an account. SimpleFIN allows custom currencies to be specified as a URL, monetr
+// cannot do anything meaningful with those so the default currency is used instead.
+func getCurrency(code string) string {
+	code = currency.Normalize(code)
+	if !currency.IsValid(code) {
+		return currency.Default
+	}
+
+	return code
+}
+
+// parseAmount converts a numeric string from SimpleFIN into the smallest unit of the provided currency.
+func parseAmount(amount, code string) (int64, error) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(amount), 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse amount %q", amount)
+	}
+
+	return currency.ToMinorUnits(value, code), nil
+}
+
+type SimpleFINBankAccountBalances struct {
+	Available int64
+	Current   int64
+	Currency  string
+}
+
+func NewSimpleFINBankAccountBalances(input account) (SimpleFINBankAccountBalances, error) {
+	code := getCurrency(input.Currency)
+	current, err := parseAmount(input.Balance, code)
+	if err != nil {
+		return SimpleFINBankAccountBalances{}, errors.Wrap(err, "failed to parse balance")
+	}
+
+	// The available balance is optional, if the bridge does not provide one then the current balance is used.
+	available := current
+	if input.AvailableBalance != nil {
+		available, err = parseAmount(*input.AvailableBalance, code)
+		if err != nil {
+			return SimpleFINBankAccountBalances{}, errors.Wrap(err, "failed to parse available balance")
+		}
+	}
+
+	return SimpleFINBankAccountBalances{
+		Available: available,
+		Current:   current,
+		Currency:  code,
+	}, nil
+}
+
+func (s SimpleFINBankAccountBalances) GetAvailable() int64 {
+	return s.Available
+}
+
+func (s SimpleFINBankAccountBalances) GetCurrent() int64 {
+	return s.Current
+}
+
+// GetLimit always returns 0, SimpleFIN does not provide credit limits.
+func (s SimpleFINBankAccountBalances) GetLimit() int64 {
+	return 0
+}
+
+func (s SimpleFINBankAccountBalances) GetCurrency() string {
+	return s.Currency
+}
+
+func (s SimpleFINBankAccountBalances) GetIsoCurrencyCode() string {
+	return s.Currency
+}
+
+func (s SimpleFINBankAccountBalances) GetUnofficialCurrencyCode() string {
+	return ""
+}
+
+type SimpleFINBankAccount struct {
+	AccountId string
+	Balances  SimpleFINBankAccountBalances
+	Name      string
+	Org       string
+}
+
+func NewSimpleFINBankAccount(input account) (SimpleFINBankAccount, error) {
+	balances, err := NewSimpleFINBankAccountBalances(input)
+	if err != nil {
+		return SimpleFINBankAccount{}, err
+	}
+
+	return SimpleFINBankAccount{
+		AccountId: input.Id,
+		Balances:  balances,
+		Name:      input.Name,
+		Org:       input.Organization.Name,
+	}, nil
+}
+
+func (s SimpleFINBankAccount) GetAccountId() string {
+	return s.AccountId
+}
+
+func (s SimpleFINBankAccount) GetBalances() aggregator.BankAccountBalances {
+	return s.Balances
+}
+
+// GetMask always returns an empty string, SimpleFIN does not provide any part of the account number.
+func (s SimpleFINBankAccount) GetMask() string {
+	return ""
+}
+
+func (s SimpleFINBankAccount) GetName() string {
+	return s.Name
+}
+
+func (s SimpleFINBankAccount) GetOfficialName() string {
+	return s.Name
+}
+
+// GetType always returns depository, SimpleFIN does not provide the type of the account.
+func (s SimpleFINBankAccount) GetType() string {
+	return "depository"
+}
+
+func (s SimpleFINBankAccount) GetSubType() string {
+	return "checking"
+}
@@ -0,0 +1,201 @@
+package simplefin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/rest-api/pkg/internal/aggregator"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrAccessRevoked is returned when the bridge rejects the credentials in the access URL. This happens when the user
+// has disabled monetr's access from their bridge, the link will need to be setup again with a new setup token.
+var ErrAccessRevoked = errors.New("access to the simplefin bridge has been revoked")
+
+type (
+	Client interface {
+		aggregator.Client
+	}
+)
+
+var (
+	_ Client            = &SimpleFINClient{}
+	_ aggregator.Client = &SimpleFINClient{}
+)
+
+type SimpleFINClient struct {
+	accessUrl *url.URL
+	log       *logrus.Entry
+	client    *http.Client
+}
+
+// accountSet is the response body from the accounts endpoint of a bridge.
+// https://www.simplefin.org/protocol.html#account-set
+type accountSet struct {
+	Errors   []string  `json:"errors"`
+	Accounts []account `json:"accounts"`
+}
+
+type account struct {
+	Organization struct {
+		Domain string `json:"domain"`
+		Name   string `json:"name"`
+	} `json:"org"`
+	Id               string        `json:"id"`
+	Name             string        `json:"name"`
+	Currency         string        `json:"currency"`
+	Balance          string        `json:"balance"`
+	AvailableBalance *string       `json:"available-balance"`
+	BalanceDate      int64         `json:"balance-date"`
+	Transactions     []transaction `json:"transactions"`
+}
+
+type transaction struct {
+	Id           string `json:"id"`
+	Posted       int64  `json:"posted"`
+	Amount       string `json:"amount"`
+	Description  string `json:"description"`
+	TransactedAt *int64 `json:"transacted_at"`
+	Pending      bool   `json:"pending"`
+}
+
+func (s *SimpleFINClient) getLog(span *sentry.Span) *logrus.Entry {
+	return s.log.WithContext(span.Context()).WithField("simplefin", span.Op)
+}
+
+func (s *SimpleFINClient) getAccountSet(span *sentry.Span, query url.Values) (*accountSet, error) {
+	accessUrl := *s.accessUrl
+	accessUrl.User = nil
+	accessUrl.Path = strings.TrimSuffix(accessUrl.Path, "/") + "/accounts"
+	accessUrl.RawQuery = query.Encode()
+
+	request, err := http.NewRequestWithContext(span.Context(), http.MethodGet, accessUrl.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create accounts request")
+	}
+
+	// The credentials are part of the access URL, but they are sent as basic auth so that they are not included in the
+	// URL that is logged or recorded anywhere.
+	password, _ := s.accessUrl.User.Password()
+	request.SetBasicAuth(s.accessUrl.User.Username(), password)
+
+	response, err := s.client.Do(request)
+	body, err := after(span, request, response, err, "Retrieving accounts from SimpleFIN", "failed to retrieve accounts")
+	if err != nil {
+		return nil, err
+	}
+
+	var result accountSet
+	if err = json.Unmarshal(body, &result); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to parse accounts from simplefin")
+	}
+
+	// Errors from the bridge are meant to be shown to the user, they do not necessarily mean that the request failed.
+	for _, message := range result.Errors {
+		s.getLog(span).WithField("bridgeError", message).Warn("simplefin bridge returned an error")
+	}
+
+	return &result, nil
+}
+
+func (s *SimpleFINClient) GetAccounts(ctx context.Context, accountIds ...string) ([]aggregator.BankAccount, error) {
+	span := sentry.StartSpan(ctx, "SimpleFIN - GetAccounts")
+	defer span.Finish()
+
+	log := s.getLog(span)
+
+	span.Data = map[string]interface{}{
+		"accountIds": "ALL_BANK_ACCOUNTS",
+	}
+	if len(accountIds) > 0 {
+		span.Data["accountIds"] = accountIds
+	}
+
+	log.Trace("retrieving bank accounts from simplefin")
+
+	query := url.Values{}
+	query.Set("balances-only", "1")
+	for _, accountId := range accountIds {
+		query.Add("account", accountId)
+	}
+
+	result, err := s.getAccountSet(span, query)
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve bank accounts from simplefin")
+		return nil, err
+	}
+
+	accounts := make([]aggregator.BankAccount, 0, len(result.Accounts))
+	for _, item := range result.Accounts {
+		bankAccount, err := NewSimpleFINBankAccount(item)
+		if err != nil {
+			log.WithError(err).Error("failed to convert bank account")
+			return nil, err
+		}
+
+		accounts = append(accounts, bankAccount)
+	}
+
+	return accounts, nil
+}
+
+func (s *SimpleFINClient) GetAllTransactions(ctx context.Context, start, end time.Time, accountIds []string) ([]aggregator.Transaction, error) {
+	span := sentry.StartSpan(ctx, "SimpleFIN - GetAllTransactions")
+	defer span.Finish()
+
+	log := s.getLog(span).WithFields(logrus.Fields{
+		"start": start,
+		"end":   end,
+	})
+
+	span.Data = map[string]interface{}{
+		"start":      start,
+		"end":        end,
+		"accountIds": accountIds,
+	}
+
+	log.Trace("retrieving transactions from simplefin")
+
+	query := url.Values{}
+	query.Set("start-date", strconv.FormatInt(start.Unix(), 10))
+	query.Set("end-date", strconv.FormatInt(end.Unix(), 10))
+	query.Set("pending", "1")
+	for _, accountId := range accountIds {
+		query.Add("account", accountId)
+	}
+
+	result, err := s.getAccountSet(span, query)
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve transactions from simplefin")
+		return nil, err
+	}
+
+	transactions := make([]aggregator.Transaction, 0)
+	for _, item := range result.Accounts {
+		for _, input := range item.Transactions {
+			transaction, err := NewTransactionFromSimpleFIN(item, input)
+			if err != nil {
+				log.WithError(err).Error("failed to convert transaction")
+				return nil, err
+			}
+
+			transactions = append(transactions, transaction)
+		}
+	}
+
+	return transactions, nil
+}
+
+// RemoveItem does not do anything for SimpleFIN. Access can only be revoked by the user from their bridge, once the
+// link is removed monetr no longer has the access URL.
+func (s *SimpleFINClient) RemoveItem(ctx context.Context) error {
+	return nil
+}
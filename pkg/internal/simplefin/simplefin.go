@@ -0,0 +1,317 @@
+package simplefin
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/crumbs"
+	"github.com/monetr/rest-api/pkg/internal/aggregator"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/monetr/rest-api/pkg/secrets"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	// SimpleFIN retrieves bank account data from a SimpleFIN Bridge. The user creates a setup token on their bridge,
+	// which is claimed by monetr for an access URL. The access URL includes the credentials needed to read the user's
+	// accounts and is stored by the SimpleFIN secrets provider.
+	SimpleFIN interface {
+		aggregator.Provider
+		// Claim exchanges the setup token provided by the user for an access URL. Setup tokens can only be claimed once.
+		Claim(ctx context.Context, setupToken string) (accessUrl string, err error)
+		NewClient(ctx context.Context, accessUrl string) (Client, error)
+	}
+)
+
+var (
+	_ SimpleFIN           = &Bridge{}
+	_ aggregator.Provider = &Bridge{}
+)
+
+var (
+	// ErrAddressNotAllowed is returned when a bridge resolves to an address that monetr will not send requests to, like
+	// loopback or private network addresses. Setup tokens are provided by users, so without this any user could have
+	// the server send requests to internal services.
+	ErrAddressNotAllowed = errors.New("simplefin bridge address is not allowed")
+)
+
+// NewSimpleFIN returns a SimpleFIN provider that will only send requests to bridges on public addresses over HTTPS.
+func NewSimpleFIN(log *logrus.Entry, secret secrets.SimpleFINSecretsProvider, options config.SimpleFIN) *Bridge {
+	return NewSimpleFINWithHTTPClient(log, secret, options, &http.Client{
+		Transport: newBridgeTransport(),
+	})
+}
+
+// NewSimpleFINWithHTTPClient returns a SimpleFIN provider that uses the provided HTTP client for requests to bridges.
+// The client's transport is used as is, so unlike NewSimpleFIN requests are not restricted to public addresses. This
+// is intended for tests where requests to the bridge are mocked.
+func NewSimpleFINWithHTTPClient(log *logrus.Entry, secret secrets.SimpleFINSecretsProvider, options config.SimpleFIN, client *http.Client) *Bridge {
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	bridge := &Bridge{
+		log:    log,
+		secret: secret,
+		config: options,
+	}
+
+	bridgeClient := *client
+	bridgeClient.Timeout = timeout
+	bridgeClient.CheckRedirect = func(request *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+
+		// Redirects are held to the same requirements as the URL provided by the user.
+		return bridge.validateBridgeUrl(request.URL)
+	}
+	bridge.client = &bridgeClient
+
+	return bridge
+}
+
+// newBridgeTransport returns a transport that will refuse to connect to any address that is not public. The address
+// is checked after it has been resolved, so a bridge host cannot resolve to a public address when it is validated and
+// then to a private address when it is dialed.
+func newBridgeTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   restrictBridgeAddress,
+	}
+
+	return &http.Transport{
+		// Requests are never sent through a proxy, otherwise the address being checked would be the proxy's.
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// restrictBridgeAddress is called with the resolved address right before a connection is made to a bridge. It will
+// return ErrAddressNotAllowed for loopback, private, link-local, multicast and unspecified addresses.
+func restrictBridgeAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return errors.Wrap(err, "invalid bridge address")
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return errors.Wrapf(ErrAddressNotAllowed, "%s is not an ip address", host)
+	}
+
+	if ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() {
+		return errors.Wrapf(ErrAddressNotAllowed, "%s", ip)
+	}
+
+	return nil
+}
+
+type Bridge struct {
+	client *http.Client
+	log    *logrus.Entry
+	secret secrets.SimpleFINSecretsProvider
+	config config.SimpleFIN
+}
+
+// validateBridgeUrl makes sure that a URL provided by a user or returned by a bridge uses HTTPS, and if an allow list
+// of bridge hosts is configured that the URL's host is on it.
+func (b *Bridge) validateBridgeUrl(bridgeUrl *url.URL) error {
+	if bridgeUrl.Scheme != "https" {
+		return errors.Errorf("bridge url must use https, not %s", bridgeUrl.Scheme)
+	}
+
+	host := bridgeUrl.Hostname()
+	if host == "" {
+		return errors.New("bridge url does not have a host")
+	}
+
+	if len(b.config.AllowedHosts) == 0 {
+		return nil
+	}
+
+	for _, allowedHost := range b.config.AllowedHosts {
+		if strings.EqualFold(strings.TrimSpace(allowedHost), host) {
+			return nil
+		}
+	}
+
+	return errors.Errorf("bridge host %s is not allowed", host)
+}
+
+func (b *Bridge) Claim(ctx context.Context, setupToken string) (string, error) {
+	span := sentry.StartSpan(ctx, "SimpleFIN - Claim")
+	defer span.Finish()
+
+	claimUrl, err := base64.StdEncoding.DecodeString(strings.TrimSpace(setupToken))
+	if err != nil {
+		span.Status = sentry.SpanStatusInvalidArgument
+		return "", errors.Wrap(err, "setup token is not valid")
+	}
+
+	parsedClaimUrl, err := url.Parse(string(claimUrl))
+	if err != nil {
+		span.Status = sentry.SpanStatusInvalidArgument
+		return "", errors.New("setup token is not valid")
+	}
+
+	if err = b.validateBridgeUrl(parsedClaimUrl); err != nil {
+		span.Status = sentry.SpanStatusInvalidArgument
+		return "", errors.Wrap(err, "setup token is not valid")
+	}
+
+	request, err := http.NewRequestWithContext(span.Context(), http.MethodPost, parsedClaimUrl.String(), nil)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return "", errors.Wrap(err, "failed to create claim request")
+	}
+	request.Header.Set("Content-Length", "0")
+
+	response, err := b.client.Do(request)
+	body, err := after(span, request, response, err, "Claiming SimpleFIN setup token", "failed to claim setup token")
+	if err != nil {
+		b.log.WithContext(span.Context()).WithError(err).Error("failed to claim simplefin setup token")
+		return "", err
+	}
+
+	accessUrl := strings.TrimSpace(string(body))
+	parsedAccessUrl, err := parseAccessUrl(accessUrl)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return "", err
+	}
+
+	if err = b.validateBridgeUrl(parsedAccessUrl); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return "", errors.Wrap(err, "access url is not valid")
+	}
+
+	return accessUrl, nil
+}
+
+func (b *Bridge) NewClient(ctx context.Context, accessUrl string) (Client, error) {
+	parsedAccessUrl, err := parseAccessUrl(accessUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = b.validateBridgeUrl(parsedAccessUrl); err != nil {
+		return nil, errors.Wrap(err, "access url is not valid")
+	}
+
+	return &SimpleFINClient{
+		accessUrl: parsedAccessUrl,
+		log:       b.log.WithField("bridge", parsedAccessUrl.Host),
+		client:    b.client,
+	}, nil
+}
+
+func (b *Bridge) GetClientForLink(ctx context.Context, link *models.Link) (aggregator.Client, error) {
+	span := sentry.StartSpan(ctx, "SimpleFIN - GetClientForLink")
+	defer span.Finish()
+
+	if link.SimpleFINLinkId == nil {
+		return nil, errors.New("cannot create simplefin client for link without simplefin info")
+	}
+
+	accessUrl, err := b.secret.GetAccessUrlForSimpleFINLink(span.Context(), link.AccountId, *link.SimpleFINLinkId)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.NewClient(span.Context(), accessUrl)
+}
+
+// SupportsWebhooks returns false, SimpleFIN bridges do not notify us when new data is available. SimpleFIN links must
+// be synced periodically instead.
+func (b *Bridge) SupportsWebhooks() bool {
+	return false
+}
+
+// parseAccessUrl will make sure that the access URL returned by a bridge includes the credentials needed to read the
+// user's accounts.
+func parseAccessUrl(accessUrl string) (*url.URL, error) {
+	parsed, err := url.Parse(accessUrl)
+	if err != nil {
+		return nil, errors.Wrap(err, "access url is not valid")
+	}
+
+	// The access URL includes the user's credentials, it must never be sent in plain text.
+	if parsed.Scheme != "https" {
+		return nil, errors.Errorf("access url has an invalid scheme: %s", parsed.Scheme)
+	}
+
+	if parsed.User == nil || parsed.User.Username() == "" {
+		return nil, errors.New("access url does not include credentials")
+	}
+
+	return parsed, nil
+}
+
+// after reads the body of a response from a bridge and records the request for diagnostics. If the request failed or
+// the bridge did not respond with a successful status code then an error is returned.
+func after(span *sentry.Span, request *http.Request, response *http.Response, err error, message, errorMessage string) ([]byte, error) {
+	if err != nil {
+		span.Status = sentry.SpanStatusUnavailable
+		return nil, errors.Wrap(err, errorMessage)
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, 10<<20))
+
+	// Never include the credentials from the URL in our diagnostic data.
+	requestUrl := *request.URL
+	requestUrl.User = nil
+
+	crumbs.HTTP(
+		span.Context(),
+		message,
+		"simplefin",
+		requestUrl.String(),
+		request.Method,
+		response.StatusCode,
+		map[string]interface{}{},
+	)
+
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, errorMessage)
+	}
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		span.Status = sentry.SpanStatusOK
+		return body, nil
+	case http.StatusForbidden:
+		span.Status = sentry.SpanStatusPermissionDenied
+		return nil, errors.Wrap(ErrAccessRevoked, errorMessage)
+	default:
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(fmt.Errorf("bridge returned status code %d", response.StatusCode), errorMessage)
+	}
+}
@@ -0,0 +1,109 @@
+package simplefin
+
+import (
+	"time"
+
+	"github.com/monetr/rest-api/pkg/internal/aggregator"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ aggregator.Transaction = SimpleFINTransaction{}
+)
+
+type SimpleFINTransaction struct {
+	Amount        int64
+	BankAccountId string
+	Currency      string
+	Date          time.Time
+	IsPending     bool
+	Name          string
+	TransactionId string
+}
+
+func NewTransactionFromSimpleFIN(bankAccount account, input transaction) (aggregator.Transaction, error) {
+	code := getCurrency(bankAccount.Currency)
+	amount, err := parseAmount(input.Amount, code)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse transaction amount")
+	}
+
+	// Pending transactions might not have a posted date yet, so prefer when the transaction actually happened.
+	date := time.Unix(input.Posted, 0)
+	if input.TransactedAt != nil && *input.TransactedAt > 0 {
+		date = time.Unix(*input.TransactedAt, 0)
+	}
+
+	return SimpleFINTransaction{
+		// SimpleFIN amounts are negative when money leaves the account, monetr stores them the other way around.
+		Amount:        -amount,
+		BankAccountId: bankAccount.Id,
+		Currency:      code,
+		Date:          date.UTC(),
+		IsPending:     input.Pending,
+		Name:          input.Description,
+		TransactionId: input.Id,
+	}, nil
+}
+
+func (s SimpleFINTransaction) GetAmount() int64 {
+	return s.Amount
+}
+
+func (s SimpleFINTransaction) GetBankAccountId() string {
+	return s.BankAccountId
+}
+
+// GetCategory always returns nil, SimpleFIN does not categorize transactions.
+func (s SimpleFINTransaction) GetCategory() []string {
+	return nil
+}
+
+func (s SimpleFINTransaction) GetCurrency() string {
+	return s.Currency
+}
+
+func (s SimpleFINTransaction) GetDate() time.Time {
+	return s.Date
+}
+
+// GetDateLocal returns the start of the day that the transaction happened on in the provided timezone. Unlike Plaid,
+// SimpleFIN provides the exact time of the transaction.
+func (s SimpleFINTransaction) GetDateLocal(timezone *time.Location) time.Time {
+	local := s.Date.In(timezone)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, timezone)
+}
+
+func (s SimpleFINTransaction) GetISOCurrencyCode() string {
+	return s.Currency
+}
+
+func (s SimpleFINTransaction) GetIsPending() bool {
+	return s.IsPending
+}
+
+func (s SimpleFINTransaction) GetMerchantName() string {
+	return ""
+}
+
+func (s SimpleFINTransaction) GetName() string {
+	return s.Name
+}
+
+func (s SimpleFINTransaction) GetOriginalDescription() string {
+	return s.Name
+}
+
+// GetPendingTransactionId always returns nil. SimpleFIN does not link posted transactions to their pending transaction,
+// bridges typically keep the same Id when a transaction posts.
+func (s SimpleFINTransaction) GetPendingTransactionId() *string {
+	return nil
+}
+
+func (s SimpleFINTransaction) GetTransactionId() string {
+	return s.TransactionId
+}
+
+func (s SimpleFINTransaction) GetUnofficialCurrencyCode() string {
+	return ""
+}
@@ -22,6 +22,7 @@ type Stripe interface {
 	UpdateCustomer(ctx context.Context, id string, customer stripe.CustomerParams) (*stripe.Customer, error)
 	GetCustomer(ctx context.Context, id string) (*stripe.Customer, error)
 	GetSubscription(ctx context.Context, stripeSubscriptionId string) (*stripe.Subscription, error)
+	UpdateSubscription(ctx context.Context, stripeSubscriptionId string, params *stripe.SubscriptionParams) (*stripe.Subscription, error)
 	NewCheckoutSession(ctx context.Context, params *stripe.CheckoutSessionParams) (*stripe.CheckoutSession, error)
 	GetCheckoutSession(ctx context.Context, checkoutSessionId string) (*stripe.CheckoutSession, error)
 	NewPortalSession(ctx context.Context, params *stripe.BillingPortalSessionParams) (*stripe.BillingPortalSession, error)
@@ -203,6 +204,23 @@ func (s *stripeBase) GetSubscription(ctx context.Context, stripeSubscriptionId s
 	return result, nil
 }
 
+func (s *stripeBase) UpdateSubscription(ctx context.Context, stripeSubscriptionId string, params *stripe.SubscriptionParams) (*stripe.Subscription, error) {
+	span := sentry.StartSpan(ctx, "Stripe - UpdateSubscription")
+	defer span.Finish()
+
+	params.Context = span.Context()
+
+	result, err := s.client.Subscriptions.Update(stripeSubscriptionId, params)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to update subscription")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return result, nil
+}
+
 func (s *stripeBase) CreateCustomer(ctx context.Context, customer stripe.CustomerParams) (*stripe.Customer, error) {
 	span := sentry.StartSpan(ctx, "Stripe - CreateCustomer")
 	defer span.Finish()
@@ -14,3 +14,19 @@ func SubscriptionIsActive(subscription stripe.Subscription) bool {
 		return false
 	}
 }
+
+// SubscriptionPriceId returns the Id of the price of the first item on the subscription. monetr subscriptions only
+// ever have a single item. If the subscription does not have any items then an empty string is returned.
+func SubscriptionPriceId(subscription stripe.Subscription) string {
+	if subscription.Items == nil {
+		return ""
+	}
+
+	for _, item := range subscription.Items.Data {
+		if item != nil && item.Price != nil {
+			return item.Price.ID
+		}
+	}
+
+	return ""
+}
@@ -38,3 +38,25 @@ func TestSubscriptionIsActive(t *testing.T) {
 		}
 	})
 }
+
+func TestSubscriptionPriceId(t *testing.T) {
+	t.Run("with price", func(t *testing.T) {
+		subscription := stripe.Subscription{
+			Items: &stripe.SubscriptionItemList{
+				Data: []*stripe.SubscriptionItem{
+					{
+						Price: &stripe.Price{
+							ID: "price_123",
+						},
+					},
+				},
+			},
+		}
+
+		assert.Equal(t, "price_123", SubscriptionPriceId(subscription), "should return the price of the first item")
+	})
+
+	t.Run("no items", func(t *testing.T) {
+		assert.Empty(t, SubscriptionPriceId(stripe.Subscription{}), "should return an empty price Id")
+	})
+}
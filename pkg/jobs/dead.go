@@ -0,0 +1,82 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/gocraft/work"
+	"github.com/gomodule/redigo/redis"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// RetryDeadJob will re-enqueue a job that has failed too many times. If the job is still present in gocraft's dead
+// queue then it is moved back onto its work queue, keeping the same job Id so that its attempt history is preserved.
+// If the job is no longer in the dead queue (if redis was restarted for example) then a new job is enqueued with the
+// same name and arguments. The Id of the job that was enqueued is returned.
+func RetryDeadJob(ctx context.Context, log *logrus.Entry, db *pg.DB, pool *redis.Pool, namespace, jobId string) (string, error) {
+	span := sentry.StartSpan(ctx, "RetryDeadJob")
+	defer span.Finish()
+
+	log = log.WithField("jobId", jobId)
+
+	var job models.Job
+	if err := db.ModelContext(span.Context(), &job).
+		Where(`"job"."job_id" = ?`, jobId).
+		Limit(1).
+		Select(&job); err != nil {
+		return "", errors.Wrap(err, "failed to retrieve job")
+	}
+
+	if job.DeadAt == nil {
+		return "", errors.Errorf("job is not dead and cannot be retried")
+	}
+
+	client := work.NewClient(namespace, pool)
+	var deadJob *work.DeadJob
+	for page := uint(1); deadJob == nil; page++ {
+		deadJobs, _, err := client.DeadJobs(page)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to retrieve dead jobs")
+		}
+
+		if len(deadJobs) == 0 {
+			break
+		}
+
+		for _, item := range deadJobs {
+			if item.ID == jobId {
+				deadJob = item
+				break
+			}
+		}
+	}
+
+	newJobId := jobId
+	if deadJob != nil {
+		log.Debug("retrying job from dead queue")
+		if err := client.RetryDeadJob(deadJob.DiedAt, jobId); err != nil {
+			return "", errors.Wrap(err, "failed to retry dead job")
+		}
+	} else {
+		log.Debug("job is no longer in the dead queue, enqueuing a new job with the same arguments")
+		newJob, err := work.NewEnqueuer(namespace, pool).Enqueue(job.Name, job.Args)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to enqueue job")
+		}
+
+		newJobId = newJob.ID
+	}
+
+	// Clear the dead state of the job now that it has been re-enqueued, this way it will not be listed again.
+	if _, err := db.ModelContext(span.Context(), &job).
+		Set(`"dead_at" = NULL`).
+		WherePK().
+		Update(); err != nil {
+		log.WithError(err).Warn("failed to clear dead state of job")
+	}
+
+	return newJobId, nil
+}
@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/gocraft/work"
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/internal/mock_secrets"
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewareDeadJob(t *testing.T) {
+	t.Run("records failures and retries", func(t *testing.T) {
+		log := testutils.GetLog(t)
+		db := testutils.GetPgDatabase(t)
+		cache := testutils.GetRedisPool(t)
+
+		account, _ := testutils.SeedAccount(t, db, testutils.Nothing)
+
+		job := NewJobManager(log, config.Jobs{}, cache, db, nil, nil, mock_secrets.NewMockPlaidSecrets(), nil, nil).(*jobManagerBase)
+		defer require.NoError(t, job.Close(), "must close job manager")
+
+		workJob := &work.Job{
+			Name:       PullLatestTransactions,
+			ID:         gofakeit.UUID(),
+			EnqueuedAt: time.Now().Unix(),
+			Args: map[string]interface{}{
+				"accountId": account.AccountId,
+			},
+		}
+
+		failure := errors.New("something went wrong")
+		for i := int64(0); i < defaultMaxFails; i++ {
+			workJob.Fails = i
+			err := job.middleware(workJob, func() error {
+				return failure
+			})
+			assert.EqualError(t, err, failure.Error(), "middleware should return the job's error")
+		}
+
+		var record models.Job
+		require.NoError(t, db.Model(&record).Where(`"job"."job_id" = ?`, workJob.ID).Select(&record), "must retrieve job record")
+		assert.Len(t, record.Attempts, defaultMaxFails, "should have recorded every attempt")
+		assert.NotNil(t, record.DeadAt, "job should be dead after too many failures")
+		require.NotNil(t, record.Error, "error should be stored")
+		assert.Equal(t, failure.Error(), *record.Error, "error message should match")
+		require.NotNil(t, record.Stack, "stack should be stored")
+		assert.Contains(t, *record.Stack, "TestMiddlewareDeadJob", "stack should include where the error was created")
+
+		// The job is not present in our fresh miniredis dead queue, so a new job should be enqueued instead.
+		newJobId, err := RetryDeadJob(context.Background(), log, db, cache, "harder", workJob.ID)
+		assert.NoError(t, err, "should be able to retry dead job")
+		assert.NotEmpty(t, newJobId, "should return the new job Id")
+
+		record = models.Job{}
+		require.NoError(t, db.Model(&record).Where(`"job"."job_id" = ?`, workJob.ID).Select(&record), "must retrieve job record")
+		assert.Nil(t, record.DeadAt, "job should no longer be dead")
+	})
+}
@@ -2,13 +2,20 @@ package jobs
 
 import (
 	"context"
+	"encoding/binary"
+	"fmt"
+	"github.com/monetr/rest-api/pkg/internal/aggregator"
 	"github.com/monetr/rest-api/pkg/internal/platypus"
+	"github.com/monetr/rest-api/pkg/internal/simplefin"
+	"hash/fnv"
 	"math"
 	"time"
 
 	"github.com/go-pg/pg/v10"
 	"github.com/gocraft/work"
 	"github.com/gomodule/redigo/redis"
+	"github.com/monetr/rest-api/pkg/communication"
+	"github.com/monetr/rest-api/pkg/config"
 	"github.com/monetr/rest-api/pkg/metrics"
 	"github.com/monetr/rest-api/pkg/models"
 	"github.com/monetr/rest-api/pkg/pubsub"
@@ -18,29 +25,58 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// defaultMaxFails is the number of times gocraft will run a job before it is considered dead. This is gocraft's default
+// when no job options are specified.
+const defaultMaxFails = 4
+
+// JobManager enqueues the jobs that are triggered by the API. Jobs are unique by their arguments, if an identical job
+// is already queued then the trigger succeeds but an empty job Id is returned as no new job was enqueued.
 type JobManager interface {
 	TriggerPullHistoricalTransactions(accountId, linkId uint64) (jobId string, err error)
 	TriggerPullInitialTransactions(accountId, userId, linkId uint64) (jobId string, err error)
 	TriggerPullLatestTransactions(accountId, linkId uint64, numberOfTransactions int64) (jobId string, err error)
 	TriggerRemoveTransactions(accountId, linkId uint64, removedTransactions []string) (jobId string, err error)
 	TriggerRemoveLink(accountId, userId, linkId uint64) (jobId string, err error)
+	TriggerSyncSimpleFINLink(accountId, linkId uint64) (jobId string, err error)
+	TriggerRecalculateTimezone(accountId, userId uint64, previousTimezone string) (jobId string, err error)
 	Close() error
 }
 
+// JobEnqueuer can enqueue jobs to be run by the workers of a running server, but it does not run any jobs itself. This
+// is used by commands that need to trigger jobs without starting a worker pool.
+type JobEnqueuer interface {
+	TriggerPullHistoricalTransactions(accountId, linkId uint64) (jobId string, err error)
+	TriggerPullInitialTransactions(accountId, userId, linkId uint64) (jobId string, err error)
+	TriggerPullLatestTransactions(accountId, linkId uint64, numberOfTransactions int64) (jobId string, err error)
+	TriggerRemoveTransactions(accountId, linkId uint64, removedTransactions []string) (jobId string, err error)
+}
+
 var (
-	_ JobManager = &jobManagerBase{}
-	_ JobManager = &nonDistributedJobManager{}
+	_ JobManager  = &jobManagerBase{}
+	_ JobManager  = &nonDistributedJobManager{}
+	_ JobEnqueuer = &jobManagerBase{}
 )
 
+// NewJobEnqueuer returns a JobEnqueuer that will enqueue jobs in the provided namespace.
+func NewJobEnqueuer(log *logrus.Entry, pool *redis.Pool, namespace string) JobEnqueuer {
+	return &jobManagerBase{
+		log:   log,
+		queue: work.NewEnqueuer(namespace, pool),
+	}
+}
+
 type jobManagerBase struct {
-	log          *logrus.Entry
-	work         *work.WorkerPool
-	queue        *work.Enqueuer
-	db           *pg.DB
-	plaidClient  platypus.Platypus
-	plaidSecrets secrets.PlaidSecretsProvider
-	stats        *metrics.Stats
-	ps           pubsub.PublishSubscribe
+	log           *logrus.Entry
+	configuration config.Jobs
+	work          *work.WorkerPool
+	queue         *work.Enqueuer
+	db            *pg.DB
+	plaidClient   platypus.Platypus
+	plaidSecrets  secrets.PlaidSecretsProvider
+	stats         *metrics.Stats
+	ps            pubsub.PublishSubscribe
+	communication communication.UserCommunication
+	providers     aggregator.Providers
 }
 
 func NewNonDistributedJobManager(
@@ -66,47 +102,85 @@ func NewNonDistributedJobManager(
 
 func NewJobManager(
 	log *logrus.Entry,
+	configuration config.Jobs,
 	pool *redis.Pool,
 	db *pg.DB,
 	plaidClient platypus.Platypus,
 	stats *metrics.Stats,
 	plaidSecrets secrets.PlaidSecretsProvider,
+	userCommunication communication.UserCommunication,
+	simpleFIN simplefin.SimpleFIN,
 ) JobManager {
+	if configuration.Namespace == "" {
+		configuration.Namespace = "harder"
+	}
+
+	if configuration.Concurrency == 0 {
+		configuration.Concurrency = 4
+	}
+
 	manager := &jobManagerBase{
-		log: log,
-		// TODO (elliotcourant) Use namespace from config.
-		work:         work.NewWorkerPool(struct{}{}, 4, "harder", pool),
-		queue:        work.NewEnqueuer("harder", pool),
-		db:           db,
-		plaidClient:  plaidClient,
-		plaidSecrets: plaidSecrets,
-		stats:        stats,
-		ps:           pubsub.NewPostgresPubSub(log, db),
+		log:           log,
+		configuration: configuration,
+		work:          work.NewWorkerPool(struct{}{}, configuration.Concurrency, configuration.Namespace, pool),
+		queue:         work.NewEnqueuer(configuration.Namespace, pool),
+		db:            db,
+		plaidClient:   plaidClient,
+		plaidSecrets:  plaidSecrets,
+		stats:         stats,
+		ps:            pubsub.NewPostgresPubSub(log, db),
+		communication: userCommunication,
+		providers:     aggregator.Providers{},
 	}
 
-	manager.work.Middleware(manager.middleware)
+	// Providers are only added when they are configured, links for any other provider cannot be synced.
+	if plaidClient != nil {
+		manager.providers[models.PlaidLinkType] = plaidClient
+	}
+	if simpleFIN != nil {
+		manager.providers[models.SimpleFINLinkType] = simpleFIN
+	}
 
-	manager.work.Job(EnqueueProcessFundingSchedules, manager.enqueueProcessFundingSchedules)
-	manager.work.Job(EnqueuePullAccountBalances, manager.enqueuePullAccountBalances)
-	manager.work.Job(EnqueuePullLatestTransactions, manager.enqueuePullLatestTransactions)
+	manager.work.Middleware(manager.middleware)
 
-	manager.work.Job(ProcessFundingSchedules, manager.processFundingSchedules)
-	manager.work.Job(PullAccountBalances, manager.pullAccountBalances)
-	manager.work.Job(PullInitialTransactions, manager.pullInitialTransactions)
-	manager.work.Job(PullLatestTransactions, manager.pullLatestTransactions)
-	manager.work.Job(PullHistoricalTransactions, manager.pullHistoricalTransactions)
-	manager.work.Job(RemoveTransactions, manager.removeTransactions)
-	manager.work.Job(RemoveLink, manager.removeLink)
+	manager.registerJob(EnqueueProcessFundingSchedules, manager.enqueueProcessFundingSchedules)
+	manager.registerJob(EnqueuePullAccountBalances, manager.enqueuePullAccountBalances)
+	manager.registerJob(EnqueuePullLatestTransactions, manager.enqueuePullLatestTransactions)
+	manager.registerJob(EnqueueRemoveStalePendingTransactions, manager.enqueueRemoveStalePendingTransactions)
+	manager.registerJob(EnqueueSyncSimpleFINLinks, manager.enqueueSyncSimpleFINLinks)
+
+	manager.registerJob(ProcessFundingSchedules, manager.processFundingSchedules)
+	manager.registerJob(PullAccountBalances, manager.pullAccountBalances)
+	manager.registerJob(PullInitialTransactions, manager.pullInitialTransactions)
+	manager.registerJob(PullLatestTransactions, manager.pullLatestTransactions)
+	manager.registerJob(PullHistoricalTransactions, manager.pullHistoricalTransactions)
+	manager.registerJob(RemoveTransactions, manager.removeTransactions)
+	manager.registerJob(RemoveStalePendingTransactions, manager.removeStalePendingTransactions)
+	manager.registerJob(RemoveLink, manager.removeLink)
+	manager.registerJob(SyncSimpleFINLink, manager.syncSimpleFINLink)
+	manager.registerJob(RecalculateTimezone, manager.recalculateTimezone)
+	manager.registerJob(UpdateInstitutions, manager.updateInstitutions)
 
 	// Every 30 minutes. 0 */30 * * * *
 
 	// Every hour.
-	manager.work.PeriodicallyEnqueue("0 0 * * * *", EnqueueProcessFundingSchedules)
+	manager.scheduleJob(EnqueueProcessFundingSchedules, "0 0 * * * *")
 
 	// Once a day. But also can be triggered by a webhook.
-	manager.work.PeriodicallyEnqueue("0 0 0 * * *", EnqueuePullAccountBalances)
-	manager.work.PeriodicallyEnqueue("0 0 0 * * *", EnqueuePullLatestTransactions)
-	//manager.work.PeriodicallyEnqueue("0 0 0 * * *", UpdateInstitutions)
+	manager.scheduleJob(EnqueuePullAccountBalances, "0 0 0 * * *")
+	manager.scheduleJob(EnqueuePullLatestTransactions, "0 0 0 * * *")
+
+	// Providers without webhooks have their links synced a few times a day instead. SimpleFIN bridges typically only
+	// refresh their data once a day, so syncing more often than this will not retrieve anything new.
+	if provider, ok := manager.providers[models.SimpleFINLinkType]; ok && !provider.SupportsWebhooks() {
+		manager.scheduleJob(EnqueueSyncSimpleFINLinks, "0 0 */6 * * *")
+	}
+
+	// Once a day, a few hours after the latest transactions have been pulled.
+	manager.scheduleJob(EnqueueRemoveStalePendingTransactions, "0 0 3 * * *")
+
+	// Every 6 hours, institution status changes more often than once a day.
+	manager.scheduleJob(UpdateInstitutions, "0 0 */6 * * *")
 
 	manager.work.Start()
 	log.Debug("job manager started")
@@ -114,12 +188,70 @@ func NewJobManager(
 	return manager
 }
 
-func (j *jobManagerBase) enqueueUniqueJob(name string, arguments map[string]interface{}) (*work.Job, error) {
+// registerJob will add the provided job handler to the worker pool, limiting its concurrency if one has been
+// configured for the job.
+func (j *jobManagerBase) registerJob(name string, handler work.GenericHandler) {
+	j.work.JobWithOptions(name, work.JobOptions{
+		MaxFails:       defaultMaxFails,
+		MaxConcurrency: j.configuration.GetConcurrency(name),
+	}, handler)
+}
+
+// scheduleJob will periodically enqueue the provided job using the schedule from the config, or the default schedule
+// if one is not configured. If the job has been disabled in the config then it will not be scheduled at all.
+func (j *jobManagerBase) scheduleJob(name, defaultSchedule string) {
+	schedule, enabled := j.configuration.GetSchedule(name, defaultSchedule)
+	if !enabled {
+		j.log.WithField("job", name).Info("job is disabled and will not be scheduled")
+		return
+	}
+
+	j.log.WithFields(logrus.Fields{
+		"job":      name,
+		"schedule": schedule,
+	}).Debug("scheduling job")
+	j.work.PeriodicallyEnqueue(schedule, name)
+}
+
+// getPlaidJitter returns the number of seconds that a scheduled Plaid pull should be delayed for the provided account.
+// The delay is consistent for a single account so that its links are synced at roughly the same time every day.
+func (j *jobManagerBase) getPlaidJitter(accountId uint64) int64 {
+	window := int64(j.configuration.PlaidJitter.Seconds())
+	if window <= 0 {
+		return 0
+	}
+
+	hash := fnv.New64a()
+	_ = binary.Write(hash, binary.BigEndian, accountId)
+
+	return int64(hash.Sum64() % uint64(window))
+}
+
+func (j *jobManagerBase) enqueueUniqueJobIn(name string, secondsFromNow int64, arguments map[string]interface{}) (*work.ScheduledJob, error) {
 	if j.stats != nil {
 		j.stats.JobEnqueued(name)
 	}
 
-	return j.queue.EnqueueUnique(name, arguments)
+	if secondsFromNow <= 0 {
+		job, err := j.queue.EnqueueUnique(name, arguments)
+		if err != nil || job == nil {
+			return nil, err
+		}
+
+		return &work.ScheduledJob{Job: job}, nil
+	}
+
+	return j.queue.EnqueueUniqueIn(name, secondsFromNow, arguments)
+}
+
+// getEnqueuedJobId returns the Id of a job returned by EnqueueUnique. gocraft returns a nil job when an identical job is
+// already queued, in that case an empty string is returned.
+func getEnqueuedJobId(job *work.Job) string {
+	if job == nil {
+		return ""
+	}
+
+	return job.ID
 }
 
 func (j *jobManagerBase) TriggerPullInitialTransactions(accountId, userId, linkId uint64) (jobId string, err error) {
@@ -132,10 +264,10 @@ func (j *jobManagerBase) TriggerPullInitialTransactions(accountId, userId, linkI
 		return "", err
 	}
 
-	return job.ID, nil
+	return getEnqueuedJobId(job), nil
 }
 
-func (j *jobManagerBase) middleware(job *work.Job, next work.NextMiddlewareFunc) error {
+func (j *jobManagerBase) middleware(job *work.Job, next work.NextMiddlewareFunc) (err error) {
 	start := time.Now()
 	log := j.getLogForJob(job)
 	log.Infof("starting job")
@@ -149,18 +281,33 @@ func (j *jobManagerBase) middleware(job *work.Job, next work.NextMiddlewareFunc)
 		StartedAt:  &start,
 		FinishedAt: nil,
 		Retries:    int(job.Fails),
+		Attempts:   []models.JobAttempt{},
 	}
 
-	if jobData.Retries == 0 {
-		log.Trace("inserting job record before running")
-		if _, err := j.db.Model(&jobData).Insert(&jobData); err != nil {
-			log.WithError(err).Warn("failed to insert job record before running")
+	// Jobs that are being retried, or that have been re-enqueued after dying, will already have a record. We want to
+	// keep the attempts from previous runs so we need to retrieve them before we update the record.
+	var existing models.Job
+	switch selectErr := j.db.Model(&existing).
+		Column("attempts").
+		Where(`"job"."job_id" = ?`, job.ID).
+		Limit(1).
+		Select(&existing); selectErr {
+	case nil:
+		if existing.Attempts != nil {
+			jobData.Attempts = existing.Attempts
 		}
-	} else {
+
 		log.Trace("updating job record before running")
-		if _, err := j.db.Model(&jobData).WherePK().Update(&jobData); err != nil {
-			log.WithError(err).Warn("failed to update job record before running")
+		if _, updateErr := j.db.Model(&jobData).WherePK().Update(&jobData); updateErr != nil {
+			log.WithError(updateErr).Warn("failed to update job record before running")
 		}
+	case pg.ErrNoRows:
+		log.Trace("inserting job record before running")
+		if _, insertErr := j.db.Model(&jobData).Insert(&jobData); insertErr != nil {
+			log.WithError(insertErr).Warn("failed to insert job record before running")
+		}
+	default:
+		log.WithError(selectErr).Warn("failed to retrieve existing job record before running")
 	}
 
 	defer func() {
@@ -172,9 +319,33 @@ func (j *jobManagerBase) middleware(job *work.Job, next work.NextMiddlewareFunc)
 		now := time.Now()
 		jobData.FinishedAt = &now
 
+		attempt := models.JobAttempt{
+			Attempt:    len(jobData.Attempts) + 1,
+			StartedAt:  start,
+			FinishedAt: now,
+		}
+
+		if err != nil {
+			// Store the error on the job record so that the client can see why a job failed.
+			message := err.Error()
+			stack := fmt.Sprintf("%+v", err)
+			jobData.Error = &message
+			jobData.Stack = &stack
+			attempt.Error = &message
+
+			// gocraft will increment the number of fails after this middleware returns, if that puts the job at the
+			// maximum number of fails then the job will be moved to the dead queue and will not be retried.
+			if job.Fails+1 >= defaultMaxFails {
+				log.WithError(err).Error("job has failed too many times and will not be retried")
+				jobData.DeadAt = &now
+			}
+		}
+
+		jobData.Attempts = append(jobData.Attempts, attempt)
+
 		log.Trace("updating job record after running")
-		if _, err := j.db.Model(&jobData).WherePK().Update(&jobData); err != nil {
-			log.WithError(err).Warn("failed to update job record after running")
+		if _, updateErr := j.db.Model(&jobData).WherePK().Update(&jobData); updateErr != nil {
+			log.WithError(updateErr).Warn("failed to update job record after running")
 		}
 	}()
 
@@ -37,6 +37,10 @@ func (n *nonDistributedJobManager) TriggerRemoveTransactions(accountId, linkId u
 	panic("implement me")
 }
 
+func (n *nonDistributedJobManager) TriggerSyncSimpleFINLink(accountId, linkId uint64) (jobId string, err error) {
+	panic("implement me")
+}
+
 func (n *nonDistributedJobManager) TriggerRemoveLink(accountId, userId, linkId uint64) (jobId string, err error) {
 	log := n.log.WithFields(logrus.Fields{
 		"accountId": accountId,
@@ -56,6 +60,23 @@ func (n *nonDistributedJobManager) TriggerRemoveLink(accountId, userId, linkId u
 	return fmt.Sprintf("%s:%X", RemoveLink, time.Now().Unix()), runner.Run(context.Background())
 }
 
+func (n *nonDistributedJobManager) TriggerRecalculateTimezone(accountId, userId uint64, previousTimezone string) (jobId string, err error) {
+	log := n.log.WithFields(logrus.Fields{
+		"accountId": accountId,
+		"userId":    userId,
+	})
+
+	runner := &RecalculateTimezoneJob{
+		accountId:        accountId,
+		userId:           userId,
+		previousTimezone: previousTimezone,
+		log:              log,
+		db:               n.db,
+	}
+
+	return fmt.Sprintf("%s:%X", RecalculateTimezone, time.Now().Unix()), runner.Run(context.Background())
+}
+
 func (n *nonDistributedJobManager) Close() error {
 	return nil
 }
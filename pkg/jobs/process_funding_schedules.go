@@ -177,6 +177,15 @@ func (j *jobManagerBase) processFundingSchedules(job *work.Job) (err error) {
 						continue
 					}
 
+					if spending.IsComplete || spending.IsArchived {
+						crumbs.Debug(span.Context(), "Spending object is complete, it will be skipped", map[string]interface{}{
+							"fundingScheduleId": fundingScheduleId,
+							"spendingId":        spending.SpendingId,
+						})
+						spendingLog.Trace("skipping spending, it has been completed")
+						continue
+					}
+
 					progressAmount := spending.GetProgressAmount()
 
 					if spending.TargetAmount <= progressAmount {
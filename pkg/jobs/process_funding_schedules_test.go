@@ -0,0 +1,108 @@
+package jobs
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/gocraft/work"
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessFundingSchedules(t *testing.T) {
+	t.Run("skips completed goals", func(t *testing.T) {
+		log := testutils.GetLog(t)
+		db := testutils.GetPgDatabase(t)
+		cache := testutils.GetRedisPool(t)
+
+		user, _ := testutils.SeedAccount(t, db, testutils.WithManualAccount)
+
+		var bankAccount models.BankAccount
+		require.NoError(t, db.Model(&bankAccount).
+			Where(`"bank_account"."account_id" = ?`, user.AccountId).
+			Limit(1).
+			Select(&bankAccount), "must retrieve bank account")
+
+		rule, err := models.NewRule("FREQ=WEEKLY;BYDAY=FR")
+		require.NoError(t, err, "must create rule")
+
+		fundingSchedule := models.FundingSchedule{
+			AccountId:      user.AccountId,
+			BankAccountId:  bankAccount.BankAccountId,
+			Name:           "Payday",
+			Rule:           rule,
+			NextOccurrence: time.Now().Add(-24 * time.Hour),
+		}
+		_, err = db.Model(&fundingSchedule).Insert(&fundingSchedule)
+		require.NoError(t, err, "must create funding schedule")
+
+		completedAt := time.Now().Add(-48 * time.Hour)
+		goals := []models.Spending{
+			{
+				AccountId:              user.AccountId,
+				BankAccountId:          bankAccount.BankAccountId,
+				FundingScheduleId:      fundingSchedule.FundingScheduleId,
+				SpendingType:           models.SpendingTypeGoal,
+				Name:                   "Vacation",
+				TargetAmount:           10000,
+				CurrentAmount:          0,
+				NextRecurrence:         time.Now().AddDate(0, 2, 0),
+				NextContributionAmount: 1000,
+				DateCreated:            time.Now(),
+			},
+			{
+				// The target amount has not been reached, but the goal has been marked as complete. It must not be
+				// funded any further.
+				AccountId:              user.AccountId,
+				BankAccountId:          bankAccount.BankAccountId,
+				FundingScheduleId:      fundingSchedule.FundingScheduleId,
+				SpendingType:           models.SpendingTypeGoal,
+				Name:                   "New Car",
+				TargetAmount:           10000,
+				CurrentAmount:          5000,
+				NextRecurrence:         time.Now().AddDate(0, 2, 0),
+				NextContributionAmount: 1000,
+				DateCreated:            time.Now(),
+				IsComplete:             true,
+				IsArchived:             true,
+				DateCompleted:          &completedAt,
+			},
+		}
+		_, err = db.Model(&goals).Insert(&goals)
+		require.NoError(t, err, "must create goals")
+
+		job := NewJobManager(log, config.Jobs{}, cache, db, nil, nil, nil, nil, nil).(*jobManagerBase)
+		defer require.NoError(t, job.Close(), "must close job manager")
+
+		err = job.processFundingSchedules(&work.Job{
+			Name:       ProcessFundingSchedules,
+			ID:         gofakeit.UUID(),
+			EnqueuedAt: time.Now().Unix(),
+			Args: map[string]interface{}{
+				"accountId":          user.AccountId,
+				"bankAccountId":      bankAccount.BankAccountId,
+				"fundingScheduleIds": strconv.FormatUint(fundingSchedule.FundingScheduleId, 10),
+			},
+		})
+		assert.NoError(t, err, "job should succeed")
+
+		var active, completed models.Spending
+		require.NoError(t, db.Model(&active).
+			Where(`"spending"."spending_id" = ?`, goals[0].SpendingId).
+			Limit(1).
+			Select(&active), "must retrieve active goal")
+		require.NoError(t, db.Model(&completed).
+			Where(`"spending"."spending_id" = ?`, goals[1].SpendingId).
+			Limit(1).
+			Select(&completed), "must retrieve completed goal")
+
+		assert.EqualValues(t, 1000, active.CurrentAmount, "active goal should have been funded")
+		assert.EqualValues(t, 5000, completed.CurrentAmount, "completed goal should not have been funded")
+		assert.True(t, completed.IsComplete, "completed goal should still be complete")
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/getsentry/sentry-go"
 	"github.com/monetr/rest-api/pkg/crumbs"
+	"github.com/monetr/rest-api/pkg/internal/aggregator"
 	"github.com/sirupsen/logrus"
 	"strconv"
 	"time"
@@ -64,7 +65,7 @@ func (j *jobManagerBase) enqueuePullAccountBalances(job *work.Job) error {
 			})
 			accountLog.Trace("enqueueing for account balance update")
 
-			_, err = j.enqueueUniqueJob(PullAccountBalances, map[string]interface{}{
+			_, err = j.enqueueUniqueJobIn(PullAccountBalances, j.getPlaidJitter(account.AccountID), map[string]interface{}{
 				"accountId": account.AccountID,
 				"linkId":    linkId,
 			})
@@ -138,13 +139,6 @@ func (j *jobManagerBase) pullAccountBalances(job *work.Job) (err error) {
 			return nil
 		}
 
-		accessToken, err := j.plaidSecrets.GetAccessTokenForPlaidLinkId(span.Context(), accountId, link.PlaidLink.ItemId)
-		if err != nil || accessToken == "" {
-			log.WithError(err).Errorf("failed to retrieve access token for link")
-			crumbs.Error(span.Context(), "Could not retrieve Plaid access token for link", "plaid", nil)
-			return nil
-		}
-
 		bankAccounts, err := repo.GetBankAccountsByLinkId(span.Context(), linkId)
 		if err != nil {
 			log.WithError(err).Error("failed to retrieve bank account details to pull balances")
@@ -161,13 +155,14 @@ func (j *jobManagerBase) pullAccountBalances(job *work.Job) (err error) {
 
 		log.Debugf("requesting information for %d bank account(s)", len(itemBankAccountIds))
 
-		platypus, err := j.plaidClient.NewClient(span.Context(), link, accessToken)
+		client, err := j.providers.GetClientForLink(span.Context(), link)
 		if err != nil {
-			log.WithError(err).Error("failed to create plaid client")
-			return err
+			log.WithError(err).Errorf("failed to create client for link")
+			crumbs.Error(span.Context(), "Could not create client for link", "plaid", nil)
+			return nil
 		}
 
-		result, err := platypus.GetAccounts(
+		result, err := client.GetAccounts(
 			span.Context(),
 			itemBankAccountIds...,
 		)
@@ -176,6 +171,29 @@ func (j *jobManagerBase) pullAccountBalances(job *work.Job) (err error) {
 			return errors.Wrap(err, "failed to retrieve bank accounts from plaid")
 		}
 
+		// Credit accounts also have a statement balance, but it is not included with the account balances. If the
+		// provider can retrieve it then we request it for just the credit accounts.
+		statementBalances := map[string]int64{}
+		if statementClient, ok := client.(aggregator.StatementBalanceClient); ok {
+			creditBankAccountIds := make([]string, 0, len(bankAccounts))
+			for _, bankAccount := range bankAccounts {
+				if bankAccount.Type == models.CreditBankAccountType {
+					creditBankAccountIds = append(creditBankAccountIds, bankAccount.PlaidAccountId)
+				}
+			}
+
+			if len(creditBankAccountIds) > 0 {
+				statementBalances, err = statementClient.GetStatementBalances(span.Context(), creditBankAccountIds...)
+				if err != nil {
+					// Not every item will have the liabilities product, if we cannot retrieve the statement balances
+					// then we still want to update the other balances.
+					log.WithError(err).Warn("failed to retrieve statement balances, they will not be updated")
+					crumbs.Warn(span.Context(), "Failed to retrieve statement balances", "plaid", nil)
+					statementBalances = map[string]int64{}
+				}
+			}
+		}
+
 		updatedBankAccounts := make([]models.BankAccount, 0, len(result))
 		for _, item := range result {
 			bankAccount := plaidIdsToBank[item.GetAccountId()]
@@ -183,23 +201,22 @@ func (j *jobManagerBase) pullAccountBalances(job *work.Job) (err error) {
 				"bankAccountId": bankAccount.BankAccountId,
 				"linkId":        bankAccount.LinkId,
 			})
-			shouldUpdate := false
-			available := item.GetBalances().GetAvailable()
-			current := item.GetBalances().GetCurrent()
-
-			if bankAccount.CurrentBalance != current {
-				bankLog = bankLog.WithField("currentBalanceChanged", true)
-				shouldUpdate = true
-			} else {
-				bankLog = bankLog.WithField("currentBalanceChanged", false)
+			previousAvailable := bankAccount.AvailableBalance
+			previousCurrent := bankAccount.CurrentBalance
+			shouldUpdate := bankAccount.SetBalances(
+				item.GetBalances().GetAvailable(),
+				item.GetBalances().GetCurrent(),
+				item.GetBalances().GetLimit(),
+			)
+
+			if statement, ok := statementBalances[item.GetAccountId()]; ok {
+				shouldUpdate = bankAccount.SetStatementBalance(statement) || shouldUpdate
 			}
 
-			if bankAccount.AvailableBalance != available {
-				bankLog = bankLog.WithField("availableBalanceChanged", true)
-				shouldUpdate = true
-			} else {
-				bankLog = bankLog.WithField("availableBalanceChanged", false)
-			}
+			bankLog = bankLog.WithFields(logrus.Fields{
+				"currentBalanceChanged":   bankAccount.CurrentBalance != previousCurrent,
+				"availableBalanceChanged": bankAccount.AvailableBalance != previousAvailable,
+			})
 
 			bankLog = bankLog.WithField("willUpdate", shouldUpdate)
 
@@ -213,14 +230,17 @@ func (j *jobManagerBase) pullAccountBalances(job *work.Job) (err error) {
 				updatedBankAccounts = append(updatedBankAccounts, models.BankAccount{
 					BankAccountId:    bankAccount.BankAccountId,
 					AccountId:        accountId,
-					AvailableBalance: available,
-					CurrentBalance:   current,
+					AvailableBalance: bankAccount.AvailableBalance,
+					CurrentBalance:   bankAccount.CurrentBalance,
+					LimitBalance:     bankAccount.LimitBalance,
+					StatementBalance: bankAccount.StatementBalance,
+					OriginalBalance:  bankAccount.OriginalBalance,
 					LastUpdated:      start.UTC(),
 				})
 			}
 		}
 
-		if err := repo.UpdateBankAccounts(span.Context(), updatedBankAccounts); err != nil {
+		if err := repo.UpdateBankAccountBalances(span.Context(), updatedBankAccounts); err != nil {
 			log.WithError(err).Error("failed to update bank account balances")
 			return err
 		}
@@ -11,8 +11,8 @@ import (
 	"github.com/monetr/rest-api/pkg/internal/mock_secrets"
 	"github.com/monetr/rest-api/pkg/internal/platypus"
 	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/monetr/rest-api/pkg/models"
 	"github.com/monetr/rest-api/pkg/repository"
-	"github.com/monetr/rest-api/pkg/secrets"
 	"github.com/plaid/plaid-go/plaid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -43,20 +43,19 @@ func TestPullAccountBalances(t *testing.T) {
 			return nil
 		}), "must retrieve linkId")
 
-		secretProvider := secrets.NewPostgresPlaidSecretsProvider(log, db)
+		plaidSecrets := mock_secrets.NewMockPlaidSecrets()
+		for accessToken, data := range plaidData.PlaidTokens {
+			plaidSecrets = plaidSecrets.WithSecret(account.AccountId, data.ItemId, accessToken)
+		}
+
 		plaidRepo := repository.NewPlaidRepository(db)
-		plaidClient := platypus.NewPlaid(log, secretProvider, plaidRepo, config.Plaid{
+		plaidClient := platypus.NewPlaid(log, plaidSecrets, plaidRepo, config.Plaid{
 			ClientID:                      gofakeit.UUID(),
 			ClientSecret:                  gofakeit.UUID(),
 			Environment:                   plaid.Sandbox,
 		})
 
-		plaidSecrets := mock_secrets.NewMockPlaidSecrets()
-		for accessToken, data := range plaidData.PlaidTokens {
-			plaidSecrets = plaidSecrets.WithSecret(account.AccountId, data.ItemId, accessToken)
-		}
-
-		job := NewJobManager(log, cache, db, plaidClient, nil, plaidSecrets).(*jobManagerBase)
+		job := NewJobManager(log, config.Jobs{}, cache, db, plaidClient, nil, plaidSecrets, nil, nil).(*jobManagerBase)
 		defer require.NoError(t, job.Close(), "must close job manager")
 
 		// TODO (elliotcourant) Tweak the plaid data balances before we make our request. This way we can add proper
@@ -84,4 +83,123 @@ func TestPullAccountBalances(t *testing.T) {
 			"POST https://sandbox.plaid.com/accounts/get": 1,
 		}, httpmock.GetCallCountInfo(), "call counts should match")
 	})
+	t.Run("credit card paid off", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		log := testutils.GetLog(t)
+
+		db := testutils.GetPgDatabase(t)
+		cache := testutils.GetRedisPool(t)
+
+		account, plaidData := testutils.SeedAccount(t, db, testutils.WithPlaidAccount)
+
+		var linkId uint64
+		var checking, creditCard models.BankAccount
+		var accessToken string
+		for token := range plaidData.PlaidTokens {
+			accessToken = token
+		}
+
+		// Turn the seeded savings account into a credit card that has a balance owed on it, and have it be paid from
+		// the checking account. The amount owed will be reserved in the checking account.
+		require.NoError(t, db.RunInTransaction(context.Background(), func(txn *pg.Tx) error {
+			repo := repository.NewRepositoryFromSession(account.UserId, account.AccountId, txn)
+			links, err := repo.GetLinks(context.Background())
+			require.NoError(t, err, "must retrieve links for account")
+			require.Len(t, links, 1, "should have exactly one link")
+			linkId = links[0].LinkId
+
+			bankAccounts, err := repo.GetBankAccountsByLinkId(context.Background(), linkId)
+			require.NoError(t, err, "must retrieve bank accounts")
+			for _, bankAccount := range bankAccounts {
+				switch bankAccount.SubType {
+				case "checking":
+					checking = bankAccount
+				default:
+					creditCard = bankAccount
+				}
+			}
+			require.NotZero(t, checking.BankAccountId, "must have a checking account")
+			require.NotZero(t, creditCard.BankAccountId, "must have a credit card")
+
+			_, err = txn.Model(&creditCard).
+				Set(`"account_type" = ?`, models.CreditBankAccountType).
+				Set(`"account_sub_type" = ?`, models.CreditCartBankAccountSubType).
+				Set(`"current_balance" = ?`, 5000).
+				Set(`"available_balance" = ?`, 0).
+				Set(`"payment_bank_account_id" = ?`, checking.BankAccountId).
+				WherePK().
+				Update()
+			require.NoError(t, err, "must update credit card")
+
+			balances, err := repo.GetBalances(context.Background(), checking.BankAccountId)
+			require.NoError(t, err, "must retrieve balances")
+			assert.EqualValues(t, 5000, balances.CreditReserve, "credit card balance should be reserved")
+
+			return nil
+		}), "must setup credit card")
+
+		// Plaid now reports that the card has been paid off.
+		creditCardSubType := plaid.ACCOUNTSUBTYPE_CREDIT_CARD
+		plaidAccount := plaidData.BankAccounts[accessToken][creditCard.PlaidAccountId]
+		plaidAccount.Type = plaid.ACCOUNTTYPE_CREDIT
+		plaidAccount.Subtype = *plaid.NewNullableAccountSubtype(&creditCardSubType)
+		plaidAccount.Balances.SetAvailable(0)
+		plaidAccount.Balances.SetCurrent(0)
+		plaidData.BankAccounts[accessToken][creditCard.PlaidAccountId] = plaidAccount
+
+		plaidSecrets := mock_secrets.NewMockPlaidSecrets()
+		for token, data := range plaidData.PlaidTokens {
+			plaidSecrets = plaidSecrets.WithSecret(account.AccountId, data.ItemId, token)
+		}
+
+		plaidRepo := repository.NewPlaidRepository(db)
+		plaidClient := platypus.NewPlaid(log, plaidSecrets, plaidRepo, config.Plaid{
+			ClientID:     gofakeit.UUID(),
+			ClientSecret: gofakeit.UUID(),
+			Environment:  plaid.Sandbox,
+		})
+
+		job := NewJobManager(log, config.Jobs{}, cache, db, plaidClient, nil, plaidSecrets, nil, nil).(*jobManagerBase)
+		defer require.NoError(t, job.Close(), "must close job manager")
+
+		mock_plaid.MockGetAccountsExtended(t, plaidData)
+		mock_plaid.MockGetLiabilities(t, []plaid.AccountBase{plaidAccount}, map[string]float64{
+			creditCard.PlaidAccountId: 0,
+		})
+
+		err := job.pullAccountBalances(&work.Job{
+			Name:       PullAccountBalances,
+			ID:         gofakeit.UUID(),
+			EnqueuedAt: time.Now().Unix(),
+			Args: map[string]interface{}{
+				"accountId": account.AccountId,
+				"linkId":    linkId,
+			},
+			Unique: true,
+		})
+		assert.NoError(t, err, "job should succeed")
+
+		assert.Equal(t, map[string]int{
+			"POST https://sandbox.plaid.com/accounts/get":    1,
+			"POST https://sandbox.plaid.com/liabilities/get": 1,
+		}, httpmock.GetCallCountInfo(), "call counts should match")
+
+		require.NoError(t, db.RunInTransaction(context.Background(), func(txn *pg.Tx) error {
+			repo := repository.NewRepositoryFromSession(account.UserId, account.AccountId, txn)
+			updated, err := repo.GetBankAccount(context.Background(), creditCard.BankAccountId)
+			require.NoError(t, err, "must retrieve credit card")
+			assert.Zero(t, updated.CurrentBalance, "a zero balance must be stored")
+			assert.Zero(t, updated.AvailableBalance, "a zero available balance must be stored")
+			require.NotNil(t, updated.StatementBalance, "statement balance should be stored")
+			assert.Zero(t, *updated.StatementBalance, "statement balance should be zero")
+
+			balances, err := repo.GetBalances(context.Background(), checking.BankAccountId)
+			require.NoError(t, err, "must retrieve balances")
+			assert.Zero(t, balances.CreditReserve, "credit reserve should be cleared once the card is paid off")
+
+			return nil
+		}), "must verify balances")
+	})
 }
@@ -35,7 +35,7 @@ func (j *jobManagerBase) TriggerPullHistoricalTransactions(accountId, linkId uin
 		return "", errors.Wrap(err, "failed to enqueue pulling historical transactions")
 	}
 
-	return job.ID, nil
+	return getEnqueuedJobId(job), nil
 }
 
 func (j *jobManagerBase) pullHistoricalTransactions(job *work.Job) (err error) {
@@ -71,8 +71,6 @@ func (j *jobManagerBase) pullHistoricalTransactions(job *work.Job) (err error) {
 		scope.SetTag("jobId", job.ID)
 	})
 
-	twoYearsAgo := time.Now().Add(-2 * 365 * 24 * time.Hour).UTC()
-
 	return j.getRepositoryForJob(job, func(repo repository.Repository) error {
 		link, err := repo.GetLink(span.Context(), linkId)
 		if err != nil {
@@ -96,12 +94,6 @@ func (j *jobManagerBase) pullHistoricalTransactions(job *work.Job) (err error) {
 			return nil
 		}
 
-		accessToken, err := j.plaidSecrets.GetAccessTokenForPlaidLinkId(span.Context(), accountId, link.PlaidLink.ItemId)
-		if err != nil {
-			log.WithError(err).Errorf("failed to retrieve access token for link")
-			return err
-		}
-
 		bankAccounts, err := repo.GetBankAccountsByLinkId(span.Context(), linkId)
 		if err != nil {
 			log.WithError(err).Error("failed to retrieve bank account details to pull transactions")
@@ -119,27 +111,10 @@ func (j *jobManagerBase) pullHistoricalTransactions(job *work.Job) (err error) {
 		log.Debugf("retrieving transactions for %d bank account(s)", len(itemBankAccountIds))
 
 
-		platypus, err := j.plaidClient.NewClient(span.Context(), link, accessToken)
-		if err != nil {
-			log.WithError(err).Error("failed to create plaid client for link")
-			return err
-		}
-
-		transactions, err := platypus.GetAllTransactions(span.Context(), twoYearsAgo, time.Now(), itemBankAccountIds)
-		if err != nil {
+		// Historical transactions are retrieved from the cursor stored by the initial pull, so only the transactions
+		// that we have not seen yet are returned.
+		if _, err = j.syncPlaidTransactions(span.Context(), log, repo, link, plaidIdsToBankIds); err != nil {
 			log.WithError(err).Error("failed to retrieve transactions from plaid")
-			return errors.Wrap(err, "failed to retrieve transactions from plaid")
-		}
-
-		if err = j.upsertTransactions(
-			span.Context(),
-			log,
-			repo,
-			link,
-			plaidIdsToBankIds,
-			transactions,
-		); err != nil {
-			log.WithError(err).Error("failed to upsert transactions from plaid")
 			return err
 		}
 
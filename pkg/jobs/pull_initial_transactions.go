@@ -68,56 +68,29 @@ func (j *jobManagerBase) pullInitialTransactions(job *work.Job) (err error) {
 			return nil
 		}
 
-		accessToken, err := j.plaidSecrets.GetAccessTokenForPlaidLinkId(span.Context(), accountId, link.PlaidLink.ItemId)
-		if err != nil {
-			log.WithError(err).Errorf("failed to retrieve access token for link")
-			return err
-		}
-
 		if len(link.BankAccounts) == 0 {
 			log.Error("no bank accounts for plaid link")
 			return nil
 		}
 
 		plaidIdsToBankIds := map[string]uint64{}
-		bankAccountIds := make([]string, len(link.BankAccounts))
-		for i, bankAccount := range link.BankAccounts {
-			bankAccountIds[i] = bankAccount.PlaidAccountId
+		for _, bankAccount := range link.BankAccounts {
 			plaidIdsToBankIds[bankAccount.PlaidAccountId] = bankAccount.BankAccountId
 		}
 
-		now := time.Now().UTC()
-		platypus, err := j.plaidClient.NewClient(span.Context(), link, accessToken)
-		if err != nil {
-			log.WithError(err).Error("failed to create plaid client for link")
-			return err
-		}
-
-		plaidTransactions, err := platypus.GetAllTransactions(span.Context(), now.Add(-30*24*time.Hour), now, bankAccountIds)
+		// Syncing the link for the first time will retrieve all of the transactions that are available so far, and will
+		// store the cursor so that later updates only retrieve what has changed since.
+		changes, err := j.syncPlaidTransactions(span.Context(), log, repo, link, plaidIdsToBankIds)
 		if err != nil {
 			log.WithError(err).Error("failed to retrieve initial transactions from plaid")
-			return errors.Wrap(err, "failed to retrieve initial transactions from plaid")
+			return err
 		}
 
-		if len(plaidTransactions) == 0 {
+		if changes == 0 {
 			log.Warn("no transactions were retrieved from plaid")
 			return nil
 		}
 
-		log.Debugf("retreived %d transaction(s) from plaid, processing now", len(plaidTransactions))
-
-		if err = j.upsertTransactions(
-			span.Context(),
-			log,
-			repo,
-			link,
-			plaidIdsToBankIds,
-			plaidTransactions,
-		); err != nil {
-			log.WithError(err).Error("failed to upsert transactions from plaid")
-			return err
-		}
-
 		link.LinkStatus = models.LinkStatusSetup
 		link.LastSuccessfulUpdate = myownsanity.TimeP(time.Now().UTC())
 		if err = repo.UpdateLink(span.Context(), link); err != nil {
@@ -37,19 +37,23 @@ func (j *jobManagerBase) TriggerPullLatestTransactions(accountId, linkId uint64,
 		log.WithError(err).Error("failed to enqueue pulling latest transactions")
 		return "", errors.Wrap(err, "failed to enqueue pulling latest transactions")
 	}
-	log = log.WithField("pullLatestTransactionsJobId", job.ID)
+
+	jobId = getEnqueuedJobId(job)
+	if jobId == "" {
+		log.Debug("pulling latest transactions is already queued for link")
+	}
+	log = log.WithField("pullLatestTransactionsJobId", jobId)
 
 	log.Infof("queueing account balances update for account")
-	job, err = j.queue.EnqueueUnique(PullAccountBalances, map[string]interface{}{
+	if _, err = j.queue.EnqueueUnique(PullAccountBalances, map[string]interface{}{
 		"accountId": accountId,
 		"linkId":    linkId,
-	})
-	if err != nil {
+	}); err != nil {
 		log.WithError(err).Error("failed to enqueue pulling account balances")
 		return "", errors.Wrap(err, "failed to enqueue pulling account balances")
 	}
 
-	return job.ID, nil
+	return jobId, nil
 }
 
 func (j *jobManagerBase) enqueuePullLatestTransactions(job *work.Job) error {
@@ -70,7 +74,7 @@ func (j *jobManagerBase) enqueuePullLatestTransactions(job *work.Job) error {
 				"linkId":    linkId,
 			})
 			accountLog.Trace("enqueueing for latest transactions update")
-			_, err := j.queue.EnqueueUnique(PullLatestTransactions, map[string]interface{}{
+			_, err := j.enqueueUniqueJobIn(PullLatestTransactions, j.getPlaidJitter(account.AccountID), map[string]interface{}{
 				"accountId": account.AccountID,
 				"linkId":    linkId,
 			})
@@ -144,12 +148,6 @@ func (j *jobManagerBase) pullLatestTransactions(job *work.Job) (err error) {
 			return nil
 		}
 
-		accessToken, err := j.plaidSecrets.GetAccessTokenForPlaidLinkId(span.Context(), accountId, link.PlaidLink.ItemId)
-		if err != nil {
-			log.WithError(err).Errorf("failed to retrieve access token for link")
-			return err
-		}
-
 		bankAccounts, err := repo.GetBankAccountsByLinkId(span.Context(), linkId)
 		if err != nil {
 			log.WithError(err).Error("failed to retrieve bank account details to pull transactions")
@@ -164,41 +162,9 @@ func (j *jobManagerBase) pullLatestTransactions(job *work.Job) (err error) {
 			plaidIdsToBankIds[bankAccount.PlaidAccountId] = bankAccount.BankAccountId
 		}
 
-		log.Debugf("retrieving transactions for %d bank account(s)", len(itemBankAccountIds))
-
-		// Request the last 7 days worth of transactions for update.
-		start := time.Now().Add(-7 * 24 * time.Hour)
-		if link.LastSuccessfulUpdate == nil {
-			// But if there has not been a last successful update set yet, then request the last 30 days to handle this
-			// update.
-			start = time.Now().Add(-30 * 24 * time.Hour)
-		} else if start.After(*link.LastSuccessfulUpdate) {
-			// If we haven't seen an update in longer than 7 days, then use the last successful update date instead.
-			start = *link.LastSuccessfulUpdate
-		}
-		end := time.Now()
-
-		platypus, err := j.plaidClient.NewClient(span.Context(), link, accessToken)
-		if err != nil {
-			log.WithError(err).Error("failed to create plaid client for link")
-			return err
-		}
-
-		transactions, err := platypus.GetAllTransactions(span.Context(), start, end, itemBankAccountIds)
-		if err != nil {
-			log.WithError(err).Error("failed to retrieve transactions from plaid")
-			return errors.Wrap(err, "failed to retrieve transactions from plaid")
-		}
+		log.Debugf("syncing transactions for %d bank account(s)", len(itemBankAccountIds))
 
-		if err = j.upsertTransactions(
-			span.Context(),
-			log,
-			repo,
-			link,
-			plaidIdsToBankIds,
-			transactions,
-		); err != nil {
-			log.WithError(err).Error("failed to upsert transactions from plaid")
+		if _, err = j.syncPlaidTransactions(span.Context(), log, repo, link, plaidIdsToBankIds); err != nil {
 			return err
 		}
 
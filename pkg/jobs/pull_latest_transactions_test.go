@@ -0,0 +1,243 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/go-pg/pg/v10"
+	"github.com/gocraft/work"
+	"github.com/jarcoal/httpmock"
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/internal/mock_plaid"
+	"github.com/monetr/rest-api/pkg/internal/mock_secrets"
+	"github.com/monetr/rest-api/pkg/internal/platypus"
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/monetr/rest-api/pkg/repository"
+	"github.com/plaid/plaid-go/plaid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// givenIHaveAPlaidJobManager will create a job manager that can talk to the mocked Plaid API for the seeded account.
+func givenIHaveAPlaidJobManager(t *testing.T, db *pg.DB, user *models.User, plaidData *testutils.MockPlaidData) *jobManagerBase {
+	log := testutils.GetLog(t)
+	cache := testutils.GetRedisPool(t)
+
+	plaidSecrets := mock_secrets.NewMockPlaidSecrets()
+	for accessToken, data := range plaidData.PlaidTokens {
+		plaidSecrets = plaidSecrets.WithSecret(user.AccountId, data.ItemId, accessToken)
+	}
+
+	plaidRepo := repository.NewPlaidRepository(db)
+	plaidClient := platypus.NewPlaid(log, plaidSecrets, plaidRepo, config.Plaid{
+		ClientID:     gofakeit.UUID(),
+		ClientSecret: gofakeit.UUID(),
+		Environment:  plaid.Sandbox,
+	})
+
+	job := NewJobManager(log, config.Jobs{}, cache, db, plaidClient, nil, plaidSecrets, nil, nil).(*jobManagerBase)
+	t.Cleanup(func() {
+		require.NoError(t, job.Close(), "must close job manager")
+	})
+
+	return job
+}
+
+// givenIHaveAPlaidLink returns the seeded Plaid link and the Plaid account Ids of its bank accounts.
+func givenIHaveAPlaidLink(t *testing.T, db *pg.DB, user *models.User) (linkId uint64, plaidAccountIds []string) {
+	require.NoError(t, db.RunInTransaction(context.Background(), func(txn *pg.Tx) error {
+		repo := repository.NewRepositoryFromSession(user.UserId, user.AccountId, txn)
+		links, err := repo.GetLinks(context.Background())
+		require.NoError(t, err, "must retrieve links for account")
+		require.Len(t, links, 1, "should have exactly one link")
+		linkId = links[0].LinkId
+
+		bankAccounts, err := repo.GetBankAccountsByLinkId(context.Background(), linkId)
+		require.NoError(t, err, "must retrieve bank accounts for link")
+		require.NotEmpty(t, bankAccounts, "link must have bank accounts")
+		for _, bankAccount := range bankAccounts {
+			plaidAccountIds = append(plaidAccountIds, bankAccount.PlaidAccountId)
+		}
+
+		return nil
+	}), "must retrieve link")
+
+	return linkId, plaidAccountIds
+}
+
+// thenTheLinkShouldHave asserts the stored cursor for the link and returns the link's transactions keyed by their
+// Plaid transaction Id.
+func thenTheLinkShouldHave(t *testing.T, db *pg.DB, user *models.User, linkId uint64, cursor string, plaidTransactionIds []string) (*models.Link, map[string]models.Transaction) {
+	var link *models.Link
+	var transactions map[string]models.Transaction
+	require.NoError(t, db.RunInTransaction(context.Background(), func(txn *pg.Tx) (err error) {
+		repo := repository.NewRepositoryFromSession(user.UserId, user.AccountId, txn)
+		link, err = repo.GetLink(context.Background(), linkId)
+		require.NoError(t, err, "must retrieve link")
+		require.NotNil(t, link.PlaidLink, "link must have plaid details")
+		require.NotNil(t, link.PlaidLink.TransactionsCursor, "cursor must be stored")
+		assert.Equal(t, cursor, *link.PlaidLink.TransactionsCursor, "cursor should be the last one returned by plaid")
+
+		transactions, err = repo.GetTransactionsByPlaidId(context.Background(), linkId, plaidTransactionIds)
+		require.NoError(t, err, "must retrieve transactions")
+
+		return nil
+	}), "must retrieve link details")
+
+	return link, transactions
+}
+
+func TestTriggerPullLatestTransactions(t *testing.T) {
+	t.Run("already queued", func(t *testing.T) {
+		enqueuer := NewJobEnqueuer(testutils.GetLog(t), testutils.GetRedisPool(t), "monetr")
+
+		jobId, err := enqueuer.TriggerPullLatestTransactions(1234, 5678, 0)
+		assert.NoError(t, err, "should enqueue job")
+		assert.NotEmpty(t, jobId, "should return the Id of the enqueued job")
+
+		// A webhook and a manual sync will both enqueue the exact same job, the second should not fail.
+		jobId, err = enqueuer.TriggerPullLatestTransactions(1234, 5678, 0)
+		assert.NoError(t, err, "a job that is already queued should not be an error")
+		assert.Empty(t, jobId, "should not return a job Id when the job is already queued")
+
+		jobId, err = enqueuer.TriggerPullLatestTransactions(1234, 9012, 0)
+		assert.NoError(t, err, "should enqueue job for another link")
+		assert.NotEmpty(t, jobId, "jobs for other links should still be enqueued")
+	})
+}
+
+func TestPullLatestTransactions(t *testing.T) {
+	t.Run("added, modified and removed", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		db := testutils.GetPgDatabase(t)
+		user, plaidData := testutils.SeedAccount(t, db, testutils.WithPlaidAccount)
+		linkId, plaidAccountIds := givenIHaveAPlaidLink(t, db, user)
+		job := givenIHaveAPlaidJobManager(t, db, user, plaidData)
+
+		now := time.Now().UTC()
+		added := mock_plaid.GenerateTransactions(t, now.AddDate(0, 0, -30), now, 5, plaidAccountIds)
+		// Changes for accounts that we are not tracking should be ignored.
+		untracked := mock_plaid.GenerateTransactions(t, now.AddDate(0, 0, -30), now, 1, []string{gofakeit.UUID()})
+		added = append(added, untracked...)
+		transactionIds := make([]string, len(added))
+		for i, transaction := range added {
+			transactionIds[i] = transaction.GetTransactionId()
+		}
+
+		pull := func() {
+			err := job.pullLatestTransactions(&work.Job{
+				Name:       PullLatestTransactions,
+				ID:         gofakeit.UUID(),
+				EnqueuedAt: time.Now().Unix(),
+				Args: map[string]interface{}{
+					"accountId": user.AccountId,
+					"linkId":    linkId,
+				},
+				Unique: true,
+			})
+			assert.NoError(t, err, "job should succeed")
+		}
+
+		{ // The first sync should add every transaction and store the cursor.
+			cursor := mock_plaid.MockSyncTransactions(t, added, nil, nil)
+			pull()
+
+			_, transactions := thenTheLinkShouldHave(t, db, user, linkId, cursor, transactionIds)
+			assert.Len(t, transactions, len(added)-len(untracked), "should have every tracked transaction")
+			for _, transaction := range untracked {
+				assert.NotContains(t, transactions, transaction.GetTransactionId(), "untracked transaction should be ignored")
+			}
+		}
+
+		modified := added[0]
+		modified.SetName("A Brand New Name")
+		modified.SetAmount(12.34)
+		removed := added[1]
+
+		{ // The second sync should start from the stored cursor, so only the changes are returned.
+			cursor := mock_plaid.MockSyncTransactions(t, added, []plaid.Transaction{modified}, []string{
+				removed.GetTransactionId(),
+			})
+			pull()
+
+			link, transactions := thenTheLinkShouldHave(t, db, user, linkId, cursor, transactionIds)
+			assert.Len(t, transactions, len(added)-len(untracked)-1, "removed transaction should be deleted")
+			assert.NotContains(t, transactions, removed.GetTransactionId(), "removed transaction should be deleted")
+			require.Contains(t, transactions, modified.GetTransactionId(), "modified transaction should still exist")
+			assert.EqualValues(t, 1234, transactions[modified.GetTransactionId()].Amount, "modified amount should be stored")
+			assert.Equal(t, "A Brand New Name", transactions[modified.GetTransactionId()].Name, "modified name should be stored")
+			assert.NotNil(t, link.LastSuccessfulUpdate, "last successful update should be set")
+		}
+	})
+}
+
+func TestPullInitialAndHistoricalTransactions(t *testing.T) {
+	t.Run("seeds cursor", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		db := testutils.GetPgDatabase(t)
+		user, plaidData := testutils.SeedAccount(t, db, testutils.WithPlaidAccount)
+		linkId, plaidAccountIds := givenIHaveAPlaidLink(t, db, user)
+		job := givenIHaveAPlaidJobManager(t, db, user, plaidData)
+
+		// The initial pull only runs for links that have not been setup yet.
+		_, err := db.Model(&models.Link{}).
+			Set(`"link_status" = ?`, models.LinkStatusPending).
+			Where(`"link"."link_id" = ?`, linkId).
+			Update()
+		require.NoError(t, err, "must mark link as pending")
+
+		now := time.Now().UTC()
+		initial := mock_plaid.GenerateTransactions(t, now.AddDate(0, 0, -30), now, 3, plaidAccountIds)
+		historical := mock_plaid.GenerateTransactions(t, now.AddDate(-1, 0, 0), now.AddDate(0, 0, -31), 3, plaidAccountIds)
+		all := append(append([]plaid.Transaction{}, initial...), historical...)
+		transactionIds := make([]string, len(all))
+		for i, transaction := range all {
+			transactionIds[i] = transaction.GetTransactionId()
+		}
+
+		{ // The initial pull should store the cursor so later pulls only retrieve what has changed.
+			cursor := mock_plaid.MockSyncTransactions(t, initial, nil, nil)
+			err := job.pullInitialTransactions(&work.Job{
+				Name:       PullInitialTransactions,
+				ID:         gofakeit.UUID(),
+				EnqueuedAt: time.Now().Unix(),
+				Args: map[string]interface{}{
+					"accountId": user.AccountId,
+					"userId":    user.UserId,
+					"linkId":    linkId,
+				},
+				Unique: true,
+			})
+			assert.NoError(t, err, "job should succeed")
+
+			link, transactions := thenTheLinkShouldHave(t, db, user, linkId, cursor, transactionIds)
+			assert.Equal(t, models.LinkStatusSetup, link.LinkStatus, "link should be setup")
+			assert.Len(t, transactions, len(initial), "should have the initial transactions")
+		}
+
+		{ // Historical transactions are then retrieved starting from that cursor.
+			cursor := mock_plaid.MockSyncTransactions(t, all, nil, nil)
+			err := job.pullHistoricalTransactions(&work.Job{
+				Name:       PullHistoricalTransactions,
+				ID:         gofakeit.UUID(),
+				EnqueuedAt: time.Now().Unix(),
+				Args: map[string]interface{}{
+					"accountId": user.AccountId,
+					"linkId":    linkId,
+				},
+				Unique: true,
+			})
+			assert.NoError(t, err, "job should succeed")
+
+			_, transactions := thenTheLinkShouldHave(t, db, user, linkId, cursor, transactionIds)
+			assert.Len(t, transactions, len(all), "should have the initial and historical transactions")
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/gocraft/work"
+	"github.com/monetr/rest-api/pkg/crumbs"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/monetr/rest-api/pkg/repository"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	RecalculateTimezone = "RecalculateTimezone"
+)
+
+func (j *jobManagerBase) TriggerRecalculateTimezone(accountId, userId uint64, previousTimezone string) (jobId string, err error) {
+	job, err := j.queue.EnqueueUnique(RecalculateTimezone, map[string]interface{}{
+		"accountId":        accountId,
+		"userId":           userId,
+		"previousTimezone": previousTimezone,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to enqueue timezone recalculation")
+	}
+
+	// If the timezone is changed again before the first job has run then the job is already queued. The job uses the
+	// account's current timezone when it runs, so nothing needs to be enqueued.
+	return getEnqueuedJobId(job), nil
+}
+
+func (j *jobManagerBase) recalculateTimezone(job *work.Job) error {
+	accountId, err := j.getAccountId(job)
+	if err != nil {
+		return err
+	}
+
+	runner := &RecalculateTimezoneJob{
+		jobId:            job.ID,
+		accountId:        accountId,
+		userId:           uint64(job.ArgInt64("userId")),
+		previousTimezone: job.ArgString("previousTimezone"),
+		log:              j.getLogForJob(job),
+		db:               j.db,
+	}
+
+	return runner.Run(context.Background())
+}
+
+// RecalculateTimezoneJob is run after an account's timezone has been changed. Funding schedules and spending objects
+// are always due at midnight in the account's timezone, so they need to be moved to midnight in the new timezone. The
+// contributions for each spending object are then recalculated as the number of contributions before it is due may
+// have changed.
+type RecalculateTimezoneJob struct {
+	jobId            string
+	accountId        uint64
+	userId           uint64
+	previousTimezone string
+	log              *logrus.Entry
+	db               *pg.DB
+}
+
+func (r *RecalculateTimezoneJob) Run(ctx context.Context) error {
+	span := sentry.StartSpan(ctx, "Job", sentry.TransactionName("Recalculate Timezone"))
+	defer span.Finish()
+
+	span.SetTag("jobId", r.jobId)
+	span.SetTag("accountId", strconv.FormatUint(r.accountId, 10))
+
+	if hub := sentry.GetHubFromContext(span.Context()); hub != nil {
+		hub.ConfigureScope(func(scope *sentry.Scope) {
+			scope.SetUser(sentry.User{
+				ID:       strconv.FormatUint(r.accountId, 10),
+				Username: fmt.Sprintf("account:%d", r.accountId),
+			})
+		})
+	}
+
+	log := r.log.WithField("previousTimezone", r.previousTimezone)
+
+	previous, err := time.LoadLocation(r.previousTimezone)
+	if err != nil {
+		crumbs.Warn(span.Context(), "failed to parse previous timezone, this job will not be retried", "weirdness", nil)
+		log.WithError(err).Error("failed to parse previous timezone, this job will not be retried")
+		return nil
+	}
+
+	return r.db.RunInTransaction(span.Context(), func(txn *pg.Tx) error {
+		repo := repository.NewRepositoryFromSession(r.userId, r.accountId, txn)
+
+		account, err := repo.GetAccount(span.Context())
+		if err != nil {
+			log.WithError(err).Error("failed to retrieve account")
+			return err
+		}
+
+		timezone, err := account.GetTimezone()
+		if err != nil {
+			log.WithError(err).Error("failed to parse account's timezone")
+			return err
+		}
+
+		log = log.WithField("timezone", timezone.String())
+
+		if timezone.String() == previous.String() {
+			log.Info("account timezone has not changed, nothing to recalculate")
+			return nil
+		}
+
+		bankAccounts, err := repo.GetBankAccounts(span.Context())
+		if err != nil {
+			log.WithError(err).Error("failed to retrieve bank accounts")
+			return err
+		}
+
+		for _, bankAccount := range bankAccounts {
+			bankLog := log.WithField("bankAccountId", bankAccount.BankAccountId)
+
+			fundingSchedules, err := repo.GetFundingSchedules(span.Context(), bankAccount.BankAccountId)
+			if err != nil {
+				bankLog.WithError(err).Error("failed to retrieve funding schedules")
+				return err
+			}
+
+			fundingSchedulesById := map[uint64]*models.FundingSchedule{}
+			for i := range fundingSchedules {
+				fundingSchedule := &fundingSchedules[i]
+				fundingSchedule.ChangeTimezone(previous, timezone)
+				if err = repo.UpdateFundingSchedule(span.Context(), fundingSchedule); err != nil {
+					bankLog.WithError(err).
+						WithField("fundingScheduleId", fundingSchedule.FundingScheduleId).
+						Error("failed to update funding schedule")
+					return err
+				}
+
+				fundingSchedulesById[fundingSchedule.FundingScheduleId] = fundingSchedule
+			}
+
+			spending, err := repo.GetSpending(span.Context(), bankAccount.BankAccountId)
+			if err != nil {
+				bankLog.WithError(err).Error("failed to retrieve spending")
+				return err
+			}
+
+			if len(spending) == 0 {
+				continue
+			}
+
+			for i := range spending {
+				item := &spending[i]
+				item.ChangeTimezone(previous, timezone)
+
+				fundingSchedule, ok := fundingSchedulesById[item.FundingScheduleId]
+				if !ok || item.IsPaused {
+					continue
+				}
+
+				if err = item.CalculateNextContribution(
+					span.Context(),
+					account.Timezone,
+					fundingSchedule.NextOccurrence,
+					fundingSchedule.Rule,
+				); err != nil {
+					bankLog.WithError(err).
+						WithField("spendingId", item.SpendingId).
+						Error("failed to calculate next contribution for spending")
+					return err
+				}
+			}
+
+			if err = repo.UpdateSpending(span.Context(), bankAccount.BankAccountId, spending); err != nil {
+				bankLog.WithError(err).Error("failed to update spending")
+				return err
+			}
+
+			bankLog.WithFields(logrus.Fields{
+				"fundingSchedules": len(fundingSchedules),
+				"spending":         len(spending),
+			}).Debug("recalculated funding schedules and spending for new timezone")
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,92 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTriggerRecalculateTimezone(t *testing.T) {
+	t.Run("already queued", func(t *testing.T) {
+		enqueuer := NewJobEnqueuer(testutils.GetLog(t), testutils.GetRedisPool(t), "monetr").(*jobManagerBase)
+
+		jobId, err := enqueuer.TriggerRecalculateTimezone(1234, 5678, "America/Chicago")
+		assert.NoError(t, err, "should enqueue job")
+		assert.NotEmpty(t, jobId, "should return the Id of the enqueued job")
+
+		// Changing the timezone again before the job has run will enqueue the same job.
+		jobId, err = enqueuer.TriggerRecalculateTimezone(1234, 5678, "America/Chicago")
+		assert.NoError(t, err, "a job that is already queued should not be an error")
+		assert.Empty(t, jobId, "should not return a job Id when the job is already queued")
+	})
+}
+
+func TestRecalculateTimezoneJob_Run(t *testing.T) {
+	t.Run("invalid previous timezone", func(t *testing.T) {
+		job := &RecalculateTimezoneJob{
+			previousTimezone: "Not/A_Timezone",
+			db:               testutils.GetPgDatabase(t),
+			log:              testutils.GetLog(t),
+		}
+
+		err := job.Run(context.Background())
+		assert.NoError(t, err, "job should not be retried for an invalid timezone")
+	})
+
+	t.Run("moves funding schedules", func(t *testing.T) {
+		db := testutils.GetPgDatabase(t)
+		user, _ := testutils.SeedAccount(t, db, testutils.WithManualAccount)
+
+		var bankAccount models.BankAccount
+		require.NoError(t, db.Model(&bankAccount).
+			Where(`"bank_account"."account_id" = ?`, user.AccountId).
+			Limit(1).
+			Select(&bankAccount), "must retrieve bank account")
+
+		rule, err := models.NewRule("FREQ=MONTHLY;BYMONTHDAY=15,-1")
+		require.NoError(t, err, "must create rule")
+
+		nextOccurrence := time.Date(2030, 1, 15, 0, 0, 0, 0, time.UTC)
+		fundingSchedule := models.FundingSchedule{
+			AccountId:      user.AccountId,
+			BankAccountId:  bankAccount.BankAccountId,
+			Name:           "Payday",
+			Rule:           rule,
+			NextOccurrence: nextOccurrence,
+		}
+		_, err = db.Model(&fundingSchedule).Insert(&fundingSchedule)
+		require.NoError(t, err, "must create funding schedule")
+
+		chicago, err := time.LoadLocation("America/Chicago")
+		require.NoError(t, err, "must load timezone")
+
+		_, err = db.Model(&models.Account{}).
+			Set(`"timezone" = ?`, chicago.String()).
+			Where(`"account"."account_id" = ?`, user.AccountId).
+			Update()
+		require.NoError(t, err, "must update account timezone")
+
+		job := &RecalculateTimezoneJob{
+			accountId:        user.AccountId,
+			userId:           user.UserId,
+			previousTimezone: "UTC",
+			db:               db,
+			log:              testutils.GetLog(t),
+		}
+
+		err = job.Run(context.Background())
+		assert.NoError(t, err, "must recalculate timezone")
+
+		var updated models.FundingSchedule
+		require.NoError(t, db.Model(&updated).
+			Where(`"funding_schedule"."funding_schedule_id" = ?`, fundingSchedule.FundingScheduleId).
+			Limit(1).
+			Select(&updated), "must retrieve updated funding schedule")
+		assert.Equal(t, time.Date(2030, 1, 15, 0, 0, 0, 0, chicago).Unix(), updated.NextOccurrence.Unix(), "should be midnight in the new timezone")
+	})
+}
@@ -29,7 +29,7 @@ func (j *jobManagerBase) TriggerRemoveLink(accountId, userId, linkId uint64) (jo
 		return "", errors.Wrap(err, "failed to enqueue link removal")
 	}
 
-	return job.ID, nil
+	return getEnqueuedJobId(job), nil
 }
 
 type RemoveLinkJob struct {
@@ -158,6 +158,19 @@ func (r *RemoveLinkJob) Run(ctx context.Context) error {
 			log.WithField("removed", result.RowsAffected()).Info("successfully removed link")
 		}
 
+		// The SimpleFIN access URL is a credential for the user's bridge, it should not be kept once the link is gone.
+		if link.SimpleFINLinkId != nil {
+			_, err := txn.ModelContext(span.Context(), &models.SimpleFINLink{
+				SimpleFINLinkId: *link.SimpleFINLinkId,
+			}).
+				WherePK().
+				Delete()
+			if err != nil {
+				log.WithError(err).Error("failed to delete simplefin link")
+				return errors.Wrap(err, "failed to delete simplefin link")
+			}
+		}
+
 		channelName := fmt.Sprintf("link:remove:%d:%d", r.accountId, r.linkId)
 
 		if err = r.notify.Notify(span.Context(), channelName, "success"); err != nil {
@@ -0,0 +1,296 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gocraft/work"
+	"github.com/monetr/rest-api/pkg/communication"
+	"github.com/monetr/rest-api/pkg/crumbs"
+	"github.com/monetr/rest-api/pkg/currency"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/monetr/rest-api/pkg/repository"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	EnqueueRemoveStalePendingTransactions = "EnqueueRemoveStalePendingTransactions"
+	RemoveStalePendingTransactions        = "RemoveStalePendingTransactions"
+)
+
+// stalePendingTransactionAge is how old a pending transaction needs to be before we check whether it still exists in
+// Plaid. Most pending transactions post within a few days, so anything older than this has likely been dropped by the
+// institution without Plaid telling us that it was removed.
+const stalePendingTransactionAge = 7 * 24 * time.Hour
+
+func (j *jobManagerBase) enqueueRemoveStalePendingTransactions(job *work.Job) error {
+	log := j.getLogForJob(job)
+
+	var items []repository.CheckingPendingTransactionsItem
+	if err := j.getJobHelperRepository(job, func(repo repository.JobRepository) (err error) {
+		items, err = repo.GetBankAccountsWithPendingTransactions(context.Background(), time.Now().Add(-stalePendingTransactionAge))
+		return err
+	}); err != nil {
+		log.WithError(err).Errorf("failed to retrieve links with stale pending transactions")
+		return err
+	}
+
+	log.Infof("enqueueing %d link(s) to remove stale pending transactions", len(items))
+
+	for _, item := range items {
+		linkLog := log.WithFields(logrus.Fields{
+			"accountId": item.AccountId,
+			"linkId":    item.LinkId,
+		})
+		linkLog.Trace("enqueueing to remove stale pending transactions")
+		_, err := j.enqueueUniqueJobIn(RemoveStalePendingTransactions, j.getPlaidJitter(item.AccountId), map[string]interface{}{
+			"accountId": item.AccountId,
+			"linkId":    item.LinkId,
+		})
+		if err != nil {
+			linkLog.WithError(err).Error("could not enqueue link, stale pending transactions will not be removed")
+			continue
+		}
+
+		linkLog.Trace("successfully enqueued link to remove stale pending transactions")
+	}
+
+	return nil
+}
+
+// removeStalePendingTransactions will retrieve the transactions from Plaid for the date range covered by a link's old
+// pending transactions. Pending transactions that have posted are reconciled with their posted transaction, any that
+// are no longer returned by Plaid at all are removed and their spending is returned to the spending objects.
+func (j *jobManagerBase) removeStalePendingTransactions(job *work.Job) (err error) {
+	log := j.getLogForJob(job)
+	log.Infof("removing stale pending transactions")
+
+	hub := sentry.CurrentHub().Clone()
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	span := sentry.StartSpan(ctx, "Job", sentry.TransactionName("Remove Stale Pending Transactions"))
+	defer span.Finish()
+
+	defer func() {
+		if err != nil {
+			hub.CaptureException(err)
+		}
+	}()
+
+	accountId, err := j.getAccountId(job)
+	if err != nil {
+		log.WithError(err).Error("could not run job, no account Id")
+		return err
+	}
+
+	linkId := uint64(job.ArgInt64("linkId"))
+
+	hub.ConfigureScope(func(scope *sentry.Scope) {
+		scope.SetUser(sentry.User{
+			ID:       strconv.FormatUint(accountId, 10),
+			Username: fmt.Sprintf("account:%d", accountId),
+		})
+		scope.SetTag("accountId", strconv.FormatUint(accountId, 10))
+		scope.SetTag("linkId", strconv.FormatUint(linkId, 10))
+		scope.SetTag("jobId", job.ID)
+	})
+
+	var removed []models.Transaction
+	var logins []models.Login
+	err = j.getRepositoryForJob(job, func(repo repository.Repository) error {
+		link, err := repo.GetLink(span.Context(), linkId)
+		if err != nil {
+			log.WithError(err).Error("failed to retrieve link details to remove stale pending transactions")
+			return err
+		}
+
+		log = log.WithField("linkId", link.LinkId)
+
+		if link.PlaidLink == nil {
+			err = errors.Errorf("cannot remove stale pending transactions for link without plaid info")
+			log.WithError(err).Errorf("failed to remove stale pending transactions")
+			return err
+		}
+
+		switch link.LinkStatus {
+		case models.LinkStatusSetup, models.LinkStatusPendingExpiration:
+			break
+		default:
+			crumbs.Warn(span.Context(), "Link is not in a state where data can be retrieved", "plaid", map[string]interface{}{
+				"status": link.LinkStatus,
+			})
+			return nil
+		}
+
+		bankAccounts, err := repo.GetBankAccountsByLinkId(span.Context(), linkId)
+		if err != nil {
+			log.WithError(err).Error("failed to retrieve bank account details to remove stale pending transactions")
+			return err
+		}
+
+		cutoff := time.Now().Add(-stalePendingTransactionAge)
+		oldest := cutoff
+		stale := make([]models.Transaction, 0)
+		plaidIdsToBankIds := map[string]uint64{}
+		itemBankAccountIds := make([]string, len(bankAccounts))
+		for i, bankAccount := range bankAccounts {
+			itemBankAccountIds[i] = bankAccount.PlaidAccountId
+			plaidIdsToBankIds[bankAccount.PlaidAccountId] = bankAccount.BankAccountId
+
+			pending, err := repo.GetPendingTransactionsForBankAccount(span.Context(), bankAccount.BankAccountId)
+			if err != nil {
+				log.WithError(err).Error("failed to retrieve pending transactions for bank account")
+				return err
+			}
+
+			for _, transaction := range pending {
+				if !transaction.Date.Before(cutoff) || transaction.PlaidTransactionId == "" {
+					continue
+				}
+
+				if transaction.Date.Before(oldest) {
+					oldest = transaction.Date
+				}
+
+				stale = append(stale, transaction)
+			}
+		}
+
+		if len(stale) == 0 {
+			log.Debug("link does not have any stale pending transactions")
+			return nil
+		}
+
+		log.Debugf("checking %d stale pending transaction(s)", len(stale))
+
+		client, err := j.providers.GetClientForLink(span.Context(), link)
+		if err != nil {
+			log.WithError(err).Error("failed to create plaid client for link")
+			return err
+		}
+
+		// Start a day before the oldest pending transaction, the date of the transaction is in the account's time zone
+		// and might not line up with the date that Plaid has for it.
+		transactions, err := client.GetAllTransactions(span.Context(), oldest.AddDate(0, 0, -1), time.Now(), itemBankAccountIds)
+		if err != nil {
+			log.WithError(err).Error("failed to retrieve transactions from plaid")
+			return errors.Wrap(err, "failed to retrieve transactions from plaid")
+		}
+
+		// A pending transaction still exists if Plaid returns it as is. If it has posted then Plaid returns the posted
+		// transaction that references it instead, upsertTransactions will reconcile the pending transaction with any
+		// posted transaction that we have not seen before.
+		returned := map[string]struct{}{}
+		posted := map[string]struct{}{}
+		for _, transaction := range transactions {
+			returned[transaction.GetTransactionId()] = struct{}{}
+			if pendingId := transaction.GetPendingTransactionId(); pendingId != nil {
+				posted[*pendingId] = struct{}{}
+			}
+		}
+
+		if len(transactions) > 0 {
+			if err = j.upsertTransactions(
+				span.Context(),
+				log,
+				repo,
+				link,
+				plaidIdsToBankIds,
+				transactions,
+			); err != nil {
+				log.WithError(err).Error("failed to upsert transactions from plaid")
+				return err
+			}
+		}
+
+		candidateIds := make([]string, 0, len(stale))
+		for _, transaction := range stale {
+			if _, ok := returned[transaction.PlaidTransactionId]; ok {
+				continue
+			}
+
+			candidateIds = append(candidateIds, transaction.PlaidTransactionId)
+		}
+
+		// Pending transactions that were reconciled above have already been removed. Any that are left either posted
+		// before we started reconciling them, or were dropped by the institution entirely.
+		remaining, err := repo.GetTransactionsByPlaidId(span.Context(), linkId, candidateIds)
+		if err != nil {
+			log.WithError(err).Error("failed to retrieve remaining stale pending transactions")
+			return err
+		}
+
+		if len(remaining) == 0 {
+			log.Debug("all stale pending transactions still exist in plaid")
+			return nil
+		}
+
+		removedIds := make([]string, 0, len(remaining))
+		for plaidTransactionId, transaction := range remaining {
+			removedIds = append(removedIds, plaidTransactionId)
+
+			// Only tell the user about transactions that never posted.
+			if _, ok := posted[plaidTransactionId]; !ok {
+				removed = append(removed, transaction)
+			}
+		}
+
+		log.Infof("removing %d pending transaction(s) that no longer exist in plaid", len(removedIds))
+
+		if err = j.deleteTransactions(span.Context(), log, repo, linkId, removedIds); err != nil {
+			log.WithError(err).Error("failed to remove stale pending transactions")
+			return err
+		}
+
+		if j.communication != nil {
+			logins, err = repo.GetAccountLogins(span.Context())
+			if err != nil {
+				log.WithError(err).Warn("failed to retrieve logins to notify about removed pending transactions")
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Users are only notified once the removal has been committed.
+	j.notifyPendingTransactionsRemoved(span.Context(), log, logins, removed)
+
+	return nil
+}
+
+// notifyPendingTransactionsRemoved will send an email to each of the provided logins listing the pending transactions
+// that were removed. Failures are logged but are not returned, the transactions have already been removed.
+func (j *jobManagerBase) notifyPendingTransactionsRemoved(ctx context.Context, log *logrus.Entry, logins []models.Login, transactions []models.Transaction) {
+	if j.communication == nil || len(transactions) == 0 {
+		return
+	}
+
+	removed := make([]communication.RemovedTransaction, len(transactions))
+	for i, transaction := range transactions {
+		name := transaction.Name
+		if transaction.CustomName != nil && *transaction.CustomName != "" {
+			name = *transaction.CustomName
+		}
+
+		removed[i] = communication.RemovedTransaction{
+			Name:   name,
+			Amount: currency.Format(transaction.Amount, transaction.Currency),
+			Date:   transaction.Date,
+		}
+	}
+
+	for _, login := range logins {
+		if err := j.communication.SendPendingTransactionsRemovedEmail(ctx, communication.PendingTransactionsRemovedParams{
+			Login:        login,
+			Transactions: removed,
+		}); err != nil {
+			log.WithError(err).WithField("loginId", login.LoginId).Warn("failed to notify login about removed pending transactions")
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/go-pg/pg/v10"
+	"github.com/gocraft/work"
+	"github.com/jarcoal/httpmock"
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/internal/mock_plaid"
+	"github.com/monetr/rest-api/pkg/internal/mock_secrets"
+	"github.com/monetr/rest-api/pkg/internal/platypus"
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/monetr/rest-api/pkg/repository"
+	"github.com/plaid/plaid-go/plaid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveStalePendingTransactions(t *testing.T) {
+	t.Run("dropped pending transaction", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		log := testutils.GetLog(t)
+
+		db := testutils.GetPgDatabase(t)
+		cache := testutils.GetRedisPool(t)
+
+		account, plaidData := testutils.SeedAccount(t, db, testutils.WithPlaidAccount)
+
+		now := time.Now().UTC()
+		var linkId, spendingId uint64
+		var bankAccount models.BankAccount
+		var stale, recent models.Transaction
+		require.NoError(t, db.RunInTransaction(context.Background(), func(txn *pg.Tx) error {
+			repo := repository.NewRepositoryFromSession(account.UserId, account.AccountId, txn)
+			links, err := repo.GetLinks(context.Background())
+			require.NoError(t, err, "must retrieve links for account")
+			require.Len(t, links, 1, "should have exactly one link")
+			linkId = links[0].LinkId
+
+			bankAccounts, err := repo.GetBankAccountsByLinkId(context.Background(), linkId)
+			require.NoError(t, err, "must retrieve bank accounts for link")
+			require.NotEmpty(t, bankAccounts, "link must have bank accounts")
+			bankAccount = bankAccounts[0]
+
+			rule, err := models.NewRule("FREQ=MONTHLY;BYMONTHDAY=15,-1")
+			require.NoError(t, err, "must parse rule")
+
+			fundingSchedule := models.FundingSchedule{
+				BankAccountId:  bankAccount.BankAccountId,
+				Name:           "Payday",
+				Rule:           rule,
+				NextOccurrence: now.AddDate(0, 0, 7),
+			}
+			require.NoError(t, repo.CreateFundingSchedule(context.Background(), &fundingSchedule), "must create funding schedule")
+
+			spending := models.Spending{
+				BankAccountId:     bankAccount.BankAccountId,
+				FundingScheduleId: fundingSchedule.FundingScheduleId,
+				FundingSchedule:   &fundingSchedule,
+				SpendingType:      models.SpendingTypeExpense,
+				Name:              "Dinner",
+				TargetAmount:      5000,
+				CurrentAmount:     5000,
+				NextRecurrence:    now.AddDate(0, 1, 0),
+			}
+			require.NoError(t, repo.CreateSpending(context.Background(), &spending), "must create spending")
+			spendingId = spending.SpendingId
+
+			stale = models.Transaction{
+				BankAccountId:      bankAccount.BankAccountId,
+				PlaidTransactionId: gofakeit.UUID(),
+				Amount:             2000,
+				SpendingId:         &spendingId,
+				Date:               now.AddDate(0, 0, -10),
+				Name:               "Pizza Place",
+				OriginalName:       "PIZZA PLACE 1234",
+				IsPending:          true,
+				CreatedAt:          now,
+			}
+			require.NoError(t, repo.AddExpenseToTransaction(context.Background(), &stale, &spending), "must spend pending transaction")
+			require.NoError(t, repo.UpdateSpending(context.Background(), bankAccount.BankAccountId, []models.Spending{
+				spending,
+			}), "must update spending")
+
+			// Recent pending transactions are not checked, even if Plaid does not return them.
+			recent = models.Transaction{
+				BankAccountId:      bankAccount.BankAccountId,
+				PlaidTransactionId: gofakeit.UUID(),
+				Amount:             1000,
+				Date:               now.AddDate(0, 0, -1),
+				Name:               "Coffee",
+				OriginalName:       "COFFEE",
+				IsPending:          true,
+				CreatedAt:          now,
+			}
+
+			return repo.InsertTransactions(context.Background(), []models.Transaction{
+				stale,
+				recent,
+			})
+		}), "must seed pending transactions")
+
+		// Plaid no longer returns the pending transaction, the institution dropped it.
+		mock_plaid.MockGetTransactions(t, []plaid.Transaction{})
+
+		plaidSecrets := mock_secrets.NewMockPlaidSecrets()
+		for accessToken, data := range plaidData.PlaidTokens {
+			plaidSecrets = plaidSecrets.WithSecret(account.AccountId, data.ItemId, accessToken)
+		}
+
+		plaidRepo := repository.NewPlaidRepository(db)
+		plaidClient := platypus.NewPlaid(log, plaidSecrets, plaidRepo, config.Plaid{
+			ClientID:     gofakeit.UUID(),
+			ClientSecret: gofakeit.UUID(),
+			Environment:  plaid.Sandbox,
+		})
+
+		job := NewJobManager(log, config.Jobs{}, cache, db, plaidClient, nil, plaidSecrets, nil, nil).(*jobManagerBase)
+		defer require.NoError(t, job.Close(), "must close job manager")
+
+		err := job.removeStalePendingTransactions(&work.Job{
+			Name:       RemoveStalePendingTransactions,
+			ID:         gofakeit.UUID(),
+			EnqueuedAt: time.Now().Unix(),
+			Args: map[string]interface{}{
+				"accountId": account.AccountId,
+				"linkId":    linkId,
+			},
+			Unique: true,
+		})
+		assert.NoError(t, err, "job should succeed")
+
+		require.NoError(t, db.RunInTransaction(context.Background(), func(txn *pg.Tx) error {
+			repo := repository.NewRepositoryFromSession(account.UserId, account.AccountId, txn)
+			transactions, err := repo.GetTransactionsByPlaidId(context.Background(), linkId, []string{
+				stale.PlaidTransactionId,
+				recent.PlaidTransactionId,
+			})
+			require.NoError(t, err, "must retrieve transactions")
+			assert.NotContains(t, transactions, stale.PlaidTransactionId, "stale pending transaction should be removed")
+			assert.Contains(t, transactions, recent.PlaidTransactionId, "recent pending transaction should not be removed")
+
+			spending, err := repo.GetSpendingById(context.Background(), bankAccount.BankAccountId, spendingId)
+			require.NoError(t, err, "must retrieve spending")
+			assert.EqualValues(t, 5000, spending.CurrentAmount, "spent amount should be returned to the spending")
+
+			return nil
+		}), "must verify removal")
+	})
+}
@@ -0,0 +1,294 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gocraft/work"
+	"github.com/monetr/rest-api/pkg/crumbs"
+	"github.com/monetr/rest-api/pkg/internal/myownsanity"
+	"github.com/monetr/rest-api/pkg/internal/platypus"
+	"github.com/monetr/rest-api/pkg/internal/simplefin"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/monetr/rest-api/pkg/repository"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	EnqueueSyncSimpleFINLinks = "EnqueueSyncSimpleFINLinks"
+	SyncSimpleFINLink         = "SyncSimpleFINLink"
+)
+
+const (
+	// simpleFINInitialSyncDays is how far back transactions are retrieved the first time a SimpleFIN link is synced.
+	simpleFINInitialSyncDays = 90
+	// simpleFINSyncOverlapDays is how far before the last successful sync transactions are retrieved again. SimpleFIN
+	// does not tell us what has changed, so pending transactions that have posted since are picked up by this overlap.
+	simpleFINSyncOverlapDays = 14
+)
+
+func (j *jobManagerBase) TriggerSyncSimpleFINLink(accountId, linkId uint64) (jobId string, err error) {
+	job, err := j.queue.EnqueueUnique(SyncSimpleFINLink, map[string]interface{}{
+		"accountId": accountId,
+		"linkId":    linkId,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to enqueue simplefin sync")
+	}
+
+	return getEnqueuedJobId(job), nil
+}
+
+func (j *jobManagerBase) getSimpleFINLinksByAccount() ([]PullAccountBalanceWorkItem, error) {
+	var accounts []PullAccountBalanceWorkItem
+
+	_, err := j.db.Query(&accounts, `
+		SELECT
+			"links"."account_id",
+			array_agg("links"."link_id") "link_ids"
+		FROM "links"
+		WHERE "links"."link_type" = ? AND "links"."simplefin_link_id" IS NOT NULL
+		GROUP BY "links"."account_id"
+	`, models.SimpleFINLinkType)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve accounts with simplefin links")
+	}
+
+	return accounts, nil
+}
+
+func (j *jobManagerBase) enqueueSyncSimpleFINLinks(job *work.Job) error {
+	log := j.getLogForJob(job)
+
+	accounts, err := j.getSimpleFINLinksByAccount()
+	if err != nil {
+		log.WithError(err).Errorf("failed to retrieve simplefin links that need to be synced")
+		return err
+	}
+
+	log.Infof("enqueueing %d account(s) to sync simplefin links", len(accounts))
+
+	for _, account := range accounts {
+		for _, linkId := range account.LinkIDs {
+			accountLog := log.WithFields(logrus.Fields{
+				"accountId": account.AccountID,
+				"linkId":    linkId,
+			})
+			accountLog.Trace("enqueueing for simplefin sync")
+			_, err := j.enqueueUniqueJobIn(SyncSimpleFINLink, j.getPlaidJitter(account.AccountID), map[string]interface{}{
+				"accountId": account.AccountID,
+				"linkId":    linkId,
+			})
+			if err != nil {
+				accountLog.WithError(err).Error("could not enqueue link, data will not be synced")
+				continue
+			}
+
+			accountLog.Trace("successfully enqueued link for simplefin sync")
+		}
+	}
+
+	return nil
+}
+
+// syncSimpleFINLink will update the balances and transactions of every bank account for a SimpleFIN link. SimpleFIN
+// does not provide webhooks or a changes cursor, so each sync retrieves a window of recent transactions and upserts
+// them. Pending transactions within that window that are no longer returned by the bridge are removed.
+func (j *jobManagerBase) syncSimpleFINLink(job *work.Job) (err error) {
+	log := j.getLogForJob(job)
+	log.Infof("syncing simplefin link")
+
+	hub := sentry.CurrentHub().Clone()
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	span := sentry.StartSpan(ctx, "Job", sentry.TransactionName("Sync SimpleFIN Link"))
+	defer span.Finish()
+
+	defer func() {
+		if err != nil {
+			hub.CaptureException(err)
+		}
+	}()
+
+	accountId, err := j.getAccountId(job)
+	if err != nil {
+		log.WithError(err).Error("could not run job, no account Id")
+		return err
+	}
+
+	linkId := uint64(job.ArgInt64("linkId"))
+
+	hub.ConfigureScope(func(scope *sentry.Scope) {
+		scope.SetUser(sentry.User{
+			ID:       strconv.FormatUint(accountId, 10),
+			Username: fmt.Sprintf("account:%d", accountId),
+		})
+		scope.SetTag("accountId", strconv.FormatUint(accountId, 10))
+		scope.SetTag("linkId", strconv.FormatUint(linkId, 10))
+		scope.SetTag("jobId", job.ID)
+	})
+
+	return j.getRepositoryForJob(job, func(repo repository.Repository) error {
+		link, err := repo.GetLink(span.Context(), linkId)
+		if err != nil {
+			log.WithError(err).Error("failed to retrieve link details to sync")
+			return err
+		}
+
+		log = log.WithField("linkId", link.LinkId)
+
+		if link.SimpleFINLink == nil {
+			err = errors.Errorf("cannot sync link without simplefin info")
+			log.WithError(err).Errorf("failed to sync simplefin link")
+			return err
+		}
+
+		if link.LinkStatus != models.LinkStatusSetup {
+			crumbs.Warn(span.Context(), "Link is not in a state where data can be retrieved", "simplefin", map[string]interface{}{
+				"status": link.LinkStatus,
+			})
+			return nil
+		}
+
+		bankAccounts, err := repo.GetBankAccountsByLinkId(span.Context(), linkId)
+		if err != nil {
+			log.WithError(err).Error("failed to retrieve bank account details to sync")
+			return err
+		}
+
+		idsToBank := map[string]models.BankAccount{}
+		idsToBankIds := map[string]uint64{}
+		itemBankAccountIds := make([]string, len(bankAccounts))
+		for i, bankAccount := range bankAccounts {
+			itemBankAccountIds[i] = bankAccount.SimpleFINAccountId
+			idsToBank[bankAccount.SimpleFINAccountId] = bankAccount
+			idsToBankIds[bankAccount.SimpleFINAccountId] = bankAccount.BankAccountId
+		}
+
+		if len(itemBankAccountIds) == 0 {
+			log.Debug("link does not have any bank accounts to sync")
+			return nil
+		}
+
+		client, err := j.providers.GetClientForLink(span.Context(), link)
+		if err != nil {
+			log.WithError(err).Error("failed to create simplefin client for link")
+			return err
+		}
+
+		now := time.Now().UTC()
+		start := now.AddDate(0, 0, -simpleFINInitialSyncDays)
+		if link.LastSuccessfulUpdate != nil {
+			start = link.LastSuccessfulUpdate.AddDate(0, 0, -simpleFINSyncOverlapDays)
+		}
+
+		log.Debugf("syncing %d bank account(s) since %s", len(itemBankAccountIds), start)
+
+		accounts, err := client.GetAccounts(span.Context(), itemBankAccountIds...)
+		if err != nil {
+			if errors.Cause(err) == simplefin.ErrAccessRevoked {
+				log.WithError(err).Warn("access to simplefin bridge has been revoked, link will need to be setup again")
+				link.LinkStatus = models.LinkStatusRevoked
+				link.ErrorMessage = myownsanity.StringP(err.Error())
+				return repo.UpdateLink(span.Context(), link)
+			}
+
+			log.WithError(err).Error("failed to retrieve bank accounts from simplefin")
+			return err
+		}
+
+		updatedBankAccounts := make([]models.BankAccount, 0, len(accounts))
+		for _, item := range accounts {
+			bankAccount, ok := idsToBank[item.GetAccountId()]
+			if !ok {
+				continue
+			}
+
+			if bankAccount.SetBalances(
+				item.GetBalances().GetAvailable(),
+				item.GetBalances().GetCurrent(),
+				item.GetBalances().GetLimit(),
+			) {
+				updatedBankAccounts = append(updatedBankAccounts, models.BankAccount{
+					BankAccountId:    bankAccount.BankAccountId,
+					AccountId:        accountId,
+					AvailableBalance: bankAccount.AvailableBalance,
+					CurrentBalance:   bankAccount.CurrentBalance,
+					LimitBalance:     bankAccount.LimitBalance,
+					StatementBalance: bankAccount.StatementBalance,
+					OriginalBalance:  bankAccount.OriginalBalance,
+					LastUpdated:      now,
+				})
+			}
+		}
+
+		if err = repo.UpdateBankAccountBalances(span.Context(), updatedBankAccounts); err != nil {
+			log.WithError(err).Error("failed to update bank account balances")
+			return err
+		}
+
+		result, err := client.GetAllTransactions(span.Context(), start, now, itemBankAccountIds)
+		if err != nil {
+			log.WithError(err).Error("failed to retrieve transactions from simplefin")
+			return errors.Wrap(err, "failed to retrieve transactions from simplefin")
+		}
+
+		returned := map[string]struct{}{}
+		transactions := make([]platypus.Transaction, 0, len(result))
+		for _, transaction := range result {
+			if _, ok := idsToBankIds[transaction.GetBankAccountId()]; !ok {
+				continue
+			}
+
+			returned[transaction.GetTransactionId()] = struct{}{}
+			transactions = append(transactions, transaction)
+		}
+
+		if len(transactions) > 0 {
+			if err = j.upsertTransactions(
+				span.Context(),
+				log,
+				repo,
+				link,
+				idsToBankIds,
+				transactions,
+			); err != nil {
+				log.WithError(err).Error("failed to upsert transactions from simplefin")
+				return err
+			}
+		}
+
+		removedIds := make([]string, 0)
+		for _, bankAccount := range bankAccounts {
+			pending, err := repo.GetPendingTransactionsForBankAccount(span.Context(), bankAccount.BankAccountId)
+			if err != nil {
+				log.WithError(err).Error("failed to retrieve pending transactions for bank account")
+				return err
+			}
+
+			for _, transaction := range pending {
+				if transaction.PlaidTransactionId == "" || transaction.Date.Before(start) {
+					continue
+				}
+
+				if _, ok := returned[transaction.PlaidTransactionId]; !ok {
+					removedIds = append(removedIds, transaction.PlaidTransactionId)
+				}
+			}
+		}
+
+		if len(removedIds) > 0 {
+			log.Infof("removing %d pending transaction(s) that no longer exist in simplefin", len(removedIds))
+			if err = j.deleteTransactions(span.Context(), log, repo, linkId, removedIds); err != nil {
+				log.WithError(err).Error("failed to remove pending transactions")
+				return err
+			}
+		}
+
+		link.LastSuccessfulUpdate = myownsanity.TimeP(now)
+		return repo.UpdateLink(span.Context(), link)
+	})
+}
@@ -3,9 +3,11 @@ package jobs
 import (
 	"context"
 	"github.com/getsentry/sentry-go"
+	"github.com/monetr/rest-api/pkg/internal/myownsanity"
 	"github.com/monetr/rest-api/pkg/internal/platypus"
 	"github.com/monetr/rest-api/pkg/models"
 	"github.com/monetr/rest-api/pkg/repository"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"time"
 )
@@ -44,8 +46,25 @@ func (j *jobManagerBase) upsertTransactions(
 		return err
 	}
 
+	// When a pending transaction posts, Plaid creates a new transaction that references the pending one. We need the
+	// pending transactions so that the changes the user made to them can be carried over to the posted transactions.
+	pendingPlaidTransactionIds := make([]string, 0)
+	for _, transaction := range plaidTransactions {
+		if pendingId := transaction.GetPendingTransactionId(); pendingId != nil && !transaction.GetIsPending() {
+			pendingPlaidTransactionIds = append(pendingPlaidTransactionIds, *pendingId)
+		}
+	}
+
+	pendingByPlaidId, err := repo.GetTransactionsByPlaidId(span.Context(), link.LinkId, pendingPlaidTransactionIds)
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve pending transactions for posted plaid transactions")
+		return err
+	}
+
 	transactionsToUpdate := make([]*models.Transaction, 0)
 	transactionsToInsert := make([]models.Transaction, 0)
+	pendingToRemove := make([]models.Transaction, 0)
+	spendingToUpdate := map[uint64]*models.Spending{}
 	now := time.Now().UTC()
 	for _, plaidTransaction := range plaidTransactions {
 		amount := plaidTransaction.GetAmount()
@@ -63,11 +82,12 @@ func (j *jobManagerBase) upsertTransactions(
 
 		existingTransaction, ok := transactionsByPlaidId[plaidTransaction.GetTransactionId()]
 		if !ok {
-			transactionsToInsert = append(transactionsToInsert, models.Transaction{
+			transaction := models.Transaction{
 				AccountId:                 repo.AccountId(),
 				BankAccountId:             plaidIdsToBankIds[plaidTransaction.GetBankAccountId()],
 				PlaidTransactionId:        plaidTransaction.GetTransactionId(),
 				Amount:                    amount,
+				Currency:                  plaidTransaction.GetCurrency(),
 				SpendingId:                nil,
 				Spending:                  nil,
 				Categories:                plaidTransaction.GetCategory(),
@@ -80,7 +100,27 @@ func (j *jobManagerBase) upsertTransactions(
 				IsPending:                 plaidTransaction.GetIsPending(),
 				CreatedAt:                 now,
 				PendingPlaidTransactionId: plaidTransaction.GetPendingTransactionId(),
-			})
+			}
+
+			if pendingId := plaidTransaction.GetPendingTransactionId(); pendingId != nil && !transaction.IsPending {
+				if pending, ok := pendingByPlaidId[*pendingId]; ok {
+					if err = reconcilePendingTransaction(
+						span.Context(),
+						repo,
+						&pending,
+						&transaction,
+						spendingToUpdate,
+					); err != nil {
+						log.WithError(err).WithField("transactionId", pending.TransactionId).
+							Error("failed to reconcile pending transaction with posted transaction")
+						return err
+					}
+
+					pendingToRemove = append(pendingToRemove, pending)
+				}
+			}
+
+			transactionsToInsert = append(transactionsToInsert, transaction)
 			continue
 		}
 
@@ -127,6 +167,20 @@ func (j *jobManagerBase) upsertTransactions(
 		}
 	}
 
+	if len(spendingToUpdate) > 0 {
+		spendingByBankAccount := map[uint64][]models.Spending{}
+		for _, spending := range spendingToUpdate {
+			spendingByBankAccount[spending.BankAccountId] = append(spendingByBankAccount[spending.BankAccountId], *spending)
+		}
+
+		for bankAccountId, spending := range spendingByBankAccount {
+			if err = repo.UpdateSpending(span.Context(), bankAccountId, spending); err != nil {
+				log.WithError(err).Error("failed to update spending for reconciled transactions")
+				return err
+			}
+		}
+	}
+
 	if len(transactionsToInsert) > 0 {
 		log.Infof("creating %d transactions", len(transactionsToInsert))
 		// Reverse the list so the oldest records are inserted first.
@@ -139,5 +193,153 @@ func (j *jobManagerBase) upsertTransactions(
 		}
 	}
 
+	// The posted transactions replace the pending transactions that they were reconciled with. Plaid will also tell us
+	// that the pending transactions were removed, but by then they will already be gone.
+	if len(pendingToRemove) > 0 {
+		log.Infof("removing %d reconciled pending transaction(s)", len(pendingToRemove))
+		for _, pending := range pendingToRemove {
+			if err = repo.DeleteTransaction(span.Context(), pending.BankAccountId, pending.TransactionId); err != nil {
+				log.WithError(err).WithField("transactionId", pending.TransactionId).
+					Error("failed to remove reconciled pending transaction")
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// syncPlaidTransactions will retrieve every change to the link's transactions since the link's stored cursor and apply
+// them. If the link has not been synced before then this includes all of the transaction history that Plaid has for
+// the item. Changes for accounts that are not in plaidIdsToBankIds are ignored. The new cursor is stored with the same
+// repository as the changes, so if anything fails the next sync will retrieve the same changes again. The number of
+// changes that were applied is returned.
+func (j *jobManagerBase) syncPlaidTransactions(
+	ctx context.Context,
+	log *logrus.Entry,
+	repo repository.BaseRepository,
+	link *models.Link,
+	plaidIdsToBankIds map[string]uint64,
+) (int, error) {
+	span := sentry.StartSpan(ctx, "Job - Sync Plaid Transactions")
+	defer span.Finish()
+
+	providerClient, err := j.providers.GetClientForLink(span.Context(), link)
+	if err != nil {
+		log.WithError(err).Error("failed to create plaid client for link")
+		return 0, err
+	}
+
+	// Syncing with a cursor is specific to Plaid, so the client for the link must be a Plaid client.
+	client, ok := providerClient.(platypus.Client)
+	if !ok {
+		err = errors.Errorf("client for link does not support syncing transactions")
+		log.WithError(err).Error("failed to create plaid client for link")
+		return 0, err
+	}
+
+	result, err := client.Sync(span.Context(), link.PlaidLink.TransactionsCursor)
+	if err != nil {
+		log.WithError(err).Error("failed to sync transactions from plaid")
+		return 0, errors.Wrap(err, "failed to sync transactions from plaid")
+	}
+
+	log.Debugf("retrieved %d added, %d modified and %d removed transaction(s)", len(result.Added), len(result.Modified), len(result.Removed))
+
+	// Added and modified transactions are handled the same way, upsertTransactions will update any transactions we
+	// already have. Plaid returns changes for every account on the item, so ignore any accounts we don't track.
+	transactions := make([]platypus.Transaction, 0, len(result.Added)+len(result.Modified))
+	for _, transaction := range append(result.Added, result.Modified...) {
+		if _, ok := plaidIdsToBankIds[transaction.GetBankAccountId()]; !ok {
+			continue
+		}
+
+		transactions = append(transactions, transaction)
+	}
+
+	if len(transactions) > 0 {
+		if err = j.upsertTransactions(
+			span.Context(),
+			log,
+			repo,
+			link,
+			plaidIdsToBankIds,
+			transactions,
+		); err != nil {
+			log.WithError(err).Error("failed to upsert transactions from plaid")
+			return 0, err
+		}
+	}
+
+	if len(result.Removed) > 0 {
+		if err = j.deleteTransactions(span.Context(), log, repo, link.LinkId, result.Removed); err != nil {
+			log.WithError(err).Error("failed to remove transactions from plaid")
+			return 0, err
+		}
+	}
+
+	link.PlaidLink.TransactionsCursor = &result.NextCursor
+	if err = repo.UpdatePlaidLink(span.Context(), link.PlaidLink); err != nil {
+		log.WithError(err).Error("failed to store transactions cursor")
+		return 0, err
+	}
+
+	return len(transactions) + len(result.Removed), nil
+}
+
+// reconcilePendingTransaction will carry the changes that the user made to a pending transaction over to the posted
+// transaction that replaces it. If the pending transaction was spent from a spending object then that allocation is
+// moved to the posted transaction. When the amount changes as the transaction posts (like a tip being added) the
+// difference is taken from, or returned to, the spending object. The spending objects that are changed are stored in
+// the provided map so that they can be updated together, and so that multiple transactions that were spent from the
+// same spending object build on each other's changes.
+func reconcilePendingTransaction(
+	ctx context.Context,
+	repo repository.BaseRepository,
+	pending, posted *models.Transaction,
+	spendingToUpdate map[uint64]*models.Spending,
+) error {
+	span := sentry.StartSpan(ctx, "Job - Reconcile Pending Transaction")
+	defer span.Finish()
+
+	posted.CustomName = pending.CustomName
+
+	// Categories are only carried over if the user changed them, otherwise we want to keep the categories that Plaid
+	// provided for the posted transaction.
+	if !myownsanity.StringSlicesEqual(pending.Categories, pending.OriginalCategories) {
+		posted.Categories = pending.Categories
+	}
+
+	if pending.SpendingId == nil {
+		return nil
+	}
+
+	spending, ok := spendingToUpdate[*pending.SpendingId]
+	if !ok {
+		var err error
+		spending, err = repo.GetSpendingById(span.Context(), pending.BankAccountId, *pending.SpendingId)
+		if err != nil {
+			return errors.Wrap(err, "failed to retrieve spending for pending transaction")
+		}
+	}
+
+	// Return what was taken from the spending object for the pending transaction, the allocation is then calculated
+	// again using the posted transaction's amount.
+	if pending.SpendingAmount != nil {
+		spending.CurrentAmount += *pending.SpendingAmount
+		if spending.SpendingType == models.SpendingTypeGoal {
+			spending.UsedAmount -= *pending.SpendingAmount
+		}
+	}
+
+	posted.SpendingId = pending.SpendingId
+	if err := repo.AddExpenseToTransaction(span.Context(), posted, spending); err != nil {
+		return errors.Wrap(err, "failed to move spending to posted transaction")
+	}
+
+	pending.SpendingId = nil
+	pending.SpendingAmount = nil
+	spendingToUpdate[spending.SpendingId] = spending
+
 	return nil
 }
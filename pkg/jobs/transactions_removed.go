@@ -8,6 +8,7 @@ import (
 	"github.com/monetr/rest-api/pkg/internal/myownsanity"
 	"github.com/monetr/rest-api/pkg/repository"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"strconv"
 	"strings"
 	"time"
@@ -27,7 +28,7 @@ func (j *jobManagerBase) TriggerRemoveTransactions(accountId, linkId uint64, rem
 		return "", errors.Wrap(err, "failed to enqueue transaction removal")
 	}
 
-	return job.ID, nil
+	return getEnqueuedJobId(job), nil
 }
 
 func (j *jobManagerBase) removeTransactions(job *work.Job) error {
@@ -74,62 +75,89 @@ func (j *jobManagerBase) removeTransactions(job *work.Job) error {
 			return err
 		}
 
-		transactions, err := repo.GetTransactionsByPlaidTransactionId(span.Context(), linkId, transactionIds)
-		if err != nil {
-			log.WithError(err).Error("failed to retrieve transactions by plaid transaction Id for removal")
+		// Removals for links that sync with a cursor are applied by the sync, after any posted transactions that
+		// replace pending ones have been reconciled. Deleting them here would lose the changes made to the pending
+		// transactions.
+		if link.PlaidLink.TransactionsCursor != nil {
+			log.Debug("link syncs transactions with a cursor, removed transactions will be applied by the next sync")
+			return nil
+		}
+
+		if err = j.deleteTransactions(span.Context(), log, repo, linkId, transactionIds); err != nil {
 			return err
 		}
 
-		if len(transactions) == 0 {
-			log.Warnf("no transactions retrieved, nothing to be done. transactions might already have been deleted")
-			return nil
+		link.LastSuccessfulUpdate = myownsanity.TimeP(time.Now().UTC())
+		return repo.UpdateLink(span.Context(), link)
+	})
+}
+
+// deleteTransactions will remove the transactions with the provided Plaid transaction Ids from the link. If any of the
+// transactions were spent from a spending object then that is reversed before the transaction is deleted.
+func (j *jobManagerBase) deleteTransactions(
+	ctx context.Context,
+	log *logrus.Entry,
+	repo repository.BaseRepository,
+	linkId uint64,
+	transactionIds []string,
+) error {
+	span := sentry.StartSpan(ctx, "Job - Delete Transactions")
+	defer span.Finish()
+
+	transactions, err := repo.GetTransactionsByPlaidTransactionId(span.Context(), linkId, transactionIds)
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve transactions by plaid transaction Id for removal")
+		return err
+	}
+
+	if len(transactions) == 0 {
+		log.Warnf("no transactions retrieved, nothing to be done. transactions might already have been deleted")
+		return nil
+	}
+
+	if len(transactions) != len(transactionIds) {
+		log.Warnf("number of transactions retrieved does not match expected number of transactions, expected: %d found: %d", len(transactionIds), len(transactions))
+	}
+
+	for _, existingTransaction := range transactions {
+		if existingTransaction.SpendingId == nil {
+			continue
 		}
 
-		if len(transactions) != len(transactionIds) {
-			log.Warnf("number of transactions retrieved does not match expected number of transactions, expected: %d found: %d", len(transactionIds), len(transactions))
+		// If the transaction is spent from something then we need to remove the spent from before deleting it to
+		// maintain our balances correctly.
+		updatedTransaction := existingTransaction
+		updatedTransaction.SpendingId = nil
+
+		// This is a simple sanity check, working with objects in slices and for loops can be goofy, or my
+		// understanding of the way objects works with how they are referenced in memory is poor. This is to make
+		// sure im not doing it wrong though. I'm worried that making a "copy" of the object and then modifying the
+		// copy will modify the original as well.
+		if existingTransaction.SpendingId == nil {
+			sentry.CaptureMessage("original transaction modified")
+			panic("original transaction modified")
 		}
 
-		for _, existingTransaction := range transactions {
-			if existingTransaction.SpendingId == nil {
-				continue
-			}
-
-			// If the transaction is spent from something then we need to remove the spent from before deleting it to
-			// maintain our balances correctly.
-			updatedTransaction := existingTransaction
-			updatedTransaction.SpendingId = nil
-
-			// This is a simple sanity check, working with objects in slices and for loops can be goofy, or my
-			// understanding of the way objects works with how they are referenced in memory is poor. This is to make
-			// sure im not doing it wrong though. I'm worried that making a "copy" of the object and then modifying the
-			// copy will modify the original as well.
-			if existingTransaction.SpendingId == nil {
-				sentry.CaptureMessage("original transaction modified")
-				panic("original transaction modified")
-			}
-
-			_, err = repo.ProcessTransactionSpentFrom(
-				span.Context(),
-				existingTransaction.BankAccountId,
-				&updatedTransaction,
-				&existingTransaction,
-			)
-			if err != nil {
-				return err
-			}
+		_, err = repo.ProcessTransactionSpentFrom(
+			span.Context(),
+			existingTransaction.BankAccountId,
+			&updatedTransaction,
+			&existingTransaction,
+		)
+		if err != nil {
+			return err
 		}
+	}
 
-		for _, transaction := range transactions {
-			if err := repo.DeleteTransaction(span.Context(), transaction.BankAccountId, transaction.TransactionId); err != nil {
-				log.WithField("transactionId", transaction.TransactionId).WithError(err).
-					Error("failed to delete transaction")
-				return err
-			}
+	for _, transaction := range transactions {
+		if err := repo.DeleteTransaction(span.Context(), transaction.BankAccountId, transaction.TransactionId); err != nil {
+			log.WithField("transactionId", transaction.TransactionId).WithError(err).
+				Error("failed to delete transaction")
+			return err
 		}
+	}
 
-		log.Debugf("successfully removed %d transaction(s)", len(transactions))
+	log.Debugf("successfully removed %d transaction(s)", len(transactions))
 
-		link.LastSuccessfulUpdate = myownsanity.TimeP(time.Now().UTC())
-		return repo.UpdateLink(span.Context(), link)
-	})
+	return nil
 }
@@ -0,0 +1,247 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/go-pg/pg/v10"
+	"github.com/gocraft/work"
+	"github.com/jarcoal/httpmock"
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/internal/mock_plaid"
+	"github.com/monetr/rest-api/pkg/internal/mock_secrets"
+	"github.com/monetr/rest-api/pkg/internal/myownsanity"
+	"github.com/monetr/rest-api/pkg/internal/platypus"
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/monetr/rest-api/pkg/repository"
+	"github.com/plaid/plaid-go/plaid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// givenIHaveASpentPendingTransaction creates a pending transaction on the account's Plaid link that was spent from a
+// goal, with a custom name and categories. This is what the user would have changed before the transaction posts.
+func givenIHaveASpentPendingTransaction(t *testing.T, db *pg.DB, account *models.User) (linkId, spendingId uint64, bankAccount models.BankAccount, pending models.Transaction) {
+	now := time.Now().UTC()
+	require.NoError(t, db.RunInTransaction(context.Background(), func(txn *pg.Tx) error {
+		repo := repository.NewRepositoryFromSession(account.UserId, account.AccountId, txn)
+		links, err := repo.GetLinks(context.Background())
+		require.NoError(t, err, "must retrieve links for account")
+		require.Len(t, links, 1, "should have exactly one link")
+		linkId = links[0].LinkId
+
+		bankAccounts, err := repo.GetBankAccountsByLinkId(context.Background(), linkId)
+		require.NoError(t, err, "must retrieve bank accounts for link")
+		require.NotEmpty(t, bankAccounts, "link must have bank accounts")
+		bankAccount = bankAccounts[0]
+
+		rule, err := models.NewRule("FREQ=MONTHLY;BYMONTHDAY=15,-1")
+		require.NoError(t, err, "must parse rule")
+
+		fundingSchedule := models.FundingSchedule{
+			BankAccountId:  bankAccount.BankAccountId,
+			Name:           "Payday",
+			Rule:           rule,
+			NextOccurrence: now.AddDate(0, 0, 7),
+		}
+		require.NoError(t, repo.CreateFundingSchedule(context.Background(), &fundingSchedule), "must create funding schedule")
+
+		spending := models.Spending{
+			BankAccountId:     bankAccount.BankAccountId,
+			FundingScheduleId: fundingSchedule.FundingScheduleId,
+			FundingSchedule:   &fundingSchedule,
+			SpendingType:      models.SpendingTypeGoal,
+			Name:              "Dinner",
+			TargetAmount:      10000,
+			CurrentAmount:     5000,
+			NextRecurrence:    now.AddDate(0, 1, 0),
+		}
+		require.NoError(t, repo.CreateSpending(context.Background(), &spending), "must create spending")
+		spendingId = spending.SpendingId
+
+		pending = models.Transaction{
+			BankAccountId:      bankAccount.BankAccountId,
+			PlaidTransactionId: gofakeit.UUID(),
+			Amount:             2000,
+			SpendingId:         &spendingId,
+			Categories:         []string{"Date Night"},
+			OriginalCategories: []string{"Food and Drink"},
+			Date:               now,
+			Name:               "Pizza Place",
+			CustomName:         myownsanity.StringP("Date night"),
+			OriginalName:       "PIZZA PLACE 1234",
+			IsPending:          true,
+			CreatedAt:          now,
+		}
+		require.NoError(t, repo.AddExpenseToTransaction(context.Background(), &pending, &spending), "must spend pending transaction")
+		require.NoError(t, repo.UpdateSpending(context.Background(), bankAccount.BankAccountId, []models.Spending{
+			spending,
+		}), "must update spending")
+
+		return repo.InsertTransactions(context.Background(), []models.Transaction{
+			pending,
+		})
+	}), "must seed pending transaction")
+
+	return linkId, spendingId, bankAccount, pending
+}
+
+// assertPendingTransactionReconciled makes sure that the pending transaction from givenIHaveASpentPendingTransaction
+// was replaced by the posted transaction, and that the user's changes were carried over to it.
+func assertPendingTransactionReconciled(t *testing.T, db *pg.DB, account *models.User, linkId, spendingId uint64, bankAccount models.BankAccount, pending models.Transaction, posted plaid.Transaction) {
+	require.NoError(t, db.RunInTransaction(context.Background(), func(txn *pg.Tx) error {
+		repo := repository.NewRepositoryFromSession(account.UserId, account.AccountId, txn)
+		transactions, err := repo.GetTransactionsByPlaidId(context.Background(), linkId, []string{
+			posted.GetTransactionId(),
+			pending.PlaidTransactionId,
+		})
+		require.NoError(t, err, "must retrieve transactions")
+		assert.NotContains(t, transactions, pending.PlaidTransactionId, "pending transaction should be removed")
+		require.Contains(t, transactions, posted.GetTransactionId(), "posted transaction should be created")
+
+		result := transactions[posted.GetTransactionId()]
+		assert.EqualValues(t, 2500, result.Amount, "posted amount should be used")
+		assert.Equal(t, &spendingId, result.SpendingId, "spending should be carried over")
+		require.NotNil(t, result.SpendingAmount, "spending amount should be carried over")
+		assert.EqualValues(t, 2500, *result.SpendingAmount, "spending amount should match the posted amount")
+		assert.Equal(t, pending.CustomName, result.CustomName, "custom name should be carried over")
+		assert.Equal(t, pending.Categories, result.Categories, "user categories should be carried over")
+
+		spending, err := repo.GetSpendingById(context.Background(), bankAccount.BankAccountId, spendingId)
+		require.NoError(t, err, "must retrieve spending")
+		assert.EqualValues(t, 2500, spending.CurrentAmount, "difference should be taken from the spending")
+		assert.EqualValues(t, 2500, spending.UsedAmount, "used amount should match the posted amount")
+
+		return nil
+	}), "must verify reconciliation")
+}
+
+// newTestPlaidJobManager returns a job manager that uses the seeded account's Plaid credentials.
+func newTestPlaidJobManager(t *testing.T, db *pg.DB, account *models.User, plaidData *testutils.MockPlaidData) *jobManagerBase {
+	log := testutils.GetLog(t)
+	cache := testutils.GetRedisPool(t)
+
+	plaidSecrets := mock_secrets.NewMockPlaidSecrets()
+	for accessToken, data := range plaidData.PlaidTokens {
+		plaidSecrets = plaidSecrets.WithSecret(account.AccountId, data.ItemId, accessToken)
+	}
+
+	plaidRepo := repository.NewPlaidRepository(db)
+	plaidClient := platypus.NewPlaid(log, plaidSecrets, plaidRepo, config.Plaid{
+		ClientID:     gofakeit.UUID(),
+		ClientSecret: gofakeit.UUID(),
+		Environment:  plaid.Sandbox,
+	})
+
+	job := NewJobManager(log, config.Jobs{}, cache, db, plaidClient, nil, plaidSecrets, nil, nil).(*jobManagerBase)
+	t.Cleanup(func() {
+		require.NoError(t, job.Close(), "must close job manager")
+	})
+
+	return job
+}
+
+func TestUpsertTransactions(t *testing.T) {
+	t.Run("pending transaction posts", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		db := testutils.GetPgDatabase(t)
+		account, plaidData := testutils.SeedAccount(t, db, testutils.WithPlaidAccount)
+		linkId, spendingId, bankAccount, pending := givenIHaveASpentPendingTransaction(t, db, account)
+
+		// The transaction posts with a tip added, so the amount is higher than the pending amount.
+		now := time.Now().UTC()
+		posted := mock_plaid.GenerateTransactions(t, now, now, 1, []string{bankAccount.PlaidAccountId})[0]
+		posted.SetAmount(25)
+		posted.SetPending(false)
+		posted.SetPendingTransactionId(pending.PlaidTransactionId)
+		mock_plaid.MockSyncTransactions(t, []plaid.Transaction{posted}, nil, []string{pending.PlaidTransactionId})
+
+		job := newTestPlaidJobManager(t, db, account, plaidData)
+
+		err := job.pullLatestTransactions(&work.Job{
+			Name:       PullLatestTransactions,
+			ID:         gofakeit.UUID(),
+			EnqueuedAt: time.Now().Unix(),
+			Args: map[string]interface{}{
+				"accountId": account.AccountId,
+				"linkId":    linkId,
+			},
+			Unique: true,
+		})
+		assert.NoError(t, err, "job should succeed")
+
+		assertPendingTransactionReconciled(t, db, account, linkId, spendingId, bankAccount, pending, posted)
+	})
+
+	t.Run("pending transaction removed before it posts", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		db := testutils.GetPgDatabase(t)
+		account, plaidData := testutils.SeedAccount(t, db, testutils.WithPlaidAccount)
+		linkId, spendingId, bankAccount, pending := givenIHaveASpentPendingTransaction(t, db, account)
+
+		// The link has already been synced, so it has a cursor.
+		require.NoError(t, db.RunInTransaction(context.Background(), func(txn *pg.Tx) error {
+			repo := repository.NewRepositoryFromSession(account.UserId, account.AccountId, txn)
+			link, err := repo.GetLink(context.Background(), linkId)
+			require.NoError(t, err, "must retrieve link")
+			link.PlaidLink.TransactionsCursor = myownsanity.StringP("offset:0")
+			return repo.UpdatePlaidLink(context.Background(), link.PlaidLink)
+		}), "must store transactions cursor")
+
+		job := newTestPlaidJobManager(t, db, account, plaidData)
+
+		// Plaid tells us the pending transaction was removed before the posted transaction can be retrieved.
+		err := job.removeTransactions(&work.Job{
+			Name:       RemoveTransactions,
+			ID:         gofakeit.UUID(),
+			EnqueuedAt: time.Now().Unix(),
+			Args: map[string]interface{}{
+				"accountId":           account.AccountId,
+				"linkId":              linkId,
+				"removedTransactions": pending.PlaidTransactionId,
+			},
+			Unique: true,
+		})
+		assert.NoError(t, err, "job should succeed")
+
+		require.NoError(t, db.RunInTransaction(context.Background(), func(txn *pg.Tx) error {
+			repo := repository.NewRepositoryFromSession(account.UserId, account.AccountId, txn)
+			transactions, err := repo.GetTransactionsByPlaidId(context.Background(), linkId, []string{
+				pending.PlaidTransactionId,
+			})
+			require.NoError(t, err, "must retrieve transactions")
+			assert.Contains(t, transactions, pending.PlaidTransactionId, "pending transaction should not be removed until it is synced")
+
+			return nil
+		}), "must verify pending transaction")
+
+		// The next sync has both the posted transaction and the removal of the pending transaction.
+		now := time.Now().UTC()
+		posted := mock_plaid.GenerateTransactions(t, now, now, 1, []string{bankAccount.PlaidAccountId})[0]
+		posted.SetAmount(25)
+		posted.SetPending(false)
+		posted.SetPendingTransactionId(pending.PlaidTransactionId)
+		mock_plaid.MockSyncTransactions(t, []plaid.Transaction{posted}, nil, []string{pending.PlaidTransactionId})
+
+		err = job.pullLatestTransactions(&work.Job{
+			Name:       PullLatestTransactions,
+			ID:         gofakeit.UUID(),
+			EnqueuedAt: time.Now().Unix(),
+			Args: map[string]interface{}{
+				"accountId": account.AccountId,
+				"linkId":    linkId,
+			},
+			Unique: true,
+		})
+		assert.NoError(t, err, "job should succeed")
+
+		assertPendingTransactionReconciled(t, db, account, linkId, spendingId, bankAccount, pending, posted)
+	})
+}
@@ -0,0 +1,159 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/gocraft/work"
+	"github.com/monetr/rest-api/pkg/crumbs"
+	"github.com/monetr/rest-api/pkg/internal/platypus"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/monetr/rest-api/pkg/repository"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	UpdateInstitutions = "UpdateInstitutions"
+)
+
+func (j *jobManagerBase) updateInstitutions(job *work.Job) error {
+	runner := &UpdateInstitutionsJob{
+		jobId:       job.ID,
+		log:         j.getLogForJob(job),
+		db:          j.db,
+		plaidClient: j.plaidClient,
+	}
+
+	return runner.Run(context.Background())
+}
+
+// UpdateInstitutionsJob will retrieve the metadata and the current status of every institution that one of our links
+// is connected to from Plaid. Institutions that we have not seen before are created, and any links that are not yet
+// associated with their institution will be associated with it.
+type UpdateInstitutionsJob struct {
+	jobId       string
+	log         *logrus.Entry
+	db          *pg.DB
+	plaidClient platypus.Platypus
+}
+
+func (u *UpdateInstitutionsJob) Run(ctx context.Context) error {
+	span := sentry.StartSpan(ctx, "Job", sentry.TransactionName("Update Institutions"))
+	defer span.Finish()
+
+	span.SetTag("jobId", u.jobId)
+
+	log := u.log
+
+	var plaidIds []string
+	var existing map[string]models.Institution
+	if err := u.db.RunInTransaction(span.Context(), func(txn *pg.Tx) (err error) {
+		repo := repository.NewJobRepository(txn)
+		plaidIds, err = repo.GetLinkedPlaidInstitutionIds(span.Context())
+		if err != nil {
+			return err
+		}
+
+		if len(plaidIds) == 0 {
+			return nil
+		}
+
+		existing, err = repo.GetInstitutionsByPlaidID(span.Context(), plaidIds)
+		return err
+	}); err != nil {
+		log.WithError(err).Error("failed to retrieve institutions to update")
+		return err
+	}
+
+	if len(plaidIds) == 0 {
+		log.Debug("no institutions to update")
+		return nil
+	}
+
+	log.Infof("updating %d institution(s)", len(plaidIds))
+
+	// Institutions are retrieved from Plaid outside of a transaction, we don't want to hold a transaction open while we
+	// are waiting on Plaid.
+	now := time.Now().UTC()
+	creates := make([]*models.Institution, 0, len(plaidIds))
+	updates := make([]*models.Institution, 0, len(plaidIds))
+	failures := 0
+	for _, plaidId := range plaidIds {
+		institutionLog := log.WithField("plaidInstitutionId", plaidId)
+
+		result, err := u.plaidClient.GetInstitution(span.Context(), plaidId)
+		if err != nil {
+			crumbs.Warn(span.Context(), "Failed to retrieve institution from Plaid", "plaid", map[string]interface{}{
+				"plaidInstitutionId": plaidId,
+			})
+			institutionLog.WithError(err).Warn("failed to retrieve institution from Plaid, it will not be updated")
+			failures++
+			continue
+		}
+
+		institution, ok := existing[plaidId]
+		if !ok {
+			institution = models.Institution{
+				PlaidInstitutionId: &result.InstitutionId,
+			}
+		}
+
+		institution.Name = result.Name
+		institution.PlaidProducts = result.Products
+		institution.URL = result.URL
+		institution.PrimaryColor = result.PrimaryColor
+		institution.Logo = result.Logo
+		institution.Status = result.GetStatus()
+		institution.ProductStatuses = result.ProductStatuses
+		institution.UpdatedAt = &now
+
+		if institution.Status != nil && *institution.Status != models.Healthy {
+			institutionLog.WithField("status", *institution.Status).Info("institution is not healthy")
+		}
+
+		if ok {
+			updates = append(updates, &institution)
+		} else {
+			creates = append(creates, &institution)
+		}
+	}
+
+	if failures == len(plaidIds) {
+		return errors.New("failed to retrieve any institutions from Plaid")
+	}
+
+	return u.db.RunInTransaction(span.Context(), func(txn *pg.Tx) error {
+		repo := repository.NewJobRepository(txn)
+		if len(creates) > 0 {
+			if err := repo.CreateInstitutions(span.Context(), creates); err != nil {
+				log.WithError(err).Error("failed to create institutions")
+				return err
+			}
+		}
+
+		if len(updates) > 0 {
+			if err := repo.UpdateInstitutions(span.Context(), updates); err != nil {
+				log.WithError(err).Error("failed to update institutions")
+				return err
+			}
+		}
+
+		linked, err := repo.UpdateLinkInstitutions(span.Context())
+		if err != nil {
+			log.WithError(err).Error("failed to associate links with their institutions")
+			return err
+		}
+
+		log.WithFields(logrus.Fields{
+			"created": len(creates),
+			"updated": len(updates),
+			"failed":  failures,
+			"linked":  linked,
+		}).Info("finished updating institutions")
+
+		return nil
+	})
+}
@@ -0,0 +1,63 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/jarcoal/httpmock"
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/internal/mock_plaid"
+	"github.com/monetr/rest-api/pkg/internal/platypus"
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/monetr/rest-api/pkg/repository"
+	"github.com/monetr/rest-api/pkg/secrets"
+	"github.com/plaid/plaid-go/plaid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateInstitutionsJob_Run(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		log := testutils.GetLog(t)
+		db := testutils.GetPgDatabase(t)
+
+		user, _ := testutils.SeedAccount(t, db, testutils.WithPlaidAccount)
+
+		// The seeded Plaid link is always for institution "123".
+		mock_plaid.MockGetInstitution(t, mock_plaid.InstitutionFixture(t, "123", "DEGRADED"))
+
+		plaidClient := platypus.NewPlaid(log, secrets.NewPostgresPlaidSecretsProvider(log, db), repository.NewPlaidRepository(db), config.Plaid{
+			ClientID:     gofakeit.UUID(),
+			ClientSecret: gofakeit.UUID(),
+			Environment:  plaid.Sandbox,
+		})
+
+		job := &UpdateInstitutionsJob{
+			jobId:       gofakeit.UUID(),
+			log:         log,
+			db:          db,
+			plaidClient: plaidClient,
+		}
+		require.NoError(t, job.Run(context.Background()), "job should succeed")
+
+		var link models.Link
+		require.NoError(t, db.Model(&link).
+			Relation("Institution").
+			Where(`"link"."account_id" = ?`, user.AccountId).
+			Where(`"link"."link_type" = ?`, models.PlaidLinkType).
+			Limit(1).
+			Select(&link), "must retrieve link")
+
+		require.NotNil(t, link.InstitutionId, "link should be associated with its institution")
+		require.NotNil(t, link.Institution, "institution should be present")
+		require.NotNil(t, link.Institution.Status, "institution status should be present")
+		assert.Equal(t, models.Degraded, *link.Institution.Status, "institution should be degraded")
+		assert.Equal(t, models.Degraded, link.Institution.ProductStatuses["transactions_updates"], "product status should be stored")
+		assert.NotNil(t, link.Institution.UpdatedAt, "updated at should be set")
+	})
+}
@@ -14,8 +14,16 @@ type Account struct {
 	Timezone                     string     `json:"timezone" pg:"timezone,notnull,default:'UTC'"`
 	StripeCustomerId             *string    `json:"-" pg:"stripe_customer_id"`
 	StripeSubscriptionId         *string    `json:"-" pg:"stripe_subscription_id"`
+	StripePriceId                *string    `json:"-" pg:"stripe_price_id"`
 	StripeWebhookLatestTimestamp *time.Time `json:"-" pg:"stripe_webhook_latest_timestamp"`
 	SubscriptionActiveUntil      *time.Time `json:"subscriptionActiveUntil" pg:"subscription_active_until"`
+	PaymentFailedAt              *time.Time `json:"-" pg:"payment_failed_at"`
+	// Features are the features included in the account's current plan. They are derived from the plan's
+	// configuration whenever the account's subscription is updated.
+	Features []feature.Feature `json:"planFeatures" pg:"features,array"`
+	// FeatureOverrides are set by an admin to grant or revoke a feature regardless of what the account's plan
+	// includes.
+	FeatureOverrides map[feature.Feature]bool `json:"featureOverrides,omitempty" pg:"feature_overrides,type:jsonb"`
 }
 
 func (a *Account) GetTimezone() (*time.Location, error) {
@@ -27,11 +35,74 @@ func (a *Account) GetTimezone() (*time.Location, error) {
 	return location, nil
 }
 
-func (a *Account) HasFeature(feature feature.Feature) bool {
-	// TODO Implement feature system with accounts.
+// HasFeature will return true if the account is entitled to the provided feature. An override set by an admin will
+// take precedence over the features of the account's plan.
+func (a *Account) HasFeature(item feature.Feature) bool {
+	if enabled, ok := a.FeatureOverrides[item]; ok {
+		return enabled
+	}
+
+	for _, planFeature := range a.Features {
+		if planFeature == item {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetFeatures returns every feature that the account is entitled to, including any admin overrides.
+func (a *Account) GetFeatures() []feature.Feature {
+	features := make([]feature.Feature, 0, len(feature.All()))
+	for _, item := range feature.All() {
+		if a.HasFeature(item) {
+			features = append(features, item)
+		}
+	}
+
+	return features
+}
+
+// HasFeatureByDefault is like HasFeature, but every feature is included unless an admin has revoked it for the account.
+// This is used when billing is not enabled, as accounts will not have a plan.
+func (a *Account) HasFeatureByDefault(item feature.Feature) bool {
+	if enabled, ok := a.FeatureOverrides[item]; ok {
+		return enabled
+	}
+
 	return true
 }
 
+// GetFeaturesByDefault returns every feature except for the ones that an admin has revoked for the account.
+func (a *Account) GetFeaturesByDefault() []feature.Feature {
+	features := make([]feature.Feature, 0, len(feature.All()))
+	for _, item := range feature.All() {
+		if a.HasFeatureByDefault(item) {
+			features = append(features, item)
+		}
+	}
+
+	return features
+}
+
+// GetGracePeriodEndsAt returns the time that the account will stop being usable because of a failed payment. If there
+// is not an outstanding failed payment then nil is returned.
+func (a *Account) GetGracePeriodEndsAt(gracePeriod time.Duration) *time.Time {
+	if a.PaymentFailedAt == nil {
+		return nil
+	}
+
+	endsAt := a.PaymentFailedAt.Add(gracePeriod)
+	return &endsAt
+}
+
+// IsInGracePeriod will return true if a payment for the account's subscription has failed recently enough that the
+// account should still be usable.
+func (a *Account) IsInGracePeriod(gracePeriod time.Duration) bool {
+	endsAt := a.GetGracePeriodEndsAt(gracePeriod)
+	return endsAt != nil && endsAt.After(time.Now())
+}
+
 // IsSubscriptionActive will return true if the SubscriptionActiveUntil date is not nill and is in the future. Even if
 // the StripeSubscriptionId or StripeCustomerId is nil.
 func (a *Account) IsSubscriptionActive() bool {
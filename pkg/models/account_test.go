@@ -0,0 +1,91 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/monetr/rest-api/pkg/feature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccount_HasFeature(t *testing.T) {
+	t.Run("from plan", func(t *testing.T) {
+		account := Account{
+			Features: []feature.Feature{
+				feature.FeatureManualBudgeting,
+			},
+		}
+
+		assert.True(t, account.HasFeature(feature.FeatureManualBudgeting), "plan includes manual budgeting")
+		assert.False(t, account.HasFeature(feature.FeatureLinkedBudgeting), "plan does not include linked budgeting")
+	})
+
+	t.Run("no plan", func(t *testing.T) {
+		account := Account{}
+
+		assert.Empty(t, account.GetFeatures(), "account without a plan should not have any features")
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		account := Account{
+			Features: []feature.Feature{
+				feature.FeatureManualBudgeting,
+			},
+			FeatureOverrides: map[feature.Feature]bool{
+				feature.FeatureManualBudgeting: false,
+				feature.FeatureLinkedBudgeting: true,
+			},
+		}
+
+		assert.False(t, account.HasFeature(feature.FeatureManualBudgeting), "override should revoke manual budgeting")
+		assert.True(t, account.HasFeature(feature.FeatureLinkedBudgeting), "override should grant linked budgeting")
+		assert.Equal(t, []feature.Feature{
+			feature.FeatureLinkedBudgeting,
+		}, account.GetFeatures(), "features should include overrides")
+	})
+
+	t.Run("by default", func(t *testing.T) {
+		account := Account{}
+
+		assert.True(t, account.HasFeatureByDefault(feature.FeatureLinkedBudgeting), "every feature is included by default")
+		assert.Equal(t, feature.All(), account.GetFeaturesByDefault(), "should have every feature without overrides")
+
+		account.FeatureOverrides = map[feature.Feature]bool{
+			feature.FeatureLinkedBudgeting: false,
+		}
+		assert.False(t, account.HasFeatureByDefault(feature.FeatureLinkedBudgeting), "override should revoke linked budgeting")
+		assert.Equal(t, []feature.Feature{
+			feature.FeatureManualBudgeting,
+		}, account.GetFeaturesByDefault(), "revoked features should not be included")
+	})
+}
+
+func TestAccount_IsInGracePeriod(t *testing.T) {
+	gracePeriod := 7 * 24 * time.Hour
+
+	t.Run("no failed payment", func(t *testing.T) {
+		account := Account{}
+
+		assert.False(t, account.IsInGracePeriod(gracePeriod), "account without a failed payment is not in a grace period")
+		assert.Nil(t, account.GetGracePeriodEndsAt(gracePeriod), "grace period should not have an end")
+	})
+
+	t.Run("recent failed payment", func(t *testing.T) {
+		failedAt := time.Now().Add(-24 * time.Hour)
+		account := Account{
+			PaymentFailedAt: &failedAt,
+		}
+
+		assert.True(t, account.IsInGracePeriod(gracePeriod), "account should be in its grace period")
+		assert.Equal(t, failedAt.Add(gracePeriod), *account.GetGracePeriodEndsAt(gracePeriod), "grace period should end relative to the failure")
+	})
+
+	t.Run("old failed payment", func(t *testing.T) {
+		failedAt := time.Now().Add(-8 * 24 * time.Hour)
+		account := Account{
+			PaymentFailedAt: &failedAt,
+		}
+
+		assert.False(t, account.IsInGracePeriod(gracePeriod), "grace period should be over")
+	})
+}
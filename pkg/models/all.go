@@ -4,9 +4,13 @@ var (
 	AllModels = []interface{}{
 		&Login{},
 		&Account{},
+		&AuditLog{},
 		&User{},
 		&Job{},
 		&PlaidLink{},
+		&PlaidWebhook{},
+		&SimpleFINLink{},
+		&StripeEvent{},
 		&Link{},
 		&BankAccount{},
 		&FundingSchedule{},
@@ -17,13 +21,17 @@ var (
 	// This silences any warnings about the tableName field not being used. It's used via reflection in our ORM to
 	// query and generate schemas/SQL.
 	_ = Account{}.tableName
+	_ = AuditLog{}.tableName
 	_ = BankAccount{}.tableName
 	_ = FundingSchedule{}.tableName
 	_ = Job{}.tableName
 	_ = Link{}.tableName
 	_ = Login{}.tableName
 	_ = PlaidLink{}.tableName
+	_ = PlaidWebhook{}.tableName
+	_ = SimpleFINLink{}.tableName
 	_ = Spending{}.tableName
+	_ = StripeEvent{}.tableName
 	_ = Transaction{}.tableName
 	_ = User{}.tableName
 )
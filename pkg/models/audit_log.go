@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditActionLookupAccount      AuditAction = "lookup_account"
+	AuditActionViewAccount        AuditAction = "view_account"
+	AuditActionResyncLink         AuditAction = "resync_link"
+	AuditActionExtendSubscription AuditAction = "extend_subscription"
+	AuditActionSetLoginEnabled    AuditAction = "set_login_enabled"
+	AuditActionSetFeatureOverride AuditAction = "set_feature_override"
+	AuditActionCreateBetaCodes    AuditAction = "create_beta_codes"
+	AuditActionRevokeBetaCode     AuditAction = "revoke_beta_code"
+	AuditActionSetBetaInviteQuota AuditAction = "set_beta_invite_quota"
+)
+
+// AuditLog is a record of an action taken by an operator through the admin API or the admin commands. Audit logs are
+// kept even if the account or login that was acted upon is removed.
+type AuditLog struct {
+	tableName string `pg:"audit_logs"`
+
+	AuditLogId uint64 `json:"auditLogId" pg:"audit_log_id,notnull,pk,type:'bigserial'"`
+	// Actor describes who performed the action. For the admin API this is the email of the admin's login, for the
+	// admin commands this is the name of the operating system user that ran the command.
+	Actor           string                 `json:"actor" pg:"actor,notnull"`
+	ActorLoginId    *uint64                `json:"actorLoginId" pg:"actor_login_id,on_delete:SET NULL"`
+	Action          AuditAction            `json:"action" pg:"action,notnull"`
+	TargetAccountId *uint64                `json:"targetAccountId" pg:"target_account_id"`
+	TargetLoginId   *uint64                `json:"targetLoginId" pg:"target_login_id"`
+	Details         map[string]interface{} `json:"details" pg:"details,type:jsonb"`
+	CreatedAt       time.Time              `json:"createdAt" pg:"created_at,notnull"`
+}
@@ -27,22 +27,26 @@ const (
 
 	CreditCartBankAccountSubType BankAccountSubType = "credit card"
 
-	AutoBankAccountSubType BankAccountSubType = "auto"
-	// I'll add other bank account sub types later. Right now I'm really only working with depository anyway.
+	AutoBankAccountSubType     BankAccountSubType = "auto"
+	MortgageBankAccountSubType BankAccountSubType = "mortgage"
+	StudentBankAccountSubType  BankAccountSubType = "student"
 )
 
 type BankAccount struct {
 	tableName string `pg:"bank_accounts"`
 
-	BankAccountId    uint64   `json:"bankAccountId" pg:"bank_account_id,notnull,pk,type:'bigserial'" example:"1234"`
-	AccountId        uint64   `json:"-" pg:"account_id,notnull,pk,on_delete:CASCADE"`
-	Account          *Account `json:"-" pg:"rel:has-one"`
-	LinkId           uint64   `json:"linkId" pg:"link_id,notnull,on_delete:CASCADE" example:"2345"`
-	Link             *Link    `json:"-,omitempty" pg:"rel:has-one"`
-	PlaidAccountId   string   `json:"-" pg:"plaid_account_id"`
-	AvailableBalance int64    `json:"availableBalance" pg:"available_balance,notnull,use_zero" example:"102356"`
+	BankAccountId  uint64   `json:"bankAccountId" pg:"bank_account_id,notnull,pk,type:'bigserial'" example:"1234"`
+	AccountId      uint64   `json:"-" pg:"account_id,notnull,pk,on_delete:CASCADE"`
+	Account        *Account `json:"-" pg:"rel:has-one"`
+	LinkId         uint64   `json:"linkId" pg:"link_id,notnull,on_delete:CASCADE" example:"2345"`
+	Link           *Link    `json:"-,omitempty" pg:"rel:has-one"`
+	PlaidAccountId string   `json:"-" pg:"plaid_account_id"`
+	// SimpleFINAccountId is the bridge's Id for the account when the bank account belongs to a SimpleFIN link.
+	SimpleFINAccountId string `json:"-" pg:"simplefin_account_id"`
+	AvailableBalance   int64  `json:"availableBalance" pg:"available_balance,notnull,use_zero" example:"102356"`
 	// Current Balance is a 64-bit representation of a bank account's total balance (excluding pending transactions) in
-	// the form of an integer. To derive a decimal value divide this value by 100.
+	// the form of an integer. The balance is in the minor units of the bank account's currency, to derive a decimal
+	// value for USD divide this value by 100.
 	CurrentBalance    int64              `json:"currentBalance" pg:"current_balance,notnull,use_zero" example:"102400"`
 	Mask              string             `json:"mask" pg:"mask" example:"0000"`
 	Name              string             `json:"name,omitempty" pg:"name,notnull" example:"Checking Account"`
@@ -51,4 +55,83 @@ type BankAccount struct {
 	Type              BankAccountType    `json:"accountType" pg:"account_type" example:"depository"`
 	SubType           BankAccountSubType `json:"accountSubType" pg:"account_sub_type" example:"checking"`
 	LastUpdated       time.Time          `json:"lastUpdated" pg:"last_updated,notnull"`
+	// LimitBalance is the credit limit of a credit account in cents. This will be nil for other types of accounts, or
+	// if the limit is not provided by the institution.
+	LimitBalance *int64 `json:"limitBalance" pg:"limit_balance"`
+	// StatementBalance is the balance of a credit account as of its last statement in cents. This is the amount that
+	// needs to be paid to avoid interest. It will be nil if the institution does not provide it.
+	StatementBalance *int64 `json:"statementBalance" pg:"statement_balance"`
+	// OriginalBalance is the largest amount that has been owed on a loan account in cents. It is used to track how much
+	// of the loan has been paid off.
+	OriginalBalance *int64 `json:"originalBalance" pg:"original_balance"`
+	// PaymentBankAccountId is the depository bank account that a credit account is paid from. The amount owed on the
+	// credit account is set aside in the payment account so that it is not considered safe to spend.
+	PaymentBankAccountId *uint64 `json:"paymentBankAccountId" pg:"payment_bank_account_id"`
+	// Currency is the ISO 4217 currency code of the bank account's balances. Transactions and spending objects for the
+	// bank account are in the same currency.
+	Currency string `json:"currency" pg:"currency,notnull,default:'USD'" example:"USD"`
+}
+
+// IsLiability returns true if the bank account represents money that is owed rather than money that is held. For
+// liability accounts the current balance is the amount that is owed, a positive balance means there is a balance on
+// the card or the loan that needs to be paid. This matches how Plaid reports balances for these accounts.
+func (b *BankAccount) IsLiability() bool {
+	switch b.Type {
+	case CreditBankAccountType, LoanBankAccountType:
+		return true
+	default:
+		return false
+	}
+}
+
+// SetBalances will update the bank account's balances with the balances provided by the institution, all of the
+// balances are in cents. A limit of 0 is treated as the institution not providing a limit. Returns true if any of the
+// balances on the bank account were changed.
+func (b *BankAccount) SetBalances(available, current, limit int64) (changed bool) {
+	switch b.Type {
+	case CreditBankAccountType:
+		// Not every institution provides an available balance for credit accounts, but when they do it is the limit
+		// minus the amount owed. If we have a limit then we derive it so that it is consistent.
+		if limit > 0 {
+			available = limit - current
+			if b.LimitBalance == nil || *b.LimitBalance != limit {
+				b.LimitBalance = &limit
+				changed = true
+			}
+		}
+	case LoanBankAccountType:
+		// The original balance of the loan is not provided, so the largest amount that we have seen owed on the loan is
+		// used instead. This is usually the balance when the loan was first linked.
+		if b.OriginalBalance == nil || current > *b.OriginalBalance {
+			b.OriginalBalance = &current
+			changed = true
+		}
+	}
+
+	if b.AvailableBalance != available {
+		b.AvailableBalance = available
+		changed = true
+	}
+
+	if b.CurrentBalance != current {
+		b.CurrentBalance = current
+		changed = true
+	}
+
+	return changed
+}
+
+// SetStatementBalance will update the statement balance of a credit account with the balance provided by the
+// institution in cents. Returns true if the statement balance was changed.
+func (b *BankAccount) SetStatementBalance(statement int64) (changed bool) {
+	if b.Type != CreditBankAccountType {
+		return false
+	}
+
+	if b.StatementBalance != nil && *b.StatementBalance == statement {
+		return false
+	}
+
+	b.StatementBalance = &statement
+	return true
 }
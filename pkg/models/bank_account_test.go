@@ -0,0 +1,95 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBankAccount_IsLiability(t *testing.T) {
+	assert.False(t, (&BankAccount{Type: DepositoryBankAccountType}).IsLiability(), "depository is not a liability")
+	assert.False(t, (&BankAccount{Type: InvestmentBankAccountType}).IsLiability(), "investment is not a liability")
+	assert.True(t, (&BankAccount{Type: CreditBankAccountType}).IsLiability(), "credit is a liability")
+	assert.True(t, (&BankAccount{Type: LoanBankAccountType}).IsLiability(), "loan is a liability")
+}
+
+func TestBankAccount_SetBalances(t *testing.T) {
+	t.Run("depository", func(t *testing.T) {
+		bankAccount := BankAccount{
+			Type: DepositoryBankAccountType,
+		}
+
+		assert.True(t, bankAccount.SetBalances(1000, 1200, 0), "balances should have changed")
+		assert.Equal(t, int64(1000), bankAccount.AvailableBalance, "available balance should match")
+		assert.Equal(t, int64(1200), bankAccount.CurrentBalance, "current balance should match")
+		assert.Nil(t, bankAccount.LimitBalance, "depository should not have a limit")
+		assert.Nil(t, bankAccount.OriginalBalance, "depository should not have an original balance")
+
+		assert.False(t, bankAccount.SetBalances(1000, 1200, 0), "balances should not have changed")
+	})
+
+	t.Run("credit", func(t *testing.T) {
+		bankAccount := BankAccount{
+			Type: CreditBankAccountType,
+		}
+
+		assert.True(t, bankAccount.SetBalances(0, 1500, 5000), "balances should have changed")
+		require.NotNil(t, bankAccount.LimitBalance, "limit should be set")
+		assert.Equal(t, int64(5000), *bankAccount.LimitBalance, "limit should match")
+		assert.Equal(t, int64(3500), bankAccount.AvailableBalance, "available should be derived from the limit")
+
+		assert.False(t, bankAccount.SetBalances(3500, 1500, 5000), "balances should not have changed")
+		assert.True(t, bankAccount.SetBalances(3500, 1500, 6000), "limit increase should be a change")
+		assert.Equal(t, int64(4500), bankAccount.AvailableBalance, "available should reflect the new limit")
+	})
+
+	t.Run("credit without limit", func(t *testing.T) {
+		bankAccount := BankAccount{
+			Type: CreditBankAccountType,
+		}
+
+		assert.True(t, bankAccount.SetBalances(2000, 1500, 0), "balances should have changed")
+		assert.Nil(t, bankAccount.LimitBalance, "limit should not be set")
+		assert.Equal(t, int64(2000), bankAccount.AvailableBalance, "available should be used as is")
+	})
+
+	t.Run("loan", func(t *testing.T) {
+		bankAccount := BankAccount{
+			Type: LoanBankAccountType,
+		}
+
+		assert.True(t, bankAccount.SetBalances(0, 100000, 0), "balances should have changed")
+		require.NotNil(t, bankAccount.OriginalBalance, "original balance should be set")
+		assert.Equal(t, int64(100000), *bankAccount.OriginalBalance, "original balance should match")
+
+		assert.True(t, bankAccount.SetBalances(0, 95000, 0), "balances should have changed")
+		assert.Equal(t, int64(100000), *bankAccount.OriginalBalance, "original balance should not decrease")
+		assert.Equal(t, int64(95000), bankAccount.CurrentBalance, "current balance should match")
+	})
+}
+
+func TestBankAccount_SetStatementBalance(t *testing.T) {
+	t.Run("credit", func(t *testing.T) {
+		bankAccount := BankAccount{
+			Type: CreditBankAccountType,
+		}
+
+		assert.True(t, bankAccount.SetStatementBalance(2500), "statement balance should have changed")
+		require.NotNil(t, bankAccount.StatementBalance, "statement balance should be set")
+		assert.Equal(t, int64(2500), *bankAccount.StatementBalance, "statement balance should match")
+		assert.False(t, bankAccount.SetStatementBalance(2500), "statement balance should not have changed")
+
+		assert.True(t, bankAccount.SetStatementBalance(0), "a paid off statement should still be recorded")
+		assert.Equal(t, int64(0), *bankAccount.StatementBalance, "statement balance should be zero")
+	})
+
+	t.Run("depository", func(t *testing.T) {
+		bankAccount := BankAccount{
+			Type: DepositoryBankAccountType,
+		}
+
+		assert.False(t, bankAccount.SetStatementBalance(2500), "depository accounts do not have statements")
+		assert.Nil(t, bankAccount.StatementBalance, "statement balance should not be set")
+	})
+}
@@ -2,12 +2,40 @@ package models
 
 import "time"
 
+type BetaStatus string
+
+const (
+	BetaStatusUnused  BetaStatus = "unused"
+	BetaStatusUsed    BetaStatus = "used"
+	BetaStatusExpired BetaStatus = "expired"
+	BetaStatusRevoked BetaStatus = "revoked"
+)
+
 type Beta struct {
 	tableName string `pg:"betas"`
 
-	BetaID       uint64    `json:"betaId" pg:"beta_id,notnull,pk,type:'bigserial'"`
-	CodeHash     string    `json:"-" pg:"code_hash,notnull,unique"`
-	UsedByUserId *uint64   `json:"usedByUserId" pg:"used_by_user_id,on_delete:CASCADE"`
-	UsedByUser   *User     `json:"-" pg:"rel:has-one,fk:used_by_user_id"`
-	ExpiresAt    time.Time `json:"expiresAt" pg:"expires_at,notnull"`
+	BetaID          uint64     `json:"betaId" pg:"beta_id,notnull,pk,type:'bigserial'"`
+	CodeHash        string     `json:"-" pg:"code_hash,notnull,unique"`
+	UsedByUserId    *uint64    `json:"usedByUserId" pg:"used_by_user_id,on_delete:CASCADE"`
+	UsedByUser      *User      `json:"-" pg:"rel:has-one,fk:used_by_user_id"`
+	CreatedByUserId *uint64    `json:"createdByUserId" pg:"created_by_user_id,on_delete:SET NULL"`
+	CreatedByUser   *User      `json:"-" pg:"rel:has-one,fk:created_by_user_id"`
+	ExpiresAt       time.Time  `json:"expiresAt" pg:"expires_at,notnull"`
+	CreatedAt       time.Time  `json:"createdAt" pg:"created_at,notnull,default:now()"`
+	RevokedAt       *time.Time `json:"revokedAt" pg:"revoked_at"`
+}
+
+// Status returns the state of the beta code at the provided time. A code that has been used will always be considered
+// used, even if it has since expired.
+func (b *Beta) Status(now time.Time) BetaStatus {
+	switch {
+	case b.UsedByUserId != nil:
+		return BetaStatusUsed
+	case b.RevokedAt != nil:
+		return BetaStatusRevoked
+	case !now.Before(b.ExpiresAt):
+		return BetaStatusExpired
+	default:
+		return BetaStatusUnused
+	}
 }
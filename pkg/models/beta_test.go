@@ -0,0 +1,57 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBeta_Status(t *testing.T) {
+	now := time.Date(2021, 9, 5, 12, 0, 0, 0, time.UTC)
+	userId := uint64(1234)
+	revokedAt := now.Add(-1 * time.Hour)
+
+	testCases := []struct {
+		name     string
+		beta     Beta
+		expected BetaStatus
+	}{
+		{
+			name: "unused",
+			beta: Beta{
+				ExpiresAt: now.Add(24 * time.Hour),
+			},
+			expected: BetaStatusUnused,
+		},
+		{
+			name: "expired",
+			beta: Beta{
+				ExpiresAt: now.Add(-24 * time.Hour),
+			},
+			expected: BetaStatusExpired,
+		},
+		{
+			name: "used after expiration",
+			beta: Beta{
+				UsedByUserId: &userId,
+				ExpiresAt:    now.Add(-24 * time.Hour),
+			},
+			expected: BetaStatusUsed,
+		},
+		{
+			name: "revoked",
+			beta: Beta{
+				ExpiresAt: now.Add(24 * time.Hour),
+				RevokedAt: &revokedAt,
+			},
+			expected: BetaStatusRevoked,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, testCase.beta.Status(now))
+		})
+	}
+}
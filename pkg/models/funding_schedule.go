@@ -50,3 +50,13 @@ func (f *FundingSchedule) CalculateNextOccurrence(ctx context.Context, timezone
 
 	return true
 }
+
+// ChangeTimezone will move the next and last occurrence of the funding schedule to midnight in the new timezone. The
+// occurrences stay on the same calendar day that they were on in the previous timezone.
+func (f *FundingSchedule) ChangeTimezone(previous, timezone *time.Location) {
+	f.NextOccurrence = util.MidnightInLocal(f.NextOccurrence.In(previous), timezone)
+	if f.LastOccurrence != nil {
+		lastOccurrence := util.MidnightInLocal(f.LastOccurrence.In(previous), timezone)
+		f.LastOccurrence = &lastOccurrence
+	}
+}
@@ -57,3 +57,22 @@ func TestFundingSchedule_CalculateNextOccurrence(t *testing.T) {
 		assert.Equal(t, originalOccurrence.Unix(), fundingSchedule.NextOccurrence.Unix(), "next occurrence should not have changed")
 	})
 }
+
+func TestFundingSchedule_ChangeTimezone(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err, "must load chicago timezone")
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err, "must load tokyo timezone")
+
+	lastOccurrence := time.Date(2021, 9, 1, 0, 0, 0, 0, chicago)
+	fundingSchedule := FundingSchedule{
+		Name:           "Payday",
+		LastOccurrence: &lastOccurrence,
+		NextOccurrence: time.Date(2021, 9, 15, 0, 0, 0, 0, chicago).UTC(),
+	}
+
+	fundingSchedule.ChangeTimezone(chicago, tokyo)
+	assert.Equal(t, time.Date(2021, 9, 15, 0, 0, 0, 0, tokyo).Unix(), fundingSchedule.NextOccurrence.Unix(), "next occurrence should be the same day in the new timezone")
+	require.NotNil(t, fundingSchedule.LastOccurrence, "last occurrence should not be removed")
+	assert.Equal(t, time.Date(2021, 9, 1, 0, 0, 0, 0, tokyo).Unix(), fundingSchedule.LastOccurrence.Unix(), "last occurrence should be the same day in the new timezone")
+}
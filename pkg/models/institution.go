@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type InstitutionStatus string
 
 const (
@@ -18,4 +20,11 @@ type Institution struct {
 	URL                *string  `json:"url" pg:"url"`
 	PrimaryColor       *string  `json:"primaryColor" pg:"primary_color"`
 	Logo               *string  `json:"logo" pg:"logo"`
+	// Status is the overall health of the institution for the products that we use. This will be nil if we have not
+	// retrieved the status of the institution yet.
+	Status *InstitutionStatus `json:"status" pg:"status"`
+	// ProductStatuses is the health of each of the products that the institution supports, keyed by the name of the
+	// product.
+	ProductStatuses map[string]InstitutionStatus `json:"productStatuses" pg:"product_statuses,type:jsonb"`
+	UpdatedAt       *time.Time                   `json:"updatedAt" pg:"updated_at"`
 }
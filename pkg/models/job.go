@@ -14,4 +14,18 @@ type Job struct {
 	StartedAt  *time.Time             `json:"startedAt" pg:"started_at"`
 	FinishedAt *time.Time             `json:"finishedAt" pg:"finished_at"`
 	Retries    int                    `json:"retries" pg:"retries,use_zero"`
+	Error      *string                `json:"error" pg:"error"`
+	// Stack is the stack trace of the most recent failure (if there is one). It is not returned to the client.
+	Stack    *string      `json:"-" pg:"stack"`
+	Attempts []JobAttempt `json:"attempts" pg:"attempts,type:jsonb"`
+	// DeadAt is set once a job has failed too many times and will no longer be retried automatically.
+	DeadAt *time.Time `json:"deadAt" pg:"dead_at"`
+}
+
+// JobAttempt is a single execution of a job, jobs that are retried will have one attempt recorded for each run.
+type JobAttempt struct {
+	Attempt    int       `json:"attempt"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Error      *string   `json:"error"`
 }
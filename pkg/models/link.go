@@ -25,6 +25,9 @@ type Link struct {
 	PlaidLink             *PlaidLink   `json:"-" pg:"rel:has-one"`
 	LinkStatus            LinkStatus   `json:"linkStatus" pg:"link_status,notnull,default:0"`
 	ErrorCode             *string      `json:"errorCode,omitempty" pg:"error_code"`
+	ErrorType             *string      `json:"errorType,omitempty" pg:"error_type"`
+	ErrorMessage          *string      `json:"errorMessage,omitempty" pg:"error_message"`
+	NewAccountsAvailable  bool         `json:"newAccountsAvailable" pg:"new_accounts_available,notnull,use_zero"`
 	ExpirationDate        *time.Time   `json:"expirationDate" pg:"expiration_date"`
 	InstitutionId         *uint64      `json:"institutionId" pg:"institution_id,on_delete:SET NULL"`
 	Institution           *Institution `json:"institution,omitempty" pg:"rel:has-one"`
@@ -38,5 +41,9 @@ type Link struct {
 	UpdatedByUser         *User        `json:"updatedByUser,omitempty" pg:"rel:has-one,fk:updated_by_user_id"`
 	LastSuccessfulUpdate  *time.Time   `json:"lastSuccessfulUpdate" pg:"last_successful_update"`
 
+	// SimpleFINLink is only present for links that are synced through a SimpleFIN Bridge.
+	SimpleFINLinkId *uint64        `json:"-" pg:"simplefin_link_id,on_delete:SET NULL"`
+	SimpleFINLink   *SimpleFINLink `json:"-" pg:"rel:has-one,fk:simplefin_link_id"`
+
 	BankAccounts []BankAccount `json:"-" pg:"rel:has-many"`
 }
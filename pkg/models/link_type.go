@@ -7,4 +7,6 @@ const (
 	UnknownLinkType LinkType = iota
 	PlaidLinkType
 	ManualLinkType
+	// SimpleFINLinkType links are synced through a SimpleFIN Bridge rather than Plaid.
+	SimpleFINLinkType
 )
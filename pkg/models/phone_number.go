@@ -21,15 +21,51 @@ type PhoneNumber struct {
 	number phonenumbers.PhoneNumber
 }
 
+// ParsePhoneNumber parses the provided phone number, numbers without a country code are assumed to be US numbers.
+// Numbers that include a country code are kept as is, and are always stored and serialized in E.164 format so
+// that the country code is never lost.
+func ParsePhoneNumber(input string) (*PhoneNumber, error) {
+	number, err := phonenumbers.Parse(input, "US")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse phone number")
+	}
+
+	if !phonenumbers.IsValidNumber(number) {
+		return nil, errors.New("phone number is not valid")
+	}
+
+	return &PhoneNumber{
+		number: *number,
+	}, nil
+}
+
 func (p *PhoneNumber) E164() string {
 	return phonenumbers.Format(&p.number, phonenumbers.E164)
 }
 
+// parseStoredPhoneNumber parses a phone number that was previously serialized. Phone numbers are serialized in
+// E.164 format, which always includes the country code; so no default region is used for those. Numbers that were
+// stored before we kept the country code were always US numbers in the national format, so those still fall back
+// to the US region.
+func parseStoredPhoneNumber(input string) (*phonenumbers.PhoneNumber, error) {
+	region := ""
+	if !strings.HasPrefix(input, "+") {
+		region = "US"
+	}
+
+	number, err := phonenumbers.Parse(input, region)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse phone number")
+	}
+
+	return number, nil
+}
+
 func (p *PhoneNumber) UnmarshalJSON(bytes []byte) error {
 	str := strings.Trim(string(bytes), `"`)
-	number, err := phonenumbers.Parse(str, "US")
+	number, err := parseStoredPhoneNumber(str)
 	if err != nil {
-		return errors.Wrap(err, "failed to parse phone number")
+		return err
 	}
 
 	*p = PhoneNumber{
@@ -39,16 +75,14 @@ func (p *PhoneNumber) UnmarshalJSON(bytes []byte) error {
 }
 
 func (p *PhoneNumber) MarshalJSON() ([]byte, error) {
-	format := phonenumbers.Format(&p.number, phonenumbers.NATIONAL)
-	return []byte(fmt.Sprintf(`"%s"`, format)), nil
+	return []byte(fmt.Sprintf(`"%s"`, p.E164())), nil
 }
 
 func (p *PhoneNumber) AppendValue(b []byte, flags int) ([]byte, error) {
 	if flags == 1 {
 		b = append(b, '\'')
 	}
-	format := phonenumbers.Format(&p.number, phonenumbers.NATIONAL)
-	b = append(b, format...)
+	b = append(b, p.E164()...)
 	if flags == 1 {
 		b = append(b, '\'')
 	}
@@ -65,9 +99,9 @@ func (p *PhoneNumber) ScanValue(rd types.Reader, n int) error {
 		return err
 	}
 
-	number, err := phonenumbers.Parse(string(tmp), "US")
+	number, err := parseStoredPhoneNumber(string(tmp))
 	if err != nil {
-		return errors.Wrap(err, "failed to parse phone number")
+		return err
 	}
 
 	*p = PhoneNumber{
@@ -1,11 +1,64 @@
 package models
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
 	"github.com/nyaruka/phonenumbers"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"testing"
 )
 
+// phoneNumberReader is a minimal types.Reader so that we can feed the value produced by AppendValue back into
+// ScanValue without needing a database.
+type phoneNumberReader struct {
+	*bytes.Reader
+}
+
+func newPhoneNumberReader(b []byte) *phoneNumberReader {
+	return &phoneNumberReader{
+		Reader: bytes.NewReader(b),
+	}
+}
+
+func (r *phoneNumberReader) Buffered() int {
+	return r.Len()
+}
+
+func (r *phoneNumberReader) Bytes() []byte {
+	b, _ := r.ReadFull()
+	return b
+}
+
+func (r *phoneNumberReader) ReadSlice(delim byte) ([]byte, error) {
+	var b []byte
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return b, err
+		}
+		b = append(b, c)
+		if c == delim {
+			return b, nil
+		}
+	}
+}
+
+func (r *phoneNumberReader) Discard(n int) (int, error) {
+	discarded, err := io.CopyN(io.Discard, r, int64(n))
+	return int(discarded), err
+}
+
+func (r *phoneNumberReader) ReadFull() ([]byte, error) {
+	return io.ReadAll(r)
+}
+
+func (r *phoneNumberReader) ReadFullTemp() ([]byte, error) {
+	return r.ReadFull()
+}
+
 func mustParsePhoneNumber(t *testing.T, number string) {
 	num, err := phonenumbers.Parse(number, "US")
 	require.NoError(t, err, "`%s` should have parsed successfully", number)
@@ -23,3 +76,66 @@ func TestParsePhoneNumberLibrary(t *testing.T) {
 		mustParsePhoneNumber(t, number)
 	}
 }
+
+func TestParsePhoneNumber(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		number, err := ParsePhoneNumber("(612) 555-0123")
+		assert.NoError(t, err, "should parse valid phone number")
+		require.NotNil(t, number, "phone number must be returned")
+		assert.Equal(t, "+16125550123", number.E164())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		number, err := ParsePhoneNumber("612-123-5423")
+		assert.EqualError(t, err, "phone number is not valid")
+		assert.Nil(t, number)
+	})
+
+	t.Run("garbage", func(t *testing.T) {
+		number, err := ParsePhoneNumber("not a phone number")
+		assert.Error(t, err, "should not parse garbage")
+		assert.Nil(t, number)
+	})
+}
+
+func TestPhoneNumber_RoundTrip(t *testing.T) {
+	numbers := map[string]string{
+		"(612) 555-0123":   "+16125550123",
+		"+44 20 7946 0958": "+442079460958",
+		"+49 30 901820":    "+4930901820",
+	}
+
+	for input, expected := range numbers {
+		t.Run(input, func(t *testing.T) {
+			number, err := ParsePhoneNumber(input)
+			require.NoError(t, err, "must parse phone number")
+			require.Equal(t, expected, number.E164(), "should parse the correct number")
+
+			t.Run("database", func(t *testing.T) {
+				value, err := number.AppendValue(nil, 0)
+				require.NoError(t, err, "must append value")
+				assert.Equal(t, expected, string(value), "phone number should be stored in E.164 format")
+
+				var scanned PhoneNumber
+				require.NoError(t, scanned.ScanValue(newPhoneNumberReader(value), len(value)), "must scan value")
+				assert.Equal(t, expected, scanned.E164(), "scanned phone number should match")
+			})
+
+			t.Run("json", func(t *testing.T) {
+				encoded, err := json.Marshal(number)
+				require.NoError(t, err, "must marshal phone number")
+
+				var decoded PhoneNumber
+				require.NoError(t, json.Unmarshal(encoded, &decoded), "must unmarshal phone number")
+				assert.Equal(t, expected, decoded.E164(), "decoded phone number should match")
+			})
+		})
+	}
+
+	t.Run("legacy national format", func(t *testing.T) {
+		value := []byte("(612) 555-0123")
+		var scanned PhoneNumber
+		require.NoError(t, scanned.ScanValue(newPhoneNumberReader(value), len(value)), "must scan legacy value")
+		assert.Equal(t, "+16125550123", scanned.E164(), "legacy numbers should be treated as US numbers")
+	})
+}
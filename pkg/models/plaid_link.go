@@ -12,4 +12,7 @@ type PlaidLink struct {
 	WebhookUrl      string   `json:"-" pg:"webhook_url"`
 	InstitutionId   string   `json:"-" pg:"institution_id"`
 	InstitutionName string   `json:"-" pg:"institution_name"`
+	// TransactionsCursor is the cursor returned by Plaid's transactions sync endpoint. It is only updated once all of
+	// the changes from a sync have been applied, this way a failed sync will be retried from the same point.
+	TransactionsCursor *string `json:"-" pg:"transactions_cursor"`
 }
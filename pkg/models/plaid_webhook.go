@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// PlaidWebhook is a record of a webhook that we have received from Plaid. Every webhook is stored before it is
+// processed, along with the result of processing it, so that failures can be debugged later.
+type PlaidWebhook struct {
+	tableName string `pg:"plaid_webhooks"`
+
+	PlaidWebhookId uint64                 `json:"plaidWebhookId" pg:"plaid_webhook_id,notnull,pk,type:'bigserial'"`
+	ItemId         string                 `json:"itemId" pg:"item_id,notnull"`
+	AccountId      *uint64                `json:"accountId" pg:"account_id,on_delete:CASCADE"`
+	LinkId         *uint64                `json:"linkId" pg:"link_id,on_delete:CASCADE"`
+	WebhookType    string                 `json:"webhookType" pg:"webhook_type,notnull"`
+	WebhookCode    string                 `json:"webhookCode" pg:"webhook_code,notnull"`
+	Body           map[string]interface{} `json:"body" pg:"body,type:jsonb,notnull"`
+	ReceivedAt     time.Time              `json:"receivedAt" pg:"received_at,notnull"`
+	ProcessedAt    *time.Time             `json:"processedAt" pg:"processed_at"`
+	Error          *string                `json:"error" pg:"error"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// SimpleFINLink represents a link that is synced through a SimpleFIN Bridge rather than Plaid. The access URL for the
+// bridge includes the user's credentials, so it is stored by the SimpleFIN secrets provider rather than on this model.
+type SimpleFINLink struct {
+	tableName string `pg:"simplefin_links"`
+
+	SimpleFINLinkId uint64 `json:"-" pg:"simplefin_link_id,notnull,pk,type:'bigserial'"`
+}
@@ -0,0 +1,10 @@
+package models
+
+type SimpleFINToken struct {
+	tableName string `pg:"simplefin_tokens"`
+
+	SimpleFINLinkId uint64   `pg:"simplefin_link_id,notnull"`
+	AccountId       uint64   `pg:"account_id,notnull"`
+	Account         *Account `pg:"rel:has-one"`
+	AccessURL       string   `pg:"access_url,notnull"`
+}
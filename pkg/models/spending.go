@@ -34,6 +34,7 @@ type Spending struct {
 	TargetAmount           int64            `json:"targetAmount" pg:"target_amount,notnull,use_zero"`
 	CurrentAmount          int64            `json:"currentAmount" pg:"current_amount,notnull,use_zero"`
 	UsedAmount             int64            `json:"usedAmount" pg:"used_amount,notnull,use_zero"`
+	Currency               string           `json:"currency" pg:"currency,notnull,default:'USD'"`
 	RecurrenceRule         *Rule            `json:"recurrenceRule" pg:"recurrence_rule,type:'text'" swaggertype:"string"`
 	LastRecurrence         *time.Time       `json:"lastRecurrence" pg:"last_recurrence"`
 	NextRecurrence         time.Time        `json:"nextRecurrence" pg:"next_recurrence,notnull"`
@@ -41,6 +42,13 @@ type Spending struct {
 	IsBehind               bool             `json:"isBehind" pg:"is_behind,notnull,use_zero"`
 	IsPaused               bool             `json:"isPaused" pg:"is_paused,notnull,use_zero"`
 	DateCreated            time.Time        `json:"dateCreated" pg:"date_created,notnull"`
+
+	// Goals are completed once their progress reaches their target amount. Completed goals are archived and no longer
+	// receive contributions. Archived goals are hidden from the spending list unless they are specifically requested,
+	// but are still kept so that their history is not lost.
+	IsComplete    bool       `json:"isComplete" pg:"is_complete,notnull,use_zero"`
+	IsArchived    bool       `json:"isArchived" pg:"is_archived,notnull,use_zero"`
+	DateCompleted *time.Time `json:"dateCompleted" pg:"date_completed"`
 }
 
 func (e Spending) GetProgressAmount() int64 {
@@ -54,6 +62,17 @@ func (e Spending) GetProgressAmount() int64 {
 	}
 }
 
+// ChangeTimezone will move the next and last recurrence of the spending object to midnight in the new timezone. The
+// recurrences stay on the same calendar day that they were on in the previous timezone. The next contribution should
+// be recalculated afterwards.
+func (e *Spending) ChangeTimezone(previous, timezone *time.Location) {
+	e.NextRecurrence = util.MidnightInLocal(e.NextRecurrence.In(previous), timezone)
+	if e.LastRecurrence != nil {
+		lastRecurrence := util.MidnightInLocal(e.LastRecurrence.In(previous), timezone)
+		e.LastRecurrence = &lastRecurrence
+	}
+}
+
 func (e *Spending) CalculateNextContribution(
 	ctx context.Context,
 	accountTimezone string,
@@ -70,6 +89,11 @@ func (e *Spending) CalculateNextContribution(
 		return errors.Wrap(err, "failed to parse account's timezone")
 	}
 
+	if e.SpendingType == SpendingTypeGoal {
+		e.calculateNextGoalContribution(timezone, nextContributionDate, nextContributionRule)
+		return nil
+	}
+
 	// The total needed needs to be calculated differently for goals and expenses. How much expenses need is always a
 	// representation of the target amount minus the current amount allocated to the expense. But goals work a bit
 	// differently because the allocated amount can fluctuate throughout the life of the goal. When a transaction is
@@ -127,3 +151,67 @@ func (e *Spending) CalculateNextContribution(
 	e.NextContributionAmount = perContribution
 	return nil
 }
+
+// calculateNextGoalContribution calculates the contribution for a goal. Goals do not recur, their next recurrence is
+// simply the date the target amount should be reached by. Once the goal's progress reaches the target amount the goal
+// is marked as complete and archived, and will not receive any more contributions.
+func (e *Spending) calculateNextGoalContribution(
+	timezone *time.Location,
+	nextContributionDate time.Time,
+	nextContributionRule *Rule,
+) {
+	e.IsBehind = false
+
+	// If money has been moved out of a completed goal, or its target has been increased, then it needs to be funded
+	// again.
+	e.ResetCompletion()
+
+	progressAmount := e.GetProgressAmount()
+	if e.TargetAmount <= progressAmount && !e.IsComplete {
+		now := time.Now()
+		e.IsComplete = true
+		e.IsArchived = true
+		e.DateCompleted = &now
+	}
+
+	if e.IsComplete {
+		e.NextContributionAmount = 0
+		return
+	}
+
+	needed := e.TargetAmount - progressAmount
+	nextContributionDate = util.MidnightInLocal(nextContributionDate, timezone)
+	targetDate := util.MidnightInLocal(e.NextRecurrence, timezone)
+
+	// If the goal's target date is on or before the next contribution then the rest of the goal needs to be funded by
+	// that contribution. It is only behind if the target date has already been missed.
+	if !nextContributionDate.Before(targetDate) {
+		e.NextContributionAmount = needed
+		e.IsBehind = nextContributionDate.After(targetDate)
+		return
+	}
+
+	// Unlike expenses, a contribution made on the target date of a goal still counts towards the goal.
+	nowInTimezone := time.Now().In(timezone)
+	nextContributionRule.DTStart(nextContributionDate)
+	numberOfContributions := int64(len(nextContributionRule.Between(nowInTimezone, targetDate, true)))
+	if numberOfContributions == 0 {
+		e.NextContributionAmount = needed
+		return
+	}
+
+	e.NextContributionAmount = needed / numberOfContributions
+}
+
+// ResetCompletion will move a completed goal back into an incomplete state if its progress is now below its target
+// amount. This happens when the target amount is increased, or when money is transferred out of the goal. This is
+// called by CalculateNextContribution, but can also be called on its own when contributions are not recalculated.
+func (e *Spending) ResetCompletion() {
+	if e.SpendingType != SpendingTypeGoal || !e.IsComplete || e.GetProgressAmount() >= e.TargetAmount {
+		return
+	}
+
+	e.IsComplete = false
+	e.IsArchived = false
+	e.DateCompleted = nil
+}
@@ -1,8 +1,12 @@
 package models
 
 import (
+	"context"
+	"github.com/monetr/rest-api/pkg/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestSpending_GetProgressAmount(t *testing.T) {
@@ -41,3 +45,118 @@ func TestSpending_GetProgressAmount(t *testing.T) {
 		assert.EqualValues(t, 5000, progress, "progress should be 5000")
 	})
 }
+
+func TestSpending_ChangeTimezone(t *testing.T) {
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err, "must load los angeles timezone")
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err, "must load new york timezone")
+
+	spending := Spending{
+		SpendingType:   SpendingTypeExpense,
+		NextRecurrence: time.Date(2021, 10, 1, 0, 0, 0, 0, losAngeles),
+	}
+
+	spending.ChangeTimezone(losAngeles, newYork)
+	assert.Equal(t, time.Date(2021, 10, 1, 0, 0, 0, 0, newYork).Unix(), spending.NextRecurrence.Unix(), "next recurrence should be the same day in the new timezone")
+	assert.Nil(t, spending.LastRecurrence, "last recurrence should still be nil")
+}
+
+func TestSpending_CalculateNextContribution(t *testing.T) {
+	t.Run("goal by target date", func(t *testing.T) {
+		rule, err := NewRule("FREQ=DAILY")
+		require.NoError(t, err, "must be able to create a rule")
+
+		today := util.MidnightInLocal(time.Now(), time.UTC)
+		goal := Spending{
+			SpendingType:   SpendingTypeGoal,
+			TargetAmount:   10000,
+			CurrentAmount:  0,
+			NextRecurrence: today.AddDate(0, 0, 10),
+		}
+
+		err = goal.CalculateNextContribution(context.Background(), "UTC", today.AddDate(0, 0, 1), rule)
+		assert.NoError(t, err, "should calculate next contribution")
+		assert.EqualValues(t, 1000, goal.NextContributionAmount, "contribution should be split across 10 days")
+		assert.False(t, goal.IsBehind, "goal should not be behind")
+		assert.False(t, goal.IsComplete, "goal should not be complete")
+		assert.Nil(t, goal.RecurrenceRule, "goal should not have gained a recurrence rule")
+	})
+
+	t.Run("goal behind", func(t *testing.T) {
+		rule, err := NewRule("FREQ=WEEKLY")
+		require.NoError(t, err, "must be able to create a rule")
+
+		today := util.MidnightInLocal(time.Now(), time.UTC)
+		goal := Spending{
+			SpendingType:   SpendingTypeGoal,
+			TargetAmount:   10000,
+			CurrentAmount:  2500,
+			UsedAmount:     2500,
+			NextRecurrence: today.AddDate(0, 0, 2),
+		}
+
+		err = goal.CalculateNextContribution(context.Background(), "UTC", today.AddDate(0, 0, 7), rule)
+		assert.NoError(t, err, "should calculate next contribution")
+		assert.EqualValues(t, 5000, goal.NextContributionAmount, "next contribution should be the remainder")
+		assert.True(t, goal.IsBehind, "goal should be behind")
+		assert.Equal(t, today.AddDate(0, 0, 2), goal.NextRecurrence, "target date should not change")
+	})
+
+	t.Run("goal completed", func(t *testing.T) {
+		rule, err := NewRule("FREQ=DAILY")
+		require.NoError(t, err, "must be able to create a rule")
+
+		today := util.MidnightInLocal(time.Now(), time.UTC)
+		goal := Spending{
+			SpendingType:           SpendingTypeGoal,
+			TargetAmount:           10000,
+			CurrentAmount:          6000,
+			UsedAmount:             4000,
+			NextContributionAmount: 1000,
+			NextRecurrence:         today.AddDate(0, 0, 10),
+		}
+
+		err = goal.CalculateNextContribution(context.Background(), "UTC", today.AddDate(0, 0, 1), rule)
+		assert.NoError(t, err, "should calculate next contribution")
+		assert.Zero(t, goal.NextContributionAmount, "completed goal should not receive contributions")
+		assert.True(t, goal.IsComplete, "goal should be complete")
+		assert.True(t, goal.IsArchived, "goal should be archived")
+		assert.NotNil(t, goal.DateCompleted, "goal should have a completion date")
+
+		// Raising the target amount should put the goal back into progress.
+		goal.TargetAmount = 20000
+		goal.ResetCompletion()
+		err = goal.CalculateNextContribution(context.Background(), "UTC", today.AddDate(0, 0, 1), rule)
+		assert.NoError(t, err, "should calculate next contribution")
+		assert.False(t, goal.IsComplete, "goal should no longer be complete")
+		assert.False(t, goal.IsArchived, "goal should no longer be archived")
+		assert.Nil(t, goal.DateCompleted, "goal should no longer have a completion date")
+		assert.EqualValues(t, 1000, goal.NextContributionAmount, "contribution should be split across 10 days")
+	})
+	t.Run("goal reopened by transfer out", func(t *testing.T) {
+		rule, err := NewRule("FREQ=DAILY")
+		require.NoError(t, err, "must be able to create a rule")
+
+		today := util.MidnightInLocal(time.Now(), time.UTC)
+		completed := today.AddDate(0, 0, -1)
+		goal := Spending{
+			SpendingType:   SpendingTypeGoal,
+			TargetAmount:   10000,
+			CurrentAmount:  5000,
+			UsedAmount:     0,
+			NextRecurrence: today.AddDate(0, 0, 10),
+			IsComplete:     true,
+			IsArchived:     true,
+			DateCompleted:  &completed,
+		}
+
+		// Half of the goal's money was transferred out after it was completed, ResetCompletion is not called first.
+		err = goal.CalculateNextContribution(context.Background(), "UTC", today.AddDate(0, 0, 1), rule)
+		assert.NoError(t, err, "should calculate next contribution")
+		assert.False(t, goal.IsComplete, "goal should no longer be complete")
+		assert.False(t, goal.IsArchived, "goal should no longer be archived")
+		assert.Nil(t, goal.DateCompleted, "goal should no longer have a completion date")
+		assert.EqualValues(t, 500, goal.NextContributionAmount, "remaining amount should be split across 10 days")
+	})
+}
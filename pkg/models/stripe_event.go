@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type StripeEventOutcome string
+
+const (
+	StripeEventOutcomePending   StripeEventOutcome = "pending"
+	StripeEventOutcomeProcessed StripeEventOutcome = "processed"
+	StripeEventOutcomeFailed    StripeEventOutcome = "failed"
+)
+
+// StripeEvent is a record of an event that we have received from Stripe. Events are stored before they are processed
+// so that duplicate deliveries can be skipped, and so that an event can be replayed later for debugging.
+type StripeEvent struct {
+	tableName string `pg:"stripe_events"`
+
+	StripeEventId string                 `json:"stripeEventId" pg:"stripe_event_id,notnull,pk"`
+	Type          string                 `json:"type" pg:"type,notnull"`
+	Payload       map[string]interface{} `json:"payload" pg:"payload,type:jsonb,notnull"`
+	// PayloadHash is a SHA-256 hash of the event as it was received. If Stripe delivers an event with the same Id but
+	// a different hash then something is wrong and it is logged.
+	PayloadHash string             `json:"payloadHash" pg:"payload_hash,notnull"`
+	Outcome     StripeEventOutcome `json:"outcome" pg:"outcome,notnull"`
+	Error       *string            `json:"error" pg:"error"`
+	Attempts    int                `json:"attempts" pg:"attempts,notnull,use_zero"`
+	ReceivedAt  time.Time          `json:"receivedAt" pg:"received_at,notnull"`
+	// ClaimedAt is when the event was last claimed for processing. A pending event is only claimed again once its lease
+	// has expired, this way an event that is still being processed is not processed twice.
+	ClaimedAt   time.Time  `json:"claimedAt" pg:"claimed_at,notnull"`
+	ProcessedAt *time.Time `json:"processedAt" pg:"processed_at"`
+}
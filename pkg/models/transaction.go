@@ -15,6 +15,7 @@ type Transaction struct {
 	PlaidTransactionId        string       `json:"-" pg:"plaid_transaction_id,unique:per_bank_account"`
 	PendingPlaidTransactionId *string      `json:"-" pg:"pending_plaid_transaction_id"`
 	Amount                    int64        `json:"amount" pg:"amount,notnull,use_zero"`
+	Currency                  string       `json:"currency" pg:"currency,notnull,default:'USD'"`
 	SpendingId                *uint64      `json:"spendingId" pg:"spending_id,on_delete:SET NULL"`
 	Spending                  *Spending    `json:"spending,omitempty" pg:"rel:has-one"`
 	// SpendingAmount is the amount deducted from the expense this transaction was spent from. This is used when a
@@ -11,4 +11,6 @@ type User struct {
 	FirstName        string   `json:"firstName" pg:"first_name,notnull"`
 	LastName         string   `json:"lastName" pg:"last_name"`
 	StripeCustomerId *string  `json:"-" pg:"stripe_customer_id"`
+	// BetaInviteQuota is the number of beta codes that this user is allowed to create to invite other people.
+	BetaInviteQuota int `json:"-" pg:"beta_invite_quota,notnull,use_zero"`
 }
@@ -0,0 +1,226 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/rest-api/pkg/cache"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrInvalidUnlockToken = errors.New("unlock token is not valid or has expired")
+)
+
+// lockoutMemory is the minimum amount of time that we will remember failures and previous lockouts for a key. This is
+// what makes lockouts progressive, if a key is locked out again within this time then the lockout will be longer.
+const lockoutMemory = 24 * time.Hour
+
+// Lockout keeps track of consecutive failures for a key, and will lock the key out once too many failures have
+// happened. Each time a key is locked out the duration of the lockout is doubled, up to a maximum.
+type Lockout interface {
+	// Check returns the remaining amount of time that the provided key is locked out for. If the key is not locked out
+	// then 0 is returned.
+	Check(ctx context.Context, key string) (lockedFor time.Duration, err error)
+	// Failure will record a single failure for the provided key. If this failure causes the key to be locked out then
+	// the duration of the lockout is returned.
+	Failure(ctx context.Context, key string) (lockedFor time.Duration, err error)
+	// Reset will clear any failures and lockouts for the provided key. This should be called after a successful
+	// attempt.
+	Reset(ctx context.Context, key string) error
+	// CreateUnlockToken returns a random token that can be used to remove the lockout for the provided key without
+	// waiting for it to expire. This token is meant to be sent to the owner of the key, like in an email.
+	CreateUnlockToken(ctx context.Context, key string) (token string, err error)
+	// Unlock will remove the lockout for the key associated with the provided unlock token. The token can only be used
+	// once. The key that was unlocked is returned.
+	Unlock(ctx context.Context, token string) (key string, err error)
+}
+
+var (
+	_ Lockout = &progressiveLockout{}
+)
+
+type lockoutState struct {
+	Failures    int   `msgpack:"failures"`
+	Lockouts    int   `msgpack:"lockouts"`
+	LockedUntil int64 `msgpack:"lockedUntil"`
+}
+
+type progressiveLockout struct {
+	log         *logrus.Entry
+	cache       cache.Cache
+	threshold   int
+	duration    time.Duration
+	maxDuration time.Duration
+	clock       func() time.Time
+}
+
+// NewProgressiveLockout returns a lockout that will lock a key out for the provided duration once threshold
+// consecutive failures have been recorded. Every following lockout will double in length until maxDuration is
+// reached.
+func NewProgressiveLockout(
+	log *logrus.Entry,
+	client cache.Cache,
+	threshold int,
+	duration, maxDuration time.Duration,
+) Lockout {
+	if maxDuration < duration {
+		maxDuration = duration
+	}
+
+	return &progressiveLockout{
+		log:         log,
+		cache:       client,
+		threshold:   threshold,
+		duration:    duration,
+		maxDuration: maxDuration,
+		clock:       time.Now,
+	}
+}
+
+func (p *progressiveLockout) getKey(key string) string {
+	return fmt.Sprintf("ratelimit:lockout:%s", key)
+}
+
+func (p *progressiveLockout) getUnlockKey(token string) string {
+	return fmt.Sprintf("ratelimit:unlock:%s", token)
+}
+
+func (p *progressiveLockout) getState(ctx context.Context, key string) (lockoutState, error) {
+	var state lockoutState
+	if err := p.cache.GetEz(ctx, p.getKey(key), &state); err != nil {
+		return state, errors.Wrap(err, "failed to retrieve lockout state")
+	}
+
+	return state, nil
+}
+
+func (p *progressiveLockout) Check(ctx context.Context, key string) (lockedFor time.Duration, err error) {
+	span := sentry.StartSpan(ctx, "Lockout - Check")
+	defer span.Finish()
+
+	if p.threshold <= 0 {
+		return 0, nil
+	}
+
+	state, err := p.getState(span.Context(), key)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return 0, err
+	}
+
+	if remaining := time.Unix(0, state.LockedUntil).Sub(p.clock()); remaining > 0 {
+		span.Status = sentry.SpanStatusResourceExhausted
+		return remaining, nil
+	}
+
+	span.Status = sentry.SpanStatusOK
+	return 0, nil
+}
+
+func (p *progressiveLockout) Failure(ctx context.Context, key string) (lockedFor time.Duration, err error) {
+	span := sentry.StartSpan(ctx, "Lockout - Failure")
+	defer span.Finish()
+
+	if p.threshold <= 0 {
+		return 0, nil
+	}
+
+	state, err := p.getState(span.Context(), key)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return 0, err
+	}
+
+	now := p.clock()
+	state.Failures++
+	if state.Failures >= p.threshold {
+		state.Failures = 0
+		state.Lockouts++
+
+		lockedFor = p.duration
+		for i := 1; i < state.Lockouts && lockedFor < p.maxDuration; i++ {
+			lockedFor *= 2
+		}
+		if lockedFor > p.maxDuration {
+			lockedFor = p.maxDuration
+		}
+
+		state.LockedUntil = now.Add(lockedFor).UnixNano()
+		p.log.WithContext(span.Context()).WithFields(logrus.Fields{
+			"lockouts":  state.Lockouts,
+			"lockedFor": lockedFor.String(),
+		}).Info("too many failed attempts, key has been locked out")
+	}
+
+	lifetime := lockoutMemory
+	if p.maxDuration > lifetime {
+		lifetime = p.maxDuration
+	}
+
+	if err = p.cache.SetEzTTL(span.Context(), p.getKey(key), state, lifetime); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return 0, errors.Wrap(err, "failed to store lockout state")
+	}
+
+	span.Status = sentry.SpanStatusOK
+	return lockedFor, nil
+}
+
+func (p *progressiveLockout) Reset(ctx context.Context, key string) error {
+	span := sentry.StartSpan(ctx, "Lockout - Reset")
+	defer span.Finish()
+
+	return errors.Wrap(p.cache.Delete(span.Context(), p.getKey(key)), "failed to reset lockout")
+}
+
+func (p *progressiveLockout) CreateUnlockToken(ctx context.Context, key string) (token string, err error) {
+	span := sentry.StartSpan(ctx, "Lockout - CreateUnlockToken")
+	defer span.Finish()
+
+	data := make([]byte, 32)
+	if _, err = rand.Read(data); err != nil {
+		return "", errors.Wrap(err, "failed to generate unlock token")
+	}
+
+	token = hex.EncodeToString(data)
+	if err = p.cache.SetTTL(span.Context(), p.getUnlockKey(token), []byte(key), p.maxDuration); err != nil {
+		return "", errors.Wrap(err, "failed to store unlock token")
+	}
+
+	return token, nil
+}
+
+func (p *progressiveLockout) Unlock(ctx context.Context, token string) (key string, err error) {
+	span := sentry.StartSpan(ctx, "Lockout - Unlock")
+	defer span.Finish()
+
+	if token == "" {
+		return "", errors.WithStack(ErrInvalidUnlockToken)
+	}
+
+	data, err := p.cache.Get(span.Context(), p.getUnlockKey(token))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to retrieve unlock token")
+	}
+
+	if len(data) == 0 {
+		return "", errors.WithStack(ErrInvalidUnlockToken)
+	}
+
+	key = string(data)
+	if err = p.cache.Delete(span.Context(), p.getUnlockKey(token)); err != nil {
+		return "", errors.Wrap(err, "failed to remove unlock token")
+	}
+
+	if err = p.Reset(span.Context(), key); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
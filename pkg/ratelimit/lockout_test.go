@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressiveLockout_Failure(t *testing.T) {
+	t.Run("progressive", func(t *testing.T) {
+		now := time.Now()
+		lockout := NewProgressiveLockout(testutils.GetLog(t), NewTestCache(t), 3, time.Minute, 3*time.Minute).(*progressiveLockout)
+		lockout.clock = func() time.Time {
+			return now
+		}
+
+		expected := []time.Duration{
+			time.Minute,
+			2 * time.Minute,
+			3 * time.Minute, // Capped at the max duration.
+		}
+		for _, expectedDuration := range expected {
+			for i := 0; i < 2; i++ {
+				lockedFor, err := lockout.Failure(context.Background(), "test")
+				assert.NoError(t, err, "must record failure")
+				assert.Zero(t, lockedFor, "should not be locked out yet")
+			}
+
+			lockedFor, err := lockout.Failure(context.Background(), "test")
+			assert.NoError(t, err, "must record failure")
+			assert.Equal(t, expectedDuration, lockedFor, "should be locked out")
+
+			lockedFor, err = lockout.Check(context.Background(), "test")
+			assert.NoError(t, err, "must check lockout")
+			assert.Equal(t, expectedDuration, lockedFor, "should still be locked out")
+
+			now = now.Add(lockedFor)
+			lockedFor, err = lockout.Check(context.Background(), "test")
+			assert.NoError(t, err, "must check lockout")
+			assert.Zero(t, lockedFor, "lockout should have expired")
+		}
+	})
+
+	t.Run("unlock", func(t *testing.T) {
+		lockout := NewProgressiveLockout(testutils.GetLog(t), NewTestCache(t), 1, time.Minute, time.Hour)
+
+		lockedFor, err := lockout.Failure(context.Background(), "test")
+		assert.NoError(t, err, "must record failure")
+		assert.Equal(t, time.Minute, lockedFor, "should be locked out")
+
+		token, err := lockout.CreateUnlockToken(context.Background(), "test")
+		assert.NoError(t, err, "must create unlock token")
+		assert.NotEmpty(t, token, "unlock token must not be empty")
+
+		key, err := lockout.Unlock(context.Background(), token)
+		assert.NoError(t, err, "must unlock")
+		assert.Equal(t, "test", key, "should unlock the locked out key")
+
+		lockedFor, err = lockout.Check(context.Background(), "test")
+		assert.NoError(t, err, "must check lockout")
+		assert.Zero(t, lockedFor, "should no longer be locked out")
+
+		_, err = lockout.Unlock(context.Background(), token)
+		assert.Equal(t, ErrInvalidUnlockToken, errors.Cause(err), "token should only be usable once")
+	})
+}
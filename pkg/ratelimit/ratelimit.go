@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/rest-api/pkg/cache"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Limiter keeps track of attempts made against a key within a sliding window of time.
+type Limiter interface {
+	// Attempt will record a single attempt for the provided key. If the number of attempts within the window has
+	// already reached the limit then the attempt is not recorded, allowed will be false and retryAfter will be the
+	// amount of time until another attempt can be made.
+	Attempt(ctx context.Context, key string, limit int, window time.Duration) (allowed bool, retryAfter time.Duration, err error)
+	// Reset will remove all of the attempts recorded for the provided key.
+	Reset(ctx context.Context, key string) error
+}
+
+var (
+	_ Limiter = &slidingWindowLimiter{}
+)
+
+type slidingWindowLimiter struct {
+	log   *logrus.Entry
+	cache cache.Cache
+	clock func() time.Time
+}
+
+// NewSlidingWindowLimiter returns a limiter that stores a log of recent attempts for each key in the provided cache.
+// Reading and writing the log is not atomic, so under heavy concurrency a few more attempts than the limit may be let
+// through. That is an acceptable trade-off for throttling authentication attempts.
+func NewSlidingWindowLimiter(log *logrus.Entry, client cache.Cache) Limiter {
+	return &slidingWindowLimiter{
+		log:   log,
+		cache: client,
+		clock: time.Now,
+	}
+}
+
+func (s *slidingWindowLimiter) getKey(key string) string {
+	return fmt.Sprintf("ratelimit:window:%s", key)
+}
+
+func (s *slidingWindowLimiter) Attempt(ctx context.Context, key string, limit int, window time.Duration) (allowed bool, retryAfter time.Duration, err error) {
+	span := sentry.StartSpan(ctx, "RateLimit - Attempt")
+	defer span.Finish()
+
+	if limit <= 0 || window <= 0 {
+		// A limit that is not configured is treated as unlimited.
+		return true, 0, nil
+	}
+
+	now := s.clock()
+	cacheKey := s.getKey(key)
+
+	var attempts []int64
+	if err = s.cache.GetEz(span.Context(), cacheKey, &attempts); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return false, 0, errors.Wrap(err, "failed to retrieve rate limit attempts")
+	}
+
+	// Drop any attempts that are no longer within the window.
+	windowStart := now.Add(-window).UnixNano()
+	recentAttempts := make([]int64, 0, len(attempts)+1)
+	for _, attempt := range attempts {
+		if attempt > windowStart {
+			recentAttempts = append(recentAttempts, attempt)
+		}
+	}
+
+	if len(recentAttempts) >= limit {
+		// The oldest attempt within the window is the one that needs to expire before another attempt is allowed.
+		oldest := time.Unix(0, recentAttempts[len(recentAttempts)-limit])
+		retryAfter = oldest.Add(window).Sub(now)
+		span.Status = sentry.SpanStatusResourceExhausted
+		return false, retryAfter, nil
+	}
+
+	recentAttempts = append(recentAttempts, now.UnixNano())
+	if err = s.cache.SetEzTTL(span.Context(), cacheKey, recentAttempts, window); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return false, 0, errors.Wrap(err, "failed to store rate limit attempts")
+	}
+
+	span.Status = sentry.SpanStatusOK
+	return true, 0, nil
+}
+
+func (s *slidingWindowLimiter) Reset(ctx context.Context, key string) error {
+	span := sentry.StartSpan(ctx, "RateLimit - Reset")
+	defer span.Finish()
+
+	return errors.Wrap(s.cache.Delete(span.Context(), s.getKey(key)), "failed to reset rate limit")
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+	"github.com/monetr/rest-api/pkg/cache"
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func NewTestCache(t *testing.T) cache.Cache {
+	miniRedis := miniredis.NewMiniRedis()
+	require.NoError(t, miniRedis.Start())
+	redisPool := &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", miniRedis.Server().Addr().String())
+		},
+	}
+
+	t.Cleanup(func() {
+		require.NoError(t, redisPool.Close(), "must close miniredis pool successfully")
+		miniRedis.Close()
+	})
+
+	return cache.NewCache(testutils.GetLog(t), redisPool)
+}
+
+func TestSlidingWindowLimiter_Attempt(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		now := time.Now()
+		limiter := NewSlidingWindowLimiter(testutils.GetLog(t), NewTestCache(t)).(*slidingWindowLimiter)
+		limiter.clock = func() time.Time {
+			return now
+		}
+
+		for i := 0; i < 3; i++ {
+			allowed, retryAfter, err := limiter.Attempt(context.Background(), "test", 3, time.Minute)
+			assert.NoError(t, err, "must record attempt")
+			assert.True(t, allowed, "attempt should be allowed")
+			assert.Zero(t, retryAfter, "retry after should not be present")
+			now = now.Add(10 * time.Second)
+		}
+
+		allowed, retryAfter, err := limiter.Attempt(context.Background(), "test", 3, time.Minute)
+		assert.NoError(t, err, "must check attempt")
+		assert.False(t, allowed, "attempt should not be allowed")
+		assert.Equal(t, 30*time.Second, retryAfter, "should be able to retry once the first attempt leaves the window")
+
+		// Once the first attempt has left the window, we should be able to try again.
+		now = now.Add(retryAfter + time.Second)
+		allowed, _, err = limiter.Attempt(context.Background(), "test", 3, time.Minute)
+		assert.NoError(t, err, "must record attempt")
+		assert.True(t, allowed, "attempt should be allowed")
+
+		// But other keys should not be impacted.
+		allowed, _, err = limiter.Attempt(context.Background(), "other", 3, time.Minute)
+		assert.NoError(t, err, "must record attempt")
+		assert.True(t, allowed, "attempt for another key should be allowed")
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		limiter := NewSlidingWindowLimiter(testutils.GetLog(t), NewTestCache(t))
+
+		allowed, _, err := limiter.Attempt(context.Background(), "test", 1, time.Minute)
+		assert.NoError(t, err, "must record attempt")
+		assert.True(t, allowed, "attempt should be allowed")
+
+		allowed, _, err = limiter.Attempt(context.Background(), "test", 1, time.Minute)
+		assert.NoError(t, err, "must check attempt")
+		assert.False(t, allowed, "attempt should not be allowed")
+
+		assert.NoError(t, limiter.Reset(context.Background(), "test"), "must reset limiter")
+
+		allowed, _, err = limiter.Attempt(context.Background(), "test", 1, time.Minute)
+		assert.NoError(t, err, "must record attempt")
+		assert.True(t, allowed, "attempt should be allowed after reset")
+	})
+}
@@ -31,3 +31,23 @@ func (r *repositoryBase) GetAccount(ctx context.Context) (*models.Account, error
 
 	return r.account, nil
 }
+
+func (r *repositoryBase) GetAccountLogins(ctx context.Context) ([]models.Login, error) {
+	span := sentry.StartSpan(ctx, "GetAccountLogins")
+	defer span.Finish()
+
+	logins := make([]models.Login, 0)
+	err := r.txn.ModelContext(span.Context(), &logins).
+		Join(`INNER JOIN "users" AS "user"`).
+		JoinOn(`"user"."login_id" = "login"."login_id"`).
+		Where(`"user"."account_id" = ?`, r.AccountId()).
+		Select(&logins)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve logins for account")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return logins, nil
+}
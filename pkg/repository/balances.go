@@ -16,6 +16,12 @@ type Balances struct {
 	Safe          int64  `json:"safe" pg:"safe"`
 	Expenses      int64  `json:"expenses" pg:"expenses"`
 	Goals         int64  `json:"goals" pg:"goals"`
+	// CreditReserve is the amount owed on credit accounts that are paid from this bank account. It is subtracted from
+	// the safe to spend balance.
+	CreditReserve int64  `json:"creditReserve" pg:"credit_reserve"`
+	Limit         *int64 `json:"limit" pg:"limit"`
+	Statement     *int64 `json:"statement" pg:"statement"`
+	PaidOff       *int64 `json:"paidOff" pg:"paid_off"`
 }
 
 func (r *repositoryBase) GetBalances(ctx context.Context, bankAccountId uint64) (*Balances, error) {
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryBase_GetBalances(t *testing.T) {
+	t.Run("credit and loan accounts", func(t *testing.T) {
+		repo := GetTestAuthenticatedRepository(t)
+
+		links, err := repo.GetLinks(context.Background())
+		require.NoError(t, err, "must retrieve links")
+		require.NotEmpty(t, links, "must have a link to add bank accounts to")
+
+		linkId := links[0].LinkId
+		limit, statement, original := int64(500000), int64(150000), int64(1000000)
+		bankAccounts := []models.BankAccount{
+			{
+				LinkId:           linkId,
+				AvailableBalance: 400000,
+				CurrentBalance:   400000,
+				Name:             "Checking",
+				Type:             models.DepositoryBankAccountType,
+				SubType:          models.CheckingBankAccountSubType,
+				LastUpdated:      time.Now(),
+			},
+			{
+				LinkId:           linkId,
+				AvailableBalance: 250000,
+				CurrentBalance:   250000,
+				LimitBalance:     &limit,
+				StatementBalance: &statement,
+				Name:             "Credit Card",
+				Type:             models.CreditBankAccountType,
+				SubType:          models.CreditCartBankAccountSubType,
+				LastUpdated:      time.Now(),
+			},
+			{
+				LinkId:           linkId,
+				AvailableBalance: 0,
+				CurrentBalance:   800000,
+				OriginalBalance:  &original,
+				Name:             "Car Loan",
+				Type:             models.LoanBankAccountType,
+				SubType:          models.AutoBankAccountSubType,
+				LastUpdated:      time.Now(),
+			},
+		}
+		require.NoError(t, repo.CreateBankAccounts(context.Background(), bankAccounts...), "must create bank accounts")
+
+		checking, creditCard, loan := bankAccounts[0], bankAccounts[1], bankAccounts[2]
+		require.NoError(t, repo.UpdatePaymentBankAccount(
+			context.Background(),
+			creditCard.BankAccountId,
+			&checking.BankAccountId,
+		), "must set the payment account for the credit card")
+
+		{ // The amount owed on the card should be reserved in the checking account.
+			balances, err := repo.GetBalances(context.Background(), checking.BankAccountId)
+			require.NoError(t, err, "must retrieve checking balances")
+			assert.EqualValues(t, 250000, balances.CreditReserve, "credit card balance should be reserved")
+			assert.EqualValues(t, 150000, balances.Safe, "credit reserve should be subtracted from safe to spend")
+			assert.Nil(t, balances.Limit, "checking should not have a limit")
+			assert.Nil(t, balances.Statement, "checking should not have a statement")
+			assert.Nil(t, balances.PaidOff, "checking should not have a paid off amount")
+		}
+
+		{ // The credit card should report its limit and statement balance.
+			balances, err := repo.GetBalances(context.Background(), creditCard.BankAccountId)
+			require.NoError(t, err, "must retrieve credit card balances")
+			require.NotNil(t, balances.Limit, "credit card should have a limit")
+			assert.EqualValues(t, limit, *balances.Limit, "limit should match")
+			require.NotNil(t, balances.Statement, "credit card should have a statement balance")
+			assert.EqualValues(t, statement, *balances.Statement, "statement balance should match")
+			assert.Zero(t, balances.CreditReserve, "credit card should not have a reserve itself")
+		}
+
+		{ // The loan should report how much of it has been paid off.
+			balances, err := repo.GetBalances(context.Background(), loan.BankAccountId)
+			require.NoError(t, err, "must retrieve loan balances")
+			require.NotNil(t, balances.PaidOff, "loan should have a paid off amount")
+			assert.EqualValues(t, 200000, *balances.PaidOff, "paid off should be the original minus the current balance")
+		}
+
+		// Once the card has been paid off the balance drops to zero, and nothing should be reserved anymore.
+		creditCard.CurrentBalance = 0
+		creditCard.AvailableBalance = limit
+		require.NoError(t, repo.UpdateBankAccountBalances(context.Background(), []models.BankAccount{
+			creditCard,
+		}), "must update credit card balances")
+
+		{
+			balances, err := repo.GetBalances(context.Background(), checking.BankAccountId)
+			require.NoError(t, err, "must retrieve checking balances")
+			assert.Zero(t, balances.CreditReserve, "credit reserve should be cleared")
+			assert.EqualValues(t, 400000, balances.Safe, "safe to spend should no longer include the reserve")
+
+			updated, err := repo.GetBankAccount(context.Background(), creditCard.BankAccountId)
+			require.NoError(t, err, "must retrieve credit card")
+			assert.Zero(t, updated.CurrentBalance, "zero balance should be stored")
+		}
+	})
+}
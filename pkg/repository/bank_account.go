@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
 	"github.com/monetr/rest-api/pkg/models"
 	"github.com/pkg/errors"
 )
@@ -124,3 +125,80 @@ func (r *repositoryBase) UpdateBankAccounts(ctx context.Context, accounts []mode
 
 	return nil
 }
+
+// UpdateBankAccountBalances will store the balances of the provided bank accounts. Unlike UpdateBankAccounts the
+// balance columns are always written, so a balance that has dropped to zero (like a credit card that has been paid
+// off) or a limit that is no longer provided will be stored. Only the balance columns and the last updated timestamp
+// are updated; other fields on the provided bank accounts are ignored.
+func (r *repositoryBase) UpdateBankAccountBalances(ctx context.Context, accounts []models.BankAccount) error {
+	if len(accounts) == 0 {
+		return nil
+	}
+
+	span := sentry.StartSpan(ctx, "UpdateBankAccountBalances")
+	defer span.Finish()
+
+	// Make sure each of the accounts has the correct accountId.
+	bankAccountIds := make([]uint64, len(accounts))
+	for i := range accounts {
+		accounts[i].AccountId = r.AccountId()
+		bankAccountIds[i] = accounts[i].BankAccountId
+	}
+
+	span.Data = map[string]interface{}{
+		"accountId":      r.AccountId(),
+		"bankAccountIds": bankAccountIds,
+	}
+
+	_, err := r.txn.ModelContext(span.Context(), &accounts).
+		Column(
+			"available_balance",
+			"current_balance",
+			"limit_balance",
+			"statement_balance",
+			"original_balance",
+			"last_updated",
+		).
+		WherePK().
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to update bank account balances")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// UpdatePaymentBankAccount will set the bank account that the provided credit account is paid from. If the payment
+// bank account Id is nil then the credit account will no longer be associated with a payment account.
+func (r *repositoryBase) UpdatePaymentBankAccount(ctx context.Context, bankAccountId uint64, paymentBankAccountId *uint64) error {
+	span := sentry.StartSpan(ctx, "UpdatePaymentBankAccount")
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":            r.AccountId(),
+		"bankAccountId":        bankAccountId,
+		"paymentBankAccountId": paymentBankAccountId,
+	}
+
+	result, err := r.txn.ModelContext(span.Context(), &models.BankAccount{}).
+		Set(`"payment_bank_account_id" = ?`, paymentBankAccountId).
+		Where(`"bank_account"."account_id" = ?`, r.AccountId()).
+		Where(`"bank_account"."bank_account_id" = ?`, bankAccountId).
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to update payment bank account")
+	}
+
+	if result.RowsAffected() != 1 {
+		span.Status = sentry.SpanStatusNotFound
+		return errors.Wrap(pg.ErrNoRows, "failed to update payment bank account")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
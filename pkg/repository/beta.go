@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/rest-api/pkg/hash"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrBetaInviteQuotaExceeded is returned when a user tries to create a beta invite but they have already created
+	// as many as their quota allows.
+	ErrBetaInviteQuotaExceeded = errors.New("beta invite quota exceeded")
+	// ErrBetaCodeCannotBeRevoked is returned when a beta code is revoked but it does not exist, has already been used,
+	// or has already been revoked.
+	ErrBetaCodeCannotBeRevoked = errors.New("beta code does not exist, has already been used, or has already been revoked")
+)
+
+type BetaRepository interface {
+	// CreateBetaCode will generate a new beta code that expires at the provided time. The plain text code is returned
+	// and is not stored anywhere, it cannot be retrieved again later.
+	CreateBetaCode(ctx context.Context, expiresAt time.Time, createdByUserId *uint64) (string, *models.Beta, error)
+	// GetBetaCodes returns every beta code, including the user who redeemed the code if it has been used.
+	GetBetaCodes(ctx context.Context) ([]models.Beta, error)
+	// RevokeBetaCode will prevent an unused beta code from being used. Codes that have already been used or revoked
+	// cannot be revoked, ErrBetaCodeCannotBeRevoked is returned for them.
+	RevokeBetaCode(ctx context.Context, betaId uint64) error
+
+	// GetBetaInvites returns the beta codes that were created by the provided user, as well as the number of codes
+	// that user is allowed to create in total.
+	GetBetaInvites(ctx context.Context, userId uint64) (invites []models.Beta, quota int, err error)
+	// CreateBetaInvite will create a beta code on behalf of the provided user. If the user has already created as many
+	// codes as their quota allows then ErrBetaInviteQuotaExceeded is returned. Codes count towards the quota whether
+	// or not they have been used.
+	CreateBetaInvite(ctx context.Context, userId uint64, expiresAt time.Time) (string, *models.Beta, error)
+	// SetBetaInviteQuota will change the number of beta codes the provided user is allowed to create.
+	SetBetaInviteQuota(ctx context.Context, userId uint64, quota int) error
+}
+
+func NewBetaRepository(db pg.DBI) BetaRepository {
+	return &betaRepositoryBase{
+		txn: db,
+	}
+}
+
+type betaRepositoryBase struct {
+	txn pg.DBI
+}
+
+// generateBetaCode returns a new random beta code and the hash of that code that should be stored.
+func generateBetaCode() (code, codeHash string, err error) {
+	random := make([]byte, 8)
+	if _, err = rand.Read(random); err != nil {
+		return "", "", errors.Wrap(err, "failed to read random data")
+	}
+
+	code = fmt.Sprintf("%X-%X", random[:4], random[4:])
+
+	return code, hashBetaCode(code), nil
+}
+
+func hashBetaCode(code string) string {
+	return hash.HashPassword(strings.ToLower(code), code)
+}
+
+func (b *betaRepositoryBase) CreateBetaCode(ctx context.Context, expiresAt time.Time, createdByUserId *uint64) (string, *models.Beta, error) {
+	span := sentry.StartSpan(ctx, "CreateBetaCode")
+	defer span.Finish()
+
+	code, codeHash, err := generateBetaCode()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return "", nil, err
+	}
+
+	beta := models.Beta{
+		CodeHash:        codeHash,
+		CreatedByUserId: createdByUserId,
+		ExpiresAt:       expiresAt,
+		CreatedAt:       time.Now().UTC(),
+	}
+	if _, err = b.txn.ModelContext(span.Context(), &beta).Insert(&beta); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return "", nil, errors.Wrap(err, "failed to create beta code")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return code, &beta, nil
+}
+
+func (b *betaRepositoryBase) GetBetaCodes(ctx context.Context) ([]models.Beta, error) {
+	span := sentry.StartSpan(ctx, "GetBetaCodes")
+	defer span.Finish()
+
+	betas := make([]models.Beta, 0)
+	if err := b.txn.ModelContext(span.Context(), &betas).
+		Relation("UsedByUser").
+		Relation("UsedByUser.Login").
+		Order(`beta.beta_id ASC`).
+		Select(&betas); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve beta codes")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return betas, nil
+}
+
+func (b *betaRepositoryBase) RevokeBetaCode(ctx context.Context, betaId uint64) error {
+	span := sentry.StartSpan(ctx, "RevokeBetaCode")
+	defer span.Finish()
+
+	result, err := b.txn.ModelContext(span.Context(), &models.Beta{}).
+		Set(`"revoked_at" = ?`, time.Now().UTC()).
+		Where(`"beta"."beta_id" = ?`, betaId).
+		Where(`"beta"."used_by_user_id" IS NULL`).
+		Where(`"beta"."revoked_at" IS NULL`).
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to revoke beta code")
+	}
+
+	if result.RowsAffected() != 1 {
+		span.Status = sentry.SpanStatusInvalidArgument
+		return errors.Wrapf(ErrBetaCodeCannotBeRevoked, "failed to revoke beta code %d", betaId)
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+func (b *betaRepositoryBase) GetBetaInvites(ctx context.Context, userId uint64) (invites []models.Beta, quota int, err error) {
+	span := sentry.StartSpan(ctx, "GetBetaInvites")
+	defer span.Finish()
+
+	if err = b.txn.ModelContext(span.Context(), &models.User{}).
+		Column("beta_invite_quota").
+		Where(`"user"."user_id" = ?`, userId).
+		Limit(1).
+		Select(&quota); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, 0, errors.Wrap(err, "failed to retrieve beta invite quota")
+	}
+
+	invites = make([]models.Beta, 0)
+	if err = b.txn.ModelContext(span.Context(), &invites).
+		Where(`"beta"."created_by_user_id" = ?`, userId).
+		Order(`beta.beta_id ASC`).
+		Select(&invites); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, 0, errors.Wrap(err, "failed to retrieve beta invites")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return invites, quota, nil
+}
+
+func (b *betaRepositoryBase) CreateBetaInvite(ctx context.Context, userId uint64, expiresAt time.Time) (string, *models.Beta, error) {
+	span := sentry.StartSpan(ctx, "CreateBetaInvite")
+	defer span.Finish()
+
+	// Lock the user's row so that concurrent requests cannot create more invites than the quota allows.
+	var quota int
+	if err := b.txn.ModelContext(span.Context(), &models.User{}).
+		Column("beta_invite_quota").
+		Where(`"user"."user_id" = ?`, userId).
+		For("UPDATE").
+		Limit(1).
+		Select(&quota); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return "", nil, errors.Wrap(err, "failed to retrieve beta invite quota")
+	}
+
+	created, err := b.txn.ModelContext(span.Context(), &models.Beta{}).
+		Where(`"beta"."created_by_user_id" = ?`, userId).
+		Count()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return "", nil, errors.Wrap(err, "failed to count beta invites")
+	}
+
+	if created >= quota {
+		span.Status = sentry.SpanStatusResourceExhausted
+		return "", nil, errors.WithStack(ErrBetaInviteQuotaExceeded)
+	}
+
+	return b.CreateBetaCode(span.Context(), expiresAt, &userId)
+}
+
+func (b *betaRepositoryBase) SetBetaInviteQuota(ctx context.Context, userId uint64, quota int) error {
+	span := sentry.StartSpan(ctx, "SetBetaInviteQuota")
+	defer span.Finish()
+
+	result, err := b.txn.ModelContext(span.Context(), &models.User{}).
+		Set(`"beta_invite_quota" = ?`, quota).
+		Where(`"user"."user_id" = ?`, userId).
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to update beta invite quota")
+	}
+
+	if result.RowsAffected() != 1 {
+		span.Status = sentry.SpanStatusNotFound
+		return errors.Errorf("user %d does not exist", userId)
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
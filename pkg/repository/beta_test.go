@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBetaRepositoryBase_RevokeBetaCode(t *testing.T) {
+	txn := testutils.GetPgDatabaseTxn(t)
+	betas := NewBetaRepository(txn)
+
+	code, beta, err := betas.CreateBetaCode(context.Background(), time.Now().Add(24*time.Hour), nil)
+	require.NoError(t, err, "must create beta code")
+	assert.NotEmpty(t, code, "code must be returned")
+
+	err = betas.RevokeBetaCode(context.Background(), beta.BetaID)
+	assert.NoError(t, err, "must revoke beta code")
+
+	err = betas.RevokeBetaCode(context.Background(), beta.BetaID)
+	assert.Error(t, err, "cannot revoke a beta code twice")
+
+	_, err = NewUnauthenticatedRepository(txn).ValidateBetaCode(context.Background(), code)
+	assert.Error(t, err, "revoked beta code must not be valid")
+}
+
+func TestBetaRepositoryBase_CreateBetaInvite(t *testing.T) {
+	db := testutils.GetPgDatabase(t)
+	user, _ := testutils.SeedAccount(t, db, testutils.Nothing)
+	betas := NewBetaRepository(db)
+
+	expiresAt := time.Now().Add(24 * time.Hour)
+
+	_, _, err := betas.CreateBetaInvite(context.Background(), user.UserId, expiresAt)
+	assert.True(t, errors.Is(err, ErrBetaInviteQuotaExceeded), "users have no invites by default")
+
+	require.NoError(t, betas.SetBetaInviteQuota(context.Background(), user.UserId, 1), "must set quota")
+
+	code, beta, err := betas.CreateBetaInvite(context.Background(), user.UserId, expiresAt)
+	require.NoError(t, err, "must create beta invite")
+	assert.NotEmpty(t, code, "code must be returned")
+	assert.Equal(t, models.BetaStatusUnused, beta.Status(time.Now()))
+
+	_, _, err = betas.CreateBetaInvite(context.Background(), user.UserId, expiresAt)
+	assert.True(t, errors.Is(err, ErrBetaInviteQuotaExceeded), "quota should now be used up")
+
+	invites, quota, err := betas.GetBetaInvites(context.Background(), user.UserId)
+	require.NoError(t, err, "must retrieve beta invites")
+	assert.Equal(t, 1, quota)
+	assert.Len(t, invites, 1)
+}
@@ -103,3 +103,33 @@ func (r *repositoryBase) UpdateNextFundingScheduleDate(ctx context.Context, fund
 
 	return nil
 }
+
+func (r *repositoryBase) UpdateFundingSchedule(ctx context.Context, fundingSchedule *models.FundingSchedule) error {
+	span := sentry.StartSpan(ctx, "UpdateFundingSchedule")
+	defer span.Finish()
+
+	fundingSchedule.AccountId = r.AccountId()
+
+	span.Data = map[string]interface{}{
+		"accountId":         r.AccountId(),
+		"bankAccountId":     fundingSchedule.BankAccountId,
+		"fundingScheduleId": fundingSchedule.FundingScheduleId,
+	}
+
+	result, err := r.txn.ModelContext(span.Context(), fundingSchedule).
+		WherePK().
+		Update(fundingSchedule)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to update funding schedule")
+	}
+
+	if affected := result.RowsAffected(); affected != 1 {
+		span.Status = sentry.SpanStatusNotFound
+		return errors.Errorf("invalid number of funding schedule(s) updated; expected: 1 updated: %d", affected)
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
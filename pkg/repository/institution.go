@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/pkg/errors"
+)
+
+// GetInstitution will retrieve an institution by its Id. Institutions are not owned by an account, the details of any
+// institution can be retrieved by anyone.
+func (r *repositoryBase) GetInstitution(ctx context.Context, institutionId uint64) (*models.Institution, error) {
+	span := sentry.StartSpan(ctx, "GetInstitution")
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"institutionId": institutionId,
+	}
+
+	var institution models.Institution
+	err := r.txn.ModelContext(span.Context(), &institution).
+		Where(`"institution"."institution_id" = ?`, institutionId).
+		Limit(1).
+		Select(&institution)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve institution")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return &institution, nil
+}
@@ -6,15 +6,24 @@ import (
 	"github.com/go-pg/pg/v10"
 	"github.com/monetr/rest-api/pkg/models"
 	"github.com/pkg/errors"
+	"time"
 )
 
 type JobRepository interface {
 	CreateInstitutions(ctx context.Context, institutions []*models.Institution) error
 	GetBankAccountsToSync() ([]models.BankAccount, error)
-	GetBankAccountsWithPendingTransactions() ([]CheckingPendingTransactionsItem, error)
+	// GetBankAccountsWithPendingTransactions returns the Plaid links that have at least one pending transaction that is
+	// dated before the provided time.
+	GetBankAccountsWithPendingTransactions(ctx context.Context, olderThan time.Time) ([]CheckingPendingTransactionsItem, error)
 	GetFundingSchedulesToProcess() ([]ProcessFundingSchedulesItem, error)
 	GetInstitutionsByPlaidID(ctx context.Context, plaidIds []string) (map[string]models.Institution, error)
+	// GetLinkedPlaidInstitutionIds returns the Plaid institution Id of every institution that at least one link is
+	// connected to.
+	GetLinkedPlaidInstitutionIds(ctx context.Context) ([]string, error)
 	UpdateInstitutions(ctx context.Context, institutions []*models.Institution) error
+	// UpdateLinkInstitutions will associate any Plaid links that are not yet associated with an institution with the
+	// institution record for their Plaid institution Id. It returns the number of links that were updated.
+	UpdateLinkInstitutions(ctx context.Context) (int, error)
 }
 
 type ProcessFundingSchedulesItem struct {
@@ -92,6 +101,46 @@ func (j *jobRepository) CreateInstitutions(ctx context.Context, institutions []*
 	return errors.Wrap(err, "failed to create institutions")
 }
 
+func (j *jobRepository) GetLinkedPlaidInstitutionIds(ctx context.Context) ([]string, error) {
+	span := sentry.StartSpan(ctx, "GetLinkedPlaidInstitutionIds")
+	defer span.Finish()
+
+	plaidIds := make([]string, 0)
+	_, err := j.txn.QueryContext(span.Context(), &plaidIds, `
+		SELECT DISTINCT "plaid_link"."institution_id"
+		FROM "plaid_links" AS "plaid_link"
+		INNER JOIN "links" AS "link" ON "link"."plaid_link_id" = "plaid_link"."plaid_link_id"
+		WHERE "plaid_link"."institution_id" IS NOT NULL AND "plaid_link"."institution_id" != ''
+	`)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve linked plaid institution Ids")
+	}
+	span.Status = sentry.SpanStatusOK
+
+	return plaidIds, nil
+}
+
+func (j *jobRepository) UpdateLinkInstitutions(ctx context.Context) (int, error) {
+	span := sentry.StartSpan(ctx, "UpdateLinkInstitutions")
+	defer span.Finish()
+
+	result, err := j.txn.ExecContext(span.Context(), `
+		UPDATE "links" AS "link"
+		SET "institution_id" = "institution"."institution_id"
+		FROM "plaid_links" AS "plaid_link"
+		INNER JOIN "institutions" AS "institution" ON "institution"."plaid_institution_id" = "plaid_link"."institution_id"
+		WHERE "plaid_link"."plaid_link_id" = "link"."plaid_link_id" AND "link"."institution_id" IS NULL
+	`)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return 0, errors.Wrap(err, "failed to update link institutions")
+	}
+	span.Status = sentry.SpanStatusOK
+
+	return result.RowsAffected(), nil
+}
+
 func (j *jobRepository) GetBankAccountsToSync() ([]models.BankAccount, error) {
 	var result []models.BankAccount
 	err := j.txn.Model(&result).
@@ -121,9 +170,12 @@ func (j *jobRepository) GetFundingSchedulesToProcess() ([]ProcessFundingSchedule
 	return items, nil
 }
 
-func (j *jobRepository) GetBankAccountsWithPendingTransactions() ([]CheckingPendingTransactionsItem, error) {
+func (j *jobRepository) GetBankAccountsWithPendingTransactions(ctx context.Context, olderThan time.Time) ([]CheckingPendingTransactionsItem, error) {
+	span := sentry.StartSpan(ctx, "GetBankAccountsWithPendingTransactions")
+	defer span.Finish()
+
 	var items []CheckingPendingTransactionsItem
-	_, err := j.txn.Query(&items, `
+	_, err := j.txn.QueryContext(span.Context(), &items, `
 		SELECT DISTINCT
 			"bank_account"."account_id",
 			"bank_account"."link_id"
@@ -132,22 +184,56 @@ func (j *jobRepository) GetBankAccountsWithPendingTransactions() ([]CheckingPend
 		INNER JOIN "links" AS "link" ON "link"."account_id" = "bank_account"."account_id" AND "link"."link_id" = "bank_account"."link_id"
 		WHERE
 			"link"."link_type" = ? AND
-			"transaction"."is_pending" = true
-	`, models.PlaidLinkType)
+			"transaction"."is_pending" = true AND
+			"transaction"."date" < ?
+	`, models.PlaidLinkType, olderThan)
 	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
 		return nil, errors.Wrap(err, "failed to retrieve bank accounts with pending transactions")
 	}
 
+	span.Status = sentry.SpanStatusOK
+
 	return items, nil
 }
 
-func (r *repositoryBase) GetJob(jobId string) (models.Job, error) {
+func (r *repositoryBase) GetJob(ctx context.Context, jobId string) (*models.Job, error) {
+	span := sentry.StartSpan(ctx, "GetJob")
+	defer span.Finish()
+
 	var result models.Job
-	err := r.txn.Model(&result).
+	err := r.txn.ModelContext(span.Context(), &result).
 		Where(`"job"."account_id" = ?`, r.AccountId()).
 		Where(`"job"."job_id" = ?`, jobId).
 		Limit(1).
 		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve job")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return &result, nil
+}
+
+func (r *repositoryBase) GetJobs(ctx context.Context, limit, offset int) ([]models.Job, error) {
+	span := sentry.StartSpan(ctx, "GetJobs")
+	defer span.Finish()
+
+	result := make([]models.Job, 0)
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"job"."account_id" = ?`, r.AccountId()).
+		Limit(limit).
+		Offset(offset).
+		Order(`enqueued_at DESC`).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve jobs")
+	}
+
+	span.Status = sentry.SpanStatusOK
 
-	return result, errors.Wrap(err, "failed to retrieve job")
+	return result, nil
 }
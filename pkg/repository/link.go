@@ -20,6 +20,7 @@ func (r *repositoryBase) GetLink(ctx context.Context, linkId uint64) (*models.Li
 	var link models.Link
 	err := r.txn.ModelContext(span.Context(), &link).
 		Relation("PlaidLink").
+		Relation("SimpleFINLink").
 		Relation("BankAccounts").
 		Where(`"link"."link_id" = ? AND "link"."account_id" = ?`, linkId, r.AccountId()).
 		Limit(1).
@@ -30,6 +30,9 @@ func (r *repositoryBase) UpdatePlaidLink(ctx context.Context, link *models.Plaid
 type PlaidRepository interface {
 	GetLinkByItemId(ctx context.Context, itemId string) (*models.Link, error)
 	GetLink(ctx context.Context, accountId, linkId uint64) (*models.Link, error)
+	GetPlaidWebhook(ctx context.Context, plaidWebhookId uint64) (*models.PlaidWebhook, error)
+	CreatePlaidWebhook(ctx context.Context, webhook *models.PlaidWebhook) error
+	UpdatePlaidWebhook(ctx context.Context, webhook *models.PlaidWebhook) error
 }
 
 func NewPlaidRepository(db pg.DBI) PlaidRepository {
@@ -87,3 +90,47 @@ func (r *plaidRepositoryBase) GetLink(ctx context.Context, accountId, linkId uin
 
 	return &link, nil
 }
+
+func (r *plaidRepositoryBase) GetPlaidWebhook(ctx context.Context, plaidWebhookId uint64) (*models.PlaidWebhook, error) {
+	span := sentry.StartSpan(ctx, "GetPlaidWebhook")
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"plaidWebhookId": plaidWebhookId,
+	}
+
+	var webhook models.PlaidWebhook
+	err := r.txn.ModelContext(span.Context(), &webhook).
+		Where(`"plaid_webhook"."plaid_webhook_id" = ?`, plaidWebhookId).
+		Limit(1).
+		Select(&webhook)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve Plaid webhook record")
+	}
+
+	return &webhook, nil
+}
+
+func (r *plaidRepositoryBase) CreatePlaidWebhook(ctx context.Context, webhook *models.PlaidWebhook) error {
+	span := sentry.StartSpan(ctx, "CreatePlaidWebhook")
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"itemId": webhook.ItemId,
+	}
+
+	_, err := r.txn.ModelContext(span.Context(), webhook).Insert(webhook)
+	return errors.Wrap(err, "failed to create Plaid webhook record")
+}
+
+func (r *plaidRepositoryBase) UpdatePlaidWebhook(ctx context.Context, webhook *models.PlaidWebhook) error {
+	span := sentry.StartSpan(ctx, "UpdatePlaidWebhook")
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"plaidWebhookId": webhook.PlaidWebhookId,
+	}
+
+	_, err := r.txn.ModelContext(span.Context(), webhook).WherePK().Update(webhook)
+	return errors.Wrap(err, "failed to update Plaid webhook record")
+}
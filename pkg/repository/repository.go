@@ -19,11 +19,15 @@ type BaseRepository interface {
 	CreateFundingSchedule(ctx context.Context, fundingSchedule *models.FundingSchedule) error
 	CreateLink(ctx context.Context, link *models.Link) error
 	CreatePlaidLink(ctx context.Context, link *models.PlaidLink) error
+	CreateSimpleFINLink(ctx context.Context, link *models.SimpleFINLink) error
 	CreateSpending(ctx context.Context, expense *models.Spending) error
 	CreateTransaction(ctx context.Context, bankAccountId uint64, transaction *models.Transaction) error
 	DeleteSpending(ctx context.Context, bankAccountId, spendingId uint64) error
 	DeleteTransaction(ctx context.Context, bankAccountId, transactionId uint64) error
 	GetAccount(ctx context.Context) (*models.Account, error)
+	// GetAccountLogins returns the login of every user that belongs to the current account. This is used to notify
+	// the users of an account about changes made by background jobs.
+	GetAccountLogins(ctx context.Context) ([]models.Login, error)
 	GetBalances(ctx context.Context, bankAccountId uint64) (*Balances, error)
 	GetBankAccount(ctx context.Context, bankAccountId uint64) (*models.BankAccount, error)
 	GetBankAccounts(ctx context.Context) ([]models.BankAccount, error)
@@ -31,7 +35,10 @@ type BaseRepository interface {
 	GetFundingSchedule(ctx context.Context, bankAccountId, fundingScheduleId uint64) (*models.FundingSchedule, error)
 	GetFundingSchedules(ctx context.Context, bankAccountId uint64) ([]models.FundingSchedule, error)
 	GetFundingStats(ctx context.Context, bankAccountId uint64) (*FundingStats, error)
+	GetInstitution(ctx context.Context, institutionId uint64) (*models.Institution, error)
 	GetIsSetup(ctx context.Context) (bool, error)
+	GetJob(ctx context.Context, jobId string) (*models.Job, error)
+	GetJobs(ctx context.Context, limit, offset int) ([]models.Job, error)
 	GetLink(ctx context.Context, linkId uint64) (*models.Link, error)
 	GetLinkIsManual(ctx context.Context, linkId uint64) (bool, error)
 	GetLinkIsManualByBankAccountId(ctx context.Context, bankAccountId uint64) (bool, error)
@@ -48,10 +55,13 @@ type BaseRepository interface {
 	GetTransactionsForSpending(ctx context.Context, bankAccountId, spendingId uint64, limit, offset int) ([]models.Transaction, error)
 	InsertTransactions(ctx context.Context, transactions []models.Transaction) error
 	ProcessTransactionSpentFrom(ctx context.Context, bankAccountId uint64, input, existing *models.Transaction) (updatedExpenses []models.Spending, _ error)
+	UpdateBankAccountBalances(ctx context.Context, accounts []models.BankAccount) error
 	UpdateBankAccounts(ctx context.Context, accounts []models.BankAccount) error
 	UpdateSpending(ctx context.Context, bankAccountId uint64, updates []models.Spending) error
+	UpdateFundingSchedule(ctx context.Context, fundingSchedule *models.FundingSchedule) error
 	UpdateLink(ctx context.Context, link *models.Link) error
 	UpdateNextFundingScheduleDate(ctx context.Context, fundingScheduleId uint64, nextOccurrence time.Time) error
+	UpdatePaymentBankAccount(ctx context.Context, bankAccountId uint64, paymentBankAccountId *uint64) error
 	UpdatePlaidLink(ctx context.Context, plaidLink *models.PlaidLink) error
 	UpdateTransaction(ctx context.Context, bankAccountId uint64, transaction *models.Transaction) error
 
@@ -65,6 +75,9 @@ type Repository interface {
 	UserId() uint64
 
 	GetMe(ctx context.Context) (*models.User, error)
+	// SetLoginPhoneNumberVerified will store the provided phone number on the current user's login and mark it as
+	// verified. This should only be called once the user has proven that they have access to the phone number.
+	SetLoginPhoneNumberVerified(ctx context.Context, phoneNumber *models.PhoneNumber) error
 	UpdateUser(ctx context.Context, user *models.User) error
 }
 
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/pkg/errors"
+)
+
+func (r *repositoryBase) CreateSimpleFINLink(ctx context.Context, link *models.SimpleFINLink) error {
+	span := sentry.StartSpan(ctx, "CreateSimpleFINLink")
+	defer span.Finish()
+
+	_, err := r.txn.ModelContext(span.Context(), link).Insert(link)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to create simplefin link")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
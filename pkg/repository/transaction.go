@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/getsentry/sentry-go"
 	"github.com/monetr/rest-api/pkg/crumbs"
+	"github.com/monetr/rest-api/pkg/currency"
 	"github.com/monetr/rest-api/pkg/models"
 	"github.com/pkg/errors"
 )
@@ -393,6 +394,12 @@ func (r *repositoryBase) AddExpenseToTransaction(ctx context.Context, transactio
 	span := sentry.StartSpan(ctx, "AddExpenseToTransaction")
 	defer span.Finish()
 
+	// We cannot spend a transaction from a spending object that is in another currency.
+	if err := currency.Check(transaction.Currency, spending.Currency); err != nil {
+		span.Status = sentry.SpanStatusInvalidArgument
+		return errors.Wrap(err, "cannot spend transaction from spending in a different currency")
+	}
+
 	account, err := r.GetAccount(span.Context())
 	if err != nil {
 		return err
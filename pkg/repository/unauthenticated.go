@@ -6,7 +6,6 @@ import (
 	"time"
 
 	"github.com/getsentry/sentry-go"
-	"github.com/monetr/rest-api/pkg/hash"
 
 	"github.com/go-pg/pg/v10"
 	"github.com/monetr/rest-api/pkg/models"
@@ -130,10 +129,11 @@ func (u *unauthenticatedRepo) ValidateBetaCode(ctx context.Context, betaCode str
 	span := sentry.StartSpan(ctx, "Validate Beta Code")
 	defer span.Finish()
 	var beta models.Beta
-	hashedCode := hash.HashPassword(strings.ToLower(betaCode), betaCode)
+	hashedCode := hashBetaCode(betaCode)
 	err := u.txn.ModelContext(span.Context(), &beta).
 		Where(`"beta"."code_hash" = ?`, hashedCode).
 		Where(`"beta"."used_by_user_id" IS NULL`).
+		Where(`"beta"."revoked_at" IS NULL`).
 		Limit(1).
 		Select(&beta)
 	if err != nil {
@@ -27,3 +27,30 @@ func (r *repositoryBase) UpdateUser(ctx context.Context, user *models.User) erro
 
 	return nil
 }
+
+func (r *repositoryBase) SetLoginPhoneNumberVerified(ctx context.Context, phoneNumber *models.PhoneNumber) error {
+	span := sentry.StartSpan(ctx, "SetLoginPhoneNumberVerified")
+	defer span.Finish()
+
+	result, err := r.txn.ModelContext(span.Context(), &models.Login{}).
+		Set(`"phone_number" = ?`, phoneNumber).
+		Set(`"is_phone_verified" = ?`, true).
+		Where(`"login"."login_id" = (?)`, r.txn.ModelContext(span.Context(), &models.User{}).
+			Column("user.login_id").
+			Where(`"user"."user_id" = ? AND "user"."account_id" = ?`, r.UserId(), r.AccountId()),
+		).
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to update login phone number")
+	}
+
+	if affected := result.RowsAffected(); affected != 1 {
+		span.Status = sentry.SpanStatusNotFound
+		return errors.Errorf("invalid number of login(s) updated; expected: 1 updated: %d", affected)
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/monetr/rest-api/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryBase_SetLoginPhoneNumberVerified(t *testing.T) {
+	repo := GetTestAuthenticatedRepository(t)
+
+	me, err := repo.GetMe(context.Background())
+	require.NoError(t, err, "must retrieve current user")
+	require.NotNil(t, me.Login, "login must be included")
+	assert.False(t, me.Login.IsPhoneVerified, "phone should not be verified yet")
+
+	phoneNumber, err := models.ParsePhoneNumber("(612) 555-0123")
+	require.NoError(t, err, "must parse phone number")
+
+	err = repo.SetLoginPhoneNumberVerified(context.Background(), phoneNumber)
+	assert.NoError(t, err, "must update phone number")
+
+	me, err = repo.GetMe(context.Background())
+	require.NoError(t, err, "must retrieve current user")
+	assert.True(t, me.Login.IsPhoneVerified, "phone should now be verified")
+	require.NotNil(t, me.Login.PhoneNumber, "phone number should be stored")
+	assert.Equal(t, phoneNumber.E164(), me.Login.PhoneNumber.E164())
+}
@@ -10,7 +10,8 @@ import (
 )
 
 var (
-	_ PlaidSecretsProvider = &postgresPlaidSecretProvider{}
+	_ PlaidSecretsProvider     = &postgresPlaidSecretProvider{}
+	_ SimpleFINSecretsProvider = &postgresSimpleFINSecretsProvider{}
 )
 
 type postgresPlaidSecretProvider struct {
@@ -90,3 +91,77 @@ func (p *postgresPlaidSecretProvider) RemoveAccessTokenForPlaidLink(ctx context.
 func (p *postgresPlaidSecretProvider) Close() error {
 	return nil
 }
+
+type postgresSimpleFINSecretsProvider struct {
+	log *logrus.Entry
+	db  pg.DBI
+}
+
+func NewPostgresSimpleFINSecretsProvider(log *logrus.Entry, db pg.DBI) SimpleFINSecretsProvider {
+	return &postgresSimpleFINSecretsProvider{
+		log: log,
+		db:  db,
+	}
+}
+
+func (p *postgresSimpleFINSecretsProvider) UpdateAccessUrlForSimpleFINLink(ctx context.Context, accountId, simpleFINLinkId uint64, accessUrl string) error {
+	span := sentry.StartSpan(ctx, "UpdateAccessUrlForSimpleFINLink [POSTGRES]")
+	defer span.Finish()
+
+	token := models.SimpleFINToken{
+		SimpleFINLinkId: simpleFINLinkId,
+		AccountId:       accountId,
+		AccessURL:       accessUrl,
+	}
+	_, err := p.db.ModelContext(span.Context(), &token).
+		OnConflict(`(account_id, simplefin_link_id) DO UPDATE`).
+		Insert(&token)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to update access url")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+func (p *postgresSimpleFINSecretsProvider) GetAccessUrlForSimpleFINLink(ctx context.Context, accountId, simpleFINLinkId uint64) (accessUrl string, err error) {
+	span := sentry.StartSpan(ctx, "GetAccessUrlForSimpleFINLink [POSTGRES]")
+	defer span.Finish()
+
+	var result models.SimpleFINToken
+	err = p.db.ModelContext(span.Context(), &result).
+		Where(`"simple_fin_token"."account_id" = ?`, accountId).
+		Where(`"simple_fin_token"."simplefin_link_id" = ?`, simpleFINLinkId).
+		Limit(1).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return accessUrl, errors.Wrap(err, "failed to retrieve access url for simplefin link")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return result.AccessURL, nil
+}
+
+func (p *postgresSimpleFINSecretsProvider) RemoveAccessUrlForSimpleFINLink(ctx context.Context, accountId, simpleFINLinkId uint64) error {
+	span := sentry.StartSpan(ctx, "RemoveAccessUrlForSimpleFINLink [POSTGRES]")
+	defer span.Finish()
+
+	_, err := p.db.ModelContext(span.Context(), &models.SimpleFINToken{}).
+		Where(`"simple_fin_token"."account_id" = ?`, accountId).
+		Where(`"simple_fin_token"."simplefin_link_id" = ?`, simpleFINLinkId).
+		Delete()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to delete simplefin access url")
+	}
+
+	return nil
+}
+
+func (p *postgresSimpleFINSecretsProvider) Close() error {
+	return nil
+}
@@ -0,0 +1,12 @@
+package secrets
+
+import "context"
+
+// SimpleFINSecretsProvider stores the access URLs for SimpleFIN links. Access URLs include the credentials for the
+// user's SimpleFIN Bridge, so they are stored the same way that Plaid access tokens are.
+type SimpleFINSecretsProvider interface {
+	UpdateAccessUrlForSimpleFINLink(ctx context.Context, accountId, simpleFINLinkId uint64, accessUrl string) error
+	GetAccessUrlForSimpleFINLink(ctx context.Context, accountId, simpleFINLinkId uint64) (accessUrl string, err error)
+	RemoveAccessUrlForSimpleFINLink(ctx context.Context, accountId, simpleFINLinkId uint64) error
+	Close() error
+}
@@ -11,7 +11,8 @@ import (
 )
 
 var (
-	_ PlaidSecretsProvider = &vaultPlaidSecretsProvider{}
+	_ PlaidSecretsProvider     = &vaultPlaidSecretsProvider{}
+	_ SimpleFINSecretsProvider = &vaultSimpleFINSecretsProvider{}
 )
 
 type vaultPlaidSecretsProvider struct {
@@ -81,3 +82,66 @@ func (v *vaultPlaidSecretsProvider) buildPath(accountId uint64, plaidItemId stri
 func (v *vaultPlaidSecretsProvider) Close() error {
 	return nil
 }
+
+type vaultSimpleFINSecretsProvider struct {
+	log    *logrus.Entry
+	client vault_helper.VaultHelper
+}
+
+func NewVaultSimpleFINSecretsProvider(log *logrus.Entry, client vault_helper.VaultHelper) SimpleFINSecretsProvider {
+	return &vaultSimpleFINSecretsProvider{
+		log:    log,
+		client: client,
+	}
+}
+
+func (v *vaultSimpleFINSecretsProvider) UpdateAccessUrlForSimpleFINLink(ctx context.Context, accountId, simpleFINLinkId uint64, accessUrl string) error {
+	span := sentry.StartSpan(ctx, "UpdateAccessUrlForSimpleFINLink [VAULT]")
+	defer span.Finish()
+
+	if err := v.client.WriteKV(span.Context(), v.buildPath(accountId, simpleFINLinkId), map[string]interface{}{
+		"access_url": accessUrl,
+	}); err != nil {
+		return errors.Wrap(err, "failed to store access url in vault")
+	}
+
+	return nil
+}
+
+func (v *vaultSimpleFINSecretsProvider) GetAccessUrlForSimpleFINLink(ctx context.Context, accountId, simpleFINLinkId uint64) (accessUrl string, _ error) {
+	span := sentry.StartSpan(ctx, "GetAccessUrlForSimpleFINLink [VAULT]")
+	defer span.Finish()
+
+	result, err := v.client.ReadKV(span.Context(), v.buildPath(accountId, simpleFINLinkId))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read access url from vault")
+	}
+
+	if result.Data != nil {
+		var ok bool
+		if accessUrl, ok = result.Data["access_url"].(string); ok {
+			return accessUrl, nil
+		}
+	}
+
+	return "", errors.Errorf("access url could not be found")
+}
+
+func (v *vaultSimpleFINSecretsProvider) RemoveAccessUrlForSimpleFINLink(ctx context.Context, accountId, simpleFINLinkId uint64) error {
+	span := sentry.StartSpan(ctx, "RemoveAccessUrlForSimpleFINLink [VAULT]")
+	defer span.Finish()
+
+	if err := v.client.DeleteKV(span.Context(), v.buildPath(accountId, simpleFINLinkId)); err != nil {
+		return errors.Wrap(err, "failed to remove SimpleFIN access url")
+	}
+
+	return nil
+}
+
+func (v *vaultSimpleFINSecretsProvider) buildPath(accountId, simpleFINLinkId uint64) string {
+	return fmt.Sprintf("secret/customers/simplefin/data/%X/%X", accountId, simpleFINLinkId)
+}
+
+func (v *vaultSimpleFINSecretsProvider) Close() error {
+	return nil
+}
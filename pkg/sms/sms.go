@@ -0,0 +1,56 @@
+package sms
+
+import (
+	"context"
+
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+type SendSMSRequest struct {
+	// To is the phone number the message will be sent to in E.164 format.
+	To   string
+	Body string
+}
+
+// Sender sends text messages to phone numbers.
+type Sender interface {
+	Send(ctx context.Context, request SendSMSRequest) error
+}
+
+// NewSender returns the sender for the SMS provider in the provided configuration.
+func NewSender(log *logrus.Entry, configuration config.SMS) (Sender, error) {
+	switch configuration.Provider {
+	case config.SMSProviderTwilio, "":
+		return NewTwilioSender(log, configuration.Twilio), nil
+	case config.SMSProviderLog:
+		return NewLogSender(log), nil
+	default:
+		return nil, errors.Errorf("sms provider %s is not supported", configuration.Provider)
+	}
+}
+
+var (
+	_ Sender = &logSender{}
+)
+
+type logSender struct {
+	log *logrus.Entry
+}
+
+// NewLogSender returns a sender that does not actually send anything, the messages are written to the log instead.
+// This should only be used for local development.
+func NewLogSender(log *logrus.Entry) Sender {
+	return &logSender{
+		log: log,
+	}
+}
+
+func (l *logSender) Send(ctx context.Context, request SendSMSRequest) error {
+	l.log.WithContext(ctx).WithFields(logrus.Fields{
+		"to": request.To,
+	}).Infof("sms message: %s", request.Body)
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const TwilioBaseURL = "https://api.twilio.com/2010-04-01"
+
+var (
+	_ Sender = &twilioSender{}
+)
+
+type twilioSender struct {
+	log           *logrus.Entry
+	configuration config.Twilio
+	client        *http.Client
+}
+
+// NewTwilioSender returns a sender that sends messages using Twilio's Programmable Messaging API.
+func NewTwilioSender(log *logrus.Entry, configuration config.Twilio) Sender {
+	return &twilioSender{
+		log:           log,
+		configuration: configuration,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+func (t *twilioSender) Send(ctx context.Context, request SendSMSRequest) error {
+	span := sentry.StartSpan(ctx, "Twilio - Send")
+	defer span.Finish()
+
+	form := url.Values{}
+	form.Set("To", request.To)
+	form.Set("From", t.configuration.From)
+	form.Set("Body", request.Body)
+
+	messagesUrl := fmt.Sprintf("%s/Accounts/%s/Messages.json", TwilioBaseURL, t.configuration.AccountSID)
+	httpRequest, err := http.NewRequestWithContext(
+		span.Context(),
+		http.MethodPost,
+		messagesUrl,
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create twilio request")
+	}
+	httpRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpRequest.Header.Set("Accept", "application/json")
+	httpRequest.SetBasicAuth(t.configuration.AccountSID, t.configuration.AuthToken)
+
+	response, err := t.client.Do(httpRequest)
+	if err != nil {
+		span.Status = sentry.SpanStatusUnavailable
+		return errors.Wrap(err, "failed to send sms")
+	}
+	defer response.Body.Close()
+
+	// Errors use a different body than a created message, the status of an error is the numeric HTTP status rather
+	// than the status of the message.
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		span.Status = sentry.SpanStatusInternalError
+		var twilioError struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		if err = json.NewDecoder(response.Body).Decode(&twilioError); err != nil {
+			return errors.Wrapf(err, "failed to decode twilio error, status: %d", response.StatusCode)
+		}
+
+		return errors.Errorf(
+			"failed to send sms, status: %d code: %d %s",
+			response.StatusCode, twilioError.Code, twilioError.Message,
+		)
+	}
+
+	var result struct {
+		Sid    string `json:"sid"`
+		Status string `json:"status"`
+	}
+	if err = json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return errors.Wrap(err, "failed to decode twilio response")
+	}
+
+	t.log.WithContext(ctx).WithFields(logrus.Fields{
+		"messageSid": result.Sid,
+		"status":     result.Status,
+	}).Debug("sent sms message")
+
+	return nil
+}
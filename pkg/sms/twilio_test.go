@@ -0,0 +1,105 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/jarcoal/httpmock"
+	"github.com/monetr/rest-api/pkg/config"
+	"github.com/monetr/rest-api/pkg/internal/mock_http_helper"
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwilioSender_Send(t *testing.T) {
+	configuration := config.Twilio{
+		AccountSID: "AC" + gofakeit.UUID(),
+		AuthToken:  gofakeit.UUID(),
+		From:       "+16125550100",
+	}
+	messagesUrl := fmt.Sprintf("%s/Accounts/%s/Messages.json", TwilioBaseURL, configuration.AccountSID)
+
+	t.Run("simple", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		mock_http_helper.NewHttpMockJsonResponder(t,
+			"POST", messagesUrl,
+			func(t *testing.T, request *http.Request) (interface{}, int) {
+				username, password, ok := request.BasicAuth()
+				assert.True(t, ok, "request must use basic auth")
+				assert.Equal(t, configuration.AccountSID, username)
+				assert.Equal(t, configuration.AuthToken, password)
+
+				require.NoError(t, request.ParseForm(), "must parse message request")
+				assert.Equal(t, "+16125550123", request.PostForm.Get("To"))
+				assert.Equal(t, configuration.From, request.PostForm.Get("From"))
+				assert.Equal(t, "Hello!", request.PostForm.Get("Body"))
+
+				return map[string]interface{}{
+					"sid":    "SM" + gofakeit.UUID(),
+					"status": "queued",
+				}, http.StatusCreated
+			},
+			func(t *testing.T, request *http.Request, response interface{}, status int) map[string][]string {
+				return nil
+			},
+		)
+
+		sender := NewTwilioSender(testutils.GetLog(t), configuration)
+		err := sender.Send(context.Background(), SendSMSRequest{
+			To:   "+16125550123",
+			Body: "Hello!",
+		})
+		assert.NoError(t, err, "must send message")
+		assert.Equal(t, 1, httpmock.GetTotalCallCount(), "must have called twilio")
+	})
+
+	t.Run("invalid number", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		mock_http_helper.NewHttpMockJsonResponder(t,
+			"POST", messagesUrl,
+			func(t *testing.T, request *http.Request) (interface{}, int) {
+				return map[string]interface{}{
+					"code":    21211,
+					"message": "The 'To' number is not a valid phone number.",
+					"status":  400,
+				}, http.StatusBadRequest
+			},
+			func(t *testing.T, request *http.Request, response interface{}, status int) map[string][]string {
+				return nil
+			},
+		)
+
+		sender := NewTwilioSender(testutils.GetLog(t), configuration)
+		err := sender.Send(context.Background(), SendSMSRequest{
+			To:   "+1000",
+			Body: "Hello!",
+		})
+		assert.EqualError(t, err, "failed to send sms, status: 400 code: 21211 The 'To' number is not a valid phone number.")
+	})
+}
+
+func TestNewSender(t *testing.T) {
+	sender, err := NewSender(testutils.GetLog(t), config.SMS{
+		Provider: config.SMSProviderLog,
+	})
+	assert.NoError(t, err, "must create log sender")
+	assert.IsType(t, &logSender{}, sender)
+	assert.NoError(t, sender.Send(context.Background(), SendSMSRequest{
+		To:   "+16125550123",
+		Body: "Hello!",
+	}), "log sender should never fail")
+
+	sender, err = NewSender(testutils.GetLog(t), config.SMS{
+		Provider: "carrier-pigeon",
+	})
+	assert.EqualError(t, err, "sms provider carrier-pigeon is not supported")
+	assert.Nil(t, sender)
+}
@@ -0,0 +1,215 @@
+package sms
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/rest-api/pkg/cache"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrNoPendingVerification = errors.New("no verification code has been sent or it has expired")
+	ErrInvalidCode           = errors.New("verification code is not valid")
+	ErrTooManyAttempts       = errors.New("too many incorrect verification codes, request a new code")
+)
+
+// PhoneVerification sends a short code to a phone number and checks the code that the user provides back, proving
+// that the user has access to that phone number.
+type PhoneVerification interface {
+	// SendCode will send a new verification code to the provided phone number for the login, replacing any code that
+	// was sent before. If a code was sent to the login too recently then nothing is sent and the amount of time to
+	// wait before trying again is returned.
+	SendCode(ctx context.Context, loginId uint64, phoneNumber string) (retryAfter time.Duration, err error)
+	// VerifyCode will check the provided code against the code most recently sent to the login. If the code is correct
+	// the phone number that the code was sent to is returned and the code cannot be used again.
+	VerifyCode(ctx context.Context, loginId uint64, code string) (phoneNumber string, err error)
+}
+
+var (
+	_ PhoneVerification = &phoneVerification{}
+)
+
+type verificationState struct {
+	PhoneNumber string `msgpack:"phoneNumber"`
+	CodeHash    string `msgpack:"codeHash"`
+	Attempts    int    `msgpack:"attempts"`
+	SentAt      int64  `msgpack:"sentAt"`
+}
+
+type phoneVerification struct {
+	log         *logrus.Entry
+	cache       cache.Cache
+	sender      Sender
+	lifetime    time.Duration
+	maxAttempts int
+	cooldown    time.Duration
+	clock       func() time.Time
+}
+
+// NewPhoneVerification returns a phone verification that sends 6 digit codes using the provided sender. Codes can be
+// used for the provided lifetime, and only maxAttempts incorrect codes can be provided before a new code must be
+// sent.
+func NewPhoneVerification(
+	log *logrus.Entry,
+	client cache.Cache,
+	sender Sender,
+	lifetime time.Duration,
+	maxAttempts int,
+	cooldown time.Duration,
+) PhoneVerification {
+	return &phoneVerification{
+		log:         log,
+		cache:       client,
+		sender:      sender,
+		lifetime:    lifetime,
+		maxAttempts: maxAttempts,
+		cooldown:    cooldown,
+		clock:       time.Now,
+	}
+}
+
+func (p *phoneVerification) getKey(loginId uint64) string {
+	return fmt.Sprintf("sms:verification:%d", loginId)
+}
+
+// hashCode is used so that the actual code is never stored. The login is included so the same code for two different
+// logins will not have the same hash.
+func (p *phoneVerification) hashCode(loginId uint64, code string) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%d:%s", loginId, code)))
+	return hex.EncodeToString(hash[:])
+}
+
+func (p *phoneVerification) newCode() (string, error) {
+	value, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to generate verification code")
+	}
+
+	return fmt.Sprintf("%06d", value.Int64()), nil
+}
+
+func (p *phoneVerification) getState(ctx context.Context, loginId uint64) (*verificationState, error) {
+	var state verificationState
+	if err := p.cache.GetEz(ctx, p.getKey(loginId), &state); err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve verification state")
+	}
+
+	if state.CodeHash == "" {
+		return nil, nil
+	}
+
+	return &state, nil
+}
+
+func (p *phoneVerification) SendCode(ctx context.Context, loginId uint64, phoneNumber string) (time.Duration, error) {
+	span := sentry.StartSpan(ctx, "PhoneVerification - SendCode")
+	defer span.Finish()
+
+	now := p.clock()
+	existing, err := p.getState(span.Context(), loginId)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return 0, err
+	}
+
+	if existing != nil {
+		if retryAfter := time.Unix(0, existing.SentAt).Add(p.cooldown).Sub(now); retryAfter > 0 {
+			span.Status = sentry.SpanStatusResourceExhausted
+			return retryAfter, nil
+		}
+	}
+
+	code, err := p.newCode()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return 0, err
+	}
+
+	state := verificationState{
+		PhoneNumber: phoneNumber,
+		CodeHash:    p.hashCode(loginId, code),
+		Attempts:    0,
+		SentAt:      now.UnixNano(),
+	}
+	if err = p.cache.SetEzTTL(span.Context(), p.getKey(loginId), state, p.lifetime); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return 0, errors.Wrap(err, "failed to store verification state")
+	}
+
+	if err = p.sender.Send(span.Context(), SendSMSRequest{
+		To: phoneNumber,
+		Body: fmt.Sprintf(
+			"Your monetr verification code is %s. It will expire in %d minutes.",
+			code, int(p.lifetime.Minutes()),
+		),
+	}); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		// If we could not send the code then remove it, this way the user is not stuck waiting for the cooldown.
+		if deleteErr := p.cache.Delete(span.Context(), p.getKey(loginId)); deleteErr != nil {
+			p.log.WithContext(ctx).WithError(deleteErr).Warn("failed to remove verification state after send failed")
+		}
+
+		return 0, errors.Wrap(err, "failed to send verification code")
+	}
+
+	return 0, nil
+}
+
+func (p *phoneVerification) VerifyCode(ctx context.Context, loginId uint64, code string) (string, error) {
+	span := sentry.StartSpan(ctx, "PhoneVerification - VerifyCode")
+	defer span.Finish()
+
+	state, err := p.getState(span.Context(), loginId)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return "", err
+	}
+
+	if state == nil {
+		span.Status = sentry.SpanStatusNotFound
+		return "", errors.WithStack(ErrNoPendingVerification)
+	}
+
+	now := p.clock()
+	expiresAt := time.Unix(0, state.SentAt).Add(p.lifetime)
+	if !now.Before(expiresAt) {
+		span.Status = sentry.SpanStatusNotFound
+		return "", errors.WithStack(ErrNoPendingVerification)
+	}
+
+	if state.Attempts >= p.maxAttempts {
+		span.Status = sentry.SpanStatusResourceExhausted
+		return "", errors.WithStack(ErrTooManyAttempts)
+	}
+
+	hash := p.hashCode(loginId, code)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(state.CodeHash)) != 1 {
+		span.Status = sentry.SpanStatusPermissionDenied
+		state.Attempts++
+		if err = p.cache.SetEzTTL(span.Context(), p.getKey(loginId), *state, expiresAt.Sub(now)); err != nil {
+			return "", errors.Wrap(err, "failed to store verification state")
+		}
+
+		if state.Attempts >= p.maxAttempts {
+			return "", errors.WithStack(ErrTooManyAttempts)
+		}
+
+		return "", errors.WithStack(ErrInvalidCode)
+	}
+
+	if err = p.cache.Delete(span.Context(), p.getKey(loginId)); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return "", errors.Wrap(err, "failed to remove verification state")
+	}
+
+	return state.PhoneNumber, nil
+}
@@ -0,0 +1,217 @@
+package sms
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+	"github.com/monetr/rest-api/pkg/cache"
+	"github.com/monetr/rest-api/pkg/internal/testutils"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func NewTestCache(t *testing.T) cache.Cache {
+	miniRedis := miniredis.NewMiniRedis()
+	require.NoError(t, miniRedis.Start())
+	redisPool := &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", miniRedis.Server().Addr().String())
+		},
+	}
+
+	t.Cleanup(func() {
+		require.NoError(t, redisPool.Close(), "must close miniredis pool successfully")
+		miniRedis.Close()
+	})
+
+	return cache.NewCache(testutils.GetLog(t), redisPool)
+}
+
+var (
+	_ Sender = &recordingSender{}
+)
+
+// recordingSender keeps every message that was sent so that tests can read the verification code.
+type recordingSender struct {
+	messages []SendSMSRequest
+	err      error
+}
+
+func (r *recordingSender) Send(ctx context.Context, request SendSMSRequest) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	r.messages = append(r.messages, request)
+	return nil
+}
+
+var codePattern = regexp.MustCompile(`\d{6}`)
+
+func (r *recordingSender) lastCode(t *testing.T) string {
+	require.NotEmpty(t, r.messages, "a message must have been sent")
+	code := codePattern.FindString(r.messages[len(r.messages)-1].Body)
+	require.NotEmpty(t, code, "message must contain a code")
+	return code
+}
+
+func newTestPhoneVerification(t *testing.T, sender Sender, now *time.Time) *phoneVerification {
+	verification := NewPhoneVerification(
+		testutils.GetLog(t),
+		NewTestCache(t),
+		sender,
+		10*time.Minute,
+		3,
+		time.Minute,
+	).(*phoneVerification)
+	verification.clock = func() time.Time {
+		return *now
+	}
+
+	return verification
+}
+
+func TestPhoneVerification_SendCode(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		now := time.Now()
+		sender := &recordingSender{}
+		verification := newTestPhoneVerification(t, sender, &now)
+
+		retryAfter, err := verification.SendCode(context.Background(), 1234, "+16125550123")
+		assert.NoError(t, err, "must send code")
+		assert.Zero(t, retryAfter, "should not need to wait")
+		require.Len(t, sender.messages, 1, "one message should be sent")
+		assert.Equal(t, "+16125550123", sender.messages[0].To)
+		assert.Regexp(t, `^Your monetr verification code is \d{6}\. It will expire in 10 minutes\.$`, sender.messages[0].Body)
+	})
+
+	t.Run("cooldown", func(t *testing.T) {
+		now := time.Now()
+		sender := &recordingSender{}
+		verification := newTestPhoneVerification(t, sender, &now)
+
+		_, err := verification.SendCode(context.Background(), 1234, "+16125550123")
+		assert.NoError(t, err, "must send code")
+
+		now = now.Add(20 * time.Second)
+		retryAfter, err := verification.SendCode(context.Background(), 1234, "+16125550123")
+		assert.NoError(t, err, "should not return an error during cooldown")
+		assert.Equal(t, 40*time.Second, retryAfter, "should have to wait for the rest of the cooldown")
+		assert.Len(t, sender.messages, 1, "second message should not be sent")
+
+		// A different login is not affected by the cooldown.
+		_, err = verification.SendCode(context.Background(), 5678, "+16125550124")
+		assert.NoError(t, err, "must send code")
+		assert.Len(t, sender.messages, 2, "message should be sent to other login")
+
+		now = now.Add(time.Minute)
+		retryAfter, err = verification.SendCode(context.Background(), 1234, "+16125550123")
+		assert.NoError(t, err, "must send code")
+		assert.Zero(t, retryAfter, "cooldown should be over")
+		assert.Len(t, sender.messages, 3, "third message should be sent")
+	})
+
+	t.Run("send failure", func(t *testing.T) {
+		now := time.Now()
+		sender := &recordingSender{
+			err: errors.New("carrier is down"),
+		}
+		verification := newTestPhoneVerification(t, sender, &now)
+
+		_, err := verification.SendCode(context.Background(), 1234, "+16125550123")
+		assert.EqualError(t, err, "failed to send verification code: carrier is down")
+
+		// Since the code was never sent, the user should be able to try again right away.
+		sender.err = nil
+		retryAfter, err := verification.SendCode(context.Background(), 1234, "+16125550123")
+		assert.NoError(t, err, "must send code")
+		assert.Zero(t, retryAfter, "should not need to wait after a failure")
+	})
+}
+
+func TestPhoneVerification_VerifyCode(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		now := time.Now()
+		sender := &recordingSender{}
+		verification := newTestPhoneVerification(t, sender, &now)
+
+		_, err := verification.SendCode(context.Background(), 1234, "+16125550123")
+		require.NoError(t, err, "must send code")
+
+		phoneNumber, err := verification.VerifyCode(context.Background(), 1234, sender.lastCode(t))
+		assert.NoError(t, err, "code should be valid")
+		assert.Equal(t, "+16125550123", phoneNumber)
+
+		// The code can only be used once.
+		phoneNumber, err = verification.VerifyCode(context.Background(), 1234, sender.lastCode(t))
+		assert.Equal(t, ErrNoPendingVerification, errors.Cause(err))
+		assert.Empty(t, phoneNumber)
+	})
+
+	t.Run("other login", func(t *testing.T) {
+		now := time.Now()
+		sender := &recordingSender{}
+		verification := newTestPhoneVerification(t, sender, &now)
+
+		_, err := verification.SendCode(context.Background(), 1234, "+16125550123")
+		require.NoError(t, err, "must send code")
+
+		phoneNumber, err := verification.VerifyCode(context.Background(), 5678, sender.lastCode(t))
+		assert.Equal(t, ErrNoPendingVerification, errors.Cause(err), "code must not work for another login")
+		assert.Empty(t, phoneNumber)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		now := time.Now()
+		sender := &recordingSender{}
+		verification := newTestPhoneVerification(t, sender, &now)
+
+		_, err := verification.SendCode(context.Background(), 1234, "+16125550123")
+		require.NoError(t, err, "must send code")
+
+		now = now.Add(10 * time.Minute)
+		phoneNumber, err := verification.VerifyCode(context.Background(), 1234, sender.lastCode(t))
+		assert.Equal(t, ErrNoPendingVerification, errors.Cause(err), "code should be expired")
+		assert.Empty(t, phoneNumber)
+	})
+
+	t.Run("too many attempts", func(t *testing.T) {
+		now := time.Now()
+		sender := &recordingSender{}
+		verification := newTestPhoneVerification(t, sender, &now)
+
+		_, err := verification.SendCode(context.Background(), 1234, "+16125550123")
+		require.NoError(t, err, "must send code")
+		code := sender.lastCode(t)
+		wrongCode := "000000"
+		if code == wrongCode {
+			wrongCode = "111111"
+		}
+
+		_, err = verification.VerifyCode(context.Background(), 1234, wrongCode)
+		assert.Equal(t, ErrInvalidCode, errors.Cause(err))
+		_, err = verification.VerifyCode(context.Background(), 1234, wrongCode)
+		assert.Equal(t, ErrInvalidCode, errors.Cause(err))
+		_, err = verification.VerifyCode(context.Background(), 1234, wrongCode)
+		assert.Equal(t, ErrTooManyAttempts, errors.Cause(err), "third wrong code should use up the attempts")
+
+		// Even the correct code should not work now.
+		phoneNumber, err := verification.VerifyCode(context.Background(), 1234, code)
+		assert.Equal(t, ErrTooManyAttempts, errors.Cause(err))
+		assert.Empty(t, phoneNumber)
+
+		// But a new code can be requested once the cooldown is over.
+		now = now.Add(time.Minute)
+		_, err = verification.SendCode(context.Background(), 1234, "+16125550123")
+		require.NoError(t, err, "must send code")
+
+		phoneNumber, err = verification.VerifyCode(context.Background(), 1234, sender.lastCode(t))
+		assert.NoError(t, err, "new code should be valid")
+		assert.Equal(t, "+16125550123", phoneNumber)
+	})
+}
@@ -0,0 +1,39 @@
+package swag
+
+import (
+	"time"
+)
+
+type AdminExtendSubscriptionRequest struct {
+	// The date that the account's subscription should be active until, must be in the future.
+	ActiveUntil time.Time `json:"activeUntil" example:"2021-12-31T00:00:00Z"`
+}
+
+type AdminSetLoginEnabledRequest struct {
+	// Whether or not the login should be able to authenticate.
+	Enabled bool `json:"enabled" example:"false"`
+}
+
+type AdminSetFeatureOverrideRequest struct {
+	// Whether the account should have the feature regardless of its plan. If this is null then the override is
+	// removed and the account's plan decides whether the feature is available.
+	Enabled *bool `json:"enabled" example:"true" extensions:"x-nullable"`
+}
+
+type AdminCreateBetaCodesRequest struct {
+	// The number of beta codes to create, must be between 1 and 100.
+	Count int `json:"count" example:"5"`
+	// When the codes should expire, must be in the future. If this is omitted then the codes will expire in 14 days.
+	ExpiresAt *time.Time `json:"expiresAt" example:"2021-12-31T00:00:00Z" extensions:"x-nullable"`
+}
+
+type AdminCreateBetaCodesResponse struct {
+	// The beta codes that were created. These are only returned once.
+	Codes     []string  `json:"codes" example:"A1B2C3D4-E5F6A7B8"`
+	ExpiresAt time.Time `json:"expiresAt" example:"2021-12-31T00:00:00Z"`
+}
+
+type AdminSetBetaInviteQuotaRequest struct {
+	// The number of beta codes the user is allowed to create in total, must be at least 0.
+	Quota int `json:"quota" example:"5"`
+}
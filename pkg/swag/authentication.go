@@ -61,3 +61,26 @@ type RegisterResponse struct {
 	// The created user and some basic information. This allows the UI to skip an API call to the /users/me endpoint.
 	User models.User `json:"user"`
 }
+
+type UnlockLoginRequest struct {
+	// The unlock token that was sent to the login's email address when the login was locked due to too many failed
+	// login attempts.
+	Token string `json:"token" example:"6c3b3e0b0f5d..."`
+}
+
+type OIDCAuthorizeResponse struct {
+	// The URL that the user should be sent to in order to authenticate with the identity provider.
+	URL string `json:"url" example:"https://sso.example.com/authorize?client_id=monetr&..."`
+}
+
+type OIDCCallbackRequest struct {
+	// The authorization code provided by the identity provider when it redirected the user back to monetr.
+	Code string `json:"code"`
+	// The state provided by the identity provider when it redirected the user back to monetr. This must match the state
+	// that was generated by the authorize endpoint.
+	State string `json:"state"`
+	// The timezone of the user, only used if a new login is being created. Defaults to UTC.
+	Timezone string `json:"timezone" example:"America/Chicago" extensions:"x-nullable"`
+	// A beta code is required when a new login is created if beta codes are enabled on the server.
+	BetaCode *string `json:"betaCode" example:"F2917D98-024633A8" extensions:"x-nullable"`
+}
@@ -8,11 +8,21 @@ type BalanceResponse struct {
 	Current int64 `json:"current" example:"124396"`
 	// The available balance of the account, usually the current balance minus any pending transactions.
 	Available int64 `json:"available" example:"124000"`
-	// The amount left over in the bank account after all expense and goal allocations have been subtracted from the
-	// available balance.
+	// The amount left over in the bank account after all expense and goal allocations, and the credit reserve, have
+	// been subtracted from the available balance.
 	Safe int64 `json:"safe" example:"12350"`
 	// The amount allocated to expense spending objects.
 	Expenses int64 `json:"expenses" example:"100000"`
 	// The amount allocated to goal spending objects.
 	Goals int64 `json:"goals" example:"11650"`
-}
\ No newline at end of file
+	// The amount owed on credit accounts that are paid from this bank account. As card transactions post this amount
+	// increases, and it is set aside so that it is not considered safe to spend.
+	CreditReserve int64 `json:"creditReserve" example:"2500"`
+	// The credit limit of the account in cents, this is only present for credit accounts.
+	Limit *int64 `json:"limit" extensions:"x-nullable" example:"500000"`
+	// The balance of a credit account as of its last statement in cents, this is the amount that needs to be paid to
+	// avoid interest. This is only present for credit accounts when the institution provides it.
+	Statement *int64 `json:"statement" extensions:"x-nullable" example:"170877"`
+	// The amount of a loan that has been paid off in cents, this is only present for loan accounts.
+	PaidOff *int64 `json:"paidOff" extensions:"x-nullable" example:"120000"`
+}
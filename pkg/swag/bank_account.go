@@ -11,6 +11,7 @@ type AlwaysBankAccount struct {
 	// maintained manually for manual links.
 	AvailableBalance int64 `json:"availableBalance" example:"102356"`
 	// The current balance in the account as whole cents without taking into consideration any pending transactions.
+	// For credit and loan accounts this is the amount that is owed, a positive balance means money is owed.
 	CurrentBalance int64 `json:"currentBalance" example:"102400"`
 	// Last 4 digits of the bank account's account number. We do not store the full bank account number or any other
 	// sensitive account information.
@@ -31,12 +32,12 @@ type CreateBankAccountRequest struct {
 	// created through the Plaid interface. At the time of writing this there is not a way to add or remove a bank
 	// account from an existing Plaid Link.
 	LinkId uint64 `json:"linkId" example:"2345" validate:"required"`
-	// Account Type can be; depository, credit, loan, investment or other. At the time of writing this the application
-	// will only support depository. Other types may be supported in the future.
+	// Account Type can be; depository, credit, loan, investment or other. Credit and loan accounts are liabilities,
+	// their balances represent the amount that is owed.
 	Type models.BankAccountType `json:"accountType" example:"depository" validate:"required"`
-	// Sub Type can have numerous values, but given that the application currently only supports depository the most
-	// common values you will see or use are; checking and savings. Other supported types (albeit untested) are; hsa,
-	// cd, money market, paypal, prepaid, cash management and ebt.
+	// Sub Type can have numerous values, the most common values you will see or use are; checking, savings and credit
+	// card. Other supported types (albeit untested) are; hsa, cd, money market, paypal, prepaid, cash management, ebt,
+	// auto, mortgage and student.
 	// More information on these can be found here: https://plaid.com/docs/api/accounts/#account-type-schema
 	SubType models.BankAccountSubType `json:"accountSubType" example:"checking" validate:"required"`
 	// The original name of the bank account from when it was created. This name cannot be changed after the bank
@@ -46,6 +47,10 @@ type CreateBankAccountRequest struct {
 	// Official name is only used with bank accounts coming from Plaid. It is another name that Plaid uses for an
 	// account.
 	PlaidOfficialName string `json:"officialName" example:"US Bank - Checking Account"`
+	// The ISO 4217 currency code of the bank account's balances, this defaults to USD if it is not provided. All of the
+	// balances and amounts for this bank account are in the smallest unit of this currency, cents for USD or whole yen
+	// for JPY. This cannot be changed after the bank account is created.
+	Currency string `json:"currency" example:"USD"`
 }
 
 type BankAccountResponse struct {
@@ -58,4 +63,22 @@ type BankAccountResponse struct {
 	// are provided by an external party (like Plaid) are changed. It is not necessarily changed when the Safe-To-Spend
 	// balance changes or when other fields on the bank account change.
 	LastUpdated time.Time `json:"lastUpdated" example:"2021-04-15T00:00:00-05:00"`
+	// The credit limit of a credit account in cents. This is null for other types of accounts or if the institution
+	// does not provide a limit.
+	LimitBalance *int64 `json:"limitBalance" extensions:"x-nullable" example:"500000"`
+	// The balance of a credit account as of its last statement in cents. This is null for other types of accounts or
+	// if the institution does not provide it.
+	StatementBalance *int64 `json:"statementBalance" extensions:"x-nullable" example:"170877"`
+	// The largest amount that has been owed on a loan account in cents, this is used to track how much of the loan has
+	// been paid off.
+	OriginalBalance *int64 `json:"originalBalance" extensions:"x-nullable" example:"2500000"`
+	// The depository bank account that a credit account is paid from. The amount owed on the credit account is set
+	// aside in this bank account.
+	PaymentBankAccountId *uint64 `json:"paymentBankAccountId" extensions:"x-nullable" example:"85474"`
+}
+
+type UpdatePaymentBankAccountRequest struct {
+	// The depository bank account that the credit account is paid from. Specify null to stop setting aside money for
+	// the credit account.
+	PaymentBankAccountId *uint64 `json:"paymentBankAccountId" extensions:"x-nullable" example:"85474"`
 }
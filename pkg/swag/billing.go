@@ -1,5 +1,9 @@
 package swag
 
+import (
+	"github.com/monetr/rest-api/pkg/feature"
+)
+
 type CreateCheckoutSessionRequest struct {
 	// Specify a specific Stripe Price ID to be used when creating the checkout session. If this is left blank then
 	// the default price will be used for the checkout session.
@@ -37,3 +41,36 @@ type AfterCheckoutResponse struct {
 	// to either configure a Plaid link, or will present them with their budgeting data if there already is some.
 	NextURL  string `json:"nextUrl"`
 }
+
+type BillingPlan struct {
+	// The Stripe price Id of the plan, this is used to subscribe to or change to the plan.
+	PriceId string `json:"priceId" example:"price_1JFQFuI4uGGnwpgwquHOo34s"`
+	// The nickname of the price in Stripe.
+	Name string `json:"name" example:"Monthly"`
+	// The price of the plan in the smallest unit of the currency, for example cents.
+	UnitAmount int64  `json:"unitAmount" example:"199"`
+	Currency   string `json:"currency" example:"usd"`
+	// How often the plan is billed, for example month or year.
+	Interval      string `json:"interval,omitempty" example:"month"`
+	IntervalCount int64  `json:"intervalCount,omitempty" example:"1"`
+	FreeTrialDays int32  `json:"freeTrialDays" example:"30"`
+	// The features that are included with the plan.
+	Features []feature.Feature `json:"features"`
+	// IsDefault is true for the plan that is used when a new subscription does not specify a plan.
+	IsDefault bool `json:"isDefault"`
+	// IsCurrent is true if the account is currently subscribed to this plan.
+	IsCurrent bool `json:"isCurrent"`
+}
+
+type ChangePlanRequest struct {
+	// The Stripe price Id of the plan that the subscription should be changed to.
+	PriceId string `json:"priceId" example:"price_1JFQFuI4uGGnwpgwquHOo34s"`
+}
+
+type ChangePlanResponse struct {
+	// The Stripe price Id of the plan that the account is now subscribed to.
+	PriceId  string `json:"priceId" example:"price_1JFQFuI4uGGnwpgwquHOo34s"`
+	IsActive bool   `json:"isActive"`
+	// The features that the account is now entitled to.
+	Features []feature.Feature `json:"features"`
+}
@@ -19,6 +19,13 @@ type ConfigResponse struct {
 	// not enabled.
 	ReCAPTCHAKey string `json:"ReCAPTCHAKey" extensions:"x-nullable"`
 
+	// The captcha service that the public key belongs to, the UI should load the widget for this provider. Is omitted
+	// if captcha is not enabled.
+	CaptchaProvider string `json:"captchaProvider" enums:"recaptcha,hcaptcha,turnstile" extensions:"x-nullable"`
+
+	// The version of reCAPTCHA that should be used, either 2 or 3. Is omitted if the captcha provider is not reCAPTCHA.
+	CaptchaVersion int `json:"captchaVersion" enums:"2,3" extensions:"x-nullable"`
+
 	// The public key for Stripe, will be used for stripe elements on the frontend. Is omitted if stripe is not enabled.
 	StripePublicKey string `json:"stripePublicKey" extensions:"x-nullable"`
 
@@ -32,4 +39,22 @@ type ConfigResponse struct {
 	// Indicates that registration requests will require a one time use beta code in order to be accepted. Beta codes
 	// must be generated before hand by an admin.
 	RequireBetaCode bool `json:"requireBetaCode"`
+
+	// Indicates that users can authenticate using an OpenID Connect identity provider. The UI should request an
+	// authorization URL from the API and redirect the user to it.
+	OIDCEnabled bool `json:"oidcEnabled"`
+
+	// The display name of the OpenID Connect identity provider, used for the login button. Is omitted if OIDC is not
+	// enabled.
+	OIDCName string `json:"oidcName" extensions:"x-nullable"`
+
+	// Indicates that the API can send text messages, and that users can verify their phone number.
+	VerifyPhoneNumber bool `json:"verifyPhoneNumber"`
+
+	// Indicates that users can link a SimpleFIN Bridge as an alternative to Plaid.
+	AllowSimpleFIN bool `json:"allowSimpleFIN"`
+
+	// Indicates that Plaid is running in fake mode. The UI should link fake institutions through the `/plaid/fake`
+	// endpoints instead of loading Plaid Link.
+	FakePlaid bool `json:"fakePlaid"`
 }
@@ -0,0 +1,27 @@
+package swag
+
+import (
+	"github.com/monetr/rest-api/pkg/models"
+	"time"
+)
+
+type InstitutionResponse struct {
+	// Our unique identifier for the institution. Links that are connected to this institution will have this Id as
+	// their `institutionId`.
+	InstitutionId uint64 `json:"institutionId" example:"1234"`
+	// The name of the institution.
+	Name string `json:"name" example:"US Bank"`
+	// The URL of the institution's website.
+	URL *string `json:"url" extensions:"x-nullable" example:"https://www.usbank.com"`
+	// The primary color of the institution's brand as a hex color.
+	PrimaryColor *string `json:"primaryColor" extensions:"x-nullable" example:"#0c2074"`
+	// The institution's logo as a base64 encoded PNG.
+	Logo *string `json:"logo" extensions:"x-nullable"`
+	// The overall health of the institution for the products that monetr uses. If the institution is degraded or down
+	// then the user's data may not be updated. This will be null if the status of the institution is not known yet.
+	Status *models.InstitutionStatus `json:"status" extensions:"x-nullable" enums:"HEALTHY,DEGRADED,DOWN" example:"HEALTHY"`
+	// The health of each of the products that the institution supports, keyed by the name of the product.
+	ProductStatuses map[string]models.InstitutionStatus `json:"productStatuses"`
+	// The last time the details of the institution were updated.
+	UpdatedAt *time.Time `json:"updatedAt" extensions:"x-nullable" example:"2021-09-01T00:00:00.000000Z"`
+}
@@ -0,0 +1,47 @@
+package swag
+
+import "time"
+
+type JobResponse struct {
+	// The unique identifier of the job, this is generated by the job queue when the job is enqueued.
+	JobId string `json:"jobId" example:"2b5b0c8a2e0a4d1c9b8f3e71"`
+	// The name of the job that was run. This indicates what kind of work the job is performing.
+	Name string `json:"name" example:"PullLatestTransactions"`
+	// The arguments that the job was enqueued with. These vary by job.
+	Arguments map[string]interface{} `json:"arguments"`
+	// The timestamp that the job was added to the queue.
+	EnqueuedAt time.Time `json:"enqueuedAt" example:"2021-09-01T00:00:00.000000Z"`
+	// The timestamp that a worker started processing the job. This will be null if the job has not been started.
+	StartedAt *time.Time `json:"startedAt" extensions:"x-nullable" example:"2021-09-01T00:00:01.000000Z"`
+	// The timestamp that the job finished, whether it succeeded or failed. This will be null if the job is still
+	// running or has not been started.
+	FinishedAt *time.Time `json:"finishedAt" extensions:"x-nullable" example:"2021-09-01T00:00:05.000000Z"`
+	// The number of times this job has been retried after failing.
+	Retries int `json:"retries" example:"0"`
+	// If the last attempt of this job failed then the error message will be included here.
+	Error *string `json:"error" extensions:"x-nullable" example:"failed to retrieve transactions"`
+	// Each time the job is run an attempt is recorded, including the error for that attempt if it failed.
+	Attempts []JobAttemptResponse `json:"attempts"`
+	// If the job has failed too many times it will no longer be retried automatically. This is the timestamp that the
+	// job was given up on.
+	DeadAt *time.Time `json:"deadAt" extensions:"x-nullable" example:"2021-09-01T00:10:00.000000Z"`
+}
+
+type JobAttemptResponse struct {
+	// The attempt number, starting at 1 for the first time the job was run.
+	Attempt int `json:"attempt" example:"1"`
+	// The timestamp that this attempt was started.
+	StartedAt time.Time `json:"startedAt" example:"2021-09-01T00:00:01.000000Z"`
+	// The timestamp that this attempt finished.
+	FinishedAt time.Time `json:"finishedAt" example:"2021-09-01T00:00:05.000000Z"`
+	// The error returned by this attempt, this will be null if the attempt succeeded.
+	Error *string `json:"error" extensions:"x-nullable" example:"failed to retrieve transactions"`
+}
+
+type PlaidSyncResponse struct {
+	// The Id of the job that was enqueued to retrieve the latest transactions for the link. This can be used with the
+	// jobs endpoint to check the status of the sync. This will be null if the link is already being synced.
+	JobId *string `json:"jobId" extensions:"x-nullable" example:"2b5b0c8a2e0a4d1c9b8f3e71"`
+	// Will be true if a sync for the link was already queued, like from a webhook. In that case no new job is enqueued.
+	AlreadySyncing bool `json:"alreadySyncing" example:"false"`
+}
@@ -38,6 +38,13 @@ type LinkResponse struct {
 	// If the link error is due to a problem on Plaid's side, then an error code will be included here to help display
 	// helpful messages on the frontend to the user.
 	ErrorCode *string `json:"errorCode" extensions:"x-nullable" example:"NO_ACCOUNTS"`
+	// The type of the error reported by Plaid, this is included alongside the error code.
+	ErrorType *string `json:"errorType" extensions:"x-nullable" example:"ITEM_ERROR"`
+	// A message describing the error that can be shown to the end user.
+	ErrorMessage *string `json:"errorMessage" extensions:"x-nullable" example:"the login details of this item have changed"`
+	// Plaid has reported that there are new accounts available at the institution for this link. The link can be
+	// updated with account selection to add the new accounts.
+	NewAccountsAvailable bool `json:"newAccountsAvailable" example:"false"`
 	// Our internal Id for an institution. This is just an abstraction layer on top of Plaid's institution Id but would
 	// allow us to associate institutions with multiple integrations in the future. It is also meant to keep Plaid Id's
 	// away from the client's view as much as possible.
@@ -27,3 +27,22 @@ type NewPlaidTokenCallbackRequest struct {
 	InstitutionName string   `json:"institutionName" example:"Navy Federal Credit Union"`
 	AccountIds      []string `json:"accountIds" example:"KEdQjMo39lFwXKqKLlqEt6R3AgBWW1C6l8vDn,r3DVlexNymfJkgZgonZeSQ4n5Koqqjtyrwvkp"`
 }
+
+type FakeInstitutionResponse struct {
+	InstitutionId string `json:"institutionId" example:"ins_fake_1"`
+	Name          string `json:"name" example:"First Platypus Bank"`
+}
+
+type FakePublicTokenRequest struct {
+	// The fake institution that the user is linking, see the fake institutions endpoint.
+	InstitutionId string `json:"institutionId" example:"ins_fake_1"`
+}
+
+type FakeWebhookRequest struct {
+	WebhookType         string   `json:"webhook_type" example:"TRANSACTIONS"`
+	WebhookCode         string   `json:"webhook_code" example:"DEFAULT_UPDATE"`
+	NewTransactions     int64    `json:"new_transactions" example:"3"`
+	RemovedTransactions []string `json:"removed_transactions"`
+	// Error is only used for `ITEM.ERROR` and `ITEM.USER_PERMISSION_REVOKED` webhooks.
+	Error map[string]interface{} `json:"error" extensions:"x-nullable"`
+}
@@ -0,0 +1,19 @@
+package swag
+
+type NewSimpleFINLinkRequest struct {
+	// The setup token that the user created on their SimpleFIN Bridge. Setup tokens can only be claimed once.
+	SetupToken string `json:"setupToken" example:"aHR0cHM6Ly9icmlkZ2Uuc2ltcGxlZmluLm9yZy9zaW1wbGVmaW4vY2xhaW0vZGVtbw=="`
+	// The name that should be shown for the link. If this is omitted then the name of the first account's institution
+	// is used.
+	InstitutionName string `json:"institutionName" example:"Navy Federal Credit Union"`
+	// The SimpleFIN account Ids that should be added to monetr. If this is omitted then every account is added.
+	AccountIds []string `json:"accountIds" example:"ACT-2a5d4c8b,ACT-7f3e1a9d"`
+}
+
+type NewSimpleFINLinkResponse struct {
+	Success bool `json:"success"`
+	// LinkId is the Id of the link that was created for the SimpleFIN Bridge.
+	LinkId uint64 `json:"linkId"`
+	// The job Id of the initial sync for the link, this can be used to check for initial transactions being retrieved.
+	JobId string `json:"jobId"`
+}
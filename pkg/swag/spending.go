@@ -30,10 +30,11 @@ type AlwaysSpending struct {
 	// Changing this rule would recalculate contributions to this spending object.
 	RecurrenceRule *models.Rule `json:"recurrenceRule" swaggertype:"string" example:"FREQ=MONTHLY;BYMONTHDAY=1" extensions:"x-nullable"`
 	// The next time this expense or goal is due. For expenses this date is recalculated each time this date passes.
-	// For goals this date is somewhat static. It can be modified but is not automatically recalculated once it is
-	// reached. Changing this date would recalculate contributions to this spending object. These dates should be
-	// provided in RFC3339 format with the timezone of the client included. The timezone is important as its used to
-	// calculate the next time this expense recurs.
+	// For goals this is the target date that the goal should be fully funded by. It can be modified but is not
+	// automatically recalculated once it is reached, and it is required when creating a goal. Changing this date would
+	// recalculate contributions to this spending object. These dates should be provided in RFC3339 format with the
+	// timezone of the client included. The timezone is important as its used to calculate the next time this expense
+	// recurs.
 	NextRecurrence *time.Time `json:"nextRecurrence" example:"2021-05-01T00:00:00-05:00"`
 	// Indicate whether or not this spending object should receive contributions on it's funding schedule occurrence. If
 	// the spending object is paused, the next time its funding schedule occurs, no additional amount will be allocated
@@ -70,6 +71,9 @@ type SpendingResponse struct {
 	// and is used to keep track of the goal's progress to its target without affecting the accuracy of the
 	// `currentAmount` field. A goal is complete when the `currentAmount` + `usedAmount` = `targetAmount`.
 	UsedAmount int64 `json:"usedAmount" example:"1043"`
+	// The ISO 4217 currency code of the spending object's amounts. This is always the currency of the bank account the
+	// spending object belongs to and cannot be changed.
+	Currency string `json:"currency" example:"USD"`
 	// The last time this spending object reset. A spending object is reset each time its `nextRecurrence` date elapses,
 	// the `nextRecurrence` date is then moved to this field. This field is null if a spending object has never elapsed
 	// before. Or if the spending object is a goal. This field is maintained automatically and cannot be modified.
@@ -81,6 +85,17 @@ type SpendingResponse struct {
 	IsBehind bool `json:"isBehind" example:"false"`
 	// When the spending object was initially created. This value cannot be changed.
 	DateCreated time.Time `json:"dateCreated" example:"2021-04-04T12:43:23-05:00"`
+	// Only valid for goals. Indicates that the goal's progress has reached its `targetAmount`. Completed goals no
+	// longer receive contributions, and funds cannot be transferred to them. If the `targetAmount` of a completed goal
+	// is raised above its progress, or funds are transferred out of it, then the goal is no longer considered complete.
+	// This value is calculated automatically and cannot be changed.
+	IsComplete bool `json:"isComplete" example:"false"`
+	// Goals are archived automatically when they are completed, and un-archived if they are no longer complete.
+	// Archived goals are not included when listing spending unless `archived=true` is specified. This value cannot be
+	// changed.
+	IsArchived bool `json:"isArchived" example:"false"`
+	// The time that the goal was completed. This field is null if the spending object is not a completed goal.
+	DateCompleted *time.Time `json:"dateCompleted" example:"2021-08-01T00:00:00-05:00" extensions:"x-nullable"`
 }
 
 type TransferResponse struct {
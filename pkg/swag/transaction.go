@@ -67,6 +67,9 @@ type NewTransactionRequest struct {
 	// Original categories from when the transaction was created by the user, or imported from Plaid. this field cannot
 	// be changed after the transaction has been created.
 	OriginalCategories []string `json:"originalCategories" example:"Restaurants,Fast Food" extensions:"x-nullable"`
+	// The ISO 4217 currency code of the transaction's amount. Manually created transactions must be in the currency of
+	// their bank account, if this is omitted then the bank account's currency is used.
+	Currency string `json:"currency" example:"USD"`
 }
 
 type TransactionResponse struct {
@@ -0,0 +1,94 @@
+package swag
+
+import (
+	"time"
+
+	"github.com/monetr/rest-api/pkg/models"
+)
+
+type SendPhoneVerificationRequest struct {
+	// The phone number that the verification code should be sent to. Numbers without a country code are assumed to be
+	// US numbers.
+	PhoneNumber string `json:"phoneNumber" example:"(612) 555-0123"`
+}
+
+type VerifyPhoneNumberRequest struct {
+	// The 6 digit code that was sent to the user's phone number.
+	Code string `json:"code" example:"123456"`
+}
+
+type VerifyPhoneNumberResponse struct {
+	// The phone number that was verified.
+	PhoneNumber string `json:"phoneNumber" example:"(612) 555-0123"`
+	// Will always be true.
+	IsPhoneVerified bool `json:"isPhoneVerified" example:"true"`
+}
+
+type UpdateMeRequest struct {
+	// The user's first name, cannot be blank.
+	FirstName string `json:"firstName" example:"Elliot"`
+	// The user's last name.
+	LastName string `json:"lastName" example:"Courant"`
+}
+
+type ChangePasswordRequest struct {
+	// The login's current password.
+	CurrentPassword string `json:"currentPassword" example:"superSecretPassword123"`
+	// The new password, must be at least 8 characters.
+	NewPassword string `json:"newPassword" example:"evenMoreSecretPassword456"`
+}
+
+type ChangeEmailRequest struct {
+	// The new email address for the login.
+	Email string `json:"email" example:"new@example.com"`
+	// The login's current password.
+	CurrentPassword string `json:"currentPassword" example:"superSecretPassword123"`
+}
+
+type ChangeEmailResponse struct {
+	// Will be true if the email has not been changed yet, and the user must click the link sent to the new email.
+	VerificationRequired bool `json:"verificationRequired" example:"true"`
+}
+
+type VerifyEmailChangeRequest struct {
+	// The token from the link that was sent to the new email address.
+	Token string `json:"token"`
+}
+
+type UpdateTimezoneRequest struct {
+	// The IANA name of the new timezone for the account.
+	Timezone string `json:"timezone" example:"America/Chicago"`
+}
+
+type UpdateTimezoneResponse struct {
+	Account models.Account `json:"account"`
+	// The ID of the job that is moving funding schedules and spending into the new timezone. This will be omitted if
+	// the timezone did not change, or if the job was already queued by a previous change that has not run yet.
+	JobId string `json:"jobId,omitempty" example:"5d1b3f9e0c7a"`
+}
+
+type BetaInvite struct {
+	BetaId uint64 `json:"betaId" example:"1234"`
+	// The user who registered using this invite, will be null if the invite has not been used.
+	UsedByUserId *uint64   `json:"usedByUserId" example:"1234"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	CreatedAt    time.Time `json:"createdAt"`
+	// Will be set if the invite was revoked by an administrator.
+	RevokedAt *time.Time `json:"revokedAt"`
+	// The status of the invite, can be one of; unused, used, expired or revoked.
+	Status models.BetaStatus `json:"status" example:"unused"`
+}
+
+type BetaInvitesResponse struct {
+	// The total number of invites the user is allowed to create.
+	Quota int `json:"quota" example:"5"`
+	// The number of invites the user can still create.
+	Remaining int          `json:"remaining" example:"3"`
+	Invites   []BetaInvite `json:"invites"`
+}
+
+type CreateBetaInviteResponse struct {
+	// The beta code that should be given to the person being invited. This is only returned once.
+	Code   string     `json:"code" example:"A1B2C3D4-E5F6A7B8"`
+	Invite BetaInvite `json:"invite"`
+}